@@ -0,0 +1,70 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"pr-business/config"
+	"pr-business/services"
+)
+
+// 每日对账：建议由 cron 在夜间执行，例如
+//
+//	0 2 * * * cd /app && ./reconcile -csv /var/reports/reconciliation.csv
+//
+// 存在差异时退出码为 2，便于告警
+func main() {
+	csvPath := flag.String("csv", "", "差异报告 CSV 输出路径（为空则不输出）")
+	flag.Parse()
+
+	// 加载配置
+	cfg := config.Load()
+
+	// 连接数据库
+	db, err := config.InitDB(cfg)
+	if err != nil {
+		log.Fatal("连接数据库失败:", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatal("获取数据库连接失败:", err)
+	}
+	defer sqlDB.Close()
+
+	reconciliationService := services.NewReconciliationService(db)
+
+	// 执行对账
+	run, err := reconciliationService.Run("system:reconcile")
+	if err != nil {
+		log.Fatal("执行对账失败:", err)
+	}
+
+	fmt.Printf("对账批次 %s 完成：核对账户 %d 个，差异 %d 条\n", run.ID, run.CheckedAccounts, run.DiscrepancyCount)
+
+	// 输出 CSV
+	if *csvPath != "" {
+		report, err := reconciliationService.GetRun(run.ID.String())
+		if err != nil {
+			log.Fatal("读取对账报告失败:", err)
+		}
+
+		file, err := os.Create(*csvPath)
+		if err != nil {
+			log.Fatal("创建 CSV 文件失败:", err)
+		}
+		if err := reconciliationService.WriteCSV(report, file); err != nil {
+			file.Close()
+			log.Fatal("写入 CSV 失败:", err)
+		}
+		file.Close()
+
+		fmt.Printf("差异报告已写入 %s\n", *csvPath)
+	}
+
+	if run.DiscrepancyCount > 0 {
+		sqlDB.Close()
+		os.Exit(2)
+	}
+}
@@ -36,8 +36,52 @@ type Config struct {
 	AuthCenterURL        string `mapstructure:"AUTH_CENTER_URL"`
 	AuthCenterAPIKey      string `mapstructure:"AUTH_CENTER_API_KEY"`
 	AuthCenterRedirectURI string `mapstructure:"AUTH_CENTER_REDIRECT_URI"`
+	AuthCenterTimeout     time.Duration `mapstructure:"AUTH_CENTER_TIMEOUT"`
+
+	AuthTokenVerifyMode      string        `mapstructure:"AUTH_TOKEN_VERIFY_MODE"`
+	AuthTokenIssuer          string        `mapstructure:"AUTH_TOKEN_ISSUER"`
+	AuthJWKSURL              string        `mapstructure:"AUTH_JWKS_URL"`
+	AuthJWKSRefreshInterval  time.Duration `mapstructure:"AUTH_JWKS_REFRESH_INTERVAL"`
+	AuthIdentityCacheSize    int           `mapstructure:"AUTH_IDENTITY_CACHE_SIZE"`
+	AuthIdentityCacheTTL     time.Duration `mapstructure:"AUTH_IDENTITY_CACHE_TTL"`
+	AuthUserCacheTTL         time.Duration `mapstructure:"AUTH_USER_CACHE_TTL"`
+	AuthProfileSyncInterval  time.Duration `mapstructure:"AUTH_PROFILE_SYNC_INTERVAL"`
 
 	FrontendURL string `mapstructure:"FRONTEND_URL"`
+
+	SettlementWorkerCount  int           `mapstructure:"SETTLEMENT_WORKER_COUNT"`
+	SettlementMaxAttempts  int           `mapstructure:"SETTLEMENT_MAX_ATTEMPTS"`
+	SettlementPollInterval time.Duration `mapstructure:"SETTLEMENT_POLL_INTERVAL"`
+
+	SchedulerInterval time.Duration `mapstructure:"SCHEDULER_INTERVAL"`
+	ReviewSLA         time.Duration `mapstructure:"REVIEW_SLA"`
+	ReviewSLAAction   string        `mapstructure:"REVIEW_SLA_ACTION"`
+
+	IdempotencyTTL     time.Duration `mapstructure:"IDEMPOTENCY_TTL"`
+	IdempotencyLockTTL time.Duration `mapstructure:"IDEMPOTENCY_LOCK_TTL"`
+
+	PaymentNotifyBaseURL string `mapstructure:"PAYMENT_NOTIFY_BASE_URL"`
+	PaymentMockEnabled   bool   `mapstructure:"PAYMENT_MOCK_ENABLED"`
+	PaymentMockSecret    string `mapstructure:"PAYMENT_MOCK_SECRET"`
+
+	WechatPayAppID            string `mapstructure:"WECHAT_PAY_APP_ID"`
+	WechatPayMchID            string `mapstructure:"WECHAT_PAY_MCH_ID"`
+	WechatPaySerialNo         string `mapstructure:"WECHAT_PAY_SERIAL_NO"`
+	WechatPayPrivateKeyPath   string `mapstructure:"WECHAT_PAY_PRIVATE_KEY_PATH"`
+	WechatPayPlatformKeyPath  string `mapstructure:"WECHAT_PAY_PLATFORM_KEY_PATH"`
+	WechatPayPlatformSerialNo string `mapstructure:"WECHAT_PAY_PLATFORM_SERIAL_NO"`
+	WechatPayTransferSceneID  string `mapstructure:"WECHAT_PAY_TRANSFER_SCENE_ID"`
+	WechatPayAPIv3Key         string `mapstructure:"WECHAT_PAY_API_V3_KEY"`
+
+	AlipayAppID          string `mapstructure:"ALIPAY_APP_ID"`
+	AlipayGatewayURL     string `mapstructure:"ALIPAY_GATEWAY_URL"`
+	AlipayPrivateKeyPath string `mapstructure:"ALIPAY_PRIVATE_KEY_PATH"`
+	AlipayPublicKeyPath  string `mapstructure:"ALIPAY_PUBLIC_KEY_PATH"`
+
+	PayoutPollInterval time.Duration `mapstructure:"PAYOUT_POLL_INTERVAL"`
+	PayoutFakeEnabled  bool          `mapstructure:"PAYOUT_FAKE_ENABLED"`
+	PayoutFakeSecret   string        `mapstructure:"PAYOUT_FAKE_SECRET"`
+	PayoutFakeDelay    time.Duration `mapstructure:"PAYOUT_FAKE_DELAY"`
 }
 
 func Load() *Config {
@@ -89,8 +133,50 @@ func setDefaults() {
 	viper.SetDefault("AUTH_CENTER_URL", "http://os.crazyaigc.com")
 	viper.SetDefault("AUTH_CENTER_API_KEY", "")
 	viper.SetDefault("AUTH_CENTER_REDIRECT_URI", "http://localhost:8081/api/v1/auth/callback")
+	viper.SetDefault("AUTH_CENTER_TIMEOUT", "5s")
+
+	viper.SetDefault("AUTH_TOKEN_VERIFY_MODE", "local")
+	viper.SetDefault("AUTH_TOKEN_ISSUER", "")
+	viper.SetDefault("AUTH_JWKS_URL", "")
+	viper.SetDefault("AUTH_JWKS_REFRESH_INTERVAL", "1h")
+	viper.SetDefault("AUTH_IDENTITY_CACHE_SIZE", 10000)
+	viper.SetDefault("AUTH_IDENTITY_CACHE_TTL", "5m")
+	viper.SetDefault("AUTH_USER_CACHE_TTL", "30s")
+	viper.SetDefault("AUTH_PROFILE_SYNC_INTERVAL", "1h")
 
 	viper.SetDefault("FRONTEND_URL", "http://localhost:5173")
+
+	viper.SetDefault("SETTLEMENT_WORKER_COUNT", 2)
+	viper.SetDefault("SETTLEMENT_MAX_ATTEMPTS", 8)
+	viper.SetDefault("SETTLEMENT_POLL_INTERVAL", "5s")
+
+	viper.SetDefault("SCHEDULER_INTERVAL", "1m")
+	viper.SetDefault("REVIEW_SLA", "72h")
+	viper.SetDefault("REVIEW_SLA_ACTION", "ESCALATE")
+
+	viper.SetDefault("IDEMPOTENCY_TTL", "24h")
+	viper.SetDefault("IDEMPOTENCY_LOCK_TTL", "5m")
+
+	viper.SetDefault("PAYMENT_NOTIFY_BASE_URL", "https://pr.crazyaigc.com")
+	viper.SetDefault("PAYMENT_MOCK_ENABLED", false)
+	viper.SetDefault("PAYMENT_MOCK_SECRET", "")
+	viper.SetDefault("WECHAT_PAY_APP_ID", "")
+	viper.SetDefault("WECHAT_PAY_MCH_ID", "")
+	viper.SetDefault("WECHAT_PAY_SERIAL_NO", "")
+	viper.SetDefault("WECHAT_PAY_PRIVATE_KEY_PATH", "")
+	viper.SetDefault("WECHAT_PAY_PLATFORM_KEY_PATH", "")
+	viper.SetDefault("WECHAT_PAY_API_V3_KEY", "")
+	viper.SetDefault("WECHAT_PAY_PLATFORM_SERIAL_NO", "")
+	viper.SetDefault("WECHAT_PAY_TRANSFER_SCENE_ID", "1005")
+	viper.SetDefault("ALIPAY_APP_ID", "")
+	viper.SetDefault("ALIPAY_GATEWAY_URL", "https://openapi.alipay.com/gateway.do")
+	viper.SetDefault("ALIPAY_PRIVATE_KEY_PATH", "")
+	viper.SetDefault("ALIPAY_PUBLIC_KEY_PATH", "")
+
+	viper.SetDefault("PAYOUT_POLL_INTERVAL", "1m")
+	viper.SetDefault("PAYOUT_FAKE_ENABLED", false)
+	viper.SetDefault("PAYOUT_FAKE_SECRET", "")
+	viper.SetDefault("PAYOUT_FAKE_DELAY", "30s")
 }
 
 func InitDB(cfg *Config) (*gorm.DB, error) {
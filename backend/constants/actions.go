@@ -0,0 +1,60 @@
+package constants
+
+// 授权策略中的操作（资源.动作）
+// 路由通过 middlewares.Authorize 声明操作，由 services.AuthorizationService 按策略表判断；
+// 同一组操作通过 /user/me/capabilities 返回给前端，用于显示或隐藏按钮
+const (
+	// 营销活动
+	ActionCampaignCreate  = "campaign.create"  // 创建活动
+	ActionCampaignUpdate  = "campaign.update"  // 修改活动信息
+	ActionCampaignDelete  = "campaign.delete"  // 删除活动
+	ActionCampaignApprove = "campaign.approve" // 审核并发布商家提交的活动
+	ActionCampaignManage  = "campaign.manage"  // 关闭、暂停、恢复活动，调整名额
+
+	// 任务
+	ActionTaskView        = "task.view"         // 查看任务时间线（提交与审核记录）
+	ActionTaskHall        = "task.hall"         // 浏览任务大厅
+	ActionTaskAccept      = "task.accept"       // 接任务
+	ActionTaskSubmit      = "task.submit"       // 提交任务内容
+	ActionTaskReview      = "task.review"       // 审核任务
+	ActionTaskReviewQueue = "task.review_queue" // 查看待审核任务
+
+	// 达人
+	ActionCreatorList   = "creator.list"   // 查看达人列表
+	ActionCreatorUpdate = "creator.update" // 编辑达人资料
+
+	// 组织
+	ActionMerchantCreate      = "merchant.create"       // 创建商家
+	ActionMerchantView        = "merchant.view"         // 查看商家详情和员工
+	ActionMerchantUpdate      = "merchant.update"       // 修改商家信息
+	ActionMerchantDelete      = "merchant.delete"       // 删除商家
+	ActionMerchantStaffManage = "merchant.staff_manage" // 添加、删除商家员工，调整员工权限
+
+	ActionServiceProviderCreate      = "service_provider.create"       // 创建服务商
+	ActionServiceProviderUpdate      = "service_provider.update"       // 修改服务商信息
+	ActionServiceProviderDelete      = "service_provider.delete"       // 删除服务商
+	ActionServiceProviderStaffManage = "service_provider.staff_manage" // 添加、删除服务商员工，调整员工权限
+
+	// 财务
+	ActionStatementView      = "statement.view"       // 查看对账单
+	ActionStatementSummary   = "statement.summary"    // 按账户类型查看全平台对账单汇总
+	ActionRechargeAudit      = "recharge.audit"       // 审核线下充值订单
+	ActionRechargeRefund     = "recharge.refund"      // 在线充值订单退款
+	ActionWithdrawalReview   = "withdrawal.review"    // 审核提现申请，查看风控复核队列
+	ActionWithdrawalPayout   = "withdrawal.payout"    // 提交打款、查询和确认打款结果
+	ActionInvoiceIssue       = "invoice.issue"        // 开具或驳回开票申请
+	ActionTaxCertificateView = "tax_certificate.view" // 查看任意个人账户的个税预扣凭证
+	ActionFinancialAuditView = "financial_audit.view" // 查看资金审计日志
+	ActionSystemAccountView  = "system_account.view"  // 查看平台系统账户余额和托管明细
+	ActionCashAccountView    = "cash_account.view"    // 查看平台现金账户
+	ActionCashAccountManage  = "cash_account.manage"  // 创建平台现金账户，调整余额
+
+	// 平台配置与运维（超级管理员）
+	ActionTaxRuleManage          = "tax_rule.manage"          // 查看、创建个税预扣规则
+	ActionSettlementJobManage    = "settlement_job.manage"    // 查看、重试、取消结算任务
+	ActionReconciliationManage   = "reconciliation.manage"    // 执行对账，查看和下载对账报告
+	ActionWithdrawalPolicyManage = "withdrawal_policy.manage" // 查看、创建、停用提现策略
+	ActionExchangeRateManage     = "exchange_rate.manage"     // 查看历史、新增积分汇率
+	ActionTransactionTypeManage  = "transaction_type.manage"  // 查看版本、新增修改、废弃交易类型
+	ActionPlatformFeeManage      = "platform_fee.manage"      // 管理平台手续费规则，查看手续费结算和收入
+)
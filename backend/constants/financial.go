@@ -5,12 +5,14 @@ const (
 	CreditTypeCredit = "CREDIT" // 统一的积分类型
 )
 
-// 提现状态常量
+// 提现状态常量（与 models.WithdrawalStatus 取值一致）
 const (
-	WithdrawalStatusPending    = "PENDING"
-	WithdrawalStatusProcessing = "PROCESSING"
-	WithdrawalStatusCompleted  = "COMPLETED"
-	WithdrawalStatusRejected   = "REJECTED"
+	WithdrawalStatusPending    = "pending"
+	WithdrawalStatusApproved   = "approved"
+	WithdrawalStatusProcessing = "processing"
+	WithdrawalStatusCompleted  = "completed"
+	WithdrawalStatusRejected   = "rejected"
+	WithdrawalStatusFailed     = "failed"
 )
 
 // 现金账户类型常量
@@ -20,6 +22,7 @@ const (
 	CashAccountTypeBankTransfer = "BANK_TRANSFER"
 	CashAccountTypeMarketing    = "MARKETING"
 	CashAccountTypeOperations  = "OPERATIONS"
+	CashAccountTypeClearing    = "CLEARING" // 外部资金清算（现金流入流出平台的对手方，余额可为负）
 )
 
 // 系统账户类型常量
@@ -27,6 +30,8 @@ const (
 	SystemAccountTypeTicketEscrow   = "TICKET_ESCROW"
 	SystemAccountTypeTaskEscrow     = "TASK_ESCROW"
 	SystemAccountTypePlatformRevenue = "PLATFORM_REVENUE" // 统一的平台收益账户
+	SystemAccountTypeCreditIssuance  = "CREDIT_ISSUANCE"  // 积分发行（充值发行、提现回收积分的对手方，余额为负）
+	SystemAccountTypeTaxPayable      = "TAX_PAYABLE"      // 代扣个税（提现时预扣、待向税务机关缴纳）
 )
 
 // 审计操作类型常量
@@ -38,6 +43,38 @@ const (
 	AuditActionCampaignPublish     = "CAMPAIGN_PUBLISH"
 	AuditActionCampaignTaskCreate  = "CAMPAIGN_TASK_CREATE"
 	AuditActionSystemAdjust       = "SYSTEM_ADJUST"
+	AuditActionSettlementRetry    = "SETTLEMENT_RETRY"
+	AuditActionSettlementCancel   = "SETTLEMENT_CANCEL"
+	AuditActionReconciliationRun  = "RECONCILIATION_RUN"
+	AuditActionPlatformFeeCreate  = "PLATFORM_FEE_RULE_CREATE"
+	AuditActionPlatformFeeEnd     = "PLATFORM_FEE_RULE_END"
+	AuditActionCampaignSubmit     = "CAMPAIGN_SUBMIT"
+	AuditActionCampaignPause      = "CAMPAIGN_PAUSE"
+	AuditActionCampaignResume     = "CAMPAIGN_RESUME"
+	AuditActionCampaignClose      = "CAMPAIGN_CLOSE"
+	AuditActionCampaignQuota      = "CAMPAIGN_QUOTA_CHANGE"
+	AuditActionTaskRelease        = "TASK_RELEASE"
+	AuditActionTaskEscalate       = "TASK_ESCALATE"
+	AuditActionTaskAutoApprove    = "TASK_AUTO_APPROVE"
+	AuditActionRechargeOnline     = "RECHARGE_ONLINE"
+	AuditActionRechargeRefund     = "RECHARGE_REFUND"
+	AuditActionRechargeRefundFail = "RECHARGE_REFUND_FAILED"
+	AuditActionWithdrawalPayout   = "WITHDRAWAL_PAYOUT"
+	AuditActionWithdrawalPaid     = "WITHDRAWAL_PAYOUT_SUCCEEDED"
+	AuditActionWithdrawalPayFail  = "WITHDRAWAL_PAYOUT_FAILED"
+	AuditActionWithdrawalPolicyCreate = "WITHDRAWAL_POLICY_CREATE"
+	AuditActionWithdrawalPolicyEnd    = "WITHDRAWAL_POLICY_DEACTIVATE"
+	AuditActionExchangeRateCreate     = "EXCHANGE_RATE_CREATE"
+	AuditActionTaxRuleCreate          = "TAX_WITHHOLDING_RULE_CREATE"
+	AuditActionBillingProfileCreate   = "BILLING_PROFILE_CREATE"
+	AuditActionBillingProfileUpdate   = "BILLING_PROFILE_UPDATE"
+	AuditActionBillingProfileDelete   = "BILLING_PROFILE_DELETE"
+	AuditActionInvoiceRequest         = "INVOICE_REQUEST"
+	AuditActionInvoiceIssue           = "INVOICE_ISSUE"
+	AuditActionInvoiceReject          = "INVOICE_REJECT"
+	AuditActionTransactionTypeDefine  = "TRANSACTION_TYPE_DEFINE"
+	AuditActionTransactionTypeDeprecate = "TRANSACTION_TYPE_DEPRECATE"
+	AuditActionUserSessionsRevoke     = "USER_SESSIONS_REVOKE"
 )
 
 // 审计资源类型常量
@@ -48,4 +85,17 @@ const (
 	AuditResourceCashAccount       = "CASH_ACCOUNT"
 	AuditResourceSystemAccount     = "SYSTEM_ACCOUNT"
 	AuditResourceCampaign          = "CAMPAIGN"
+	AuditResourceSettlementJob     = "SETTLEMENT_JOB"
+	AuditResourceReconciliation    = "RECONCILIATION_RUN"
+	AuditResourcePlatformFeeRule   = "PLATFORM_FEE_RULE"
+	AuditResourceTask              = "TASK"
+	AuditResourceRechargeOrder     = "RECHARGE_ORDER"
+	AuditResourceWithdrawal        = "WITHDRAWAL"
+	AuditResourceWithdrawalPolicy  = "WITHDRAWAL_POLICY"
+	AuditResourceExchangeRate      = "EXCHANGE_RATE"
+	AuditResourceTaxRule           = "TAX_WITHHOLDING_RULE"
+	AuditResourceBillingProfile    = "BILLING_PROFILE"
+	AuditResourceInvoiceRequest    = "INVOICE_REQUEST"
+	AuditResourceTransactionType   = "TRANSACTION_TYPE"
+	AuditResourceUser              = "USER"
 )
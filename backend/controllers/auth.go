@@ -1,27 +1,40 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
 	"pr-business/config"
+	"pr-business/constants"
 	"pr-business/models"
+	"pr-business/services"
 	"pr-business/utils"
+	"sort"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type AuthController struct {
-	cfg *config.Config
-	db  *gorm.DB
+	cfg                  *config.Config
+	db                   *gorm.DB
+	sessionService       *services.SessionService
+	roleContextService   *services.RoleContextService
+	authorizationService *services.AuthorizationService
+	auditService         *services.AuditService
 }
 
-func NewAuthController(cfg *config.Config, db *gorm.DB) *AuthController {
+func NewAuthController(cfg *config.Config, db *gorm.DB, sessionService *services.SessionService, roleContextService *services.RoleContextService, authorizationService *services.AuthorizationService, auditService *services.AuditService) *AuthController {
 	return &AuthController{
-		cfg: cfg,
-		db:  db,
+		cfg:                  cfg,
+		db:                   db,
+		sessionService:       sessionService,
+		roleContextService:   roleContextService,
+		authorizationService: authorizationService,
+		auditService:         auditService,
 	}
 }
 
@@ -51,6 +64,7 @@ func (ctrl *AuthController) GetCurrentUser(c *gin.Context) {
 	}
 
 	u := user.(*models.User)
+	roleContext, _ := utils.GetRoleContext(c)
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -61,6 +75,7 @@ func (ctrl *AuthController) GetCurrentUser(c *gin.Context) {
 			"nickname":         u.Nickname,
 			"avatarUrl":        u.AvatarURL,
 			"roles":            u.Roles,
+			"activeRole":       roleContext,
 			"status":           u.Status,
 			"createdAt":        u.CreatedAt,
 			"updatedAt":        u.UpdatedAt,
@@ -81,16 +96,8 @@ func (ctrl *AuthController) GetCurrentUser(c *gin.Context) {
 // @Success 200 {object} object
 // @Router /api/v1/users [get]
 func (ctrl *AuthController) GetUsers(c *gin.Context) {
-	// 获取当前用户
-	currentUser, exists := c.Get("user")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "未认证"})
-		return
-	}
-	user := currentUser.(*models.User)
-
 	// 权限检查：只有超级管理员可以查看所有用户
-	if !utils.IsSuperAdmin(user) {
+	if !utils.ActingAs(c, constants.RoleSuperAdmin) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "无权限访问"})
 		return
 	}
@@ -143,3 +150,335 @@ func (ctrl *AuthController) GetUsers(c *gin.Context) {
 	})
 }
 
+
+// RefreshSessionRequest 刷新会话请求
+type RefreshSessionRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+// RevokeSessionsRequest 吊销会话请求
+type RevokeSessionsRequest struct {
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+// SwitchActiveRoleRequest 切换激活角色请求
+type SwitchActiveRoleRequest struct {
+	Role  string     `json:"role" binding:"required"`
+	OrgID *uuid.UUID `json:"orgId"`
+}
+
+// GetActiveRole 获取当前激活角色和可切换的身份
+// @Summary 获取激活角色
+// @Description 返回当前请求使用的角色和组织，以及用户可切换的全部身份（组织角色每个组织一项）
+// @Tags 用户
+// @Produce json
+// @Success 200 {object} object
+// @Router /api/v1/user/active-role [get]
+func (ctrl *AuthController) GetActiveRole(c *gin.Context) {
+	u, exists := utils.GetCurrentUser(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "未认证"})
+		return
+	}
+	roleContext, _ := utils.GetRoleContext(c)
+
+	options, err := ctrl.roleContextService.Options(u)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "查询角色失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"active":  roleContext,
+			"options": options,
+		},
+	})
+}
+
+// SwitchActiveRole 切换激活角色
+// @Summary 切换激活角色
+// @Description 选择以哪个角色、代表哪个组织操作，之后的请求按该身份授权；不传 orgId 时取该角色下的第一个组织
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Param request body SwitchActiveRoleRequest true "角色和组织"
+// @Success 200 {object} models.RoleContext
+// @Failure 403 {object} utils.ErrorResponse
+// @Router /api/v1/user/active-role [put]
+func (ctrl *AuthController) SwitchActiveRole(c *gin.Context) {
+	u, exists := utils.GetCurrentUser(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "未认证"})
+		return
+	}
+
+	var req SwitchActiveRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "请求参数错误: " + err.Error()})
+		return
+	}
+
+	roleContext, err := ctrl.roleContextService.Switch(u, req.Role, req.OrgID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrRoleNotHeld):
+			c.JSON(http.StatusForbidden, gin.H{"success": false, "error": err.Error()})
+		case errors.Is(err, services.ErrRoleOrganizationNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "切换角色失败"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": roleContext})
+}
+
+// GetCapabilities 获取当前身份可执行的操作
+// @Summary 获取当前能力
+// @Description 按授权策略表计算当前激活身份可执行的操作，前端据此显示或隐藏按钮；限定组织或本人范围的操作表示可以操作本组织或本人的资源
+// @Tags 用户
+// @Produce json
+// @Success 200 {object} object
+// @Router /api/v1/user/me/capabilities [get]
+func (ctrl *AuthController) GetCapabilities(c *gin.Context) {
+	u, exists := utils.GetCurrentUser(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "未认证"})
+		return
+	}
+	roleContext, _ := utils.GetRoleContext(c)
+
+	subject, err := ctrl.authorizationService.Subject(u, roleContext)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "查询权限失败"})
+		return
+	}
+	permissions := make([]string, 0, len(subject.Permissions))
+	for code := range subject.Permissions {
+		permissions = append(permissions, code)
+	}
+	sort.Strings(permissions)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"roleContext": roleContext,
+			"actions":     ctrl.authorizationService.Capabilities(subject),
+			"permissions": permissions,
+		},
+	})
+}
+
+// CreateSession 用账号中心 token 换取会话
+// @Summary 创建会话
+// @Description 登录回调拿到账号中心 token 后调用，返回本系统的 access token 和 refresh token
+// @Tags 认证
+// @Produce json
+// @Success 200 {object} services.SessionTokens
+// @Failure 401 {object} utils.ErrorResponse
+// @Router /api/v1/auth/session [post]
+func (ctrl *AuthController) CreateSession(c *gin.Context) {
+	user, claims, ok := currentTokenUser(c)
+	if !ok {
+		return
+	}
+	if claims.SessionID != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "请使用账号中心 token 创建会话，会话过期请调用刷新接口"})
+		return
+	}
+
+	tokens, err := ctrl.sessionService.Create(user, sessionClient(c))
+	if err != nil {
+		respondSessionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": tokens})
+}
+
+// RefreshSession 刷新会话
+// @Summary 刷新会话
+// @Description 用 refresh token 换取新的 access token，refresh token 同时轮换，旧 refresh token 作废
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Param request body RefreshSessionRequest true "refresh token"
+// @Success 200 {object} services.SessionTokens
+// @Failure 401 {object} utils.ErrorResponse
+// @Router /api/v1/auth/refresh [post]
+func (ctrl *AuthController) RefreshSession(c *gin.Context) {
+	var req RefreshSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "请求参数错误: " + err.Error()})
+		return
+	}
+
+	tokens, err := ctrl.sessionService.Refresh(req.RefreshToken, sessionClient(c))
+	if err != nil {
+		respondSessionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": tokens})
+}
+
+// Logout 退出登录
+// @Summary 退出登录
+// @Description 吊销当前 token；使用会话 token 时同时吊销会话，refresh token 随之失效
+// @Tags 认证
+// @Produce json
+// @Success 200 {object} object
+// @Router /api/v1/auth/logout [post]
+func (ctrl *AuthController) Logout(c *gin.Context) {
+	user, claims, ok := currentTokenUser(c)
+	if !ok {
+		return
+	}
+
+	if err := ctrl.sessionService.Logout(user, claims); err != nil {
+		if errors.Is(err, services.ErrTokenNotRevocable) {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "退出登录失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// GetUserSessions 查询用户会话（仅超级管理员）
+// @Summary 查询用户会话
+// @Tags 用户管理
+// @Produce json
+// @Param id path string true "用户ID"
+// @Success 200 {array} models.AuthSession
+// @Failure 403 {object} utils.ErrorResponse
+// @Router /api/v1/users/{id}/sessions [get]
+func (ctrl *AuthController) GetUserSessions(c *gin.Context) {
+	if _, ok := ctrl.requireSuperAdmin(c); !ok {
+		return
+	}
+
+	sessions, err := ctrl.sessionService.ListSessions(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取会话失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": sessions})
+}
+
+// RevokeUserSessions 吊销用户全部会话（仅超级管理员）
+// @Summary 吊销用户会话
+// @Description 吊销用户全部会话，此前签发的 token（含账号中心 token）在下一次请求即失效
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param id path string true "用户ID"
+// @Param request body RevokeSessionsRequest false "吊销原因"
+// @Success 200 {object} object
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /api/v1/users/{id}/sessions [delete]
+func (ctrl *AuthController) RevokeUserSessions(c *gin.Context) {
+	admin, ok := ctrl.requireSuperAdmin(c)
+	if !ok {
+		return
+	}
+
+	var req RevokeSessionsRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
+			return
+		}
+	}
+	if req.Reason == "" {
+		req.Reason = "管理员吊销"
+	}
+
+	userID := c.Param("id")
+	revoked, err := ctrl.sessionService.RevokeUserSessions(userID, admin.ID, req.Reason)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "吊销会话失败"})
+		return
+	}
+
+	_ = ctrl.auditService.LogFinancialOperation(
+		admin.AuthCenterUserID,
+		constants.AuditActionUserSessionsRevoke,
+		constants.AuditResourceUser,
+		userID,
+		map[string]interface{}{
+			"revoked_sessions": revoked,
+			"reason":           req.Reason,
+		},
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	)
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "revokedSessions": revoked})
+}
+
+// requireSuperAdmin 获取当前用户并校验超级管理员权限
+func (ctrl *AuthController) requireSuperAdmin(c *gin.Context) (*models.User, bool) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未认证"})
+		return nil, false
+	}
+
+	userObj, ok := user.(*models.User)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "用户信息格式错误"})
+		return nil, false
+	}
+
+	if !utils.ActingAs(c, constants.RoleSuperAdmin) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权限访问"})
+		return nil, false
+	}
+
+	return userObj, true
+}
+
+// currentTokenUser 获取认证中间件写入的当前用户和 token 声明
+func currentTokenUser(c *gin.Context) (*models.User, *services.TokenClaims, bool) {
+	user, userOK := c.Get("user")
+	claims, claimsOK := c.Get("tokenClaims")
+	if !userOK || !claimsOK {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "未认证"})
+		return nil, nil, false
+	}
+	return user.(*models.User), claims.(*services.TokenClaims), true
+}
+
+// sessionClient 请求方客户端信息
+func sessionClient(c *gin.Context) services.SessionClient {
+	return services.SessionClient{
+		IPAddress: c.ClientIP(),
+		UserAgent: c.GetHeader("User-Agent"),
+	}
+}
+
+// respondSessionError 将会话服务错误映射为HTTP响应
+func respondSessionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidRefreshToken):
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": err.Error()})
+	case errors.Is(err, services.ErrUserBanned):
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "error": err.Error()})
+	case errors.Is(err, services.ErrSessionSigningDisabled):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"success": false, "error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "会话处理失败"})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"pr-business/constants"
 	"pr-business/models"
 	"pr-business/services"
 	"pr-business/utils"
@@ -12,25 +13,20 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type CampaignController struct {
-	db                *gorm.DB
-	settlementService  *services.SettlementService
+	db               *gorm.DB
+	ledgerService    *services.LedgerService
+	lifecycleService *services.CampaignLifecycleService
 }
 
-func NewCampaignController(db *gorm.DB) *CampaignController {
-	// 初始化依赖服务
-	settlementService := services.NewSettlementService(
-		db,
-		nil, // permissionService - 稍后实现
-		nil, // validatorService
-		nil, // cashAccountService
-	)
-
+func NewCampaignController(db *gorm.DB, lifecycleService *services.CampaignLifecycleService) *CampaignController {
 	return &CampaignController{
-		db:                db,
-		settlementService: settlementService,
+		db:               db,
+		ledgerService:    services.NewLedgerService(db),
+		lifecycleService: lifecycleService,
 	}
 }
 
@@ -79,13 +75,13 @@ func (ctrl *CampaignController) CreateCampaign(c *gin.Context) {
 	var status models.CampaignStatus
 
 	// 判断创建者类型并验证权限
-	if utils.IsMerchantAdmin(user) {
+	if utils.ActingAs(c, constants.RoleMerchantAdmin) {
 		// 商家管理员创建活动
 		creatorType = "MERCHANT_ADMIN"
 
 		// 获取商家信息
 		var merchant models.Merchant
-		if err := ctrl.db.Where("admin_id::text = ?", user.AuthCenterUserID).First(&merchant).Error; err != nil {
+		if err := ctrl.db.Where("id = ?", utils.ActiveOrgID(c)).First(&merchant).Error; err != nil {
 			c.JSON(http.StatusForbidden, gin.H{"error": "您不是商家管理员"})
 			return
 		}
@@ -100,7 +96,7 @@ func (ctrl *CampaignController) CreateCampaign(c *gin.Context) {
 		req.StaffReferralAmount = nil
 		req.ProviderAmount = nil
 
-	} else if utils.IsServiceProviderAdmin(user) {
+	} else if utils.ActingAs(c, constants.RoleServiceProviderAdmin) {
 		// 服务商管理员创建活动
 		creatorType = "SERVICE_PROVIDER_ADMIN"
 
@@ -112,7 +108,7 @@ func (ctrl *CampaignController) CreateCampaign(c *gin.Context) {
 
 		// 获取服务商信息
 		var provider models.ServiceProvider
-		if err := ctrl.db.Where("admin_id::text = ?", user.AuthCenterUserID).First(&provider).Error; err != nil {
+		if err := ctrl.db.Where("id = ?", utils.ActiveOrgID(c)).First(&provider).Error; err != nil {
 			c.JSON(http.StatusForbidden, gin.H{"error": "您不是服务商管理员"})
 			return
 		}
@@ -149,7 +145,7 @@ func (ctrl *CampaignController) CreateCampaign(c *gin.Context) {
 	// 计算活动总金额
 	campaignAmount := req.TaskAmount * req.Quota
 
-	// 开始事务创建活动（如果直接发布，需要冻结积分）
+	// 开始事务创建活动（如果直接发布，需要托管积分）
 	err := ctrl.db.Transaction(func(tx *gorm.DB) error {
 		// 创建营销活动
 		campaign := models.Campaign{
@@ -175,7 +171,7 @@ func (ctrl *CampaignController) CreateCampaign(c *gin.Context) {
 			return fmt.Errorf("创建营销活动失败: %w", err)
 		}
 
-		// 如果服务商直接创建活动（状态为 OPEN），需要冻结商家积分
+		// 如果服务商直接创建活动（状态为 OPEN），需要托管商家积分
 		if status == models.CampaignStatusOpen {
 			// 验证佣金分配总和
 			totalCommission := 0
@@ -190,7 +186,7 @@ func (ctrl *CampaignController) CreateCampaign(c *gin.Context) {
 			}
 
 			if totalCommission != campaign.TaskAmount {
-				return errors.New("佣金分配总和必须等于任务总金额")
+				return errCommissionMismatch
 			}
 
 			// 获取商家积分账户
@@ -201,42 +197,19 @@ func (ctrl *CampaignController) CreateCampaign(c *gin.Context) {
 
 			// 验证可用余额是否足够
 			if creditAccount.Balance < campaignAmount {
-				return errors.New("商家可用积分不足")
+				return fmt.Errorf("%w: 商家可用积分不足", services.ErrInsufficientBalance)
 			}
 
-			// 冻结积分：从可用余额转移到冻结余额
-			creditAccount.Balance -= campaignAmount
-			creditAccount.FrozenBalance += campaignAmount
-
-			// 记录冻结流水
-			freezeTransaction := models.CreditTransaction{
-				AccountID:      creditAccount.ID,
-				Type:           "CAMPAIGN_FREEZE",
-				Amount:         campaignAmount,
-				BalanceBefore:  creditAccount.Balance + campaignAmount,
-				BalanceAfter:   creditAccount.Balance,
-				RelatedCampaignID: &campaign.ID,
-				Description:    fmt.Sprintf("发布活动冻结积分：%s", campaign.Title),
-			}
-			if err := tx.Create(&freezeTransaction).Error; err != nil {
-				return fmt.Errorf("记录冻结流水失败: %w", err)
-			}
-
-			// 保存积分账户更新
-			if err := tx.Save(&creditAccount).Error; err != nil {
-				return fmt.Errorf("更新积分账户失败: %w", err)
+			// 托管积分：从商家可用余额转入任务托管账户（按活动分账）
+			if err := ctrl.fundCampaignEscrow(tx, &campaign, creditAccount.ID, campaignAmount); err != nil {
+				return err
 			}
 		}
 
-		// 预创建任务名额
-		for i := 1; i <= req.Quota; i++ {
-			task := models.Task{
-				CampaignID:     campaign.ID,
-				TaskSlotNumber: i,
-				Status:         models.TaskStatusOpen,
-			}
-			if err := tx.Create(&task).Error; err != nil {
-				return fmt.Errorf("创建任务名额失败: %w", err)
+		// 直接发布的活动在同一事务内创建任务名额；待审核活动在审核通过时创建
+		if status == models.CampaignStatusOpen {
+			if _, err := ctrl.lifecycleService.MaterializeTaskSlots(tx, &campaign); err != nil {
+				return err
 			}
 		}
 
@@ -244,7 +217,7 @@ func (ctrl *CampaignController) CreateCampaign(c *gin.Context) {
 	})
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondCampaignError(c, err)
 		return
 	}
 
@@ -265,12 +238,12 @@ type UpdateCampaignRequest struct {
 	Platforms          *string   `json:"platforms" binding:"omitempty"`
 	TaskDeadline       *time.Time `json:"taskDeadline" binding:"omitempty"`
 	SubmissionDeadline *time.Time `json:"submissionDeadline" binding:"omitempty"`
-	Status             *string   `json:"status" binding:"omitempty,oneof=DRAFT PENDING_APPROVAL OPEN CLOSED"`
+	Status             *string   `json:"status" binding:"omitempty,oneof=DRAFT PENDING_APPROVAL OPEN PAUSED CLOSED"`
 }
 
 // UpdateCampaign 更新营销活动
 // @Summary 更新营销活动
-// @Description 更新活动信息或关闭活动。只有DRAFT状态的活动可以修改基本信息。关闭活动时会解冻未完成任务的积分。
+// @Description 更新活动信息或变更状态。只有DRAFT状态的活动可以修改基本信息。状态变更与 close/pause/resume 接口一致。
 // @Tags 营销活动管理
 // @Accept json
 // @Produce json
@@ -297,62 +270,18 @@ func (ctrl *CampaignController) UpdateCampaign(c *gin.Context) {
 
 	// 查找活动
 	var campaign models.Campaign
-	if err := ctrl.db.Where("id = ?", id).First(&campaign).Error; err != nil {
+	if err := ctrl.db.Scopes(tenantScope(c).Campaigns).Where("campaigns.id = ?", id).First(&campaign).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "活动不存在"})
 		return
 	}
 
-	// 权限检查：只有创建者、服务商管理员、超级管理员可以更新
-	if !utils.IsServiceProviderAdmin(user) && !utils.IsSuperAdmin(user) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "无权限更新活动"})
-		return
-	}
-
-	// 如果是服务商管理员，验证活动属于该服务商
-	if utils.IsServiceProviderAdmin(user) {
-		var provider models.ServiceProvider
-		if err := ctrl.db.Where("admin_id::text = ?", user.AuthCenterUserID).First(&provider).Error; err == nil {
-			if campaign.ProviderID == nil || *campaign.ProviderID != provider.ID {
-				c.JSON(http.StatusForbidden, gin.H{"error": "该活动不属于您的服务商"})
-				return
-			}
-		}
-	}
+	// 角色和活动归属已由路由的授权策略校验（campaign.update）
 
-	// 处理状态变更
+	// 处理状态变更（统一走活动状态机；发布活动需走审核接口托管积分）
 	if req.Status != nil {
 		newStatus := models.CampaignStatus(*req.Status)
-
-		// 验证状态转换的合法性
-		if campaign.Status == models.CampaignStatusClosed {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "活动已关闭，无法再次操作"})
-			return
-		}
-
-		// 关闭活动
-		if newStatus == models.CampaignStatusClosed {
-			if campaign.Status != models.CampaignStatusOpen {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "只能关闭开放中的活动"})
-				return
-			}
-
-			// 调用积分解冻服务
-			if err := ctrl.settlementService.SettleCampaignAfterClose(&campaign); err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-				return
-			}
-
-			campaign.Status = models.CampaignStatusClosed
-		}
-
-		// 其他状态转换
-		if newStatus == models.CampaignStatusOpen {
-			if campaign.Status != models.CampaignStatusPendingApproval {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "只能审核待审核状态的活动"})
-				return
-			}
-			// 应该使用 ApproveCampaign 接口，这里不允许直接改为 OPEN
-			c.JSON(http.StatusBadRequest, gin.H{"error": "请使用审核接口来发布活动"})
+		if newStatus != campaign.Status {
+			ctrl.transitionCampaign(c, user, campaign.ID.String(), newStatus)
 			return
 		}
 	}
@@ -391,6 +320,174 @@ func (ctrl *CampaignController) UpdateCampaign(c *gin.Context) {
 	c.JSON(http.StatusOK, campaign)
 }
 
+// TransitionCampaignRequest 活动状态变更请求
+type TransitionCampaignRequest struct {
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+// CloseCampaign 关闭营销活动
+// @Summary 关闭营销活动
+// @Description 关闭开放中或已暂停的活动，同一事务内将未完成名额的托管积分退回商家；待审核任务保留托管，审核后再结算或退还
+// @Tags 营销活动管理
+// @Accept json
+// @Produce json
+// @Param id path string true "营销活动ID"
+// @Param request body TransitionCampaignRequest false "关闭原因"
+// @Success 200 {object} models.Campaign
+// @Router /api/v1/campaigns/{id}/close [post]
+func (ctrl *CampaignController) CloseCampaign(c *gin.Context) {
+	ctrl.handleTransition(c, models.CampaignStatusClosed)
+}
+
+// PauseCampaign 暂停营销活动
+// @Summary 暂停营销活动
+// @Description 暂停开放中的活动：停止接单，已接任务可继续提交和审核
+// @Tags 营销活动管理
+// @Accept json
+// @Produce json
+// @Param id path string true "营销活动ID"
+// @Param request body TransitionCampaignRequest false "暂停原因"
+// @Success 200 {object} models.Campaign
+// @Router /api/v1/campaigns/{id}/pause [post]
+func (ctrl *CampaignController) PauseCampaign(c *gin.Context) {
+	ctrl.handleTransition(c, models.CampaignStatusPaused)
+}
+
+// ResumeCampaign 恢复营销活动
+// @Summary 恢复营销活动
+// @Description 恢复已暂停的活动，重新开放接单
+// @Tags 营销活动管理
+// @Accept json
+// @Produce json
+// @Param id path string true "营销活动ID"
+// @Success 200 {object} models.Campaign
+// @Router /api/v1/campaigns/{id}/resume [post]
+func (ctrl *CampaignController) ResumeCampaign(c *gin.Context) {
+	ctrl.handleTransition(c, models.CampaignStatusOpen)
+}
+
+// handleTransition 状态变更接口的公共处理：鉴权后交给活动状态机
+func (ctrl *CampaignController) handleTransition(c *gin.Context, target models.CampaignStatus) {
+	currentUser, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未认证"})
+		return
+	}
+	user := currentUser.(*models.User)
+
+	var campaign models.Campaign
+	if err := ctrl.db.Scopes(tenantScope(c).Campaigns).Where("campaigns.id = ?", c.Param("id")).First(&campaign).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "活动不存在"})
+		return
+	}
+
+	ctrl.transitionCampaign(c, user, campaign.ID.String(), target)
+}
+
+// transitionCampaign 执行状态变更并输出结果
+func (ctrl *CampaignController) transitionCampaign(c *gin.Context, user *models.User, campaignID string, target models.CampaignStatus) {
+	var req TransitionCampaignRequest
+	_ = c.ShouldBindJSON(&req)
+
+	actor := services.CampaignTransitionActor{
+		UserID:    user.AuthCenterUserID,
+		Reason:    req.Reason,
+		IPAddress: c.ClientIP(),
+		UserAgent: c.GetHeader("User-Agent"),
+	}
+
+	campaign, err := ctrl.lifecycleService.Transition(campaignID, target, actor)
+	if err != nil {
+		respondCampaignError(c, err)
+		return
+	}
+
+	// 重新加载活动数据
+	ctrl.db.Preload("Merchant").Preload("Provider").First(campaign, campaign.ID)
+
+	c.JSON(http.StatusOK, campaign)
+}
+
+
+// errCommissionMismatch 佣金分配总和与任务金额不一致
+var errCommissionMismatch = errors.New("佣金分配总和必须等于任务总金额")
+
+// respondCampaignError 活动写操作的错误响应：余额不足和参数错误返回 400，状态机不允许的变更返回 409
+func respondCampaignError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrCampaignNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "活动不存在"})
+	case errors.Is(err, services.ErrInsufficientBalance), errors.Is(err, errCommissionMismatch):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidCampaignTransition):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// ChangeCampaignQuotaRequest 调整活动名额请求
+type ChangeCampaignQuotaRequest struct {
+	Quota  int    `json:"quota" binding:"required,min=1"`
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+// ChangeCampaignQuota 调整活动名额
+// @Summary 调整活动名额
+// @Description 开放中或已暂停的活动可增减名额：增加时托管差额积分并新建名额，减少时下架空闲名额并退还差额
+// @Tags 营销活动管理
+// @Accept json
+// @Produce json
+// @Param id path string true "营销活动ID"
+// @Param request body ChangeCampaignQuotaRequest true "新名额"
+// @Success 200 {object} models.Campaign
+// @Router /api/v1/campaigns/{id}/quota [put]
+func (ctrl *CampaignController) ChangeCampaignQuota(c *gin.Context) {
+	currentUser, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未认证"})
+		return
+	}
+	user := currentUser.(*models.User)
+
+	var req ChangeCampaignQuotaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var campaign models.Campaign
+	if err := ctrl.db.Scopes(tenantScope(c).Campaigns).Where("campaigns.id = ?", c.Param("id")).First(&campaign).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "活动不存在"})
+		return
+	}
+
+	updated, err := ctrl.lifecycleService.ChangeQuota(campaign.ID.String(), req.Quota, services.CampaignTransitionActor{
+		UserID:    user.AuthCenterUserID,
+		Reason:    req.Reason,
+		IPAddress: c.ClientIP(),
+		UserAgent: c.GetHeader("User-Agent"),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrCampaignNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "活动不存在"})
+		case errors.Is(err, services.ErrInvalidCampaignTransition):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrQuotaBelowCommitted), errors.Is(err, services.ErrInsufficientBalance):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	// 重新加载活动数据
+	ctrl.db.Preload("Merchant").Preload("Provider").First(updated, updated.ID)
+
+	c.JSON(http.StatusOK, updated)
+}
+
 // ApproveCampaignRequest 审核营销活动请求
 type ApproveCampaignRequest struct {
 	CreatorAmount       *int `json:"creatorAmount" binding:"required,min=0"`        // 达人佣金
@@ -425,35 +522,18 @@ func (ctrl *CampaignController) ApproveCampaign(c *gin.Context) {
 	}
 	user := currentUser.(*models.User)
 
-	// 只有服务商管理员可以审核
-	if !utils.IsServiceProviderAdmin(user) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "只有服务商管理员可以审核活动"})
-		return
-	}
-
-	// 获取服务商信息
-	var provider models.ServiceProvider
-	if err := ctrl.db.Where("admin_id::text = ?", user.AuthCenterUserID).First(&provider).Error; err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "您不是服务商管理员"})
-		return
-	}
+	// 角色和活动归属已由路由的授权策略校验（campaign.approve）
 
 	// 查找活动
 	var campaign models.Campaign
-	if err := ctrl.db.Where("id = ?", campaignID).First(&campaign).Error; err != nil {
+	if err := ctrl.db.Scopes(tenantScope(c).Campaigns).Where("campaigns.id = ?", campaignID).First(&campaign).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "活动不存在"})
 		return
 	}
 
-	// 验证活动属于该服务商
-	if campaign.ProviderID == nil || *campaign.ProviderID != provider.ID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "该活动不属于您的服务商"})
-		return
-	}
-
 	// 验证活动状态
 	if campaign.Status != models.CampaignStatusPendingApproval {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "只能审核待审核状态的活动"})
+		c.JSON(http.StatusConflict, gin.H{"error": "只能审核待审核状态的活动"})
 		return
 	}
 
@@ -474,11 +554,19 @@ func (ctrl *CampaignController) ApproveCampaign(c *gin.Context) {
 		return
 	}
 
-	// 冻结商家积分（从可用余额转移到冻结余额）
+	// 托管商家积分（从可用余额转入任务托管账户）
 	campaignAmount := campaign.TaskAmount * campaign.Quota
 
-	// 开始冻结积分事务
+	// 开始托管积分事务
 	if err := ctrl.db.Transaction(func(tx *gorm.DB) error {
+		// 锁定活动，防止重复审核导致重复托管
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", campaign.ID).First(&campaign).Error; err != nil {
+			return fmt.Errorf("获取营销活动失败: %w", err)
+		}
+		if campaign.Status != models.CampaignStatusPendingApproval {
+			return fmt.Errorf("%w: 只能审核待审核状态的活动", services.ErrInvalidCampaignTransition)
+		}
+
 		// 获取商家积分账户
 		var creditAccount models.CreditAccount
 		if err := tx.Where("owner_id = ? AND owner_type = ?", campaign.MerchantID, models.OwnerTypeOrgMerchant).First(&creditAccount).Error; err != nil {
@@ -487,41 +575,38 @@ func (ctrl *CampaignController) ApproveCampaign(c *gin.Context) {
 
 		// 验证可用余额是否足够
 		if creditAccount.Balance < campaignAmount {
-			return errors.New("商家可用积分不足")
+			return fmt.Errorf("%w: 商家可用积分不足", services.ErrInsufficientBalance)
 		}
 
-		// 冻结积分：从可用余额转移到冻结余额
-		creditAccount.Balance -= campaignAmount
-		creditAccount.FrozenBalance += campaignAmount
-
-		// 记录冻结流水
-		freezeTransaction := models.CreditTransaction{
-			AccountID:      creditAccount.ID,
-			Type:           "CAMPAIGN_FREEZE",
-			Amount:         campaignAmount,
-			BalanceBefore:  creditAccount.Balance + campaignAmount,
-			BalanceAfter:   creditAccount.Balance,
-			Description:    fmt.Sprintf("发布活动冻结积分：%s", campaign.Title),
+		// 托管积分：从商家可用余额转入任务托管账户（按活动分账）
+		if err := ctrl.fundCampaignEscrow(tx, &campaign, creditAccount.ID, campaignAmount); err != nil {
+			return err
 		}
-		if err := tx.Create(&freezeTransaction).Error; err != nil {
-			return fmt.Errorf("记录冻结流水失败: %w", err)
+
+		// 创建任务名额（与托管积分同一事务）
+		if _, err := ctrl.lifecycleService.MaterializeTaskSlots(tx, &campaign); err != nil {
+			return err
 		}
 
-		// 更新活动状态
-	updates := map[string]interface{}{
+		// 更新佣金分配
+		updates := map[string]interface{}{
 			"creator_amount":        req.CreatorAmount,
 			"staff_referral_amount": req.StaffReferralAmount,
 			"provider_amount":       req.ProviderAmount,
-			"status":                models.CampaignStatusOpen,
 		}
 
 		if err := tx.Model(&campaign).Updates(updates).Error; err != nil {
-			return fmt.Errorf("更新活动状态失败: %w", err)
+			return fmt.Errorf("更新佣金分配失败: %w", err)
 		}
 
-		return nil
+		// 发布活动（PENDING_APPROVAL → OPEN）
+		return ctrl.lifecycleService.TransitionTx(tx, &campaign, models.CampaignStatusOpen, constants.AuditActionCampaignPublish, services.CampaignTransitionActor{
+			UserID:    user.AuthCenterUserID,
+			IPAddress: c.ClientIP(),
+			UserAgent: c.GetHeader("User-Agent"),
+		})
 	}); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondCampaignError(c, err)
 		return
 	}
 
@@ -544,39 +629,23 @@ func (ctrl *CampaignController) DeleteCampaign(c *gin.Context) {
 	id := c.Param("id")
 
 	// 获取当前用户
-	currentUser, exists := c.Get("user")
+	_, exists := c.Get("user")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "未认证"})
 		return
 	}
-	user := currentUser.(*models.User)
 
 	// 查找活动
 	var campaign models.Campaign
-	if err := ctrl.db.Where("id = ?", id).First(&campaign).Error; err != nil {
+	if err := ctrl.db.Scopes(tenantScope(c).Campaigns).Where("campaigns.id = ?", id).First(&campaign).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "活动不存在"})
 		return
 	}
 
-	// 权限检查：只有服务商管理员、超级管理员可以删除
-	if !utils.IsServiceProviderAdmin(user) && !utils.IsSuperAdmin(user) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "无权限删除活动"})
-		return
-	}
-
-	// 如果是服务商管理员，验证活动属于该服务商
-	if utils.IsServiceProviderAdmin(user) {
-		var provider models.ServiceProvider
-		if err := ctrl.db.Where("admin_id::text = ?", user.AuthCenterUserID).First(&provider).Error; err == nil {
-			if campaign.ProviderID == nil || *campaign.ProviderID != provider.ID {
-				c.JSON(http.StatusForbidden, gin.H{"error": "该活动不属于您的服务商"})
-				return
-			}
-		}
-	}
+	// 角色和活动归属已由路由的授权策略校验（campaign.delete）
 
 	// 验证活动状态：只能删除 DRAFT 或 PENDING_APPROVAL 状态的活动
-	if campaign.Status == models.CampaignStatusOpen {
+	if campaign.Status == models.CampaignStatusOpen || campaign.Status == models.CampaignStatusPaused {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "活动已开放，请先关闭活动再删除"})
 		return
 	}
@@ -628,21 +697,14 @@ func (ctrl *CampaignController) GetCampaigns(c *gin.Context) {
 	query := ctrl.db.Model(&models.Campaign{})
 
 	// 获取当前用户
-	currentUser, exists := c.Get("user")
+	_, exists := c.Get("user")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "未认证"})
 		return
 	}
-	user := currentUser.(*models.User)
 
-	// 权限过滤
-	if utils.IsMerchantAdmin(user) && !utils.IsSuperAdmin(user) {
-		// 商家管理员只能看到自己的营销活动
-		var merchant models.Merchant
-		if err := ctrl.db.Where("admin_id = ?", user.ID).First(&merchant).Error; err == nil {
-			query = query.Where("merchant_id = ?", merchant.ID)
-		}
-	}
+	// 数据范围：组织身份只能看到本组织的活动，达人只能看到自己参与的活动
+	query = query.Scopes(tenantScope(c).Campaigns)
 
 	// 状态过滤
 	if status := c.Query("status"); status != "" {
@@ -675,7 +737,8 @@ func (ctrl *CampaignController) GetCampaign(c *gin.Context) {
 	id := c.Param("id")
 
 	var campaign models.Campaign
-	if err := ctrl.db.Where("id = ?", id).Preload("Merchant").Preload("Provider").Preload("Tasks").First(&campaign).Error; err != nil {
+	scope := tenantScope(c)
+	if err := ctrl.db.Scopes(scope.Campaigns).Where("campaigns.id = ?", id).Preload("Merchant").Preload("Provider").Preload("Tasks", scope.Tasks).First(&campaign).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "营销活动不存在"})
 		return
 	}
@@ -697,31 +760,30 @@ func (ctrl *CampaignController) GetMyCampaigns(c *gin.Context) {
 	query := ctrl.db.Model(&models.Campaign{})
 
 	// 获取当前用户
-	currentUser, exists := c.Get("user")
+	_, exists := c.Get("user")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "未认证"})
 		return
 	}
-	user := currentUser.(*models.User)
 
 	// 根据角色返回不同的活动列表
-	if utils.IsMerchantAdmin(user) && !utils.IsSuperAdmin(user) {
+	if utils.ActingAs(c, constants.RoleMerchantAdmin) {
 		// 商家管理员：返回自己创建的所有活动
 		var merchant models.Merchant
-		if err := ctrl.db.Where("admin_id::text = ?", user.AuthCenterUserID).First(&merchant).Error; err != nil {
+		if err := ctrl.db.Where("id = ?", utils.ActiveOrgID(c)).First(&merchant).Error; err != nil {
 			c.JSON(http.StatusForbidden, gin.H{"error": "您不是商家管理员"})
 			return
 		}
 		query = query.Where("merchant_id = ?", merchant.ID)
-	} else if utils.IsServiceProviderAdmin(user) && !utils.IsSuperAdmin(user) {
+	} else if utils.ActingAs(c, constants.RoleServiceProviderAdmin) {
 		// 服务商管理员：返回管理的所有活动
 		var provider models.ServiceProvider
-		if err := ctrl.db.Where("admin_id::text = ?", user.AuthCenterUserID).First(&provider).Error; err != nil {
+		if err := ctrl.db.Where("id = ?", utils.ActiveOrgID(c)).First(&provider).Error; err != nil {
 			c.JSON(http.StatusForbidden, gin.H{"error": "您不是服务商管理员"})
 			return
 		}
 		query = query.Where("provider_id = ?", provider.ID)
-	} else if utils.IsSuperAdmin(user) {
+	} else if utils.ActingAs(c, constants.RoleSuperAdmin) {
 		// 超级管理员：返回所有活动
 		// 不过滤
 	} else {
@@ -741,3 +803,24 @@ func (ctrl *CampaignController) GetMyCampaigns(c *gin.Context) {
 
 	c.JSON(http.StatusOK, campaigns)
 }
+
+// fundCampaignEscrow 活动发布时将商家积分转入任务托管账户
+func (ctrl *CampaignController) fundCampaignEscrow(tx *gorm.DB, campaign *models.Campaign, merchantAccountID uuid.UUID, amount int) error {
+	escrowID, err := ctrl.ledgerService.SystemAccountID(tx, constants.SystemAccountTypeTaskEscrow)
+	if err != nil {
+		return err
+	}
+
+	if _, err := ctrl.ledgerService.Post(tx, &services.LedgerTransfer{
+		Type:              models.TransactionCampaignFreeze,
+		Description:       fmt.Sprintf("发布活动托管积分：%s", campaign.Title),
+		RelatedCampaignID: &campaign.ID,
+		Postings: []services.LedgerPosting{
+			services.CreditPosting(merchantAccountID, -amount),
+			services.SystemPosting(escrowID, amount),
+		},
+	}); err != nil {
+		return fmt.Errorf("托管积分失败: %w", err)
+	}
+	return nil
+}
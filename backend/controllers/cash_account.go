@@ -5,7 +5,6 @@ import (
 	"pr-business/constants"
 	"pr-business/models"
 	"pr-business/services"
-	"pr-business/utils"
 
 	"github.com/gin-gonic/gin"
 )
@@ -38,33 +37,14 @@ func NewCashAccountController(
 // @Failure 500 {object} utils.ErrorResponse
 // @Router /api/cash-accounts [get]
 func (c *CashAccountController) GetCashAccounts(ctx *gin.Context) {
-	// 1. 获取当前用户
-	user, exists := ctx.Get("user")
-	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "未登录"})
-		return
-	}
-
-	userObj, ok := user.(*models.User)
-	if !ok {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "用户信息格式错误"})
-		return
-	}
-
-	// 2. 权限检查（只有管理员可以查看）
-	if !utils.IsSuperAdmin(userObj) && !utils.IsServiceProviderAdmin(userObj) {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "没有权限执行此操作"})
-		return
-	}
-
-	// 3. 获取账户类型
+	// 1. 获取账户类型
 	accountType := ctx.Query("account_type")
 	if accountType == "" {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "账户类型不能为空"})
 		return
 	}
 
-	// 4. 调用服务层查询
+	// 2. 调用服务层查询
 	accounts, err := c.cashAccountService.GetActiveCashAccounts(accountType)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "查询现金账户失败: " + err.Error()})
@@ -108,20 +88,14 @@ func (c *CashAccountController) CreateCashAccount(ctx *gin.Context) {
 		return
 	}
 
-	// 2. 权限检查（只有超级管理员可以创建）
-	if !utils.IsSuperAdmin(userObj) {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "没有权限执行此操作"})
-		return
-	}
-
-	// 3. 绑定请求参数
+	// 2. 绑定请求参数
 	var req CreateCashAccountRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "参数错误: " + err.Error()})
 		return
 	}
 
-	// 4. 验证账户类型
+	// 3. 验证账户类型
 	validTypes := map[string]bool{
 		constants.CashAccountTypeWeChat:       true,
 		constants.CashAccountTypeAlipay:      true,
@@ -134,7 +108,7 @@ func (c *CashAccountController) CreateCashAccount(ctx *gin.Context) {
 		return
 	}
 
-	// 5. 调用服务层创建
+	// 4. 调用服务层创建
 	account, err := c.cashAccountService.CreateCashAccount(
 		req.AccountType,
 		req.Description,
@@ -146,7 +120,7 @@ func (c *CashAccountController) CreateCashAccount(ctx *gin.Context) {
 		return
 	}
 
-	// 6. 记录审计日志
+	// 5. 记录审计日志
 	ipAddress := ctx.ClientIP()
 	userAgent := ctx.GetHeader("User-Agent")
 	_ = c.auditService.LogFinancialOperation(
@@ -200,34 +174,28 @@ func (c *CashAccountController) UpdateCashAccountBalance(ctx *gin.Context) {
 		return
 	}
 
-	// 2. 权限检查（只有超级管理员可以调整）
-	if !utils.IsSuperAdmin(userObj) {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "没有权限执行此操作"})
-		return
-	}
-
-	// 3. 获取账户ID
+	// 2. 获取账户ID
 	id := ctx.Param("id")
 	if id == "" {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "账户ID不能为空"})
 		return
 	}
 
-	// 4. 绑定请求参数
+	// 3. 绑定请求参数
 	var req UpdateCashAccountBalanceRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "参数错误: " + err.Error()})
 		return
 	}
 
-	// 5. 调用服务层更新
+	// 4. 调用服务层更新
 	err := c.cashAccountService.UpdateBalance(id, req.Amount, req.Description, nil)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "更新账户余额失败: " + err.Error()})
 		return
 	}
 
-	// 6. 记录审计日志
+	// 5. 记录审计日志
 	ipAddress := ctx.ClientIP()
 	userAgent := ctx.GetHeader("User-Agent")
 	_ = c.auditService.LogFinancialOperation(
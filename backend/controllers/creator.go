@@ -6,7 +6,6 @@ import (
 	"pr-business/models"
 	"pr-business/utils"
 	"strconv"
-	"strings"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -48,69 +47,14 @@ func (ctrl *CreatorController) GetCreators(c *gin.Context) {
 	query := ctrl.db.Model(&models.Creator{})
 
 	// 获取当前用户
-	currentUser, exists := c.Get("user")
+	_, exists := c.Get("user")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "未认证"})
 		return
 	}
-	user := currentUser.(*models.User)
 
-	// 权限过滤
-	if !utils.IsSuperAdmin(user) {
-		// 非超级管理员需要根据角色过滤数据
-
-		if utils.IsServiceProviderAdmin(user) {
-			// 服务商管理员：查看本组织的所有达人
-			var provider models.ServiceProvider
-			if err := ctrl.db.Where("admin_id::text = ?", user.AuthCenterUserID).First(&provider).Error; err != nil {
-				c.JSON(http.StatusForbidden, gin.H{"error": "未找到关联的服务商"})
-				return
-			}
-
-			// 获取本组织的所有员工ID
-			var staffIDs []string
-			if err := ctrl.db.Model(&models.ServiceProviderStaff{}).
-				Where("provider_id = ?", provider.ID).
-				Pluck("user_id::text", &staffIDs).Error; err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "获取员工列表失败"})
-				return
-			}
-
-			// 只能看到本组织员工邀请的达人
-			query = query.Where("inviter_id IN ?", staffIDs)
-
-		} else if utils.IsServiceProviderStaff(user) {
-			// 服务商员工：检查是否有达人管理权限
-			var staff models.ServiceProviderStaff
-			if err := ctrl.db.Where("user_id::text = ?", user.AuthCenterUserID).First(&staff).Error; err != nil {
-				c.JSON(http.StatusForbidden, gin.H{"error": "未找到关联的员工信息"})
-				return
-			}
-
-			// 检查是否有达人管理权限
-			var permission models.ServiceProviderStaffPermission
-			hasPermission := ctrl.db.Where("staff_id = ? AND permission_code = ?", staff.ID, "MANAGE_CREATORS").First(&permission).Error == nil
-
-			if hasPermission {
-				// 有权限：查看本组织的所有达人
-				var staffIDs []string
-				if err := ctrl.db.Model(&models.ServiceProviderStaff{}).
-					Where("provider_id = ?", staff.ProviderID).
-					Pluck("user_id::text", &staffIDs).Error; err != nil {
-					c.JSON(http.StatusInternalServerError, gin.H{"error": "获取员工列表失败"})
-					return
-				}
-				query = query.Where("inviter_id IN ?", staffIDs)
-			} else {
-				// 无权限：只能查看自己邀请的达人
-				query = query.Where("inviter_id::text = ?", user.AuthCenterUserID)
-			}
-		} else {
-			// 其他角色无权限查看达人列表
-			c.JSON(http.StatusForbidden, gin.H{"error": "无权限查看达人列表"})
-			return
-		}
-	}
+	// 数据范围：服务商只能看到本组织员工邀请的达人，无达人管理权限的员工只能看到自己邀请的达人
+	query = query.Scopes(tenantScope(c).Creators)
 
 	// 等级过滤
 	if level := c.Query("level"); level != "" {
@@ -127,14 +71,17 @@ func (ctrl *CreatorController) GetCreators(c *gin.Context) {
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
 	offset := (page - 1) * pageSize
 
-	if err := query.Preload("User").Preload("Inviter").Offset(offset).Limit(pageSize).Find(&creators).Error; err != nil {
+	// 获取总数（与列表相同的过滤条件）
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取达人列表失败"})
 		return
 	}
 
-	// 获取总数
-	var total int64
-	ctrl.db.Model(&models.Creator{}).Count(&total)
+	if err := query.Preload("User").Preload("Inviter").Offset(offset).Limit(pageSize).Find(&creators).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取达人列表失败"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"data":   creators,
@@ -157,7 +104,7 @@ func (ctrl *CreatorController) GetCreator(c *gin.Context) {
 	id := c.Param("id")
 	var creator models.Creator
 
-	if err := ctrl.db.Where("id = ?", id).Preload("User").Preload("Inviter").First(&creator).Error; err != nil {
+	if err := ctrl.db.Scopes(tenantScope(c).Creators).Where("creators.id = ?", id).Preload("User").Preload("Inviter").First(&creator).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "达人不存在"})
 		return
 	}
@@ -197,26 +144,9 @@ func (ctrl *CreatorController) UpdateCreator(c *gin.Context) {
 	}
 	user := currentUser.(*models.User)
 
-	// 权限检查
-	isCreator := user.ID == creator.UserID
-
-	// 如果是管理员，检查是否有编辑达人权限
-	if utils.IsServiceProviderStaff(user) || utils.IsMerchantStaff(user) {
-		if !utils.HasPermission(ctrl.db, user, constants.PermissionEditCreatorInfo) {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "无编辑达人权限",
-				"requiredPermission": constants.PermissionEditCreatorInfo,
-			})
-			return
-		}
-	}
-
-	isAdmin := utils.IsSuperAdmin(user) || utils.IsServiceProviderAdmin(user) || utils.IsServiceProviderStaff(user)
-
-	if !isCreator && !isAdmin {
-		c.JSON(http.StatusForbidden, gin.H{"error": "无权限更新达人信息"})
-		return
-	}
+	// 能否编辑已由路由授权策略校验（creator.update），这里按身份决定可更新的字段
+	isCreator := creator.UserID == user.ID || creator.UserID == user.AuthCenterUserID
+	isAdmin := utils.ActingAs(c, constants.RoleSuperAdmin, constants.RoleServiceProviderAdmin, constants.RoleServiceProviderStaff)
 
 	// 更新字段
 	updates := make(map[string]interface{})
@@ -330,38 +260,9 @@ func (ctrl *CreatorController) GetCreatorInviterRelationship(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
-// isCreatorRole 判断当前请求是否以达人身份访问（检查 roles 数组）
-func isCreatorRole(c *gin.Context, user *models.User) bool {
-	// 1. 检查用户是否拥有 CREATOR 角色
-	if utils.HasRole(user, constants.RoleCreator) {
-		return true
-	}
-	// 2. JWT 中的 roles（避免 DB 未同步）
-	if c != nil {
-		if rolesVal, ok := c.Get("roles"); ok && rolesVal != nil {
-			switch arr := rolesVal.(type) {
-			case []string:
-				for _, r := range arr {
-					if strings.EqualFold(r, constants.RoleCreator) {
-						return true
-					}
-				}
-			case models.Roles:
-				for _, r := range arr {
-					if strings.EqualFold(r, constants.RoleCreator) {
-						return true
-					}
-				}
-			case []interface{}:
-				for _, v := range arr {
-					if s, ok := v.(string); ok && strings.EqualFold(s, constants.RoleCreator) {
-						return true
-					}
-				}
-			}
-		}
-	}
-	return false
+// isCreatorRole 判断当前请求是否以达人身份访问（激活角色为达人）
+func isCreatorRole(c *gin.Context) bool {
+	return utils.ActingAs(c, constants.RoleCreator)
 }
 
 // ensureCreatorForUser 若当前用户无达人记录则自动创建（懒创建）。仅允许有达人身份时创建，否则返回未找到。
@@ -372,7 +273,7 @@ func (ctrl *CreatorController) ensureCreatorForUser(c *gin.Context, user *models
 		return &creator, nil
 	}
 	// 仅当具备达人身份（DB 或 JWT）时才懒创建，避免给非达人建记录
-	if !isCreatorRole(c, user) {
+	if !isCreatorRole(c) {
 		return nil, gorm.ErrRecordNotFound
 	}
 	// 插入主达人记录（user_id 与 users.id 一致）
@@ -418,7 +319,7 @@ func (ctrl *CreatorController) GetMyCreatorProfile(c *gin.Context) {
 		return
 	}
 	// 无达人记录：仅有达人身份时返回 needSetup，否则 404
-	if !isCreatorRole(c, user) {
+	if !isCreatorRole(c) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "您不是达人"})
 		return
 	}
@@ -509,7 +410,7 @@ func (ctrl *CreatorController) BreakInviterRelationship(c *gin.Context) {
 
 	// 权限检查：只有达人本人或管理员可以解除关系
 	isCreator := user.ID == creator.UserID
-	isAdmin := utils.IsSuperAdmin(user)
+	isAdmin := utils.ActingAs(c, constants.RoleSuperAdmin)
 
 	if !isCreator && !isAdmin {
 		c.JSON(http.StatusForbidden, gin.H{"error": "无权限解除邀请关系"})
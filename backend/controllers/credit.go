@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"pr-business/constants"
 	"pr-business/models"
 	"pr-business/services"
 	"pr-business/utils"
@@ -25,11 +26,6 @@ func NewCreditController(db *gorm.DB) *CreditController {
 	}
 }
 
-// RechargeRequest 充值请求
-type RechargeRequest struct {
-	Amount int `json:"amount" binding:"required,min=1,max=1000000"`
-}
-
 // GetAccountBalance 获取积分余额
 // @Summary 获取积分余额
 // @Description 获取当前用户的积分余额
@@ -47,71 +43,9 @@ func (ctrl *CreditController) GetAccountBalance(c *gin.Context) {
 	}
 	user := currentUser.(*models.User)
 
-	// 超级管理员使用个人账户
-	if utils.IsSuperAdmin(user) {
-		parsedID, err := uuid.Parse(user.AuthCenterUserID)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "用户ID格式错误"})
-			return
-		}
-
-		account, err := ctrl.findOrCreateAccount(parsedID, models.OwnerTypeUserPersonal, parsedID)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "查询或创建账户失败"})
-			return
-		}
-
-		c.JSON(http.StatusOK, account)
-		return
-	}
-
-	// 确定账户类型
-	var ownerType models.OwnerType
-	var ownerID uuid.UUID
-
-	if utils.IsMerchantAdmin(user) {
-		// 商家管理员：查找商家账户（admin_id 存的是 pr 用户 id）
-		var merchant models.Merchant
-		if err := ctrl.db.Where("admin_id = ?", user.ID).First(&merchant).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "商家信息不存在"})
-			return
-		}
-		ownerType = models.OwnerTypeOrgMerchant
-		ownerID = merchant.ID
-	} else if utils.IsServiceProviderAdmin(user) || utils.IsServiceProviderStaff(user) {
-		// 服务商：查找服务商账户（admin_id / user_id 存的是 pr 用户 id）
-		var provider models.ServiceProvider
-		if utils.IsServiceProviderAdmin(user) {
-			if err := ctrl.db.Where("admin_id = ?", user.ID).First(&provider).Error; err != nil {
-				c.JSON(http.StatusNotFound, gin.H{"error": "服务商信息不存在"})
-				return
-			}
-		} else {
-			// 服务商员工
-			var providerStaff models.ServiceProviderStaff
-			if err := ctrl.db.Where("user_id = ?", user.ID).First(&providerStaff).Error; err != nil {
-				c.JSON(http.StatusNotFound, gin.H{"error": "员工信息不存在"})
-				return
-			}
-			if err := ctrl.db.Where("id = ?", providerStaff.ProviderID).First(&provider).Error; err != nil {
-				c.JSON(http.StatusNotFound, gin.H{"error": "服务商信息不存在"})
-				return
-			}
-		}
-		ownerType = models.OwnerTypeOrgProvider
-		ownerID = provider.ID
-	} else if utils.IsCreator(user) {
-		// 达人：个人账户
-		ownerType = models.OwnerTypeUserPersonal
-		// 使用user.AuthCenterUserID作为ownerID
-		parsedID, err := uuid.Parse(user.AuthCenterUserID)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "用户ID格式错误"})
-			return
-		}
-		ownerID = parsedID
-	} else {
-		c.JSON(http.StatusForbidden, gin.H{"error": "无法确定账户类型"})
+	// 按当前身份确定账户
+	ownerType, ownerID, ok := ctrl.accountOwner(c, user)
+	if !ok {
 		return
 	}
 
@@ -152,56 +86,8 @@ func (ctrl *CreditController) GetTransactions(c *gin.Context) {
 	user := currentUser.(*models.User)
 
 	// 确定账户ID（复用上面的逻辑）
-	var ownerType models.OwnerType
-	var ownerID uuid.UUID
-
-	// 超级管理员使用个人账户
-	if utils.IsSuperAdmin(user) {
-		ownerType = models.OwnerTypeUserPersonal
-		parsedID, err := uuid.Parse(user.AuthCenterUserID)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "用户ID格式错误"})
-			return
-		}
-		ownerID = parsedID
-	} else if utils.IsMerchantAdmin(user) {
-		var merchant models.Merchant
-		if err := ctrl.db.Where("admin_id = ?", user.ID).First(&merchant).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "商家信息不存在"})
-			return
-		}
-		ownerType = models.OwnerTypeOrgMerchant
-		ownerID = merchant.ID
-	} else if utils.IsServiceProviderAdmin(user) || utils.IsServiceProviderStaff(user) {
-		var provider models.ServiceProvider
-		if utils.IsServiceProviderAdmin(user) {
-			if err := ctrl.db.Where("admin_id = ?", user.ID).First(&provider).Error; err != nil {
-				c.JSON(http.StatusNotFound, gin.H{"error": "服务商信息不存在"})
-				return
-			}
-		} else {
-			var providerStaff models.ServiceProviderStaff
-			if err := ctrl.db.Where("user_id = ?", user.ID).First(&providerStaff).Error; err != nil {
-				c.JSON(http.StatusNotFound, gin.H{"error": "员工信息不存在"})
-				return
-			}
-			if err := ctrl.db.Where("id = ?", providerStaff.ProviderID).First(&provider).Error; err != nil {
-				c.JSON(http.StatusNotFound, gin.H{"error": "服务商信息不存在"})
-				return
-			}
-		}
-		ownerType = models.OwnerTypeOrgProvider
-		ownerID = provider.ID
-	} else if utils.IsCreator(user) {
-		ownerType = models.OwnerTypeUserPersonal
-		parsedID, err := uuid.Parse(user.AuthCenterUserID)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "用户ID格式错误"})
-			return
-		}
-		ownerID = parsedID
-	} else {
-		c.JSON(http.StatusForbidden, gin.H{"error": "无法确定账户类型"})
+	ownerType, ownerID, ok := ctrl.accountOwner(c, user)
+	if !ok {
 		return
 	}
 
@@ -231,7 +117,7 @@ func (ctrl *CreditController) GetTransactions(c *gin.Context) {
 	var transactions []models.CreditTransaction
 	var total int64
 
-	query := ctrl.db.Model(&models.CreditTransaction{}).Where("account_id = ?", account.ID)
+	query := ctrl.db.Model(&models.CreditTransaction{}).Scopes(tenantScope(c).CreditTransactions).Where("account_id = ?", account.ID)
 	query.Count(&total)
 
 	if err := query.Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&transactions).Error; err != nil {
@@ -247,98 +133,6 @@ func (ctrl *CreditController) GetTransactions(c *gin.Context) {
 	})
 }
 
-// Recharge 充值（模拟支付）
-// @Summary 充值
-// @Description 商家充值积分（模拟支付）
-// @Tags 积分管理
-// @Accept json
-// @Produce json
-// @Param request body RechargeRequest true "充值请求"
-// @Success 200 {object} map[string]interface{}
-// @Router /api/v1/credit/recharge [post]
-func (ctrl *CreditController) Recharge(c *gin.Context) {
-	var req RechargeRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	// 获取当前用户
-	currentUser, exists := c.Get("user")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "未认证"})
-		return
-	}
-	user := currentUser.(*models.User)
-
-	// 权限检查：只有商家管理员可以充值
-	if !utils.IsMerchantAdmin(user) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "只有商家管理员可以充值"})
-		return
-	}
-
-	// 获取商家信息（admin_id 存的是 pr 用户 id）
-	var merchant models.Merchant
-	if err := ctrl.db.Where("admin_id = ?", user.ID).First(&merchant).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "商家信息不存在"})
-		return
-	}
-
-	// 获取用户ID用于权限授予
-	userID, err := uuid.Parse(user.AuthCenterUserID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "用户ID格式错误"})
-		return
-	}
-
-	// 获取或创建账户（自动授予权限）
-	account, err := ctrl.findOrCreateAccount(merchant.ID, models.OwnerTypeOrgMerchant, userID)
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "账户不存在"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "查询或创建账户失败"})
-		}
-		return
-	}
-
-	// 开始事务
-	tx := ctrl.db.Begin()
-
-	// 更新余额
-	balanceBefore := account.Balance
-	account.Balance += req.Amount
-
-	if err := tx.Save(&account).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "充值失败"})
-		return
-	}
-
-	// 记录流水
-	transaction := models.CreditTransaction{
-		AccountID:     account.ID,
-		Type:          models.TransactionRecharge,
-		Amount:        req.Amount,
-		BalanceBefore: balanceBefore,
-		BalanceAfter:  account.Balance,
-		Description:   "商家充值",
-	}
-
-	if err := tx.Create(&transaction).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "记录流水失败"})
-		return
-	}
-
-	tx.Commit()
-
-	c.JSON(http.StatusOK, gin.H{
-		"message": "充值成功",
-		"account": account,
-	})
-}
-
 // GetUserAccounts 获取用户可访问的所有账户
 // @Summary 获取用户可访问的所有账户
 // @Description 根据用户当前角色和权限，返回可访问的账户列表
@@ -415,6 +209,37 @@ func (ctrl *CreditController) GetUserAccounts(c *gin.Context) {
 }
 
 // 辅助函数：解析整型参数
+// accountOwner 按当前身份（激活角色和组织）确定积分账户，无法确定时写入错误响应并返回 false
+// 超管和达人使用个人账户；商家管理员、服务商管理员和员工使用所代表组织的账户
+func (ctrl *CreditController) accountOwner(c *gin.Context, user *models.User) (models.OwnerType, uuid.UUID, bool) {
+	rc, _ := utils.GetRoleContext(c)
+	switch {
+	case rc == nil:
+	case rc.Is(constants.RoleSuperAdmin, constants.RoleCreator):
+		parsedID, err := uuid.Parse(user.AuthCenterUserID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "用户ID格式错误"})
+			return "", uuid.Nil, false
+		}
+		return models.OwnerTypeUserPersonal, parsedID, true
+	case rc.Is(constants.RoleMerchantAdmin):
+		if !rc.HasOrg() {
+			c.JSON(http.StatusNotFound, gin.H{"error": "商家信息不存在"})
+			return "", uuid.Nil, false
+		}
+		return models.OwnerTypeOrgMerchant, *rc.OrgID, true
+	case rc.Is(constants.RoleServiceProviderAdmin, constants.RoleServiceProviderStaff):
+		if !rc.HasOrg() {
+			c.JSON(http.StatusNotFound, gin.H{"error": "服务商信息不存在"})
+			return "", uuid.Nil, false
+		}
+		return models.OwnerTypeOrgProvider, *rc.OrgID, true
+	}
+
+	c.JSON(http.StatusForbidden, gin.H{"error": "无法确定账户类型"})
+	return "", uuid.Nil, false
+}
+
 func parseIntParam(s string) (int, error) {
 	var result int
 	_, err := fmt.Sscanf(s, "%d", &result)
@@ -0,0 +1,137 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"pr-business/constants"
+	"pr-business/models"
+	"pr-business/services"
+)
+
+// ExchangeRateController 积分汇率控制器
+type ExchangeRateController struct {
+	rateService  *services.ExchangeRateService
+	auditService *services.AuditService
+}
+
+// NewExchangeRateController 创建积分汇率控制器
+func NewExchangeRateController(
+	rateService *services.ExchangeRateService,
+	auditService *services.AuditService,
+) *ExchangeRateController {
+	return &ExchangeRateController{
+		rateService:  rateService,
+		auditService: auditService,
+	}
+}
+
+// CreateExchangeRateRequest 新增汇率请求（credits 积分 = cents 分）
+type CreateExchangeRateRequest struct {
+	Credits       int        `json:"credits" binding:"required,min=1"`
+	Cents         int        `json:"cents" binding:"required,min=1"`
+	EffectiveFrom *time.Time `json:"effectiveFrom"` // 为空表示立即生效
+	Description   string     `json:"description" binding:"max=500"`
+}
+
+// GetCurrentExchangeRate 查询当前生效的积分汇率
+// @Summary 查询当前积分汇率
+// @Tags 积分汇率
+// @Produce json
+// @Success 200 {object} models.ExchangeRate
+// @Failure 503 {object} utils.ErrorResponse
+// @Router /api/v1/exchange-rates/current [get]
+func (c *ExchangeRateController) GetCurrentExchangeRate(ctx *gin.Context) {
+	rate, err := c.rateService.Current()
+	if err != nil {
+		if errors.Is(err, services.ErrExchangeRateNotFound) {
+			ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rate)
+}
+
+// GetExchangeRates 查询积分汇率历史版本
+// @Summary 查询积分汇率历史
+// @Tags 积分汇率
+// @Produce json
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} utils.PageResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Router /api/v1/exchange-rates [get]
+func (c *ExchangeRateController) GetExchangeRates(ctx *gin.Context) {
+	page, pageSize := parsePlatformFeePage(ctx)
+	rates, total, err := c.rateService.ListRates(pageSize, (page-1)*pageSize)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"list":      rates,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// CreateExchangeRate 新增积分汇率版本
+// @Summary 新增积分汇率
+// @Description 新版本从生效时间起用于提现和充值换算；已创建的提现和充值订单仍按原汇率处理
+// @Tags 积分汇率
+// @Accept json
+// @Produce json
+// @Param request body CreateExchangeRateRequest true "积分汇率"
+// @Success 201 {object} models.ExchangeRate
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Router /api/v1/exchange-rates [post]
+func (c *ExchangeRateController) CreateExchangeRate(ctx *gin.Context) {
+	userObj := ctx.MustGet("user").(*models.User)
+
+	var req CreateExchangeRateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+
+	rate, err := c.rateService.CreateRate(&services.CreateExchangeRateRequest{
+		Credits:       req.Credits,
+		Cents:         req.Cents,
+		EffectiveFrom: req.EffectiveFrom,
+		Description:   req.Description,
+	}, userObj.AuthCenterUserID)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidExchangeRate) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	_ = c.auditService.LogFinancialOperation(
+		userObj.AuthCenterUserID,
+		constants.AuditActionExchangeRateCreate,
+		constants.AuditResourceExchangeRate,
+		rate.ID.String(),
+		map[string]interface{}{
+			"version":        rate.Version,
+			"credits":        rate.Credits,
+			"cents":          rate.Cents,
+			"effective_from": rate.EffectiveFrom,
+		},
+		ctx.ClientIP(),
+		ctx.GetHeader("User-Agent"),
+	)
+
+	ctx.JSON(http.StatusCreated, rate)
+}
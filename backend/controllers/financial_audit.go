@@ -2,9 +2,7 @@ package controllers
 
 import (
 	"net/http"
-	"pr-business/models"
 	"pr-business/services"
-	"pr-business/utils"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -39,26 +37,7 @@ func NewFinancialAuditController(auditService *services.AuditService) *Financial
 // @Failure 500 {object} utils.ErrorResponse
 // @Router /api/financial-audit-logs [get]
 func (c *FinancialAuditController) GetAuditLogs(ctx *gin.Context) {
-	// 1. 获取当前用户
-	user, exists := ctx.Get("user")
-	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "未登录"})
-		return
-	}
-
-	userObj, ok := user.(*models.User)
-	if !ok {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "用户信息格式错误"})
-		return
-	}
-
-	// 2. 权限检查（只有管理员可以查看）
-	if !utils.IsSuperAdmin(userObj) && !utils.IsServiceProviderAdmin(userObj) {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "没有权限执行此操作"})
-		return
-	}
-
-	// 3. 获取查询参数
+	// 1. 获取查询参数
 	userID := ctx.Query("user_id")
 	action := ctx.Query("action")
 	resourceType := ctx.Query("resource_type")
@@ -78,7 +57,7 @@ func (c *FinancialAuditController) GetAuditLogs(ctx *gin.Context) {
 
 	offset := (page - 1) * pageSize
 
-	// 4. 调用服务层查询
+	// 2. 调用服务层查询
 	logs, err := c.auditService.QueryAuditLogs(
 		userID,
 		action,
@@ -92,7 +71,7 @@ func (c *FinancialAuditController) GetAuditLogs(ctx *gin.Context) {
 		return
 	}
 
-	// 5. 返回结果
+	// 3. 返回结果
 	ctx.JSON(http.StatusOK, gin.H{
 		"list":      logs,
 		"total":     len(logs),
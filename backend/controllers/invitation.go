@@ -1,11 +1,13 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"pr-business/config"
 	"pr-business/constants"
 	"pr-business/models"
+	"pr-business/services"
 	"pr-business/utils"
 
 	"github.com/gin-gonic/gin"
@@ -14,25 +16,25 @@ import (
 )
 
 type InvitationController struct {
-	db  *gorm.DB
-	cfg *config.Config
+	db        *gorm.DB
+	cfg       *config.Config
+	userCache *services.UserCache
 }
 
-// getUserRoles 从上下文或数据库获取用户角色
+// getUserRoles 判断邀请码权限使用的角色：只取当前激活的角色，不合并用户拥有的其他角色
 func (ctrl *InvitationController) getUserRoles(c *gin.Context) ([]string, error) {
-	// 优先从上下文获取
-	userID := c.GetString("userId")
-	var user models.User
-	if err := ctrl.db.Where("id = ?", userID).First(&user).Error; err != nil {
-		return nil, err
+	rc, ok := utils.GetRoleContext(c)
+	if !ok {
+		return nil, errors.New("未解析到当前身份")
 	}
-	return []string(user.Roles), nil
+	return []string{rc.Role}, nil
 }
 
-func NewInvitationController(db *gorm.DB, cfg *config.Config) *InvitationController {
+func NewInvitationController(db *gorm.DB, cfg *config.Config, userCache *services.UserCache) *InvitationController {
 	return &InvitationController{
-		db:  db,
-		cfg: cfg,
+		db:        db,
+		cfg:       cfg,
+		userCache: userCache,
 	}
 }
 
@@ -305,6 +307,7 @@ func (ctrl *InvitationController) UseInvitationCode(c *gin.Context) {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "添加角色失败"})
 			return
 		}
+		ctrl.userCache.Invalidate(user.AuthCenterUserID)
 		fmt.Printf("[UseInvitationCode] Added role %s to user %s\n", targetRole, user.ID)
 	} else {
 		fmt.Printf("[UseInvitationCode] User %s already has role %s\n", user.ID, targetRole)
@@ -481,21 +484,17 @@ func (ctrl *InvitationController) canCreateInvitationCode(roles []string, codeTy
 }
 
 // GetMyFixedInvitationCodes 获取我的固定邀请码列表
-// 返回基于用户所有角色可邀请的角色类型及其对应的邀请码
+// 返回当前激活角色可邀请的角色类型及其对应的邀请码
 // GET /api/v1/invitations/fixed-codes
 func (ctrl *InvitationController) GetMyFixedInvitationCodes(c *gin.Context) {
 	userID := c.GetString("userId")
 
-	// 获取用户信息
-	var user models.User
-	if err := ctrl.db.Where("id = ?", userID).First(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取用户信息失败"})
+	roles, err := ctrl.getUserRoles(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取用户角色失败"})
 		return
 	}
 
-	// 从数据库用户对象获取角色（models.Roles -> []string）
-	roles := []string(user.Roles)
-
 	// 获取所有可邀请的角色（已内置去重逻辑）
 	invitableRoles := constants.GetInvitableRoles(roles)
 	invitableRoleMap := make(map[string]constants.InvitableRole)
@@ -520,9 +519,9 @@ func (ctrl *InvitationController) GetMyFixedInvitationCodes(c *gin.Context) {
 
 	organizations := make([]OrganizationInfo, 0)
 
-	isSuperAdmin := utils.IsSuperAdmin(&user)
-	isServiceProviderAdmin := utils.IsServiceProviderAdmin(&user)
-	isMerchantAdmin := utils.IsMerchantAdmin(&user)
+	isSuperAdmin := utils.ActingAs(c, constants.RoleSuperAdmin)
+	isServiceProviderAdmin := utils.ActingAs(c, constants.RoleServiceProviderAdmin)
+	isMerchantAdmin := utils.ActingAs(c, constants.RoleMerchantAdmin)
 
 	// 超级管理员：获取所有服务商和商家（用于生成邀请码邀请别人成为管理员）
 	// 服务商管理员：获取自己是管理员的服务商
@@ -0,0 +1,410 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"pr-business/constants"
+	"pr-business/models"
+	"pr-business/services"
+	"pr-business/utils"
+)
+
+// InvoiceController 充值开票控制器
+// 商家管理员维护开票信息并申请开票；超管（财务）开具或驳回
+type InvoiceController struct {
+	db             *gorm.DB
+	invoiceService *services.InvoiceService
+}
+
+// NewInvoiceController 创建充值开票控制器
+func NewInvoiceController(db *gorm.DB, invoiceService *services.InvoiceService) *InvoiceController {
+	return &InvoiceController{
+		db:             db,
+		invoiceService: invoiceService,
+	}
+}
+
+// BillingProfileRequest 开票信息请求
+type BillingProfileRequest struct {
+	InvoiceType string `json:"invoiceType" binding:"required,oneof=normal special"`
+	Title       string `json:"title" binding:"required,max=200"`
+	TaxID       string `json:"taxId" binding:"required,max=50"`
+	Address     string `json:"address" binding:"max=300"`
+	Phone       string `json:"phone" binding:"max=50"`
+	BankName    string `json:"bankName" binding:"max=200"`
+	BankAccount string `json:"bankAccount" binding:"max=100"`
+	Email       string `json:"email" binding:"omitempty,email,max=200"`
+	IsDefault   bool   `json:"isDefault"`
+}
+
+// CreateInvoiceRequest 开票申请请求
+type CreateInvoiceRequest struct {
+	BillingProfileID string   `json:"billingProfileId" binding:"required,uuid"`
+	RechargeOrderIDs []string `json:"rechargeOrderIds" binding:"required,min=1,max=100,dive,uuid"`
+	Remark           string   `json:"remark" binding:"max=500"`
+}
+
+// IssueInvoiceRequest 开具发票请求
+type IssueInvoiceRequest struct {
+	InvoiceNo      string `json:"invoiceNo" binding:"required,max=50"`
+	InvoiceFileURL string `json:"invoiceFileUrl" binding:"required,max=500"`
+}
+
+// RejectInvoiceRequest 驳回开票申请请求
+type RejectInvoiceRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+// GetBillingProfiles 查询本商家的开票信息
+// @Summary 开票信息列表
+// @Tags 充值开票
+// @Produce json
+// @Success 200 {array} models.BillingProfile
+// @Failure 403 {object} utils.ErrorResponse
+// @Router /api/v1/billing-profiles [get]
+func (ctrl *InvoiceController) GetBillingProfiles(c *gin.Context) {
+	merchant, _, ok := ctrl.requireMerchant(c)
+	if !ok {
+		return
+	}
+
+	profiles, err := ctrl.invoiceService.ListBillingProfiles(merchant.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, profiles)
+}
+
+// CreateBillingProfile 新增开票信息
+// @Summary 新增开票信息
+// @Description 专用发票需要填写地址、电话、开户行和银行账号
+// @Tags 充值开票
+// @Accept json
+// @Produce json
+// @Param request body BillingProfileRequest true "开票信息"
+// @Success 201 {object} models.BillingProfile
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Router /api/v1/billing-profiles [post]
+func (ctrl *InvoiceController) CreateBillingProfile(c *gin.Context) {
+	ctrl.saveBillingProfile(c, "", http.StatusCreated)
+}
+
+// UpdateBillingProfile 修改开票信息（已提交的开票申请不受影响）
+// @Summary 修改开票信息
+// @Tags 充值开票
+// @Accept json
+// @Produce json
+// @Param id path string true "开票信息ID"
+// @Param request body BillingProfileRequest true "开票信息"
+// @Success 200 {object} models.BillingProfile
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /api/v1/billing-profiles/{id} [put]
+func (ctrl *InvoiceController) UpdateBillingProfile(c *gin.Context) {
+	ctrl.saveBillingProfile(c, c.Param("id"), http.StatusOK)
+}
+
+// DeleteBillingProfile 删除开票信息
+// @Summary 删除开票信息
+// @Tags 充值开票
+// @Produce json
+// @Param id path string true "开票信息ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /api/v1/billing-profiles/{id} [delete]
+func (ctrl *InvoiceController) DeleteBillingProfile(c *gin.Context) {
+	merchant, _, ok := ctrl.requireMerchant(c)
+	if !ok {
+		return
+	}
+	user := c.MustGet("user").(*models.User)
+
+	if err := ctrl.invoiceService.DeleteBillingProfile(merchant.ID, c.Param("id"), invoiceActor(c, user)); err != nil {
+		respondInvoiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "开票信息已删除"})
+}
+
+// GetInvoiceableOrders 查询本商家可开票的充值订单
+// @Summary 可开票充值订单
+// @Description 已完成的充值订单，金额为实付金额扣除已退款和已申请开票部分（单位：分）
+// @Tags 充值开票
+// @Produce json
+// @Success 200 {array} services.InvoiceableOrder
+// @Failure 403 {object} utils.ErrorResponse
+// @Router /api/v1/invoices/invoiceable-orders [get]
+func (ctrl *InvoiceController) GetInvoiceableOrders(c *gin.Context) {
+	_, account, ok := ctrl.requireMerchant(c)
+	if !ok {
+		return
+	}
+
+	orders, err := ctrl.invoiceService.ListInvoiceableOrders(account.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, orders)
+}
+
+// CreateInvoice 商家申请开票
+// @Summary 申请开票
+// @Description 对一笔或多笔已完成的充值订单按剩余可开票金额申请开票
+// @Tags 充值开票
+// @Accept json
+// @Produce json
+// @Param request body CreateInvoiceRequest true "开票申请"
+// @Success 201 {object} models.InvoiceRequest
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
+// @Router /api/v1/invoices [post]
+func (ctrl *InvoiceController) CreateInvoice(c *gin.Context) {
+	merchant, account, ok := ctrl.requireMerchant(c)
+	if !ok {
+		return
+	}
+	user := c.MustGet("user").(*models.User)
+
+	var req CreateInvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+
+	request, err := ctrl.invoiceService.CreateRequest(merchant.ID, account.ID, &services.CreateInvoiceRequestInput{
+		BillingProfileID: req.BillingProfileID,
+		RechargeOrderIDs: req.RechargeOrderIDs,
+		Remark:           req.Remark,
+	}, invoiceActor(c, user))
+	if err != nil {
+		respondInvoiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, request)
+}
+
+// GetInvoices 查询开票申请（商家查本商家，超管查全部）
+// @Summary 开票申请列表
+// @Tags 充值开票
+// @Produce json
+// @Param status query string false "状态 pending/issued/rejected"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} utils.PageResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Router /api/v1/invoices [get]
+func (ctrl *InvoiceController) GetInvoices(c *gin.Context) {
+	page, pageSize := parsePlatformFeePage(c)
+	filter := services.InvoiceRequestFilter{
+		Status:   c.Query("status"),
+		Page:     page,
+		PageSize: pageSize,
+	}
+	if !utils.ActingAs(c, constants.RoleSuperAdmin) {
+		merchant, _, ok := ctrl.requireMerchant(c)
+		if !ok {
+			return
+		}
+		filter.MerchantID = &merchant.ID
+	}
+
+	requests, total, err := ctrl.invoiceService.List(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"list":      requests,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// GetInvoice 查询开票申请详情
+// @Summary 开票申请详情
+// @Tags 充值开票
+// @Produce json
+// @Param id path string true "开票申请ID"
+// @Success 200 {object} models.InvoiceRequest
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /api/v1/invoices/{id} [get]
+func (ctrl *InvoiceController) GetInvoice(c *gin.Context) {
+	request, err := ctrl.invoiceService.Get(c.Param("id"))
+	if err != nil {
+		respondInvoiceError(c, err)
+		return
+	}
+
+	if !utils.ActingAs(c, constants.RoleSuperAdmin) {
+		merchant, _, ok := ctrl.requireMerchant(c)
+		if !ok {
+			return
+		}
+		if request.MerchantID != merchant.ID {
+			c.JSON(http.StatusNotFound, gin.H{"error": services.ErrInvoiceRequestNotFound.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, request)
+}
+
+// IssueInvoice 财务开具发票并上传发票文件
+// @Summary 开具发票
+// @Tags 充值开票
+// @Accept json
+// @Produce json
+// @Param id path string true "开票申请ID"
+// @Param request body IssueInvoiceRequest true "发票号码和文件"
+// @Success 200 {object} models.InvoiceRequest
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /api/v1/invoices/{id}/issue [post]
+func (ctrl *InvoiceController) IssueInvoice(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	var req IssueInvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+
+	request, err := ctrl.invoiceService.Issue(c.Param("id"), req.InvoiceNo, req.InvoiceFileURL, invoiceActor(c, user))
+	if err != nil {
+		respondInvoiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, request)
+}
+
+// RejectInvoice 财务驳回开票申请
+// @Summary 驳回开票申请
+// @Description 驳回后申请占用的金额可重新申请开票
+// @Tags 充值开票
+// @Accept json
+// @Produce json
+// @Param id path string true "开票申请ID"
+// @Param request body RejectInvoiceRequest true "驳回原因"
+// @Success 200 {object} models.InvoiceRequest
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /api/v1/invoices/{id}/reject [post]
+func (ctrl *InvoiceController) RejectInvoice(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	var req RejectInvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+
+	request, err := ctrl.invoiceService.Reject(c.Param("id"), req.Reason, invoiceActor(c, user))
+	if err != nil {
+		respondInvoiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, request)
+}
+
+// saveBillingProfile 创建或修改开票信息
+func (ctrl *InvoiceController) saveBillingProfile(c *gin.Context, profileID string, status int) {
+	merchant, _, ok := ctrl.requireMerchant(c)
+	if !ok {
+		return
+	}
+	user := c.MustGet("user").(*models.User)
+
+	var req BillingProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+
+	profile, err := ctrl.invoiceService.SaveBillingProfile(merchant.ID, profileID, &services.BillingProfileInput{
+		InvoiceType: models.InvoiceType(req.InvoiceType),
+		Title:       req.Title,
+		TaxID:       req.TaxID,
+		Address:     req.Address,
+		Phone:       req.Phone,
+		BankName:    req.BankName,
+		BankAccount: req.BankAccount,
+		Email:       req.Email,
+		IsDefault:   req.IsDefault,
+	}, invoiceActor(c, user))
+	if err != nil {
+		respondInvoiceError(c, err)
+		return
+	}
+
+	c.JSON(status, profile)
+}
+
+// requireMerchant 校验当前身份为商家管理员，返回所代表的商家和商家积分账户
+func (ctrl *InvoiceController) requireMerchant(c *gin.Context) (*models.Merchant, *models.CreditAccount, bool) {
+	if !utils.ActingAs(c, constants.RoleMerchantAdmin) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "只有商家管理员可以申请开票"})
+		return nil, nil, false
+	}
+
+	var merchant models.Merchant
+	if err := ctrl.db.Where("id = ?", utils.ActiveOrgID(c)).First(&merchant).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "商家信息不存在"})
+		return nil, nil, false
+	}
+
+	var account models.CreditAccount
+	if err := ctrl.db.Where("owner_id = ? AND owner_type = ?", merchant.ID, models.OwnerTypeOrgMerchant).
+		First(&account).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "商家积分账户不存在"})
+		return nil, nil, false
+	}
+
+	return &merchant, &account, true
+}
+
+// invoiceActor 从请求上下文构造开票操作人
+func invoiceActor(c *gin.Context, user *models.User) services.InvoiceActor {
+	return services.InvoiceActor{
+		UserID:    user.AuthCenterUserID,
+		IPAddress: c.ClientIP(),
+		UserAgent: c.GetHeader("User-Agent"),
+	}
+}
+
+// respondInvoiceError 将开票服务错误映射为HTTP响应
+func respondInvoiceError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrBillingProfileNotFound),
+		errors.Is(err, services.ErrInvoiceRequestNotFound),
+		errors.Is(err, services.ErrRechargeOrderNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidBillingProfile),
+		errors.Is(err, services.ErrInvalidInvoiceRequest):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidInvoiceRequestStatus),
+		errors.Is(err, services.ErrInvalidRechargeOrderStatus),
+		errors.Is(err, services.ErrInvoiceExceedsRecharge):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"pr-business/constants"
 	"pr-business/models"
+	"pr-business/services"
 	"pr-business/utils"
 
 	"github.com/gin-gonic/gin"
@@ -11,11 +12,12 @@ import (
 )
 
 type MerchantController struct {
-	db *gorm.DB
+	db        *gorm.DB
+	userCache *services.UserCache
 }
 
-func NewMerchantController(db *gorm.DB) *MerchantController {
-	return &MerchantController{db: db}
+func NewMerchantController(db *gorm.DB, userCache *services.UserCache) *MerchantController {
+	return &MerchantController{db: db, userCache: userCache}
 }
 
 // CreateMerchantRequest 创建商家请求
@@ -71,16 +73,11 @@ func (ctrl *MerchantController) CreateMerchant(c *gin.Context) {
 	}
 	user := currentUser.(*models.User)
 
-	// 权限检查：只有超级管理员和服务商管理员可以创建商家
-	if !utils.IsSuperAdmin(user) && !utils.IsServiceProviderAdmin(user) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "无权限创建商家"})
-		return
-	}
-
+	// 只有超级管理员和服务商管理员可以创建商家（路由授权策略 merchant.create）
 	// 如果是服务商管理员，只能为自己所属的服务商创建商家
-	if utils.IsServiceProviderAdmin(user) && !utils.IsSuperAdmin(user) {
+	if utils.ActingAs(c, constants.RoleServiceProviderAdmin) {
 		var serviceProvider models.ServiceProvider
-		if err := ctrl.db.Where("admin_id = ?", user.ID).First(&serviceProvider).Error; err != nil {
+		if err := ctrl.db.Where("id = ?", utils.ActiveOrgID(c)).First(&serviceProvider).Error; err != nil {
 			c.JSON(http.StatusForbidden, gin.H{"error": "您不是服务商管理员"})
 			return
 		}
@@ -130,18 +127,17 @@ func (ctrl *MerchantController) GetMerchants(c *gin.Context) {
 	query := ctrl.db.Model(&models.Merchant{})
 
 	// 获取当前用户
-	currentUser, exists := c.Get("user")
+	_, exists := c.Get("user")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "未认证"})
 		return
 	}
-	user := currentUser.(*models.User)
 
 	// 权限过滤
-	if utils.IsServiceProviderAdmin(user) && !utils.IsSuperAdmin(user) {
+	if utils.ActingAs(c, constants.RoleServiceProviderAdmin) {
 		// 服务商管理员只能看到自己服务商下的商家
 		var serviceProvider models.ServiceProvider
-		if err := ctrl.db.Where("admin_id = ?", user.ID).First(&serviceProvider).Error; err == nil {
+		if err := ctrl.db.Where("id = ?", utils.ActiveOrgID(c)).First(&serviceProvider).Error; err == nil {
 			query = query.Where("provider_id = ?", serviceProvider.ID)
 		}
 	}
@@ -187,7 +183,7 @@ func (ctrl *MerchantController) GetMerchant(c *gin.Context) {
 
 // UpdateMerchant 更新商家信息
 // @Summary 更新商家信息
-// @Description 只有超级管理员、服务商管理员和该商家的管理员可以更新
+// @Description 只有超级管理员、所属服务商的管理员和该商家的管理员可以更新（路由授权策略 merchant.update）
 // @Tags 商家管理
 // @Accept json
 // @Produce json
@@ -209,24 +205,6 @@ func (ctrl *MerchantController) UpdateMerchant(c *gin.Context) {
 		return
 	}
 
-	// 获取当前用户
-	currentUser, exists := c.Get("user")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "未认证"})
-		return
-	}
-	user := currentUser.(*models.User)
-
-	// 权限检查
-	hasPermission := utils.IsSuperAdmin(user) ||
-		utils.IsServiceProviderAdmin(user) ||
-		(utils.IsMerchantAdmin(user) && merchant.AdminID == user.ID)
-
-	if !hasPermission {
-		c.JSON(http.StatusForbidden, gin.H{"error": "无权限更新商家信息"})
-		return
-	}
-
 	// 更新字段
 	updates := make(map[string]interface{})
 	if req.Name != "" {
@@ -252,7 +230,7 @@ func (ctrl *MerchantController) UpdateMerchant(c *gin.Context) {
 
 // DeleteMerchant 删除商家（软删除）
 // @Summary 删除商家
-// @Description 只有超级管理员可以删除商家
+// @Description 只有超级管理员可以删除商家（路由授权策略 merchant.delete）
 // @Tags 商家管理
 // @Accept json
 // @Produce json
@@ -268,20 +246,6 @@ func (ctrl *MerchantController) DeleteMerchant(c *gin.Context) {
 		return
 	}
 
-	// 获取当前用户
-	currentUser, exists := c.Get("user")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "未认证"})
-		return
-	}
-	user := currentUser.(*models.User)
-
-	// 权限检查：只有超级管理员可以删除
-	if !utils.IsSuperAdmin(user) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "无权限删除商家"})
-		return
-	}
-
 	if err := ctrl.db.Delete(&merchant).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除商家失败"})
 		return
@@ -308,27 +272,13 @@ func (ctrl *MerchantController) AddMerchantStaff(c *gin.Context) {
 		return
 	}
 
-	// 获取当前用户
-	currentUser, exists := c.Get("user")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "未认证"})
-		return
-	}
-	user := currentUser.(*models.User)
-
-	// 获取商家
+	// 获取商家（只有商家管理员可以添加员工，路由授权策略 merchant.staff_manage）
 	var merchant models.Merchant
 	if err := ctrl.db.Where("id = ?", merchantID).First(&merchant).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "商家不存在"})
 		return
 	}
 
-	// 权限检查：只有商家管理员可以添加员工
-	if !utils.IsSuperAdmin(user) && merchant.AdminID != user.ID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "无权限添加员工"})
-		return
-	}
-
 	// 检查用户是否存在
 	var targetUser models.User
 	if err := ctrl.db.Where("id = ?", req.UserID).First(&targetUser).Error; err != nil {
@@ -370,6 +320,7 @@ func (ctrl *MerchantController) AddMerchantStaff(c *gin.Context) {
 	if !utils.HasRole(&targetUser, "merchant_staff") {
 		targetUser.Roles = append(targetUser.Roles, "merchant_staff")
 		ctrl.db.Save(&targetUser)
+		ctrl.userCache.Invalidate(targetUser.AuthCenterUserID)
 	}
 
 	c.JSON(http.StatusOK, staff)
@@ -402,13 +353,13 @@ func (ctrl *MerchantController) GetMerchantStaff(c *gin.Context) {
 // @Tags 商家管理
 // @Accept json
 // @Produce json
-// @Param merchant_id path string true "商家ID"
+// @Param id path string true "商家ID"
 // @Param staff_id path string true "员工ID"
 // @Param request body UpdateMerchantStaffPermissionRequest true "更新权限请求"
 // @Success 200 {object} map[string]interface{}
-// @Router /api/v1/merchants/{merchant_id}/staff/{staff_id}/permissions [put]
+// @Router /api/v1/merchants/{id}/staff/{staff_id}/permissions [put]
 func (ctrl *MerchantController) UpdateMerchantStaffPermission(c *gin.Context) {
-	merchantID := c.Param("merchant_id")
+	merchantID := c.Param("id")
 	staffID := c.Param("staff_id")
 	var req UpdateMerchantStaffPermissionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -416,27 +367,7 @@ func (ctrl *MerchantController) UpdateMerchantStaffPermission(c *gin.Context) {
 		return
 	}
 
-	// 获取当前用户
-	currentUser, exists := c.Get("user")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "未认证"})
-		return
-	}
-	user := currentUser.(*models.User)
-
-	// 获取商家
-	var merchant models.Merchant
-	if err := ctrl.db.Where("id = ?", merchantID).First(&merchant).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "商家不存在"})
-		return
-	}
-
-	// 权限检查：只有商家管理员可以更新员工权限
-	if !utils.IsSuperAdmin(user) && merchant.AdminID != user.ID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "无权限更新员工权限"})
-		return
-	}
-
+	// 只有商家管理员可以更新员工权限（路由授权策略 merchant.staff_manage，商家不存在时返回 404）
 	// 获取员工
 	var staff models.MerchantStaff
 	if err := ctrl.db.Where("id = ? AND merchant_id = ?", staffID, merchantID).First(&staff).Error; err != nil {
@@ -469,35 +400,15 @@ func (ctrl *MerchantController) UpdateMerchantStaffPermission(c *gin.Context) {
 // @Tags 商家管理
 // @Accept json
 // @Produce json
-// @Param merchant_id path string true "商家ID"
+// @Param id path string true "商家ID"
 // @Param staff_id path string true "员工ID"
 // @Success 200 {object} map[string]interface{}
-// @Router /api/v1/merchants/{merchant_id}/staff/{staff_id} [delete]
+// @Router /api/v1/merchants/{id}/staff/{staff_id} [delete]
 func (ctrl *MerchantController) DeleteMerchantStaff(c *gin.Context) {
-	merchantID := c.Param("merchant_id")
+	merchantID := c.Param("id")
 	staffID := c.Param("staff_id")
 
-	// 获取当前用户
-	currentUser, exists := c.Get("user")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "未认证"})
-		return
-	}
-	user := currentUser.(*models.User)
-
-	// 获取商家
-	var merchant models.Merchant
-	if err := ctrl.db.Where("id = ?", merchantID).First(&merchant).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "商家不存在"})
-		return
-	}
-
-	// 权限检查：只有商家管理员可以删除员工
-	if !utils.IsSuperAdmin(user) && merchant.AdminID != user.ID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "无权限删除员工"})
-		return
-	}
-
+	// 只有商家管理员可以删除员工（路由授权策略 merchant.staff_manage，商家不存在时返回 404）
 	// 删除员工权限
 	ctrl.db.Where("staff_id = ?", staffID).Delete(&models.MerchantStaffPermission{})
 
@@ -520,15 +431,19 @@ func (ctrl *MerchantController) DeleteMerchantStaff(c *gin.Context) {
 // @Router /api/v1/merchant/me [get]
 func (ctrl *MerchantController) GetMyMerchant(c *gin.Context) {
 	// 获取当前用户
-	currentUser, exists := c.Get("user")
+	_, exists := c.Get("user")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "未认证"})
 		return
 	}
-	user := currentUser.(*models.User)
+
+	if !utils.ActingAs(c, constants.RoleMerchantAdmin) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "您不是商家管理员"})
+		return
+	}
 
 	var merchant models.Merchant
-	if err := ctrl.db.Where("admin_id = ?", user.ID).Preload("Admin").Preload("Provider").Preload("User").Preload("Staff").First(&merchant).Error; err != nil {
+	if err := ctrl.db.Where("id = ?", utils.ActiveOrgID(c)).Preload("Admin").Preload("Provider").Preload("User").Preload("Staff").First(&merchant).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "您不是商家管理员"})
 		return
 	}
@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"pr-business/constants"
+	"pr-business/models"
+	"pr-business/services"
+	"pr-business/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxPaymentCallbackBody 回调报文大小上限
+const maxPaymentCallbackBody = 1 << 20
+
+// PaymentController 支付渠道回调
+type PaymentController struct {
+	db             *gorm.DB
+	paymentService *services.PaymentService
+}
+
+func NewPaymentController(db *gorm.DB, paymentService *services.PaymentService) *PaymentController {
+	return &PaymentController{
+		db:             db,
+		paymentService: paymentService,
+	}
+}
+
+// GetPaymentProviders 获取已启用的在线支付渠道
+// @Summary 获取在线支付渠道
+// @Tags 支付
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/payments/providers [get]
+func (ctrl *PaymentController) GetPaymentProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"providers": ctrl.paymentService.Providers()})
+}
+
+// PaymentCallback 支付渠道异步通知
+// 验签通过且支付成功时完成充值订单并入账；同一订单重复通知只入账一次
+// 应答格式按渠道要求返回，处理失败时返回非成功应答，由渠道重试
+// @Summary 支付渠道回调
+// @Tags 支付
+// @Param provider path string true "支付渠道：wechat/alipay/mock"
+// @Router /api/v1/payments/callback/{provider} [post]
+func (ctrl *PaymentController) PaymentCallback(c *gin.Context) {
+	provider := c.Param("provider")
+	gateway, err := ctrl.paymentService.Gateway(provider)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxPaymentCallbackBody))
+	if err != nil {
+		contentType, ack := gateway.CallbackAck(false, "读取回调失败")
+		c.Data(http.StatusBadRequest, contentType, ack)
+		return
+	}
+
+	order, err := ctrl.paymentService.HandleCallback(provider, c.Request, body)
+	if err != nil {
+		log.Printf("[PaymentCallback] %s 回调处理失败: %v", provider, err)
+
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, services.ErrPaymentSignatureInvalid):
+			status = http.StatusUnauthorized
+		case errors.Is(err, services.ErrRechargeOrderNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, services.ErrPaymentAmountMismatch), errors.Is(err, services.ErrInvalidRechargeOrderStatus):
+			status = http.StatusConflict
+		}
+		contentType, ack := gateway.CallbackAck(false, err.Error())
+		c.Data(status, contentType, ack)
+		return
+	}
+
+	if order != nil {
+		log.Printf("[PaymentCallback] 充值订单 %s 已入账（%s）", order.ID, provider)
+	}
+	contentType, ack := gateway.CallbackAck(true, "OK")
+	c.Data(http.StatusOK, contentType, ack)
+}
+
+// SimulateMockPayment 模拟渠道付款（仅启用模拟渠道时注册），走与真实回调相同的验签和入账流程
+// @Summary 模拟付款
+// @Tags 支付
+// @Param id path string true "充值订单ID"
+// @Router /api/v1/payments/mock/{id}/pay [post]
+func (ctrl *PaymentController) SimulateMockPayment(c *gin.Context) {
+	user, ok := utils.GetCurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未认证"})
+		return
+	}
+
+	var order models.RechargeOrder
+	if err := ctrl.db.Where("id = ?", c.Param("id")).First(&order).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "充值订单不存在"})
+		return
+	}
+	if order.UserID != user.ID && !utils.ActingAs(c, constants.RoleSuperAdmin) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权操作该订单"})
+		return
+	}
+	if order.PaymentMethod != services.PaymentProviderMock || order.OutTradeNo == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "该订单不是模拟渠道订单"})
+		return
+	}
+
+	completed, err := ctrl.paymentService.SimulateMockPayment(*order.OutTradeNo)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, completed)
+}
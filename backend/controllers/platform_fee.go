@@ -0,0 +1,309 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"pr-business/constants"
+	"pr-business/models"
+	"pr-business/services"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// PlatformFeeController 平台手续费控制器（仅超级管理员）
+type PlatformFeeController struct {
+	platformFeeService *services.PlatformFeeService
+	auditService       *services.AuditService
+}
+
+func NewPlatformFeeController(
+	platformFeeService *services.PlatformFeeService,
+	auditService *services.AuditService,
+) *PlatformFeeController {
+	return &PlatformFeeController{
+		platformFeeService: platformFeeService,
+		auditService:       auditService,
+	}
+}
+
+// CreatePlatformFeeRuleRequest 创建手续费规则请求
+type CreatePlatformFeeRuleRequest struct {
+	Scope         string     `json:"scope" binding:"required,oneof=GLOBAL PROVIDER MERCHANT CAMPAIGN"`
+	ScopeID       *string    `json:"scopeId"`
+	FeeType       string     `json:"feeType" binding:"required,oneof=PERCENTAGE FIXED"`
+	RateBasis     int        `json:"rateBasis" binding:"min=0,max=10000"` // 万分比，500 = 5%
+	FixedAmount   int        `json:"fixedAmount" binding:"min=0"`
+	EffectiveFrom *time.Time `json:"effectiveFrom"` // 为空表示立即生效
+	EffectiveTo   *time.Time `json:"effectiveTo"`
+	Description   string     `json:"description" binding:"max=500"`
+}
+
+// EndPlatformFeeRuleRequest 结束手续费规则请求
+type EndPlatformFeeRuleRequest struct {
+	EffectiveTo *time.Time `json:"effectiveTo"` // 为空表示立即失效
+}
+
+// CreatePlatformFeeRule 创建手续费规则
+// @Summary 创建平台手续费规则
+// @Description 按比例或固定金额收取，可针对服务商、商家、活动或全局设置，按生效时间生效
+// @Tags 平台手续费
+// @Accept json
+// @Produce json
+// @Param request body CreatePlatformFeeRuleRequest true "手续费规则"
+// @Success 201 {object} models.PlatformFeeRule
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Router /api/platform-fee-rules [post]
+func (c *PlatformFeeController) CreatePlatformFeeRule(ctx *gin.Context) {
+	userObj := ctx.MustGet("user").(*models.User)
+
+	var req CreatePlatformFeeRuleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+
+	input := &services.CreatePlatformFeeRuleRequest{
+		Scope:         models.PlatformFeeScope(req.Scope),
+		FeeType:       models.PlatformFeeType(req.FeeType),
+		RateBasis:     req.RateBasis,
+		FixedAmount:   req.FixedAmount,
+		EffectiveFrom: time.Now(),
+		EffectiveTo:   req.EffectiveTo,
+		Description:   req.Description,
+	}
+	if req.EffectiveFrom != nil {
+		input.EffectiveFrom = *req.EffectiveFrom
+	}
+	if req.ScopeID != nil && *req.ScopeID != "" {
+		scopeID, err := uuid.Parse(*req.ScopeID)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "无效的适用对象ID"})
+			return
+		}
+		input.ScopeID = &scopeID
+	}
+
+	rule, err := c.platformFeeService.CreateRule(input, userObj.AuthCenterUserID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	_ = c.auditService.LogFinancialOperation(
+		userObj.AuthCenterUserID,
+		constants.AuditActionPlatformFeeCreate,
+		constants.AuditResourcePlatformFeeRule,
+		rule.ID.String(),
+		map[string]interface{}{
+			"scope":          rule.Scope,
+			"scope_id":       rule.ScopeID,
+			"fee_type":       rule.FeeType,
+			"rate_basis":     rule.RateBasis,
+			"fixed_amount":   rule.FixedAmount,
+			"effective_from": rule.EffectiveFrom,
+			"effective_to":   rule.EffectiveTo,
+		},
+		ctx.ClientIP(),
+		ctx.GetHeader("User-Agent"),
+	)
+
+	ctx.JSON(http.StatusCreated, rule)
+}
+
+// GetPlatformFeeRules 查询手续费规则列表
+// @Summary 查询平台手续费规则
+// @Tags 平台手续费
+// @Accept json
+// @Produce json
+// @Param scope query string false "适用范围"
+// @Param include_inactive query bool false "包含已停用规则"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} utils.PageResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Router /api/platform-fee-rules [get]
+func (c *PlatformFeeController) GetPlatformFeeRules(ctx *gin.Context) {
+	page, pageSize := parsePlatformFeePage(ctx)
+	rules, total, err := c.platformFeeService.ListRules(
+		ctx.Query("scope"),
+		ctx.Query("include_inactive") == "true",
+		pageSize,
+		(page-1)*pageSize,
+	)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"list":      rules,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// EndPlatformFeeRule 结束手续费规则
+// @Summary 结束平台手续费规则
+// @Description 设置规则失效时间，之后结算的任务不再使用该规则；已结算的明细不受影响
+// @Tags 平台手续费
+// @Accept json
+// @Produce json
+// @Param id path string true "规则ID"
+// @Param request body EndPlatformFeeRuleRequest false "失效时间"
+// @Success 200 {object} models.PlatformFeeRule
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /api/platform-fee-rules/{id}/end [post]
+func (c *PlatformFeeController) EndPlatformFeeRule(ctx *gin.Context) {
+	userObj := ctx.MustGet("user").(*models.User)
+
+	var req EndPlatformFeeRuleRequest
+	_ = ctx.ShouldBindJSON(&req)
+	effectiveTo := time.Now()
+	if req.EffectiveTo != nil {
+		effectiveTo = *req.EffectiveTo
+	}
+
+	rule, err := c.platformFeeService.EndRule(ctx.Param("id"), effectiveTo)
+	if err != nil {
+		if errors.Is(err, services.ErrPlatformFeeRuleNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "手续费规则不存在"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	_ = c.auditService.LogFinancialOperation(
+		userObj.AuthCenterUserID,
+		constants.AuditActionPlatformFeeEnd,
+		constants.AuditResourcePlatformFeeRule,
+		rule.ID.String(),
+		map[string]interface{}{
+			"effective_to": rule.EffectiveTo,
+		},
+		ctx.ClientIP(),
+		ctx.GetHeader("User-Agent"),
+	)
+
+	ctx.JSON(http.StatusOK, rule)
+}
+
+// GetSettlementRecords 查询任务结算明细
+// @Summary 查询任务结算明细（手续费与各方分配）
+// @Tags 平台手续费
+// @Accept json
+// @Produce json
+// @Param campaign_id query string false "活动ID"
+// @Param from query string false "开始日期 YYYY-MM-DD"
+// @Param to query string false "结束日期 YYYY-MM-DD（不含）"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} utils.PageResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Router /api/platform-fees/settlements [get]
+func (c *PlatformFeeController) GetSettlementRecords(ctx *gin.Context) {
+	var from, to *time.Time
+	if value := ctx.Query("from"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "开始日期格式错误，应为 YYYY-MM-DD"})
+			return
+		}
+		from = &parsed
+	}
+	if value := ctx.Query("to"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "结束日期格式错误，应为 YYYY-MM-DD"})
+			return
+		}
+		to = &parsed
+	}
+
+	page, pageSize := parsePlatformFeePage(ctx)
+	records, total, err := c.platformFeeService.ListSettlementRecords(ctx.Query("campaign_id"), from, to, pageSize, (page-1)*pageSize)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"list":      records,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// GetPlatformRevenue 平台收益报表
+// @Summary 按日/月汇总平台手续费收益
+// @Tags 平台手续费
+// @Accept json
+// @Produce json
+// @Param from query string true "开始日期 YYYY-MM-DD"
+// @Param to query string true "结束日期 YYYY-MM-DD（不含）"
+// @Param period query string false "汇总周期 day/month" default(month)
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Router /api/platform-fees/revenue [get]
+func (c *PlatformFeeController) GetPlatformRevenue(ctx *gin.Context) {
+	from, err := time.ParseInLocation("2006-01-02", ctx.Query("from"), time.Local)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "开始日期格式错误，应为 YYYY-MM-DD"})
+		return
+	}
+	to, err := time.ParseInLocation("2006-01-02", ctx.Query("to"), time.Local)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "结束日期格式错误，应为 YYYY-MM-DD"})
+		return
+	}
+	if !to.After(from) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "结束日期必须晚于开始日期"})
+		return
+	}
+
+	period := ctx.DefaultQuery("period", "month")
+	if period != "day" && period != "month" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "汇总周期只支持 day 或 month"})
+		return
+	}
+
+	periods, err := c.platformFeeService.RevenueReport(from, to, period)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var totalFee int64
+	for _, p := range periods {
+		totalFee += p.PlatformFee
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"from":         from.Format("2006-01-02"),
+		"to":           to.Format("2006-01-02"),
+		"period":       period,
+		"list":         periods,
+		"platform_fee": totalFee,
+	})
+}
+
+// parsePlatformFeePage 解析分页参数
+func parsePlatformFeePage(ctx *gin.Context) (int, int) {
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	pageSize, err := strconv.Atoi(ctx.DefaultQuery("page_size", "20"))
+	if err != nil || pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	return page, pageSize
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"pr-business/constants"
 	"pr-business/models"
 	"pr-business/services"
 	"pr-business/utils"
@@ -19,13 +20,17 @@ type RechargeOrderController struct {
 	db                *gorm.DB
 	permissionService  *services.AccountPermissionService
 	validatorService   *services.ValidatorService
+	ledgerService      *services.LedgerService
+	paymentService    *services.PaymentService
 }
 
-func NewRechargeOrderController(db *gorm.DB) *RechargeOrderController {
+func NewRechargeOrderController(db *gorm.DB, paymentService *services.PaymentService) *RechargeOrderController {
 	return &RechargeOrderController{
 		db:                db,
 		permissionService:  services.NewAccountPermissionService(db),
 		validatorService:   services.NewValidatorService(db),
+		ledgerService:      services.NewLedgerService(db),
+		paymentService:    paymentService,
 	}
 }
 
@@ -56,14 +61,14 @@ func (ctrl *RechargeOrderController) CreateRechargeOrder(c *gin.Context) {
 	var ownerID uuid.UUID
 
 	// 只有商家管理员可以充值
-	if !utils.IsMerchantAdmin(user) {
+	if !utils.ActingAs(c, constants.RoleMerchantAdmin) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "只有商家管理员可以充值"})
 		return
 	}
 
 	// 获取商家信息
 	var merchant models.Merchant
-	if err := ctrl.db.Where("admin_id = ?", user.ID).First(&merchant).Error; err != nil {
+	if err := ctrl.db.Where("id = ?", utils.ActiveOrgID(c)).First(&merchant).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "商家信息不存在"})
 		return
 	}
@@ -84,13 +89,23 @@ func (ctrl *RechargeOrderController) CreateRechargeOrder(c *gin.Context) {
 		return
 	}
 
+	// 按当前汇率计算应付金额，审核时核对凭证金额
+	rate, cashAmount, err := ctrl.paymentService.QuoteRecharge(req.Amount)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+
 	// 创建充值订单
 	order := models.RechargeOrder{
 		UserID:        user.ID,
 		AccountID:     account.ID,
 		Amount:         req.Amount,
+		ExchangeRateID: &rate.ID,
+		CashAmount:     cashAmount,
 		PaymentMethod:  req.PaymentMethod,
 		PaymentProof:   req.PaymentProof,
+		Channel:        models.RechargeChannelOffline,
 		Status:         models.RechargeOrderStatusPending,
 	}
 
@@ -104,6 +119,7 @@ func (ctrl *RechargeOrderController) CreateRechargeOrder(c *gin.Context) {
 		"order": gin.H{
 			"id":            order.ID,
 			"amount":        order.Amount,
+			"cashAmount":    order.CashAmount,
 			"paymentMethod":  order.PaymentMethod,
 			"status":        order.Status,
 			"createdAt":     order.CreatedAt,
@@ -131,7 +147,7 @@ func (ctrl *RechargeOrderController) GetRechargeOrders(c *gin.Context) {
 	query := ctrl.db.Model(&models.RechargeOrder{})
 
 	// 超管可以查看所有订单，商家只能查看自己的
-	if !utils.IsSuperAdmin(user) {
+	if !utils.ActingAs(c, constants.RoleSuperAdmin) {
 		query = query.Where("user_id = ?", user.ID)
 	}
 
@@ -173,14 +189,9 @@ func (ctrl *RechargeOrderController) AuditRechargeOrder(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "未认证"})
 		return
 	}
+	// 只有超管可以审核（路由授权策略 recharge.audit）
 	user := currentUser.(*models.User)
 
-	// 只有超管可以审核
-	if !utils.IsSuperAdmin(user) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "无权审核充值订单"})
-		return
-	}
-
 	id := c.Param("id")
 
 	var req AuditRechargeOrderRequest
@@ -221,23 +232,19 @@ func (ctrl *RechargeOrderController) AuditRechargeOrder(c *gin.Context) {
 				return err
 			}
 
-			// 充值入账
-			balanceBefore := account.Balance
-			account.Balance += order.Amount
-			if err := tx.Save(&account).Error; err != nil {
+			// 充值入账：积分发行账户 → 商家可用余额
+			issuanceID, err := ctrl.ledgerService.SystemAccountID(tx, constants.SystemAccountTypeCreditIssuance)
+			if err != nil {
 				return err
 			}
-
-			// 记录充值流水
-			transaction := models.CreditTransaction{
-				AccountID:        account.ID,
-				Type:             models.TransactionRecharge,
-				Amount:           order.Amount,
-				BalanceBefore:     balanceBefore,
-				BalanceAfter:      account.Balance,
-				Description:       fmt.Sprintf("充值订单：%s", order.ID),
-			}
-			if err := tx.Create(&transaction).Error; err != nil {
+			if _, err := ctrl.ledgerService.Post(tx, &services.LedgerTransfer{
+				Type:        models.TransactionRecharge,
+				Description: fmt.Sprintf("充值订单：%s", order.ID),
+				Postings: []services.LedgerPosting{
+					services.SystemPosting(issuanceID, -order.Amount),
+					services.CreditPosting(account.ID, order.Amount),
+				},
+			}); err != nil {
 				return err
 			}
 
@@ -283,6 +290,182 @@ func (ctrl *RechargeOrderController) AuditRechargeOrder(c *gin.Context) {
 	})
 }
 
+// CreateOnlineRechargeRequest 在线充值请求
+type CreateOnlineRechargeRequest struct {
+	Amount   int    `json:"amount" binding:"required,min=1,max=1000000"` // 积分，应付金额按当前汇率换算
+	Provider string `json:"provider" binding:"required"`                 // 支付渠道：wechat/alipay/mock
+}
+
+// CreateOnlineRecharge 商家发起在线充值
+// @Summary 在线充值
+// @Description 创建在线充值订单并在支付渠道下单，返回扫码支付链接；支付成功后由渠道回调自动入账
+// @Tags 充值订单
+// @Accept json
+// @Produce json
+// @Param Idempotency-Key header string false "幂等键"
+// @Param request body CreateOnlineRechargeRequest true "在线充值请求"
+// @Success 200 {object} models.RechargeOrder
+// @Router /api/v1/recharge-orders/online [post]
+func (ctrl *RechargeOrderController) CreateOnlineRecharge(c *gin.Context) {
+	user, ok := utils.GetCurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未认证"})
+		return
+	}
+
+	var req CreateOnlineRechargeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !utils.ActingAs(c, constants.RoleMerchantAdmin) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "只有商家管理员可以充值"})
+		return
+	}
+
+	var merchant models.Merchant
+	if err := ctrl.db.Where("id = ?", utils.ActiveOrgID(c)).First(&merchant).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "商家信息不存在"})
+		return
+	}
+
+	userID, err := uuid.Parse(user.AuthCenterUserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "用户ID格式错误"})
+		return
+	}
+
+	account, err := ctrl.findOrCreateAccount(merchant.ID, models.OwnerTypeOrgMerchant, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取积分账户失败"})
+		return
+	}
+
+	order, err := ctrl.paymentService.CreateOnlineRecharge(c.Request.Context(), user.ID, account.ID, req.Amount, req.Provider, c.ClientIP())
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrPaymentProviderNotSupported):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "providers": ctrl.paymentService.Providers()})
+		case errors.Is(err, services.ErrPaymentGatewayUnavailable), errors.Is(err, services.ErrPaymentGatewayRejected):
+			c.JSON(http.StatusBadGateway, gin.H{"error": "支付渠道下单失败，请稍后重试"})
+		case errors.Is(err, services.ErrExchangeRateNotFound):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "创建充值订单失败"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "请在有效期内完成支付",
+		"order":   order,
+	})
+}
+
+// SyncRechargeOrder 向支付渠道查询在线充值订单状态（回调未到达时补单）
+// @Summary 查询在线充值支付状态
+// @Tags 充值订单
+// @Produce json
+// @Param id path string true "充值订单ID"
+// @Success 200 {object} models.RechargeOrder
+// @Router /api/v1/recharge-orders/{id}/sync [post]
+func (ctrl *RechargeOrderController) SyncRechargeOrder(c *gin.Context) {
+	user, ok := utils.GetCurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未认证"})
+		return
+	}
+
+	var order models.RechargeOrder
+	if err := ctrl.db.Where("id = ?", c.Param("id")).First(&order).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "充值订单不存在"})
+		return
+	}
+	if order.UserID != user.ID && !utils.ActingAs(c, constants.RoleSuperAdmin) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权查看该订单"})
+		return
+	}
+
+	synced, err := ctrl.paymentService.SyncOrder(c.Request.Context(), &order)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrRechargeOrderNotOnline):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrPaymentAmountMismatch):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrRefundPending):
+			c.JSON(http.StatusAccepted, gin.H{"message": err.Error()})
+		case errors.Is(err, services.ErrPaymentGatewayUnavailable), errors.Is(err, services.ErrPaymentGatewayRejected):
+			c.JSON(http.StatusBadGateway, gin.H{"error": "查询支付渠道失败，请稍后重试"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "查询支付状态失败"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, synced)
+}
+
+// RefundRechargeOrderRequest 在线充值退款请求
+type RefundRechargeOrderRequest struct {
+	Amount int    `json:"amount" binding:"required,min=1"`
+	Reason string `json:"reason" binding:"required,max=200"`
+}
+
+// RefundRechargeOrder 超管对在线充值订单退款（冻结商家积分后原路退款，渠道受理后扣回）
+// @Summary 在线充值退款
+// @Tags 充值订单
+// @Accept json
+// @Produce json
+// @Param id path string true "充值订单ID"
+// @Param Idempotency-Key header string false "幂等键"
+// @Param request body RefundRechargeOrderRequest true "退款请求"
+// @Success 200 {object} models.RechargeOrder
+// @Router /api/v1/recharge-orders/{id}/refund [post]
+func (ctrl *RechargeOrderController) RefundRechargeOrder(c *gin.Context) {
+	user, ok := utils.GetCurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未认证"})
+		return
+	}
+
+	var req RefundRechargeOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	order, err := ctrl.paymentService.Refund(c.Request.Context(), c.Param("id"), req.Amount, req.Reason, user.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrRechargeOrderNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrRechargeOrderNotOnline),
+			errors.Is(err, services.ErrInvalidRechargeOrderStatus),
+			errors.Is(err, services.ErrRefundExceedsPaid),
+			errors.Is(err, services.ErrInvoiceExceedsRecharge),
+			errors.Is(err, services.ErrInsufficientBalance):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrRefundInProgress):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrRefundPending):
+			// 积分已冻结，渠道结果未知；查单时按同一退款单号重试
+			c.JSON(http.StatusAccepted, gin.H{"message": err.Error()})
+		case errors.Is(err, services.ErrPaymentGatewayUnavailable), errors.Is(err, services.ErrPaymentGatewayRejected):
+			c.JSON(http.StatusBadGateway, gin.H{"error": "支付渠道退款失败: " + err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "退款失败"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "退款成功",
+		"order":   order,
+	})
+}
+
 // findOrCreateAccount 查找或创建账户（带权限）
 func (ctrl *RechargeOrderController) findOrCreateAccount(ownerID uuid.UUID, ownerType models.OwnerType, userID uuid.UUID) (*models.CreditAccount, error) {
 	var account models.CreditAccount
@@ -0,0 +1,155 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"pr-business/constants"
+	"pr-business/models"
+	"pr-business/services"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReconciliationController 对账报告控制器（仅超级管理员）
+type ReconciliationController struct {
+	reconciliationService *services.ReconciliationService
+	auditService          *services.AuditService
+}
+
+func NewReconciliationController(
+	reconciliationService *services.ReconciliationService,
+	auditService *services.AuditService,
+) *ReconciliationController {
+	return &ReconciliationController{
+		reconciliationService: reconciliationService,
+		auditService:          auditService,
+	}
+}
+
+// RunReconciliation 立即执行一次对账
+// @Summary 执行对账
+// @Description 重放积分流水和分录，核对账户余额、商家冻结积分与现金出款，差异写入对账报告
+// @Tags 对账管理
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.ReconciliationRun
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /api/reconciliation/runs [post]
+func (c *ReconciliationController) RunReconciliation(ctx *gin.Context) {
+	// 1. 获取当前用户
+	userObj := ctx.MustGet("user").(*models.User)
+
+	// 2. 执行对账
+	run, err := c.reconciliationService.Run(userObj.AuthCenterUserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "执行对账失败: " + err.Error()})
+		return
+	}
+
+	// 3. 记录审计日志
+	_ = c.auditService.LogFinancialOperation(
+		userObj.AuthCenterUserID,
+		constants.AuditActionReconciliationRun,
+		constants.AuditResourceReconciliation,
+		run.ID.String(),
+		map[string]interface{}{
+			"discrepancy_count": run.DiscrepancyCount,
+		},
+		ctx.ClientIP(),
+		ctx.GetHeader("User-Agent"),
+	)
+
+	ctx.JSON(http.StatusOK, run)
+}
+
+// GetReconciliationRuns 查询对账批次列表
+// @Summary 查询对账批次列表
+// @Tags 对账管理
+// @Accept json
+// @Produce json
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} utils.PageResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Router /api/reconciliation/runs [get]
+func (c *ReconciliationController) GetReconciliationRuns(ctx *gin.Context) {
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	pageSize, err := strconv.Atoi(ctx.DefaultQuery("page_size", "20"))
+	if err != nil || pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	runs, total, err := c.reconciliationService.ListRuns(pageSize, (page-1)*pageSize)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "查询对账批次失败: " + err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"list":      runs,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// GetReconciliationRun 查询对账批次详情（含差异明细）
+// @Summary 查询对账报告
+// @Tags 对账管理
+// @Accept json
+// @Produce json
+// @Param id path string true "对账批次ID"
+// @Success 200 {object} models.ReconciliationRun
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /api/reconciliation/runs/{id} [get]
+func (c *ReconciliationController) GetReconciliationRun(ctx *gin.Context) {
+	run, err := c.reconciliationService.GetRun(ctx.Param("id"))
+	if err != nil {
+		c.respondRunError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, run)
+}
+
+// DownloadReconciliationCSV 下载对账差异 CSV
+// @Summary 下载对账报告 CSV
+// @Tags 对账管理
+// @Produce text/csv
+// @Param id path string true "对账批次ID"
+// @Success 200 {file} file
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /api/reconciliation/runs/{id}/csv [get]
+func (c *ReconciliationController) DownloadReconciliationCSV(ctx *gin.Context) {
+	run, err := c.reconciliationService.GetRun(ctx.Param("id"))
+	if err != nil {
+		c.respondRunError(ctx, err)
+		return
+	}
+
+	filename := fmt.Sprintf("reconciliation_%s.csv", run.StartedAt.Format("20060102_150405"))
+	ctx.Header("Content-Type", "text/csv; charset=utf-8")
+	ctx.Header("Content-Disposition", "attachment; filename="+filename)
+	ctx.Status(http.StatusOK)
+
+	if err := c.reconciliationService.WriteCSV(run, ctx.Writer); err != nil {
+		_ = ctx.Error(err)
+	}
+}
+
+// respondRunError 将服务层错误映射为 HTTP 响应
+func (c *ReconciliationController) respondRunError(ctx *gin.Context, err error) {
+	if errors.Is(err, services.ErrReconciliationRunNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "对账批次不存在"})
+		return
+	}
+	ctx.JSON(http.StatusInternalServerError, gin.H{"error": "查询对账批次失败: " + err.Error()})
+}
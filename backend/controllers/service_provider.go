@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"pr-business/constants"
 	"pr-business/models"
+	"pr-business/services"
 	"pr-business/utils"
 
 	"github.com/gin-gonic/gin"
@@ -11,11 +12,12 @@ import (
 )
 
 type ServiceProviderController struct {
-	db *gorm.DB
+	db        *gorm.DB
+	userCache *services.UserCache
 }
 
-func NewServiceProviderController(db *gorm.DB) *ServiceProviderController {
-	return &ServiceProviderController{db: db}
+func NewServiceProviderController(db *gorm.DB, userCache *services.UserCache) *ServiceProviderController {
+	return &ServiceProviderController{db: db, userCache: userCache}
 }
 
 // CreateServiceProviderRequest 创建服务商请求
@@ -68,12 +70,6 @@ func (ctrl *ServiceProviderController) CreateServiceProvider(c *gin.Context) {
 	}
 	user := currentUser.(*models.User)
 
-	// 权限检查：只有超级管理员可以创建服务商
-	if !utils.IsSuperAdmin(user) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "无权限创建服务商"})
-		return
-	}
-
 	// 创建服务商（admin_id 暂时为空，后续通过邀请码绑定管理员）
 	provider := models.ServiceProvider{
 		UserID:      user.ID, // 记录创建者
@@ -160,23 +156,6 @@ func (ctrl *ServiceProviderController) UpdateServiceProvider(c *gin.Context) {
 		return
 	}
 
-	// 获取当前用户
-	currentUser, exists := c.Get("user")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "未认证"})
-		return
-	}
-	user := currentUser.(*models.User)
-
-	// 权限检查
-	hasPermission := utils.IsSuperAdmin(user) ||
-		(utils.IsServiceProviderAdmin(user) && provider.AdminID != nil && user.ID == *provider.AdminID)
-
-	if !hasPermission {
-		c.JSON(http.StatusForbidden, gin.H{"error": "无权限更新服务商信息"})
-		return
-	}
-
 	// 更新字段
 	updates := make(map[string]interface{})
 	if req.Name != "" {
@@ -215,20 +194,6 @@ func (ctrl *ServiceProviderController) DeleteServiceProvider(c *gin.Context) {
 		return
 	}
 
-	// 获取当前用户
-	currentUser, exists := c.Get("user")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "未认证"})
-		return
-	}
-	user := currentUser.(*models.User)
-
-	// 权限检查：只有超级管理员可以删除
-	if !utils.IsSuperAdmin(user) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "无权限删除服务商"})
-		return
-	}
-
 	if err := ctrl.db.Delete(&provider).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除服务商失败"})
 		return
@@ -255,14 +220,6 @@ func (ctrl *ServiceProviderController) AddServiceProviderStaff(c *gin.Context) {
 		return
 	}
 
-	// 获取当前用户
-	currentUser, exists := c.Get("user")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "未认证"})
-		return
-	}
-	user := currentUser.(*models.User)
-
 	// 获取服务商
 	var provider models.ServiceProvider
 	if err := ctrl.db.Where("id = ?", providerID).First(&provider).Error; err != nil {
@@ -270,12 +227,6 @@ func (ctrl *ServiceProviderController) AddServiceProviderStaff(c *gin.Context) {
 		return
 	}
 
-	// 权限检查：只有服务商管理员可以添加员工
-	if !utils.IsSuperAdmin(user) && (provider.AdminID == nil || *provider.AdminID != user.ID) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "无权限添加员工"})
-		return
-	}
-
 	// 检查用户是否存在
 	var targetUser models.User
 	if err := ctrl.db.Where("id = ?", req.UserID).First(&targetUser).Error; err != nil {
@@ -317,6 +268,7 @@ func (ctrl *ServiceProviderController) AddServiceProviderStaff(c *gin.Context) {
 	if !utils.HasRole(&targetUser, "SP_STAFF") {
 		targetUser.Roles = append(targetUser.Roles, "SP_STAFF")
 		ctrl.db.Save(&targetUser)
+		ctrl.userCache.Invalidate(targetUser.AuthCenterUserID)
 	}
 
 	c.JSON(http.StatusOK, staff)
@@ -349,13 +301,13 @@ func (ctrl *ServiceProviderController) GetServiceProviderStaff(c *gin.Context) {
 // @Tags 服务商管理
 // @Accept json
 // @Produce json
-// @Param provider_id path string true "服务商ID"
+// @Param id path string true "服务商ID"
 // @Param staff_id path string true "员工ID"
 // @Param request body UpdateServiceProviderStaffPermissionRequest true "更新权限请求"
 // @Success 200 {object} map[string]interface{}
-// @Router /api/v1/service-providers/{provider_id}/staff/{staff_id}/permissions [put]
+// @Router /api/v1/service-providers/{id}/staff/{staff_id}/permissions [put]
 func (ctrl *ServiceProviderController) UpdateServiceProviderStaffPermission(c *gin.Context) {
-	providerID := c.Param("provider_id")
+	providerID := c.Param("id")
 	staffID := c.Param("staff_id")
 	var req UpdateServiceProviderStaffPermissionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -363,14 +315,6 @@ func (ctrl *ServiceProviderController) UpdateServiceProviderStaffPermission(c *g
 		return
 	}
 
-	// 获取当前用户
-	currentUser, exists := c.Get("user")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "未认证"})
-		return
-	}
-	user := currentUser.(*models.User)
-
 	// 获取服务商
 	var provider models.ServiceProvider
 	if err := ctrl.db.Where("id = ?", providerID).First(&provider).Error; err != nil {
@@ -378,12 +322,6 @@ func (ctrl *ServiceProviderController) UpdateServiceProviderStaffPermission(c *g
 		return
 	}
 
-	// 权限检查：只有服务商管理员可以更新员工权限
-	if !utils.IsSuperAdmin(user) && (provider.AdminID == nil || *provider.AdminID != user.ID) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "无权限更新员工权限"})
-		return
-	}
-
 	// 获取员工
 	var staff models.ServiceProviderStaff
 	if err := ctrl.db.Where("id = ? AND provider_id = ?", staffID, providerID).First(&staff).Error; err != nil {
@@ -416,22 +354,14 @@ func (ctrl *ServiceProviderController) UpdateServiceProviderStaffPermission(c *g
 // @Tags 服务商管理
 // @Accept json
 // @Produce json
-// @Param provider_id path string true "服务商ID"
+// @Param id path string true "服务商ID"
 // @Param staff_id path string true "员工ID"
 // @Success 200 {object} map[string]interface{}
-// @Router /api/v1/service-providers/{provider_id}/staff/{staff_id} [delete]
+// @Router /api/v1/service-providers/{id}/staff/{staff_id} [delete]
 func (ctrl *ServiceProviderController) DeleteServiceProviderStaff(c *gin.Context) {
-	providerID := c.Param("provider_id")
+	providerID := c.Param("id")
 	staffID := c.Param("staff_id")
 
-	// 获取当前用户
-	currentUser, exists := c.Get("user")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "未认证"})
-		return
-	}
-	user := currentUser.(*models.User)
-
 	// 获取服务商
 	var provider models.ServiceProvider
 	if err := ctrl.db.Where("id = ?", providerID).First(&provider).Error; err != nil {
@@ -439,12 +369,6 @@ func (ctrl *ServiceProviderController) DeleteServiceProviderStaff(c *gin.Context
 		return
 	}
 
-	// 权限检查：只有服务商管理员可以删除员工
-	if !utils.IsSuperAdmin(user) && (provider.AdminID == nil || *provider.AdminID != user.ID) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "无权限删除员工"})
-		return
-	}
-
 	// 删除员工权限
 	ctrl.db.Where("staff_id = ?", staffID).Delete(&models.ServiceProviderStaffPermission{})
 
@@ -467,15 +391,19 @@ func (ctrl *ServiceProviderController) DeleteServiceProviderStaff(c *gin.Context
 // @Router /api/v1/service-provider/me [get]
 func (ctrl *ServiceProviderController) GetMyServiceProvider(c *gin.Context) {
 	// 获取当前用户
-	currentUser, exists := c.Get("user")
+	_, exists := c.Get("user")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "未认证"})
 		return
 	}
-	user := currentUser.(*models.User)
+
+	if !utils.ActingAs(c, constants.RoleServiceProviderAdmin) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "您不是服务商管理员"})
+		return
+	}
 
 	var provider models.ServiceProvider
-	if err := ctrl.db.Where("admin_id = ?", user.ID).Preload("Admin").Preload("User").Preload("Staff").Preload("Merchants").First(&provider).Error; err != nil {
+	if err := ctrl.db.Where("id = ?", utils.ActiveOrgID(c)).Preload("Admin").Preload("User").Preload("Staff").Preload("Merchants").First(&provider).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "您不是服务商管理员"})
 		return
 	}
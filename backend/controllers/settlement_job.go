@@ -0,0 +1,176 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"pr-business/constants"
+	"pr-business/models"
+	"pr-business/services"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SettlementJobController 结算任务管理控制器（仅超级管理员）
+type SettlementJobController struct {
+	settlementJobService *services.SettlementJobService
+	auditService         *services.AuditService
+}
+
+func NewSettlementJobController(
+	settlementJobService *services.SettlementJobService,
+	auditService *services.AuditService,
+) *SettlementJobController {
+	return &SettlementJobController{
+		settlementJobService: settlementJobService,
+		auditService:         auditService,
+	}
+}
+
+// GetSettlementJobs 查询结算任务列表
+// @Summary 查询结算任务列表
+// @Description 查询结算任务，支持按状态过滤（如 dead 查看需要人工处理的结算）
+// @Tags 结算管理
+// @Accept json
+// @Produce json
+// @Param status query string false "状态过滤：pending/running/succeeded/dead/cancelled"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} utils.PageResponse
+// @Failure 401 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /api/settlement-jobs [get]
+func (c *SettlementJobController) GetSettlementJobs(ctx *gin.Context) {
+	// 1. 获取查询参数
+	status := ctx.Query("status")
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	pageSize, err := strconv.Atoi(ctx.DefaultQuery("page_size", "20"))
+	if err != nil || pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	// 2. 调用服务层查询
+	jobs, total, err := c.settlementJobService.ListJobs(status, pageSize, (page-1)*pageSize)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "查询结算任务失败: " + err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"list":      jobs,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// RetrySettlementJob 重试结算任务
+// @Summary 重试结算任务
+// @Description 将死信、已取消或等待中的结算任务立即重新执行（重置重试次数）
+// @Tags 结算管理
+// @Accept json
+// @Produce json
+// @Param id path string true "结算任务ID"
+// @Success 200 {object} models.SettlementJob
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /api/settlement-jobs/{id}/retry [post]
+func (c *SettlementJobController) RetrySettlementJob(ctx *gin.Context) {
+	// 1. 获取当前用户
+	userObj := ctx.MustGet("user").(*models.User)
+
+	// 2. 调用服务层重试
+	id := ctx.Param("id")
+	job, err := c.settlementJobService.RetryJob(id)
+	if err != nil {
+		c.respondJobError(ctx, err, "重试结算任务失败")
+		return
+	}
+
+	// 3. 记录审计日志
+	_ = c.auditService.LogFinancialOperation(
+		userObj.AuthCenterUserID,
+		constants.AuditActionSettlementRetry,
+		constants.AuditResourceSettlementJob,
+		job.ID.String(),
+		map[string]interface{}{
+			"task_id": job.TaskID.String(),
+		},
+		ctx.ClientIP(),
+		ctx.GetHeader("User-Agent"),
+	)
+
+	ctx.JSON(http.StatusOK, job)
+}
+
+// CancelSettlementJobRequest 取消结算任务请求
+type CancelSettlementJobRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// CancelSettlementJob 取消结算任务
+// @Summary 取消结算任务
+// @Description 取消等待中或死信状态的结算任务，任务保持已通过状态但不再自动结算
+// @Tags 结算管理
+// @Accept json
+// @Produce json
+// @Param id path string true "结算任务ID"
+// @Param request body CancelSettlementJobRequest true "取消原因"
+// @Success 200 {object} models.SettlementJob
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /api/settlement-jobs/{id}/cancel [post]
+func (c *SettlementJobController) CancelSettlementJob(ctx *gin.Context) {
+	// 1. 获取当前用户
+	userObj := ctx.MustGet("user").(*models.User)
+
+	// 2. 绑定请求参数
+	var req CancelSettlementJobRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "参数错误: " + err.Error()})
+		return
+	}
+
+	// 3. 调用服务层取消
+	id := ctx.Param("id")
+	job, err := c.settlementJobService.CancelJob(id, req.Reason, userObj.AuthCenterUserID)
+	if err != nil {
+		c.respondJobError(ctx, err, "取消结算任务失败")
+		return
+	}
+
+	// 4. 记录审计日志
+	_ = c.auditService.LogFinancialOperation(
+		userObj.AuthCenterUserID,
+		constants.AuditActionSettlementCancel,
+		constants.AuditResourceSettlementJob,
+		job.ID.String(),
+		map[string]interface{}{
+			"task_id": job.TaskID.String(),
+			"reason":  req.Reason,
+		},
+		ctx.ClientIP(),
+		ctx.GetHeader("User-Agent"),
+	)
+
+	ctx.JSON(http.StatusOK, job)
+}
+
+// respondJobError 将服务层错误映射为 HTTP 响应
+func (c *SettlementJobController) respondJobError(ctx *gin.Context, err error, message string) {
+	if errors.Is(err, services.ErrSettlementJobNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "结算任务不存在"})
+		return
+	}
+	if errors.Is(err, services.ErrInvalidSettlementJobStatus) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "结算任务状态不正确"})
+		return
+	}
+	ctx.JSON(http.StatusInternalServerError, gin.H{"error": message + ": " + err.Error()})
+}
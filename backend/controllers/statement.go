@@ -0,0 +1,202 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"pr-business/constants"
+	"pr-business/models"
+	"pr-business/services"
+	"pr-business/utils"
+)
+
+// errNoStatementAccount 当前用户没有可查看对账单的组织积分账户
+var errNoStatementAccount = errors.New("当前用户没有组织积分账户")
+
+// StatementController 月末对账单控制器
+// 路由层已校验 VIEW_FINANCIAL_REPORTS 权限；超管可查看任意账户，商家/服务商管理员和员工只能查看本组织账户
+type StatementController struct {
+	db               *gorm.DB
+	statementService *services.StatementService
+}
+
+// NewStatementController 创建对账单控制器
+func NewStatementController(db *gorm.DB, statementService *services.StatementService) *StatementController {
+	return &StatementController{
+		db:               db,
+		statementService: statementService,
+	}
+}
+
+// GetStatementSummaries 按账户类型汇总期间对账单（仅超管）
+// @Summary 对账单汇总
+// @Description 按所有者类型列出各账户的期初余额、入账、出账和期末余额
+// @Tags 财务报表
+// @Produce json
+// @Param ownerType query string true "账户类型 ORG_MERCHANT/ORG_PROVIDER/USER_PERSONAL"
+// @Param month query string false "对账月份 YYYY-MM，默认上个月"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} utils.PageResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Router /api/v1/statements [get]
+func (ctrl *StatementController) GetStatementSummaries(c *gin.Context) {
+	period, err := services.ParseStatementMonth(c.Query("month"), time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, pageSize := parsePlatformFeePage(c)
+	summaries, total, err := ctrl.statementService.ListStatementSummaries(
+		models.OwnerType(c.Query("ownerType")),
+		period,
+		pageSize,
+		(page-1)*pageSize,
+	)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidStatementQuery) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"month":     period.Label(),
+		"list":      summaries,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// GetMyStatement 当前组织积分账户的期间对账单
+// @Summary 本组织对账单
+// @Tags 财务报表
+// @Produce json,text/csv
+// @Param month query string false "对账月份 YYYY-MM，默认上个月"
+// @Param format query string false "导出格式 json/csv/xlsx" default(json)
+// @Success 200 {object} services.AccountStatement
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /api/v1/statements/me [get]
+func (ctrl *StatementController) GetMyStatement(c *gin.Context) {
+	accountID, err := ctrl.orgAccountID(c)
+	if err != nil {
+		if errors.Is(err, errNoStatementAccount) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "组织积分账户不存在"})
+		return
+	}
+
+	ctrl.respondStatement(c, accountID)
+}
+
+// GetAccountStatement 指定积分账户的期间对账单
+// @Summary 积分账户对账单
+// @Description 商家对账单含充值、冻结、结算、退款；服务商含分成收入和员工返佣；达人含任务收入和提现
+// @Tags 财务报表
+// @Produce json,text/csv
+// @Param accountId path string true "积分账户ID"
+// @Param month query string false "对账月份 YYYY-MM，默认上个月"
+// @Param format query string false "导出格式 json/csv/xlsx" default(json)
+// @Success 200 {object} services.AccountStatement
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /api/v1/statements/accounts/{accountId} [get]
+func (ctrl *StatementController) GetAccountStatement(c *gin.Context) {
+	accountID, err := uuid.Parse(c.Param("accountId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "积分账户不存在"})
+		return
+	}
+
+	if !utils.ActingAs(c, constants.RoleSuperAdmin) {
+		ownAccountID, err := ctrl.orgAccountID(c)
+		if err != nil || ownAccountID != accountID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "无权查看该账户对账单"})
+			return
+		}
+	}
+
+	ctrl.respondStatement(c, accountID)
+}
+
+// respondStatement 按 format 参数返回对账单 JSON 或导出文件
+func (ctrl *StatementController) respondStatement(c *gin.Context, accountID uuid.UUID) {
+	period, err := services.ParseStatementMonth(c.Query("month"), time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" && format != "xlsx" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "导出格式只支持 json、csv、xlsx"})
+		return
+	}
+
+	statement, err := ctrl.statementService.GetAccountStatement(accountID, period)
+	if err != nil {
+		if errors.Is(err, services.ErrCreditAccountNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "积分账户不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("statement_%s_%s.%s", period.Label(), accountID.String()[:8], format)
+	switch format {
+	case "csv":
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", "attachment; filename="+filename)
+		c.Status(http.StatusOK)
+		if err := ctrl.statementService.WriteCSV(statement, c.Writer); err != nil {
+			_ = c.Error(err)
+		}
+	case "xlsx":
+		c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		c.Header("Content-Disposition", "attachment; filename="+filename)
+		c.Status(http.StatusOK)
+		if err := ctrl.statementService.WriteXLSX(statement, c.Writer); err != nil {
+			_ = c.Error(err)
+		}
+	default:
+		c.JSON(http.StatusOK, statement)
+	}
+}
+
+// orgAccountID 当前身份所代表的商家或服务商的积分账户
+func (ctrl *StatementController) orgAccountID(c *gin.Context) (uuid.UUID, error) {
+	rc, ok := utils.GetRoleContext(c)
+	if !ok || rc.OrgType == "" {
+		return uuid.Nil, errNoStatementAccount
+	}
+	if !rc.HasOrg() {
+		return uuid.Nil, gorm.ErrRecordNotFound
+	}
+
+	ownerType := models.OwnerTypeOrgMerchant
+	if rc.OrgType == models.OrgTypeServiceProvider {
+		ownerType = models.OwnerTypeOrgProvider
+	}
+	ownerID := *rc.OrgID
+
+	var account models.CreditAccount
+	if err := ctrl.db.Where("owner_id = ? AND owner_type = ?", ownerID, ownerType).First(&account).Error; err != nil {
+		return uuid.Nil, err
+	}
+	return account.ID, nil
+}
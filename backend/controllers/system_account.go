@@ -3,9 +3,8 @@ package controllers
 import (
 	"net/http"
 	"pr-business/constants"
-	"pr-business/models"
 	"pr-business/services"
-	"pr-business/utils"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -38,33 +37,14 @@ func NewSystemAccountController(
 // @Failure 500 {object} utils.ErrorResponse
 // @Router /api/system-accounts [get]
 func (c *SystemAccountController) GetSystemAccounts(ctx *gin.Context) {
-	// 1. 获取当前用户
-	user, exists := ctx.Get("user")
-	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "未登录"})
-		return
-	}
-
-	userObj, ok := user.(*models.User)
-	if !ok {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "用户信息格式错误"})
-		return
-	}
-
-	// 2. 权限检查（只有管理员可以查看）
-	if !utils.IsSuperAdmin(userObj) && !utils.IsServiceProviderAdmin(userObj) {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "没有权限执行此操作"})
-		return
-	}
-
-	// 3. 获取账户类型
+	// 1. 获取账户类型
 	accountType := ctx.Query("account_type")
 	if accountType == "" {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "账户类型不能为空"})
 		return
 	}
 
-	// 4. 调用服务层查询
+	// 2. 调用服务层查询
 	balance, err := c.systemAccountService.GetEscrowBalance(accountType)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "查询系统账户失败: " + err.Error()})
@@ -89,26 +69,7 @@ func (c *SystemAccountController) GetSystemAccounts(ctx *gin.Context) {
 // @Failure 500 {object} utils.ErrorResponse
 // @Router /api/system-accounts/summary [get]
 func (c *SystemAccountController) GetFinancialSummary(ctx *gin.Context) {
-	// 1. 获取当前用户
-	user, exists := ctx.Get("user")
-	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "未登录"})
-		return
-	}
-
-	userObj, ok := user.(*models.User)
-	if !ok {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "用户信息格式错误"})
-		return
-	}
-
-	// 2. 权限检查（只有管理员可以查看）
-	if !utils.IsSuperAdmin(userObj) && !utils.IsServiceProviderAdmin(userObj) {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "没有权限执行此操作"})
-		return
-	}
-
-	// 3. 查询各类账户余额
+	// 1. 查询各类账户余额
 	summary := make(map[string]interface{})
 
 	// 票务托管账户
@@ -127,5 +88,58 @@ func (c *SystemAccountController) GetFinancialSummary(ctx *gin.Context) {
 	totalEscrow := ticketEscrow + taskEscrow
 	summary["total_escrow"] = totalEscrow
 
+	// 任务托管按活动分账（客户资金信托明细）
+	escrowTotals, err := c.systemAccountService.GetCampaignEscrowTotals()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	summary["task_escrow_campaigns"] = escrowTotals
+
+	// 流通中的积分（积分发行账户余额取反）
+	creditIssuance, _ := c.systemAccountService.GetEscrowBalance(constants.SystemAccountTypeCreditIssuance)
+	summary["credits_in_circulation"] = -creditIssuance
+
 	ctx.JSON(http.StatusOK, summary)
 }
+
+// GetCampaignEscrows 获取活动托管分账列表
+// @Summary 获取活动托管分账列表
+// @Description 查看任务托管账户中每个活动的托管余额、累计注资与支出
+// @Tags 系统账户管理
+// @Accept json
+// @Produce json
+// @Param active query bool false "只看仍有托管余额的活动"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} utils.PageResponse
+// @Failure 401 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /api/system-accounts/task-escrow/campaigns [get]
+func (c *SystemAccountController) GetCampaignEscrows(ctx *gin.Context) {
+	// 1. 获取查询参数
+	onlyActive := ctx.Query("active") == "true"
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	pageSize, err := strconv.Atoi(ctx.DefaultQuery("page_size", "20"))
+	if err != nil || pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	// 2. 调用服务层查询
+	escrows, total, err := c.systemAccountService.ListCampaignEscrows(onlyActive, pageSize, (page-1)*pageSize)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"list":      escrows,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
@@ -2,36 +2,46 @@ package controllers
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"pr-business/constants"
 	"pr-business/models"
 	"pr-business/services"
 	"pr-business/utils"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type TaskController struct {
-	db                 *gorm.DB
-	settlementService   *services.SettlementService
+	db                   *gorm.DB
+	settlementJobService *services.SettlementJobService
+	settlementService    *services.SettlementService
+	taskReviewService    *services.TaskReviewService
 }
 
-func NewTaskController(db *gorm.DB) *TaskController {
-	// 初始化结算服务
-	permissionService := services.NewAccountPermissionService(db)
-	validatorService := services.NewValidatorService(db)
-	cashAccountService := services.NewCashAccountService(db, validatorService)
-
+func NewTaskController(
+	db *gorm.DB,
+	settlementJobService *services.SettlementJobService,
+	settlementService *services.SettlementService,
+	taskReviewService *services.TaskReviewService,
+) *TaskController {
 	return &TaskController{
-		db:                 db,
-		settlementService:   services.NewSettlementService(db, permissionService, validatorService, cashAccountService),
+		db:                   db,
+		settlementJobService: settlementJobService,
+		settlementService:    settlementService,
+		taskReviewService:    taskReviewService,
 	}
 }
 
+// defaultRevisionWindow 要求修改时未指定截止时间的默认重新提交期限
+const defaultRevisionWindow = 48 * time.Hour
+
 // AcceptTaskRequest 接任务请求
 type AcceptTaskRequest struct {
 	Platform string `json:"platform" binding:"required"`
@@ -46,8 +56,9 @@ type SubmitTaskRequest struct {
 
 // AuditTaskRequest 审核任务请求
 type AuditTaskRequest struct {
-	Action     string `json:"action" binding:"required,oneof=approve reject"`
-	AuditNote  string `json:"auditNote"`
+	Action           string     `json:"action" binding:"required,oneof=approve reject request_revision"`
+	AuditNote        string     `json:"auditNote"`
+	RevisionDeadline *time.Time `json:"revisionDeadline"` // 仅 request_revision，为空默认 48 小时，不晚于活动提交截止时间
 }
 
 // GetTasks 获取任务名额列表
@@ -63,7 +74,8 @@ type AuditTaskRequest struct {
 // @Router /api/v1/tasks [get]
 func (ctrl *TaskController) GetTasks(c *gin.Context) {
 	var tasks []models.Task
-	query := ctrl.db.Model(&models.Task{})
+	// 只返回当前身份可见的任务（本组织活动的任务，或达人自己的任务）
+	query := ctrl.db.Model(&models.Task{}).Scopes(tenantScope(c).Tasks)
 
 	// 营销活动过滤
 	if campaignID := c.Query("campaign_id"); campaignID != "" {
@@ -101,11 +113,12 @@ func (ctrl *TaskController) GetTask(c *gin.Context) {
 	id := c.Param("id")
 	var task models.Task
 
-	if err := ctrl.db.Where("id = ?", id).Preload("Campaign").Preload("Creator").Preload("Auditor").First(&task).Error; err != nil {
+	if err := ctrl.db.Scopes(tenantScope(c).Tasks).Where("tasks.id = ?", id).Preload("Campaign").Preload("Creator").Preload("Auditor").First(&task).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "任务不存在"})
 		return
 	}
 
+	setTaskETag(c, &task)
 	c.JSON(http.StatusOK, task)
 }
 
@@ -116,8 +129,10 @@ func (ctrl *TaskController) GetTask(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path string true "任务ID"
+// @Param If-Match header string false "任务版本（GET 返回的 ETag），不一致时返回 409"
 // @Param request body AcceptTaskRequest true "接任务请求"
 // @Success 200 {object} models.Task
+// @Failure 409 {object} map[string]interface{} "任务已被修改，返回当前状态"
 // @Router /api/v1/tasks/{id}/accept [post]
 func (ctrl *TaskController) AcceptTask(c *gin.Context) {
 	id := c.Param("id")
@@ -127,6 +142,12 @@ func (ctrl *TaskController) AcceptTask(c *gin.Context) {
 		return
 	}
 
+	expectedVersion, hasIfMatch, err := parseIfMatchVersion(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// 获取当前用户
 	currentUser, exists := c.Get("user")
 	if !exists {
@@ -135,20 +156,27 @@ func (ctrl *TaskController) AcceptTask(c *gin.Context) {
 	}
 	user := currentUser.(*models.User)
 
-	// 权限检查：只有达人可以接任务
-	if !utils.IsCreator(user) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "只有达人可以接任务"})
-		return
-	}
+	// 只有达人可以接任务（路由授权策略 task.accept）
 
 	var task models.Task
 
-	// 开始事务（使用行锁防止并发接单）
-	err := ctrl.db.Transaction(func(tx *gorm.DB) error {
-		// 使用 SELECT FOR UPDATE 锁定任务记录
-		if err := tx.Where("id = ?", id).Preload("Campaign").First(&task).Error; err != nil {
+	// 开始事务（按版本号更新，并发接单时只有一个请求成功）
+	err = ctrl.db.Transaction(func(tx *gorm.DB) error {
+		// 接的是任务大厅中尚未分配的任务，不在达人的数据范围内，下面按任务状态校验
+		if err := tx.Where("id = ?", id).First(&task).Error; err != nil {
 			return err
 		}
+		// 锁定活动（共享锁），与关闭活动（SettleCampaignAfterCloseTx 持有排他锁）互斥，锁内检查活动状态
+		var campaign models.Campaign
+		if err := tx.Clauses(clause.Locking{Strength: "SHARE"}).Where("id = ?", task.CampaignID).First(&campaign).Error; err != nil {
+			return err
+		}
+		task.Campaign = &campaign
+
+		// 前端携带的版本已过期
+		if hasIfMatch && task.Version != expectedVersion {
+			return services.ErrTaskVersionConflict
+		}
 
 		// 在锁内重新检查任务状态
 		if task.Status != models.TaskStatusOpen {
@@ -186,11 +214,13 @@ func (ctrl *TaskController) AcceptTask(c *gin.Context) {
 		task.InviterID = creator.InviterID
 		task.InviterType = creator.InviterType
 
-		return tx.Save(&task).Error
+		return services.UpdateTaskIfVersion(tx, &task, task.Version)
 	})
 
 	if err != nil {
-		if err.Error() == "任务不可接" {
+		if errors.Is(err, services.ErrTaskVersionConflict) {
+			ctrl.respondTaskConflict(c, id)
+		} else if err.Error() == "任务不可接" {
 			c.JSON(http.StatusForbidden, gin.H{"error": "任务不可接"})
 		} else if err.Error() == "营销活动未开放" {
 			c.JSON(http.StatusForbidden, gin.H{"error": "营销活动未开放"})
@@ -204,6 +234,7 @@ func (ctrl *TaskController) AcceptTask(c *gin.Context) {
 		return
 	}
 
+	setTaskETag(c, &task)
 	c.JSON(http.StatusOK, task)
 }
 
@@ -214,8 +245,10 @@ func (ctrl *TaskController) AcceptTask(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path string true "任务ID"
+// @Param If-Match header string false "任务版本（GET 返回的 ETag），不一致时返回 409"
 // @Param request body SubmitTaskRequest true "提交任务请求"
 // @Success 200 {object} models.Task
+// @Failure 409 {object} map[string]interface{} "任务已被修改，返回当前状态"
 // @Router /api/v1/tasks/{id}/submit [post]
 func (ctrl *TaskController) SubmitTask(c *gin.Context) {
 	id := c.Param("id")
@@ -225,24 +258,21 @@ func (ctrl *TaskController) SubmitTask(c *gin.Context) {
 		return
 	}
 
-	// 获取当前用户
-	currentUser, exists := c.Get("user")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "未认证"})
+	expectedVersion, hasIfMatch, err := parseIfMatchVersion(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	user := currentUser.(*models.User)
 
-	// 获取任务
+	// 获取任务（只有任务所属的达人可以提交，路由授权策略 task.submit）
 	var task models.Task
-	if err := ctrl.db.Where("id = ?", id).Preload("Campaign").First(&task).Error; err != nil {
+	if err := ctrl.db.Scopes(tenantScope(c).Tasks).Where("tasks.id = ?", id).Preload("Campaign").First(&task).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "任务不存在"})
 		return
 	}
 
-	// 权限检查：只有任务所属的达人可以提交
-	if task.CreatorID == nil || task.CreatorID.String() != user.ID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "无权限提交此任务"})
+	if hasIfMatch && task.Version != expectedVersion {
+		ctrl.respondTaskConflict(c, id)
 		return
 	}
 
@@ -252,26 +282,54 @@ func (ctrl *TaskController) SubmitTask(c *gin.Context) {
 		return
 	}
 
-	// 检查截止时间
-	if time.Now().After(task.Campaign.SubmissionDeadline) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "已过提交截止时间"})
-		return
-	}
+	// 按读取时的版本保存提交并写入提交历史
+	err = ctrl.db.Transaction(func(tx *gorm.DB) error {
+		// 锁定活动（共享锁），与关闭活动（SettleCampaignAfterCloseTx 持有排他锁）互斥，锁内检查活动状态
+		if err := tx.Clauses(clause.Locking{Strength: "SHARE"}).Where("id = ?", task.CampaignID).First(task.Campaign).Error; err != nil {
+			return err
+		}
 
-	// 更新任务状态
-	now := time.Now()
-	task.Status = models.TaskStatusSubmitted
-	task.PlatformURL = req.PlatformURL
-	task.Screenshots = req.Screenshots
-	task.Notes = req.Notes
-	task.SubmittedAt = &now
-	task.Version += 1
-
-	if err := ctrl.db.Save(&task).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "提交任务失败"})
+		// 已关闭活动不再接收提交（暂停的活动允许已接任务继续提交）
+		if task.Campaign.Status == models.CampaignStatusClosed {
+			return errors.New("营销活动已关闭")
+		}
+
+		// 检查截止时间（被要求修改的任务以重新提交截止时间为准）
+		deadline := task.Campaign.SubmissionDeadline
+		if task.RevisionDeadline != nil && task.RevisionDeadline.Before(deadline) {
+			deadline = *task.RevisionDeadline
+		}
+		now := time.Now()
+		if now.After(deadline) {
+			return errors.New("已过提交截止时间")
+		}
+
+		// 更新任务状态
+		task.Status = models.TaskStatusSubmitted
+		task.PlatformURL = req.PlatformURL
+		task.Screenshots = req.Screenshots
+		task.Notes = req.Notes
+		task.SubmittedAt = &now
+		task.RevisionDeadline = nil
+
+		if err := services.UpdateTaskIfVersion(tx, &task, task.Version); err != nil {
+			return err
+		}
+		_, err := ctrl.taskReviewService.RecordSubmission(tx, &task)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrTaskVersionConflict) {
+			ctrl.respondTaskConflict(c, id)
+		} else if err.Error() == "营销活动已关闭" || err.Error() == "已过提交截止时间" {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "提交任务失败"})
+		}
 		return
 	}
 
+	setTaskETag(c, &task)
 	c.JSON(http.StatusOK, task)
 }
 
@@ -282,8 +340,10 @@ func (ctrl *TaskController) SubmitTask(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path string true "任务ID"
+// @Param If-Match header string false "任务版本（GET 返回的 ETag），不一致时返回 409"
 // @Param request body AuditTaskRequest true "审核任务请求"
 // @Success 200 {object} models.Task
+// @Failure 409 {object} map[string]interface{} "任务已被修改，返回当前状态"
 // @Router /api/v1/tasks/{id}/audit [post]
 func (ctrl *TaskController) AuditTask(c *gin.Context) {
 	id := c.Param("id")
@@ -293,6 +353,12 @@ func (ctrl *TaskController) AuditTask(c *gin.Context) {
 		return
 	}
 
+	expectedVersion, hasIfMatch, err := parseIfMatchVersion(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// 获取当前用户
 	currentUser, exists := c.Get("user")
 	if !exists {
@@ -301,48 +367,50 @@ func (ctrl *TaskController) AuditTask(c *gin.Context) {
 	}
 	user := currentUser.(*models.User)
 
-	// 权限检查：服务商员工可以审核
-	if utils.IsServiceProviderStaff(user) || utils.IsMerchantStaff(user) {
-		// 员工需要检查权限
-		if !utils.HasPermission(ctrl.db, user, constants.PermissionReviewTask) {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "无审核任务权限",
-				"requiredPermission": constants.PermissionReviewTask,
-			})
-			return
-		}
-	} else if !utils.IsServiceProviderAdmin(user) && !utils.IsSuperAdmin(user) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "无权限审核任务"})
-		return
-	}
+	// 审核权限和任务所属组织已由路由授权策略校验（task.review）
 
 	// 获取任务
 	var task models.Task
-	if err := ctrl.db.Where("id = ?", id).Preload("Campaign").First(&task).Error; err != nil {
+	if err := ctrl.db.Scopes(tenantScope(c).Tasks).Where("tasks.id = ?", id).Preload("Campaign").First(&task).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "任务不存在"})
 		return
 	}
 
+	if hasIfMatch && task.Version != expectedVersion {
+		ctrl.respondTaskConflict(c, id)
+		return
+	}
+
 	// 检查任务状态
 	if task.Status != models.TaskStatusSubmitted {
 		c.JSON(http.StatusForbidden, gin.H{"error": "任务状态不允许审核"})
 		return
 	}
 
-	// 更新任务状态
+	// 更新任务状态（提交内容已保存在提交历史中）
 	now := time.Now()
+	reviewAction := models.TaskReviewActionApprove
 	if req.Action == "approve" {
 		task.Status = models.TaskStatusApproved
-
-		// 审核通过后，执行结算（异步处理，避免阻塞响应）
-		go func() {
-			if err := ctrl.settlementService.SettleTaskAfterApproval(&task, user.ID); err != nil {
-				// TODO: 记录结算失败日志，可能需要重试机制
-				println("结算失败:", err.Error())
-			}
-		}()
+	} else if req.Action == "request_revision" {
+		// 要求修改：达人保留名额，在新的截止时间前重新提交
+		reviewAction = models.TaskReviewActionRequestRevision
+		revisionDeadline := now.Add(defaultRevisionWindow)
+		if req.RevisionDeadline != nil {
+			revisionDeadline = *req.RevisionDeadline
+		}
+		if revisionDeadline.After(task.Campaign.SubmissionDeadline) {
+			revisionDeadline = task.Campaign.SubmissionDeadline
+		}
+		if !revisionDeadline.After(now) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "已过活动提交截止时间，无法要求修改"})
+			return
+		}
+		task.Status = models.TaskStatusAssigned
+		task.RevisionDeadline = &revisionDeadline
 	} else if req.Action == "reject" {
 		// 拒绝后释放任务，允许达人重新接单
+		reviewAction = models.TaskReviewActionReject
 		task.Status = models.TaskStatusOpen
 		task.CreatorID = nil
 		task.AssignedAt = nil
@@ -362,46 +430,132 @@ func (ctrl *TaskController) AuditTask(c *gin.Context) {
 	task.AuditedBy = &auditorID
 	task.AuditedAt = &now
 	task.AuditNote = req.AuditNote
-	task.Version += 1
 
-	if err := ctrl.db.Save(&task).Error; err != nil {
+	// 保存审核结果；审核通过时在同一事务内写入结算任务，由后台 worker 结算并自动重试
+	err = ctrl.db.Transaction(func(tx *gorm.DB) error {
+		// 锁定活动，避免与关闭活动并发；活动已关闭时拒绝不再释放名额，积分退还商家
+		if err := tx.Clauses(clause.Locking{Strength: "SHARE"}).Where("id = ?", task.CampaignID).First(task.Campaign).Error; err != nil {
+			return err
+		}
+		if task.Campaign.Status == models.CampaignStatusClosed {
+			if req.Action == "reject" {
+				task.Status = models.TaskStatusRejected
+			} else if req.Action == "request_revision" {
+				return services.ErrRevisionOnClosedCampaign
+			}
+		}
+
+		// 两个审核人同时审核同一任务时只有一个能写入，避免重复结算
+		if err := services.UpdateTaskIfVersion(tx, &task, task.Version); err != nil {
+			return err
+		}
+
+		if err := ctrl.taskReviewService.RecordReview(tx, task.ID, reviewAction, user.AuthCenterUserID, req.AuditNote, task.RevisionDeadline); err != nil {
+			return err
+		}
+
+		if task.Status == models.TaskStatusApproved {
+			if _, err := ctrl.settlementJobService.Enqueue(tx, &task, user.ID); err != nil {
+				return err
+			}
+		}
+
+		if task.Status == models.TaskStatusRejected {
+			if err := ctrl.settlementService.RefundClosedCampaignSlot(tx, task.Campaign, &task); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrTaskVersionConflict) {
+			ctrl.respondTaskConflict(c, id)
+			return
+		}
+		if errors.Is(err, services.ErrRevisionOnClosedCampaign) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "审核失败"})
 		return
 	}
 
+	setTaskETag(c, &task)
 	c.JSON(http.StatusOK, task)
 }
 
-// getIsCreator 判断当前用户是否拥有达人角色（检查 roles 数组）
-func getIsCreator(c *gin.Context, user *models.User) bool {
-	// 1. 检查用户是否拥有 CREATOR 角色
-	if utils.HasRole(user, constants.RoleCreator) {
-		return true
-	}
-	// 2. JWT 中的 roles（避免 DB 未同步）
-	if rolesVal, _ := c.Get("roles"); rolesVal != nil {
-		switch arr := rolesVal.(type) {
-		case []string:
-			for _, r := range arr {
-				if r == constants.RoleCreator || r == "creator" {
-					return true
-				}
-			}
-		case models.Roles:
-			for _, r := range arr {
-				if r == constants.RoleCreator || r == "creator" {
-					return true
-				}
-			}
-		case []interface{}:
-			for _, v := range arr {
-				if s, ok := v.(string); ok && (s == constants.RoleCreator || s == "creator") {
-					return true
-				}
-			}
-		}
+// GetTaskTimeline 获取任务时间线
+// @Summary 获取任务时间线
+// @Description 返回任务各轮提交与审核记录、系统自动处理（超时释放、升级、自动通过）以及当前接单信息
+// @Tags 任务管理
+// @Accept json
+// @Produce json
+// @Param id path string true "任务ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/tasks/{id}/timeline [get]
+func (ctrl *TaskController) GetTaskTimeline(c *gin.Context) {
+	// 路由已按 task.view 校验；查询同样限定在当前身份可见的范围内，其他租户的任务按不存在处理
+	var task models.Task
+	if err := ctrl.db.Scopes(tenantScope(c).Tasks).Where("tasks.id = ?", c.Param("id")).First(&task).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "任务不存在"})
+		return
+	}
+
+	events, err := ctrl.taskReviewService.Timeline(&task)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取任务时间线失败"})
+		return
 	}
-	return false
+
+	setTaskETag(c, &task)
+	c.JSON(http.StatusOK, gin.H{
+		"task":   task,
+		"events": events,
+	})
+}
+
+// setTaskETag 在响应头中返回任务版本，前端修改任务时通过 If-Match 回传
+func setTaskETag(c *gin.Context, task *models.Task) {
+	c.Header("ETag", fmt.Sprintf("\"%d\"", task.Version))
+}
+
+// parseIfMatchVersion 解析 If-Match 请求头中的任务版本
+// 支持 "3"、W/"3" 和 3 三种写法；未携带或为 * 时不校验
+func parseIfMatchVersion(c *gin.Context) (int, bool, error) {
+	value := strings.TrimSpace(c.GetHeader("If-Match"))
+	if value == "" || value == "*" {
+		return 0, false, nil
+	}
+
+	value = strings.TrimPrefix(value, "W/")
+	value = strings.Trim(value, "\"")
+	version, err := strconv.Atoi(value)
+	if err != nil || version < 0 {
+		return 0, false, errors.New("If-Match 格式错误，应为任务版本号")
+	}
+	return version, true, nil
+}
+
+// respondTaskConflict 任务版本冲突时返回 409 和任务的当前状态，前端据此刷新后重试
+func (ctrl *TaskController) respondTaskConflict(c *gin.Context, id string) {
+	var current models.Task
+	if err := ctrl.db.Scopes(tenantScope(c).Tasks).Where("tasks.id = ?", id).Preload("Campaign").First(&current).Error; err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": services.ErrTaskVersionConflict.Error()})
+		return
+	}
+
+	setTaskETag(c, &current)
+	c.JSON(http.StatusConflict, gin.H{
+		"error":          services.ErrTaskVersionConflict.Error(),
+		"currentVersion": current.Version,
+		"task":           current,
+	})
+}
+
+// getIsCreator 判断当前请求是否以达人身份访问（激活角色为达人）
+func getIsCreator(c *gin.Context) bool {
+	return utils.ActingAs(c, constants.RoleCreator)
 }
 
 // GetMyTasks 获取当前用户的任务列表
@@ -424,7 +578,7 @@ func (ctrl *TaskController) GetMyTasks(c *gin.Context) {
 
 	var creator models.Creator
 	if err := ctrl.db.Where("user_id = ? AND is_primary = ?", user.ID, true).First(&creator).Error; err != nil {
-		if !getIsCreator(c, user) {
+		if !getIsCreator(c) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "达人信息不存在"})
 			return
 		}
@@ -455,45 +609,15 @@ func (ctrl *TaskController) GetMyTasks(c *gin.Context) {
 // @Router /api/v1/tasks/pending-review [get]
 func (ctrl *TaskController) GetTasksForReview(c *gin.Context) {
 	// 获取当前用户
-	currentUser, exists := c.Get("user")
+	_, exists := c.Get("user")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "未认证"})
 		return
 	}
-	user := currentUser.(*models.User)
-
-	// 权限检查
-	if !utils.HasRole(user, constants.RoleServiceProviderAdmin) && !utils.HasRole(user, constants.RoleServiceProviderStaff) && !utils.HasRole(user, constants.RoleSuperAdmin) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "无权限查看待审核任务"})
-		return
-	}
 
 	var tasks []models.Task
-	query := ctrl.db.Where("status = ?", models.TaskStatusSubmitted)
-
-	// 如果是服务商员工，只能看到自己负责的营销活动的任务
-	if utils.HasRole(user, constants.RoleServiceProviderStaff) && !utils.HasRole(user, constants.RoleSuperAdmin) {
-		// 获取员工所属的服务商
-		var providerStaff models.ServiceProviderStaff
-		if err := ctrl.db.Where("user_id = ?", user.ID).First(&providerStaff).Error; err == nil {
-			// 获取该服务商下的所有营销活动
-			var campaigns []models.Campaign
-			ctrl.db.Where("provider_id = ?", providerStaff.ProviderID).Find(&campaigns)
-
-			campaignIDs := make([]string, len(campaigns))
-			for i, campaign := range campaigns {
-				campaignIDs[i] = campaign.ID.String()
-			}
-
-			if len(campaignIDs) > 0 {
-				query = query.Where("campaign_id IN ?", campaignIDs)
-			} else {
-				// 如果没有营销活动，返回空列表
-				c.JSON(http.StatusOK, []models.Task{})
-				return
-			}
-		}
-	}
+	// 服务商管理员和员工只能看到本服务商营销活动的任务
+	query := ctrl.db.Where("status = ?", models.TaskStatusSubmitted).Scopes(tenantScope(c).Tasks)
 
 	if err := query.Preload("Campaign").Preload("Creator").Order("submitted_at ASC").Find(&tasks).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取待审核任务列表失败"})
@@ -515,18 +639,11 @@ func (ctrl *TaskController) GetTasksForReview(c *gin.Context) {
 // @Router /api/v1/tasks/hall [get]
 func (ctrl *TaskController) GetTaskHall(c *gin.Context) {
 	// 获取当前用户
-	currentUser, exists := c.Get("user")
+	_, exists := c.Get("user")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "未认证"})
 		return
 	}
-	user := currentUser.(*models.User)
-
-	// 权限检查：只有达人可以查看任务大厅
-	if !utils.IsCreator(user) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "只有达人可以查看任务大厅"})
-		return
-	}
 
 	// 分页
 	page := 1
@@ -547,8 +664,10 @@ func (ctrl *TaskController) GetTaskHall(c *gin.Context) {
 	var tasks []models.Task
 	var total int64
 
-	// 查询开放中的任务
-	query := ctrl.db.Model(&models.Task{}).Where("status = ?", models.TaskStatusOpen)
+	// 查询开放中活动的空闲任务
+	query := ctrl.db.Model(&models.Task{}).
+		Where("status = ?", models.TaskStatusOpen).
+		Where("campaign_id IN (?)", ctrl.db.Model(&models.Campaign{}).Select("id").Where("status = ?", models.CampaignStatusOpen))
 
 	// 统计总数
 	query.Count(&total)
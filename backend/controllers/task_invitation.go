@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"pr-business/constants"
 	"pr-business/models"
+	"pr-business/services"
 	"pr-business/utils"
 	"strings"
 	"time"
@@ -14,11 +15,12 @@ import (
 )
 
 type TaskInvitationController struct {
-	db *gorm.DB
+	db        *gorm.DB
+	userCache *services.UserCache
 }
 
-func NewTaskInvitationController(db *gorm.DB) *TaskInvitationController {
-	return &TaskInvitationController{db: db}
+func NewTaskInvitationController(db *gorm.DB, userCache *services.UserCache) *TaskInvitationController {
+	return &TaskInvitationController{db: db, userCache: userCache}
 }
 
 // GenerateInvitationCodeRequest 生成邀请码请求
@@ -54,7 +56,7 @@ func (ctrl *TaskInvitationController) GenerateInvitationCode(c *gin.Context) {
 	user := currentUser.(*models.User)
 
 	// 权限检查：只有商家和服务商管理员可以生成邀请码
-	if !utils.HasRole(user, constants.RoleMerchantAdmin) && !utils.HasRole(user, constants.RoleServiceProviderAdmin) {
+	if !utils.ActingAs(c, constants.RoleMerchantAdmin, constants.RoleServiceProviderAdmin) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "只有商家管理员和服务商管理员可以生成邀请码"})
 		return
 	}
@@ -84,7 +86,7 @@ func (ctrl *TaskInvitationController) GenerateInvitationCode(c *gin.Context) {
 
 	// 确定生成者类型
 	generatorType := "merchant_admin"
-	if utils.HasRole(user, constants.RoleServiceProviderAdmin) {
+	if utils.ActingAs(c, constants.RoleServiceProviderAdmin) {
 		generatorType = "provider_admin"
 	}
 
@@ -269,6 +271,7 @@ func (ctrl *TaskInvitationController) UseTaskInvitationCode(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	ctrl.userCache.Invalidate(user.AuthCenterUserID)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message":        "邀请码使用成功，已获得达人身份并绑定邀请人与活动",
@@ -0,0 +1,224 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"pr-business/constants"
+	"pr-business/models"
+	"pr-business/services"
+)
+
+// TaxController 个税预扣控制器
+type TaxController struct {
+	db           *gorm.DB
+	taxService   *services.TaxWithholdingService
+	auditService *services.AuditService
+}
+
+// NewTaxController 创建个税预扣控制器
+func NewTaxController(
+	db *gorm.DB,
+	taxService *services.TaxWithholdingService,
+	auditService *services.AuditService,
+) *TaxController {
+	return &TaxController{
+		db:           db,
+		taxService:   taxService,
+		auditService: auditService,
+	}
+}
+
+// CreateTaxRuleRequest 创建预扣规则请求（金额单位：分，比例为万分比）
+type CreateTaxRuleRequest struct {
+	Name               string             `json:"name" binding:"required,max=100"`
+	ThresholdAmount    int                `json:"thresholdAmount" binding:"min=0"`
+	FixedDeduction     int                `json:"fixedDeduction" binding:"min=0"`
+	DeductionRateBasis int                `json:"deductionRateBasis" binding:"min=0,max=10000"`
+	Brackets           models.TaxBrackets `json:"brackets" binding:"required,min=1"`
+	Description        string             `json:"description" binding:"max=500"`
+}
+
+// GetTaxRules 查询个税预扣规则
+// @Summary 查询个税预扣规则
+// @Tags 个税预扣
+// @Produce json
+// @Param include_inactive query bool false "是否包含已停用规则"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} utils.PageResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Router /api/v1/tax/withholding-rules [get]
+func (c *TaxController) GetTaxRules(ctx *gin.Context) {
+	page, pageSize := parsePlatformFeePage(ctx)
+	rules, total, err := c.taxService.ListRules(ctx.Query("include_inactive") == "true", pageSize, (page-1)*pageSize)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"list":      rules,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// CreateTaxRule 创建个税预扣规则
+// @Summary 创建个税预扣规则
+// @Description 新规则立即生效并停用旧规则；已创建的提现仍按原规则预扣
+// @Tags 个税预扣
+// @Accept json
+// @Produce json
+// @Param request body CreateTaxRuleRequest true "预扣规则"
+// @Success 201 {object} models.TaxWithholdingRule
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Router /api/v1/tax/withholding-rules [post]
+func (c *TaxController) CreateTaxRule(ctx *gin.Context) {
+	userObj := ctx.MustGet("user").(*models.User)
+
+	var req CreateTaxRuleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+
+	rule, err := c.taxService.CreateRule(&services.CreateTaxRuleRequest{
+		Name:               req.Name,
+		ThresholdAmount:    req.ThresholdAmount,
+		FixedDeduction:     req.FixedDeduction,
+		DeductionRateBasis: req.DeductionRateBasis,
+		Brackets:           req.Brackets,
+		Description:        req.Description,
+	}, userObj.AuthCenterUserID)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidTaxRule) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	_ = c.auditService.LogFinancialOperation(
+		userObj.AuthCenterUserID,
+		constants.AuditActionTaxRuleCreate,
+		constants.AuditResourceTaxRule,
+		rule.ID.String(),
+		map[string]interface{}{
+			"name":                 rule.Name,
+			"threshold_amount":     rule.ThresholdAmount,
+			"fixed_deduction":      rule.FixedDeduction,
+			"deduction_rate_basis": rule.DeductionRateBasis,
+			"brackets":             rule.Brackets,
+		},
+		ctx.ClientIP(),
+		ctx.GetHeader("User-Agent"),
+	)
+
+	ctx.JSON(http.StatusCreated, rule)
+}
+
+// GetTaxCertificate 本人年度收入及个税预扣凭证
+// @Summary 年度收入及预扣凭证
+// @Description 达人查询本人凭证
+// @Tags 个税预扣
+// @Produce json,text/csv,application/pdf
+// @Param year path int true "纳税年度"
+// @Param format query string false "导出格式 json/csv/pdf" default(json)
+// @Success 200 {object} services.TaxCertificate
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /api/v1/tax/certificates/{year} [get]
+func (c *TaxController) GetTaxCertificate(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*models.User)
+
+	var account models.CreditAccount
+	if err := c.db.Where("owner_id = ? AND owner_type = ?", user.AuthCenterUserID, models.OwnerTypeUserPersonal).
+		First(&account).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "个人积分账户不存在"})
+		return
+	}
+
+	c.writeTaxCertificate(ctx, account.ID)
+}
+
+// GetAccountTaxCertificate 查询任意个人账户的年度收入及个税预扣凭证（仅超管）
+// @Summary 指定账户的年度收入及预扣凭证
+// @Tags 个税预扣
+// @Produce json,text/csv,application/pdf
+// @Param year path int true "纳税年度"
+// @Param accountId path string true "个人积分账户ID"
+// @Param format query string false "导出格式 json/csv/pdf" default(json)
+// @Success 200 {object} services.TaxCertificate
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /api/v1/tax/certificates/{year}/accounts/{accountId} [get]
+func (c *TaxController) GetAccountTaxCertificate(ctx *gin.Context) {
+	accountID, err := uuid.Parse(ctx.Param("accountId"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "积分账户不存在"})
+		return
+	}
+
+	c.writeTaxCertificate(ctx, accountID)
+}
+
+// writeTaxCertificate 按路径中的年度和 format 参数输出账户的凭证
+func (c *TaxController) writeTaxCertificate(ctx *gin.Context, accountID uuid.UUID) {
+	year, err := strconv.Atoi(ctx.Param("year"))
+	if err != nil || year < 2000 || year > time.Now().Year() {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "纳税年度无效"})
+		return
+	}
+
+	format := ctx.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" && format != "pdf" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "导出格式只支持 json、csv、pdf"})
+		return
+	}
+
+	certificate, err := c.taxService.GetCertificate(accountID, year)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrCreditAccountNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "积分账户不存在"})
+		case errors.Is(err, services.ErrTaxCertificateUnavailable):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	filename := fmt.Sprintf("tax_certificate_%d_%s.%s", year, accountID.String()[:8], format)
+	switch format {
+	case "csv":
+		ctx.Header("Content-Type", "text/csv; charset=utf-8")
+		ctx.Header("Content-Disposition", "attachment; filename="+filename)
+		ctx.Status(http.StatusOK)
+		if err := c.taxService.WriteCertificateCSV(certificate, ctx.Writer); err != nil {
+			_ = ctx.Error(err)
+		}
+	case "pdf":
+		ctx.Header("Content-Type", "application/pdf")
+		ctx.Header("Content-Disposition", "attachment; filename="+filename)
+		ctx.Status(http.StatusOK)
+		if err := c.taxService.WriteCertificatePDF(certificate, ctx.Writer); err != nil {
+			_ = ctx.Error(err)
+		}
+	default:
+		ctx.JSON(http.StatusOK, certificate)
+	}
+}
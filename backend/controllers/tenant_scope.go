@@ -0,0 +1,15 @@
+package controllers
+
+import (
+	"pr-business/services"
+	"pr-business/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tenantScope 当前请求可见的数据范围，用于列表和按ID查询：query.Scopes(tenantScope(c).Tasks)
+func tenantScope(c *gin.Context) *services.TenantScope {
+	user, _ := utils.GetCurrentUser(c)
+	rc, _ := utils.GetRoleContext(c)
+	return services.NewTenantScope(user, rc)
+}
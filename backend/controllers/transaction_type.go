@@ -0,0 +1,194 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"pr-business/constants"
+	"pr-business/models"
+	"pr-business/services"
+)
+
+// TransactionTypeController 交易类型注册表控制器
+type TransactionTypeController struct {
+	typeService  *services.TransactionTypeService
+	auditService *services.AuditService
+}
+
+// NewTransactionTypeController 创建交易类型控制器
+func NewTransactionTypeController(
+	typeService *services.TransactionTypeService,
+	auditService *services.AuditService,
+) *TransactionTypeController {
+	return &TransactionTypeController{
+		typeService:  typeService,
+		auditService: auditService,
+	}
+}
+
+// DefineTransactionTypeRequest 新增或修改交易类型请求
+type DefineTransactionTypeRequest struct {
+	Code            string   `json:"code" binding:"required,max=50"`
+	Name            string   `json:"name" binding:"required,max=100"`
+	Description     string   `json:"description" binding:"max=500"`
+	AccountTypes    []string `json:"accountTypes" binding:"required,min=1"`
+	AmountDirection string   `json:"amountDirection" binding:"required,oneof=positive negative"`
+	ChangeNote      string   `json:"changeNote" binding:"max=500"`
+}
+
+// DeprecateTransactionTypeRequest 废弃交易类型请求
+type DeprecateTransactionTypeRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+// GetTransactionTypes 查询交易类型
+// @Summary 查询交易类型
+// @Tags 交易类型
+// @Produce json
+// @Param include_deprecated query bool false "是否包含已废弃类型"
+// @Success 200 {array} models.TransactionType
+// @Router /api/v1/transaction-types [get]
+func (c *TransactionTypeController) GetTransactionTypes(ctx *gin.Context) {
+	types, err := c.typeService.List(ctx.Query("include_deprecated") == "true")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, types)
+}
+
+// GetTransactionTypeVersions 查询交易类型的历史版本
+// @Summary 交易类型版本历史
+// @Tags 交易类型
+// @Produce json
+// @Param code path string true "交易类型代码"
+// @Success 200 {array} models.TransactionTypeVersion
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /api/v1/transaction-types/{code}/versions [get]
+func (c *TransactionTypeController) GetTransactionTypeVersions(ctx *gin.Context) {
+	versions, err := c.typeService.Versions(ctx.Param("code"))
+	if err != nil {
+		if errors.Is(err, services.ErrTransactionTypeNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, versions)
+}
+
+// DefineTransactionType 新增交易类型或修改启用中的交易类型
+// @Summary 新增/修改交易类型
+// @Description 代码不存在时新增（版本1）；已存在时修改定义并升级版本号；已废弃的代码不能重新启用
+// @Tags 交易类型
+// @Accept json
+// @Produce json
+// @Param request body DefineTransactionTypeRequest true "交易类型定义"
+// @Success 200 {object} models.TransactionType
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
+// @Router /api/v1/transaction-types [post]
+func (c *TransactionTypeController) DefineTransactionType(ctx *gin.Context) {
+	userObj := ctx.MustGet("user").(*models.User)
+
+	var req DefineTransactionTypeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+
+	definition, err := c.typeService.Define(&services.DefineTransactionTypeRequest{
+		Code:            req.Code,
+		Name:            req.Name,
+		Description:     req.Description,
+		AccountTypes:    req.AccountTypes,
+		AmountDirection: req.AmountDirection,
+		ChangeNote:      req.ChangeNote,
+	}, userObj.AuthCenterUserID)
+	if err != nil {
+		respondTransactionTypeError(ctx, err)
+		return
+	}
+
+	_ = c.auditService.LogFinancialOperation(
+		userObj.AuthCenterUserID,
+		constants.AuditActionTransactionTypeDefine,
+		constants.AuditResourceTransactionType,
+		definition.Code,
+		map[string]interface{}{
+			"version":          definition.Version,
+			"account_types":    definition.AccountTypes,
+			"amount_direction": definition.AmountDirection,
+			"change_note":      req.ChangeNote,
+		},
+		ctx.ClientIP(),
+		ctx.GetHeader("User-Agent"),
+	)
+
+	ctx.JSON(http.StatusOK, definition)
+}
+
+// DeprecateTransactionType 废弃交易类型
+// @Summary 废弃交易类型
+// @Description 废弃后不能再用于记账，历史流水不受影响；记账代码仍在使用的内置类型不能废弃
+// @Tags 交易类型
+// @Accept json
+// @Produce json
+// @Param code path string true "交易类型代码"
+// @Param request body DeprecateTransactionTypeRequest true "废弃原因"
+// @Success 200 {object} models.TransactionType
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /api/v1/transaction-types/{code}/deprecate [post]
+func (c *TransactionTypeController) DeprecateTransactionType(ctx *gin.Context) {
+	userObj := ctx.MustGet("user").(*models.User)
+
+	var req DeprecateTransactionTypeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+
+	definition, err := c.typeService.Deprecate(ctx.Param("code"), req.Reason, userObj.AuthCenterUserID)
+	if err != nil {
+		respondTransactionTypeError(ctx, err)
+		return
+	}
+
+	_ = c.auditService.LogFinancialOperation(
+		userObj.AuthCenterUserID,
+		constants.AuditActionTransactionTypeDeprecate,
+		constants.AuditResourceTransactionType,
+		definition.Code,
+		map[string]interface{}{
+			"version": definition.Version,
+			"reason":  req.Reason,
+		},
+		ctx.ClientIP(),
+		ctx.GetHeader("User-Agent"),
+	)
+
+	ctx.JSON(http.StatusOK, definition)
+}
+
+// respondTransactionTypeError 将交易类型服务错误映射为HTTP响应
+func respondTransactionTypeError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrTransactionTypeNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidTransactionType):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrTransactionTypeInactive):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
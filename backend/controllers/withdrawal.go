@@ -1,18 +1,17 @@
 package controllers
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 
+	"pr-business/constants"
 	"pr-business/models"
 	"pr-business/services"
 	"pr-business/utils"
@@ -21,26 +20,31 @@ import (
 type WithdrawalController struct {
 	DB                *gorm.DB
 	permissionService *services.AccountPermissionService
+	withdrawalService *services.WithdrawalService
+	payoutService     *services.PayoutService
 }
 
 // NewWithdrawalController 创建提现控制器
-func NewWithdrawalController(db *gorm.DB) *WithdrawalController {
+func NewWithdrawalController(db *gorm.DB, withdrawalService *services.WithdrawalService, payoutService *services.PayoutService) *WithdrawalController {
 	return &WithdrawalController{
 		DB:                db,
 		permissionService: services.NewAccountPermissionService(db),
+		withdrawalService: withdrawalService,
+		payoutService:     payoutService,
 	}
 }
 
 // CreateWithdrawalRequest 申请提现请求
 type CreateWithdrawalRequest struct {
-	Amount      int                    `json:"amount" binding:"required,min=1"`
+	Amount      int                     `json:"amount" binding:"required,min=1"`
 	Method      models.WithdrawalMethod `json:"method" binding:"required,oneof=ALIPAY WECHAT BANK"`
-	AccountInfo map[string]interface{} `json:"accountInfo" binding:"required"`
+	AccountInfo map[string]interface{}  `json:"accountInfo" binding:"required"`
+	Description string                  `json:"description"`
 }
 
 // AuditWithdrawalRequest 审核提现请求
 type AuditWithdrawalRequest struct {
-	Approved  bool   `json:"approved" binding:"required"`
+	Approved  *bool  `json:"approved" binding:"required"`
 	AuditNote string `json:"auditNote"`
 }
 
@@ -59,31 +63,11 @@ func (ctrl *WithdrawalController) CreateWithdrawal(c *gin.Context) {
 		return
 	}
 
-	// 1. 确定用户的积分账户类型
-	var accountType models.OwnerType
-	if utils.IsSuperAdmin(user) {
-		accountType = models.OwnerTypeUserPersonal // 超管使用个人账户
-	} else if utils.IsServiceProviderAdmin(user) {
-		accountType = models.OwnerTypeOrgProvider
-	} else if utils.IsMerchantAdmin(user) || utils.IsMerchantStaff(user) {
-		accountType = models.OwnerTypeOrgMerchant
-	} else if utils.IsCreator(user) {
-		accountType = models.OwnerTypeUserPersonal
-	} else {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "无法确定账户类型"})
-		return
-	}
-
-	// 2. 获取或创建积分账户
-	parsedID, err := uuid.Parse(user.AuthCenterUserID)
+	account, err := ctrl.resolveAccount(c, user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "用户ID格式错误"})
-		return
-	}
-
-	account, err := ctrl.findOrCreateAccount(parsedID, accountType, parsedID)
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
+		if errors.Is(err, errNoWithdrawalAccountType) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无法确定账户类型"})
+		} else if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "账户不存在"})
 		} else {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "查询或创建账户失败"})
@@ -91,97 +75,30 @@ func (ctrl *WithdrawalController) CreateWithdrawal(c *gin.Context) {
 		return
 	}
 
-	// 3. 计算手续费（这里简单处理：0手续费，实际可以根据金额和方式计算）
-	fee := 0
-	actualAmount := req.Amount - fee
-
-	// 4. 序列化账户信息并计算哈希
-	accountInfoBytes, err := json.Marshal(req.AccountInfo)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account info"})
-		return
-	}
-	accountInfoStr := string(accountInfoBytes)
-	hash := sha256.Sum256([]byte(accountInfoStr))
-	accountInfoHash := hex.EncodeToString(hash[:])
-
-	var withdrawal models.Withdrawal
-
-	// 5. 冻结积分（在事务内检查余额并更新，防止竞态条件）
-	err = ctrl.DB.Transaction(func(tx *gorm.DB) error {
-		// 使用行锁重新获取账户
-		var lockedAccount models.CreditAccount
-		if err := tx.Where("id = ?", account.ID).First(&lockedAccount).Error; err != nil {
-			return err
-		}
-
-		// 在锁内检查余额
-		if lockedAccount.Balance < req.Amount {
-			return errors.New("积分余额不足")
-		}
-
-		// 扣除可用余额
-		lockedAccount.Balance -= req.Amount
-		// 增加冻结余额
-		lockedAccount.FrozenBalance += req.Amount
-
-		// 保存账户更新
-		if err := tx.Save(&lockedAccount).Error; err != nil {
-			return err
-		}
-
-		// 创建提现记录
-		withdrawal = models.Withdrawal{
-			AccountID:       account.ID,
-			Amount:          req.Amount,
-			Fee:             fee,
-			ActualAmount:    actualAmount,
-			Method:          req.Method,
-			AccountInfo:     accountInfoStr,
-			AccountInfoHash: accountInfoHash,
-			Status:          models.WithdrawalStatusPending,
-		}
-
-		if err := tx.Create(&withdrawal).Error; err != nil {
-			return err
-		}
-
-		// 记录冻结流水
-		transaction := models.CreditTransaction{
-			AccountID:        account.ID,
-			Type:             "WITHDRAW_FREEZE",
-			Amount:           -req.Amount,
-			BalanceBefore:    lockedAccount.Balance,
-			BalanceAfter:     lockedAccount.Balance - req.Amount,
-			Description:      fmt.Sprintf("提现申请 %d 积分", req.Amount),
-			TransactionGroupID: func() *uuid.UUID { id := uuid.New(); return &id }(),
-		}
-		if err := tx.Create(&transaction).Error; err != nil {
-			return err
-		}
-
-		// 更新外部 account 指针
-		account = &lockedAccount
-		return nil
-	})
-
+	withdrawal, err := ctrl.withdrawalService.Create(&services.WithdrawalInput{
+		AccountID:   account.ID,
+		Amount:      req.Amount,
+		Method:      req.Method,
+		AccountInfo: req.AccountInfo,
+		Description: req.Description,
+	}, withdrawalActor(c, user))
 	if err != nil {
-		if err.Error() == "积分余额不足" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "积分余额不足"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to freeze balance"})
-		}
+		respondWithdrawalError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "提现申请已提交，等待审核",
 		"withdrawal": gin.H{
-			"id":            withdrawal.ID,
-			"amount":        withdrawal.Amount,
-			"actualAmount":  withdrawal.ActualAmount,
-			"status":        withdrawal.Status,
-			"createdAt":     withdrawal.CreatedAt,
+			"id":           withdrawal.ID,
+			"amount":       withdrawal.Amount,
+			"fee":          withdrawal.Fee,
+			"taxAmount":    withdrawal.TaxAmount,
+			"actualAmount": withdrawal.ActualAmount,
+			"payoutCents":  withdrawal.PayoutCents,
+			"status":       withdrawal.Status,
+			"riskReview":   withdrawal.RiskReview,
+			"createdAt":    withdrawal.CreatedAt,
 		},
 	})
 }
@@ -195,64 +112,103 @@ func (ctrl *WithdrawalController) GetWithdrawals(c *gin.Context) {
 	}
 	user := currentUser.(*models.User)
 
-	// 分页参数
-	page := c.DefaultQuery("page", "1")
-	pageSize := c.DefaultQuery("page_size", "20")
-	status := c.Query("status")
+	filter, ok := ctrl.withdrawalFilter(c, user)
+	if !ok {
+		c.JSON(http.StatusOK, gin.H{
+			"withdrawals": []models.Withdrawal{},
+			"total":       0,
+		})
+		return
+	}
 
-	var withdrawals []models.Withdrawal
-	var total int64
+	withdrawals, total, err := ctrl.withdrawalService.List(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get withdrawals"})
+		return
+	}
 
-	query := ctrl.DB.Model(&models.Withdrawal{})
+	c.JSON(http.StatusOK, gin.H{
+		"withdrawals": withdrawals,
+		"total":       total,
+	})
+}
 
-	// 超管可以查看所有记录，其他用户只能查看自己的记录
-	if !utils.IsSuperAdmin(user) {
-		// 需要先获取用户的积分账户ID
-		var accountType models.OwnerType
-		if utils.IsServiceProviderAdmin(user) {
-			accountType = models.OwnerTypeOrgProvider
-		} else if utils.IsMerchantAdmin(user) || utils.IsMerchantStaff(user) {
-			accountType = models.OwnerTypeOrgMerchant
-		} else if utils.IsCreator(user) {
-			accountType = models.OwnerTypeUserPersonal
-		} else {
-			c.JSON(http.StatusOK, gin.H{
-				"withdrawals": []models.Withdrawal{},
-				"total":       0,
-			})
-			return
-		}
+// QuoteWithdrawal 试算提现手续费
+// @Summary 试算提现手续费
+// @Description 按当前生效的提现策略计算手续费和到账金额，并返回单笔与累计限额
+// @Tags 提现管理
+// @Param amount query int true "提现积分"
+// @Param method query string true "提现方式 ALIPAY/WECHAT/BANK"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/withdrawals/quote [get]
+func (ctrl *WithdrawalController) QuoteWithdrawal(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
 
-		var account models.CreditAccount
-		parsedID, err := uuid.Parse(user.AuthCenterUserID)
-		if err != nil {
-			c.JSON(http.StatusOK, gin.H{
-				"withdrawals": []models.Withdrawal{},
-				"total":       0,
-			})
-			return
-		}
-		if err := ctrl.DB.Where("owner_id = ? AND owner_type = ?", parsedID, accountType).First(&account).Error; err != nil {
-			c.JSON(http.StatusOK, gin.H{
-				"withdrawals": []models.Withdrawal{},
-				"total":       0,
-			})
-			return
-		}
-		query = query.Where("account_id = ?", account.ID)
+	method := models.WithdrawalMethod(c.Query("method"))
+	switch method {
+	case models.WithdrawalMethodAlipay, models.WithdrawalMethodWechat, models.WithdrawalMethodBank:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的提现方式"})
+		return
 	}
+	amount := parseWithdrawalInt(c.Query("amount"))
 
-	// 状态筛选
-	if status != "" {
-		query = query.Where("status = ?", status)
+	account, err := ctrl.ownAccount(c, user)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "账户不存在"})
+		return
 	}
 
-	// 获取总数
-	query.Count(&total)
+	quote, err := ctrl.withdrawalService.Quote(account, method, amount)
+	if err != nil {
+		respondWithdrawalError(c, err)
+		return
+	}
+
+	response := gin.H{
+		"amount":       amount,
+		"method":       method,
+		"fee":          quote.Fee,
+		"taxWithheld":  quote.TaxWithheld,
+		"taxAmount":    quote.TaxAmount,
+		"actualAmount": quote.ActualAmount,
+		"payoutCents":  quote.PayoutCents,
+		"exchangeRate": gin.H{
+			"version": quote.ExchangeRate.Version,
+			"credits": quote.ExchangeRate.Credits,
+			"cents":   quote.ExchangeRate.Cents,
+		},
+	}
+	if quote.Policy != nil {
+		response["limits"] = gin.H{
+			"minAmount":       quote.Policy.MinAmount,
+			"maxAmount":       quote.Policy.MaxAmount,
+			"dailyLimit":      quote.Policy.DailyLimit,
+			"monthlyLimit":    quote.Policy.MonthlyLimit,
+			"coolingOffHours": quote.Policy.CoolingOffHours,
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetWithdrawalReviewQueue 风控人工复核队列（仅超管）
+// 命中风控规则且待审核的提现，审核通过时必须填写复核意见
+// @Summary 提现风控复核队列
+// @Tags 提现管理
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/withdrawals/review-queue [get]
+func (ctrl *WithdrawalController) GetWithdrawalReviewQueue(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
 
-	// 分页查询
-	offset := (parseWithdrawalInt(page) - 1) * parseWithdrawalInt(pageSize)
-	if err := query.Order("created_at DESC").Offset(offset).Limit(parseWithdrawalInt(pageSize)).Find(&withdrawals).Error; err != nil {
+	filter, _ := ctrl.withdrawalFilter(c, user)
+	flagged := true
+	filter.Status = string(models.WithdrawalStatusPending)
+	filter.RiskReview = &flagged
+
+	withdrawals, total, err := ctrl.withdrawalService.List(filter)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get withdrawals"})
 		return
 	}
@@ -266,11 +222,10 @@ func (ctrl *WithdrawalController) GetWithdrawals(c *gin.Context) {
 // GetWithdrawal 获取提现详情
 func (ctrl *WithdrawalController) GetWithdrawal(c *gin.Context) {
 	user := c.MustGet("user").(*models.User)
-	id := c.Param("id")
 
-	var withdrawal models.Withdrawal
-	if err := ctrl.DB.Where("id = ?", id).First(&withdrawal).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
+	withdrawal, err := ctrl.withdrawalService.Get(c.Param("id"))
+	if err != nil {
+		if errors.Is(err, services.ErrWithdrawalNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "提现记录不存在"})
 			return
 		}
@@ -279,31 +234,9 @@ func (ctrl *WithdrawalController) GetWithdrawal(c *gin.Context) {
 	}
 
 	// 权限检查：超管可以查看所有，其他用户只能查看自己的
-	if !utils.IsSuperAdmin(user) {
-		var accountType models.OwnerType
-		if utils.IsServiceProviderAdmin(user) {
-			accountType = models.OwnerTypeOrgProvider
-		} else if utils.IsMerchantAdmin(user) || utils.IsMerchantStaff(user) {
-			accountType = models.OwnerTypeOrgMerchant
-		} else {
-			accountType = models.OwnerTypeUserPersonal
-		}
-
-		var account models.CreditAccount
-		parsedID, err := uuid.Parse(user.AuthCenterUserID)
-		if err != nil {
-			c.JSON(http.StatusForbidden, gin.H{"error": "无权访问此记录"})
-			return
-		}
-		if err := ctrl.DB.Where("owner_id = ? AND owner_type = ?", parsedID, accountType).First(&account).Error; err != nil {
-			c.JSON(http.StatusForbidden, gin.H{"error": "无权访问此记录"})
-			return
-		}
-
-		if withdrawal.AccountID != account.ID {
-			c.JSON(http.StatusForbidden, gin.H{"error": "无权访问此记录"})
-			return
-		}
+	if !ctrl.canViewWithdrawal(c, user, withdrawal) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权访问此记录"})
+		return
 	}
 
 	c.JSON(http.StatusOK, withdrawal)
@@ -313,102 +246,26 @@ func (ctrl *WithdrawalController) GetWithdrawal(c *gin.Context) {
 func (ctrl *WithdrawalController) AuditWithdrawal(c *gin.Context) {
 	user := c.MustGet("user").(*models.User)
 
-	// 只有超管可以审核
-	if !utils.IsSuperAdmin(user) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "无权审核提现申请"})
-		return
-	}
-
-	id := c.Param("id")
-
 	var req AuditWithdrawalRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
 		return
 	}
 
-	var withdrawal models.Withdrawal
-	if err := ctrl.DB.Where("id = ?", id).First(&withdrawal).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "提现记录不存在"})
-		return
-	}
-
-	// 检查状态
-	if !withdrawal.CanAudit() {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "该提现申请已被处理"})
-		return
-	}
-
-	// 获取关联账户
-	var account models.CreditAccount
-	if err := ctrl.DB.Where("id = ?", withdrawal.AccountID).First(&account).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get account"})
-		return
+	var withdrawal *models.Withdrawal
+	var err error
+	if *req.Approved {
+		withdrawal, err = ctrl.withdrawalService.Approve(c.Param("id"), req.AuditNote, user.ID, withdrawalActor(c, user))
+	} else {
+		withdrawal, err = ctrl.withdrawalService.Reject(c.Param("id"), req.AuditNote, user.ID, withdrawalActor(c, user))
 	}
-
-	err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
-		now := time.Now()
-
-		if req.Approved {
-			// 通过：更新状态为approved
-			newStatus := models.WithdrawalStatusApproved
-			auditNote := req.AuditNote
-			if err := tx.Model(&withdrawal).Updates(map[string]interface{}{
-				"status":     newStatus,
-				"audit_note": auditNote,
-				"audited_by": user.ID,
-				"audited_at": &now,
-			}).Error; err != nil {
-				return err
-			}
-		} else {
-			// 拒绝：解冻积分（从frozen_balance转回balance）
-			newStatus := models.WithdrawalStatusRejected
-			auditNote := req.AuditNote
-
-			// 更新提现状态
-			if err := tx.Model(&withdrawal).Updates(map[string]interface{}{
-				"status":     newStatus,
-				"audit_note": auditNote,
-				"audited_by": user.ID,
-				"audited_at": &now,
-			}).Error; err != nil {
-				return err
-			}
-
-			// 解冻积分
-			if err := tx.Model(&account).Update("frozen_balance", gorm.Expr("frozen_balance - ?", withdrawal.Amount)).Error; err != nil {
-				return err
-			}
-			if err := tx.Model(&account).Update("balance", gorm.Expr("balance + ?", withdrawal.Amount)).Error; err != nil {
-				return err
-			}
-
-			// 记录退款流水
-			transaction := models.CreditTransaction{
-				AccountID:       account.ID,
-				Type:            "WITHDRAW_REFUND",
-				Amount:          withdrawal.Amount,
-				BalanceBefore:   account.FrozenBalance,
-				BalanceAfter:    account.FrozenBalance - withdrawal.Amount,
-				Description:     fmt.Sprintf("提现拒绝退款 %d 积分", withdrawal.Amount),
-				TransactionGroupID: func() *uuid.UUID { id := uuid.New(); return &id }(),
-			}
-			if err := tx.Create(&transaction).Error; err != nil {
-				return err
-			}
-		}
-
-		return nil
-	})
-
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to audit withdrawal"})
+		respondWithdrawalError(c, err)
 		return
 	}
 
 	statusText := "已通过"
-	if !req.Approved {
+	if !*req.Approved {
 		statusText = "已拒绝"
 	}
 
@@ -423,86 +280,259 @@ func (ctrl *WithdrawalController) AuditWithdrawal(c *gin.Context) {
 }
 
 // ProcessWithdrawal 处理打款（仅超管）
+// 提交渠道打款后提现进入打款中（processing），最终结果由渠道回调或定时轮询确认
+// @Summary 提交提现打款
+// @Tags 提现管理
+// @Param id path string true "提现ID"
+// @Success 200 {object} models.Withdrawal
+// @Router /api/v1/withdrawals/{id}/process [post]
 func (ctrl *WithdrawalController) ProcessWithdrawal(c *gin.Context) {
 	user := c.MustGet("user").(*models.User)
 
-	// 只有超管可以打款
-	if !utils.IsSuperAdmin(user) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "无权处理打款"})
+	withdrawal, err := ctrl.withdrawalService.Process(c.Request.Context(), c.Param("id"), withdrawalActor(c, user))
+	if err != nil {
+		respondWithdrawalError(c, err)
 		return
 	}
 
-	id := c.Param("id")
+	message := "已提交打款，等待渠道确认"
+	switch withdrawal.Status {
+	case models.WithdrawalStatusCompleted:
+		message = "打款成功"
+	case models.WithdrawalStatusFailed:
+		message = "打款失败，冻结积分已退回"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    message,
+		"withdrawal": withdrawal,
+	})
+}
 
+// SyncWithdrawalPayout 主动查询打款结果（仅超管）
+// @Summary 查询提现打款结果
+// @Tags 提现管理
+// @Param id path string true "提现ID"
+// @Success 200 {object} models.Withdrawal
+// @Router /api/v1/withdrawals/{id}/sync [post]
+func (ctrl *WithdrawalController) SyncWithdrawalPayout(c *gin.Context) {
 	var withdrawal models.Withdrawal
-	if err := ctrl.DB.Where("id = ?", id).First(&withdrawal).Error; err != nil {
+	if err := ctrl.DB.Where("id = ?", c.Param("id")).First(&withdrawal).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "提现记录不存在"})
 		return
 	}
 
-	// 检查状态
-	if !withdrawal.CanProcess() {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "该提现申请不能打款"})
+	updated, err := ctrl.payoutService.Sync(c.Request.Context(), &withdrawal)
+	if err != nil {
+		respondWithdrawalError(c, err)
 		return
 	}
 
-	// 获取关联账户
-	var account models.CreditAccount
-	if err := ctrl.DB.Where("id = ?", withdrawal.AccountID).First(&account).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get account"})
+	c.JSON(http.StatusOK, updated)
+}
+
+// ConfirmPayoutRequest 人工确认打款结果请求
+type ConfirmPayoutRequest struct {
+	Succeeded  bool   `json:"succeeded"`
+	OrderNo    string `json:"orderNo"`    // 银行流水号
+	FailReason string `json:"failReason"` // 失败原因（succeeded=false 时必填）
+}
+
+// ConfirmWithdrawalPayout 人工确认银行转账结果（仅超管，仅人工打款渠道）
+// @Summary 确认提现打款结果
+// @Tags 提现管理
+// @Param id path string true "提现ID"
+// @Param request body ConfirmPayoutRequest true "打款结果"
+// @Success 200 {object} models.Withdrawal
+// @Router /api/v1/withdrawals/{id}/payout-result [post]
+func (ctrl *WithdrawalController) ConfirmWithdrawalPayout(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	var req ConfirmPayoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	if !req.Succeeded && req.FailReason == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请填写打款失败原因"})
 		return
 	}
 
-	err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
-		now := time.Now()
+	withdrawal, err := ctrl.payoutService.ConfirmManual(c.Param("id"), req.Succeeded, req.OrderNo, req.FailReason, user.AuthCenterUserID)
+	if err != nil {
+		respondWithdrawalError(c, err)
+		return
+	}
 
-		// 更新状态为completed
-		if err := tx.Model(&withdrawal).Updates(map[string]interface{}{
-			"status":       models.WithdrawalStatusCompleted,
-			"completed_at": &now,
-		}).Error; err != nil {
-			return err
-		}
+	c.JSON(http.StatusOK, withdrawal)
+}
 
-		// 扣除冻结余额
-		if err := tx.Model(&account).Update("frozen_balance", gorm.Expr("frozen_balance - ?", withdrawal.Amount)).Error; err != nil {
-			return err
-		}
+// PayoutCallback 打款渠道异步通知（无需认证，由渠道签名校验）
+// 同一笔打款重复通知只处理一次；处理失败时返回非成功应答，由渠道重试
+// @Summary 打款渠道回调
+// @Tags 提现管理
+// @Param provider path string true "打款渠道：wechat/alipay/fake"
+// @Router /api/v1/payouts/callback/{provider} [post]
+func (ctrl *WithdrawalController) PayoutCallback(c *gin.Context) {
+	providerName := c.Param("provider")
+	provider, err := ctrl.payoutService.ProviderByName(providerName)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
 
-		// 记录提现流水
-		transaction := models.CreditTransaction{
-			AccountID:       account.ID,
-			Type:            "WITHDRAW",
-			Amount:          -withdrawal.Amount,
-			BalanceBefore:   account.FrozenBalance,
-			BalanceAfter:    account.FrozenBalance - withdrawal.Amount,
-			Description:     fmt.Sprintf("提现成功 %d 积分", withdrawal.Amount),
-			TransactionGroupID: func() *uuid.UUID { id := uuid.New(); return &id }(),
-		}
-		if err := tx.Create(&transaction).Error; err != nil {
-			return err
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxPaymentCallbackBody))
+	if err != nil {
+		contentType, ack := provider.CallbackAck(false, "读取回调失败")
+		c.Data(http.StatusBadRequest, contentType, ack)
+		return
+	}
+
+	withdrawal, err := ctrl.payoutService.HandleCallback(providerName, c.Request, body)
+	if err != nil {
+		log.Printf("[PayoutCallback] %s 回调处理失败: %v", providerName, err)
+
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, services.ErrPaymentSignatureInvalid):
+			status = http.StatusUnauthorized
+		case errors.Is(err, services.ErrWithdrawalNotFound):
+			status = http.StatusNotFound
 		}
+		contentType, ack := provider.CallbackAck(false, err.Error())
+		c.Data(status, contentType, ack)
+		return
+	}
 
-		// TODO: 实际环境中，这里应该调用第三方支付接口进行打款
-		// 例如：支付宝、微信支付、银行转账等
+	log.Printf("[PayoutCallback] 提现 %s 打款状态: %s（%s）", withdrawal.ID, withdrawal.Status, providerName)
+	contentType, ack := provider.CallbackAck(true, "OK")
+	c.Data(http.StatusOK, contentType, ack)
+}
 
-		return nil
-	})
+// respondWithdrawalError 提现、打款相关错误映射为 HTTP 响应
+func respondWithdrawalError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidAmount),
+		errors.Is(err, services.ErrWithdrawalPolicyViolation),
+		errors.Is(err, services.ErrWithdrawalReviewNoteRequired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrWithdrawalNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "提现记录不存在"})
+	case errors.Is(err, services.ErrInvalidWithdrawalStatus):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "该提现申请当前状态不能执行此操作"})
+	case errors.Is(err, services.ErrPayoutProviderNotConfigured),
+		errors.Is(err, services.ErrPayoutAccountInfoInvalid),
+		errors.Is(err, services.ErrPayoutNotManual),
+		errors.Is(err, services.ErrCashAccountNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInsufficientBalance):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "余额不足"})
+	case errors.Is(err, services.ErrPaymentGatewayUnavailable), errors.Is(err, services.ErrPaymentGatewayRejected):
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrExchangeRateNotFound):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process withdrawal"})
+	}
+}
+
+// errNoWithdrawalAccountType 当前角色没有可提现的积分账户
+var errNoWithdrawalAccountType = errors.New("无法确定账户类型")
+
+// withdrawalActor 当前请求的操作者
+func withdrawalActor(c *gin.Context, user *models.User) services.WithdrawalActor {
+	return services.WithdrawalActor{
+		UserID:    user.AuthCenterUserID,
+		IPAddress: c.ClientIP(),
+		UserAgent: c.GetHeader("User-Agent"),
+	}
+}
 
+// withdrawalAccountType 按当前激活角色确定提现使用的积分账户类型
+func withdrawalAccountType(c *gin.Context) (models.OwnerType, bool) {
+	rc, ok := utils.GetRoleContext(c)
+	switch {
+	case !ok:
+	case rc.Is(constants.RoleSuperAdmin):
+		return models.OwnerTypeUserPersonal, true // 超管使用个人账户
+	case rc.Is(constants.RoleServiceProviderAdmin):
+		return models.OwnerTypeOrgProvider, true
+	case rc.Is(constants.RoleMerchantAdmin, constants.RoleMerchantStaff):
+		return models.OwnerTypeOrgMerchant, true
+	case rc.Is(constants.RoleCreator):
+		return models.OwnerTypeUserPersonal, true
+	}
+	return "", false
+}
+
+// resolveAccount 获取（不存在时创建）当前用户的提现积分账户
+func (ctrl *WithdrawalController) resolveAccount(c *gin.Context, user *models.User) (*models.CreditAccount, error) {
+	accountType, ok := withdrawalAccountType(c)
+	if !ok {
+		return nil, errNoWithdrawalAccountType
+	}
+	parsedID, err := uuid.Parse(user.AuthCenterUserID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process withdrawal"})
-		return
+		return nil, err
 	}
+	return ctrl.findOrCreateAccount(parsedID, accountType, parsedID)
+}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "打款成功",
-		"withdrawal": gin.H{
-			"id":           withdrawal.ID,
-			"status":       withdrawal.Status,
-			"actualAmount": withdrawal.ActualAmount,
-			"completedAt":  withdrawal.CompletedAt,
-		},
-	})
+// ownAccount 查询当前用户已有的提现积分账户
+func (ctrl *WithdrawalController) ownAccount(c *gin.Context, user *models.User) (*models.CreditAccount, error) {
+	accountType, ok := withdrawalAccountType(c)
+	if !ok {
+		return nil, errNoWithdrawalAccountType
+	}
+	parsedID, err := uuid.Parse(user.AuthCenterUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	var account models.CreditAccount
+	if err := ctrl.DB.Where("owner_id = ? AND owner_type = ?", parsedID, accountType).First(&account).Error; err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// withdrawalFilter 按请求参数和当前用户构造列表查询条件；非超管且没有账户时返回 false
+func (ctrl *WithdrawalController) withdrawalFilter(c *gin.Context, user *models.User) (services.WithdrawalFilter, bool) {
+	filter := services.WithdrawalFilter{
+		Scope:    tenantScope(c).Withdrawals,
+		Status:   c.Query("status"),
+		Page:     parseWithdrawalInt(c.DefaultQuery("page", "1")),
+		PageSize: parseWithdrawalInt(c.DefaultQuery("page_size", "20")),
+	}
+	if riskReview := c.Query("riskReview"); riskReview != "" {
+		flagged := riskReview == "true"
+		filter.RiskReview = &flagged
+	}
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize < 1 || filter.PageSize > 100 {
+		filter.PageSize = 20
+	}
+
+	// 超管可以查看所有记录，其他用户只能查看自己的记录
+	if !utils.ActingAs(c, constants.RoleSuperAdmin) {
+		account, err := ctrl.ownAccount(c, user)
+		if err != nil {
+			return filter, false
+		}
+		filter.AccountIDs = []uuid.UUID{account.ID}
+	}
+	return filter, true
+}
+
+// canViewWithdrawal 超管可以查看所有提现，其他用户只能查看自己账户的提现
+func (ctrl *WithdrawalController) canViewWithdrawal(c *gin.Context, user *models.User, withdrawal *models.Withdrawal) bool {
+	if utils.ActingAs(c, constants.RoleSuperAdmin) {
+		return true
+	}
+	account, err := ctrl.ownAccount(c, user)
+	return err == nil && withdrawal.AccountID == account.ID
 }
 
 // parseWithdrawalInt 辅助函数：字符串转int
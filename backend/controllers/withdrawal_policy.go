@@ -0,0 +1,199 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"pr-business/constants"
+	"pr-business/models"
+	"pr-business/services"
+)
+
+// WithdrawalPolicyController 提现策略控制器（仅超级管理员）
+type WithdrawalPolicyController struct {
+	policyService *services.WithdrawalPolicyService
+	auditService  *services.AuditService
+}
+
+// NewWithdrawalPolicyController 创建提现策略控制器
+func NewWithdrawalPolicyController(
+	policyService *services.WithdrawalPolicyService,
+	auditService *services.AuditService,
+) *WithdrawalPolicyController {
+	return &WithdrawalPolicyController{
+		policyService: policyService,
+		auditService:  auditService,
+	}
+}
+
+// WithdrawalFeeTierRequest 手续费阶梯
+type WithdrawalFeeTierRequest struct {
+	Method      string `json:"method" binding:"required,oneof=ALIPAY WECHAT BANK"`
+	MinAmount   int    `json:"minAmount" binding:"min=0"`           // 本档起始金额（含）
+	RateBasis   int    `json:"rateBasis" binding:"min=0,max=10000"` // 万分比，60 = 0.6%
+	FixedAmount int    `json:"fixedAmount" binding:"min=0"`         // 每笔固定积分
+	MinFee      int    `json:"minFee" binding:"min=0"`              // 最低手续费
+	MaxFee      int    `json:"maxFee" binding:"min=0"`              // 最高手续费，0 表示不封顶
+}
+
+// CreateWithdrawalPolicyRequest 创建提现策略请求
+type CreateWithdrawalPolicyRequest struct {
+	Scope                string                     `json:"scope" binding:"required,oneof=GLOBAL USER_PERSONAL ORG_MERCHANT ORG_PROVIDER"`
+	MinAmount            int                        `json:"minAmount" binding:"min=0"`
+	MaxAmount            int                        `json:"maxAmount" binding:"min=0"`
+	DailyLimit           int                        `json:"dailyLimit" binding:"min=0"`
+	MonthlyLimit         int                        `json:"monthlyLimit" binding:"min=0"`
+	CoolingOffHours      int                        `json:"coolingOffHours" binding:"min=0"`
+	ReviewAmount         int                        `json:"reviewAmount" binding:"min=0"`
+	ReferralWindowHours  int                        `json:"referralWindowHours" binding:"min=0"`
+	FlagNewPayoutAccount bool                       `json:"flagNewPayoutAccount"`
+	FeeTiers             []WithdrawalFeeTierRequest `json:"feeTiers" binding:"dive"`
+	Description          string                     `json:"description" binding:"max=500"`
+}
+
+// CreateWithdrawalPolicy 创建提现策略
+// @Summary 创建提现策略
+// @Description 设置单笔限额、每日/每月累计上限、首笔收入冷静期、手续费阶梯和风控规则；同一范围的旧策略自动停用
+// @Tags 提现管理
+// @Accept json
+// @Produce json
+// @Param request body CreateWithdrawalPolicyRequest true "提现策略"
+// @Success 201 {object} models.WithdrawalPolicy
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Router /api/withdrawal-policies [post]
+func (c *WithdrawalPolicyController) CreateWithdrawalPolicy(ctx *gin.Context) {
+	userObj := ctx.MustGet("user").(*models.User)
+
+	var req CreateWithdrawalPolicyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+
+	input := &services.CreateWithdrawalPolicyRequest{
+		Scope:                req.Scope,
+		MinAmount:            req.MinAmount,
+		MaxAmount:            req.MaxAmount,
+		DailyLimit:           req.DailyLimit,
+		MonthlyLimit:         req.MonthlyLimit,
+		CoolingOffHours:      req.CoolingOffHours,
+		ReviewAmount:         req.ReviewAmount,
+		ReferralWindowHours:  req.ReferralWindowHours,
+		FlagNewPayoutAccount: req.FlagNewPayoutAccount,
+		Description:          req.Description,
+	}
+	for _, tier := range req.FeeTiers {
+		input.FeeTiers = append(input.FeeTiers, models.WithdrawalFeeTier{
+			Method:      models.WithdrawalMethod(tier.Method),
+			MinAmount:   tier.MinAmount,
+			RateBasis:   tier.RateBasis,
+			FixedAmount: tier.FixedAmount,
+			MinFee:      tier.MinFee,
+			MaxFee:      tier.MaxFee,
+		})
+	}
+
+	policy, err := c.policyService.CreatePolicy(input, userObj.AuthCenterUserID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	_ = c.auditService.LogFinancialOperation(
+		userObj.AuthCenterUserID,
+		constants.AuditActionWithdrawalPolicyCreate,
+		constants.AuditResourceWithdrawalPolicy,
+		policy.ID.String(),
+		map[string]interface{}{
+			"scope":                   policy.Scope,
+			"min_amount":              policy.MinAmount,
+			"max_amount":              policy.MaxAmount,
+			"daily_limit":             policy.DailyLimit,
+			"monthly_limit":           policy.MonthlyLimit,
+			"cooling_off_hours":       policy.CoolingOffHours,
+			"review_amount":           policy.ReviewAmount,
+			"referral_window_hours":   policy.ReferralWindowHours,
+			"flag_new_payout_account": policy.FlagNewPayoutAccount,
+			"fee_tiers":               policy.FeeTiers,
+		},
+		ctx.ClientIP(),
+		ctx.GetHeader("User-Agent"),
+	)
+
+	ctx.JSON(http.StatusCreated, policy)
+}
+
+// GetWithdrawalPolicies 查询提现策略列表
+// @Summary 查询提现策略
+// @Tags 提现管理
+// @Accept json
+// @Produce json
+// @Param scope query string false "策略范围"
+// @Param include_inactive query bool false "包含已停用策略"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} utils.PageResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Router /api/withdrawal-policies [get]
+func (c *WithdrawalPolicyController) GetWithdrawalPolicies(ctx *gin.Context) {
+	page, pageSize := parsePlatformFeePage(ctx)
+	policies, total, err := c.policyService.ListPolicies(
+		ctx.Query("scope"),
+		ctx.Query("include_inactive") == "true",
+		pageSize,
+		(page-1)*pageSize,
+	)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"list":      policies,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// DeactivateWithdrawalPolicy 停用提现策略
+// @Summary 停用提现策略
+// @Description 停用后该范围按全局策略处理（全局策略停用后不限制、不收手续费）；已申请的提现不受影响
+// @Tags 提现管理
+// @Accept json
+// @Produce json
+// @Param id path string true "策略ID"
+// @Success 200 {object} models.WithdrawalPolicy
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /api/withdrawal-policies/{id}/deactivate [post]
+func (c *WithdrawalPolicyController) DeactivateWithdrawalPolicy(ctx *gin.Context) {
+	userObj := ctx.MustGet("user").(*models.User)
+
+	policy, err := c.policyService.DeactivatePolicy(ctx.Param("id"))
+	if err != nil {
+		if errors.Is(err, services.ErrWithdrawalPolicyNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "提现策略不存在"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	_ = c.auditService.LogFinancialOperation(
+		userObj.AuthCenterUserID,
+		constants.AuditActionWithdrawalPolicyEnd,
+		constants.AuditResourceWithdrawalPolicy,
+		policy.ID.String(),
+		map[string]interface{}{
+			"scope": policy.Scope,
+		},
+		ctx.ClientIP(),
+		ctx.GetHeader("User-Agent"),
+	)
+
+	ctx.JSON(http.StatusOK, policy)
+}
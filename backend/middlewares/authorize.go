@@ -0,0 +1,88 @@
+package middlewares
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"pr-business/services"
+	"pr-business/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Authorize 按授权策略校验当前身份能否执行操作（须在 AuthCenterMiddleware 之后）
+// loader 非空时按路由参数 id 加载资源，并校验资源是否属于当前身份的组织或本人
+func Authorize(authz *services.AuthorizationService, action string, loader services.PolicyResourceLoader) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, exists := utils.GetCurrentUser(c)
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "未认证"})
+			c.Abort()
+			return
+		}
+		roleContext, _ := utils.GetRoleContext(c)
+
+		subject, err := authz.Subject(user, roleContext)
+		if err != nil {
+			log.Printf("[Authorize] load subject for %s failed: %v", action, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "权限校验失败"})
+			c.Abort()
+			return
+		}
+
+		var resource *services.PolicyResource
+		if loader != nil {
+			resource, err = loader(c.Param("id"))
+			if err != nil {
+				switch {
+				case errors.Is(err, services.ErrCampaignNotFound),
+					errors.Is(err, services.ErrTaskNotFound),
+					errors.Is(err, services.ErrCreatorNotFound),
+					errors.Is(err, services.ErrMerchantNotFound),
+					errors.Is(err, services.ErrServiceProviderNotFound):
+					c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+				default:
+					log.Printf("[Authorize] load resource for %s failed: %v", action, err)
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "权限校验失败"})
+				}
+				c.Abort()
+				return
+			}
+		}
+
+		decision := authz.Authorize(subject, action, resource)
+		if decision.OutOfScope {
+			// 其他组织或其他用户的资源不暴露是否存在
+			c.JSON(http.StatusNotFound, gin.H{"error": resourceNotFound(resource).Error()})
+			c.Abort()
+			return
+		}
+		if !decision.Allowed {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":  decision.Reason,
+				"action": action,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// resourceNotFound 资源类型对应的不存在错误
+func resourceNotFound(resource *services.PolicyResource) error {
+	switch resource.Type {
+	case "campaign":
+		return services.ErrCampaignNotFound
+	case "task":
+		return services.ErrTaskNotFound
+	case "creator":
+		return services.ErrCreatorNotFound
+	case "merchant":
+		return services.ErrMerchantNotFound
+	case "service_provider":
+		return services.ErrServiceProviderNotFound
+	}
+	return errors.New("资源不存在")
+}
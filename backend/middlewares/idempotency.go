@@ -0,0 +1,130 @@
+package middlewares
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"pr-business/models"
+	"pr-business/services"
+	"pr-business/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// IdempotencyKeyHeader 客户端传入的幂等键请求头
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader 响应为重放结果时返回 true
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+)
+
+// idempotencyResponseWriter 在写出响应的同时保留一份副本用于保存
+type idempotencyResponseWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *idempotencyResponseWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *idempotencyResponseWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency 幂等中间件，用于资金类接口，需放在认证中间件之后
+// 请求携带 Idempotency-Key 时：
+// 1. 首次请求正常处理并保存响应（5xx 不保存，允许用同一幂等键重试）
+// 2. 相同幂等键、相同请求内容的重试直接返回保存的响应
+// 3. 相同幂等键、不同请求内容返回 422；首次请求仍在处理中返回 409
+// 未携带 Idempotency-Key 的请求不做去重
+func Idempotency(idempotencyService *services.IdempotencyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key 过长"})
+			return
+		}
+
+		user, ok := utils.GetCurrentUser(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "未认证"})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "读取请求失败"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		method := c.Request.Method
+		path := c.Request.URL.Path
+		hash := sha256.New()
+		hash.Write([]byte(method + "\n" + path + "\n"))
+		hash.Write(body)
+		requestHash := hex.EncodeToString(hash.Sum(nil))
+
+		record, created, err := idempotencyService.Begin(user.ID, key, method, path, requestHash)
+		if err != nil {
+			if errors.Is(err, services.ErrIdempotencyKeyMismatch) {
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+				return
+			}
+			log.Printf("[Idempotency] 占用幂等键失败: %v", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "处理幂等键失败"})
+			return
+		}
+
+		if !created {
+			if record.Status != models.IdempotencyKeyStatusCompleted {
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "相同请求正在处理中，请稍后重试"})
+				return
+			}
+			c.Header(IdempotentReplayedHeader, "true")
+			c.Data(record.ResponseStatus, record.ResponseType, record.ResponseBody)
+			c.Abort()
+			return
+		}
+
+		writer := &idempotencyResponseWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		// 处理过程中 panic 时释放幂等键，再交给 Recovery 中间件
+		defer func() {
+			if r := recover(); r != nil {
+				if err := idempotencyService.Release(record.ID); err != nil {
+					log.Printf("[Idempotency] %v", err)
+				}
+				panic(r)
+			}
+		}()
+
+		c.Next()
+
+		status := writer.Status()
+		if status >= http.StatusInternalServerError {
+			if err := idempotencyService.Release(record.ID); err != nil {
+				log.Printf("[Idempotency] %v", err)
+			}
+			return
+		}
+
+		if err := idempotencyService.Complete(record.ID, status, writer.Header().Get("Content-Type"), writer.body.Bytes()); err != nil {
+			log.Printf("[Idempotency] %v", err)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+-- ============================================
+-- 结算任务表（持久化结算队列）
+-- 用途：任务审核通过后的结算由后台 worker 执行，失败自动重试，重试耗尽进入死信
+-- ============================================
+
+CREATE TABLE IF NOT EXISTS settlement_jobs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    task_id UUID NOT NULL UNIQUE REFERENCES tasks(id),
+    campaign_id UUID NOT NULL REFERENCES campaigns(id),
+    auditor_user_id VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'succeeded', 'dead', 'cancelled')),
+    attempts INT NOT NULL DEFAULT 0,
+    max_attempts INT NOT NULL DEFAULT 8,
+    next_run_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    locked_at TIMESTAMP,
+    locked_by VARCHAR(100),
+    last_error TEXT,
+    completed_at TIMESTAMP,
+    cancelled_by VARCHAR(255),
+    cancel_reason TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- 索引
+CREATE INDEX IF NOT EXISTS idx_settlement_jobs_status_next_run ON settlement_jobs(status, next_run_at);
+CREATE INDEX IF NOT EXISTS idx_settlement_jobs_campaign ON settlement_jobs(campaign_id);
+
+-- 注释
+COMMENT ON TABLE settlement_jobs IS '结算任务表（任务审核通过后的持久化结算队列）';
+COMMENT ON COLUMN settlement_jobs.task_id IS '待结算的任务（每个任务只结算一次）';
+COMMENT ON COLUMN settlement_jobs.status IS '状态：pending-待执行, running-执行中, succeeded-已结算, dead-死信, cancelled-已取消';
+COMMENT ON COLUMN settlement_jobs.attempts IS '已执行次数';
+COMMENT ON COLUMN settlement_jobs.next_run_at IS '下次执行时间（指数退避）';
+COMMENT ON COLUMN settlement_jobs.locked_at IS 'worker 领取时间（超时未完成视为 worker 崩溃，可被重新领取）';
+COMMENT ON COLUMN settlement_jobs.last_error IS '最近一次失败原因';
+
+-- 历史数据：已审核通过但从未结算的任务补入队列
+INSERT INTO settlement_jobs (task_id, campaign_id, auditor_user_id)
+SELECT t.id, t.campaign_id, COALESCE(t.audited_by::text, 'system')
+FROM tasks t
+WHERE t.status = 'APPROVED'
+  AND NOT EXISTS (
+      SELECT 1 FROM credit_transactions ct
+      WHERE ct.related_task_id = t.id AND ct.type = 'TASK_INCOME'
+  )
+ON CONFLICT (task_id) DO NOTHING;
@@ -0,0 +1,113 @@
+-- ============================================
+-- 复式记账分录表
+-- 用途：所有积分、托管、现金余额变动都以平衡分录记录，账户余额为分录的投影
+-- ============================================
+
+CREATE TABLE IF NOT EXISTS ledger_entries (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    transaction_group_id UUID NOT NULL,
+    group_sequence INT NOT NULL,
+    account_kind VARCHAR(20) NOT NULL CHECK (account_kind IN ('CREDIT', 'CREDIT_FROZEN', 'SYSTEM', 'CASH')),
+    account_id UUID NOT NULL,
+    unit VARCHAR(10) NOT NULL CHECK (unit IN ('CREDIT', 'CNY_FEN')),
+    direction VARCHAR(10) NOT NULL CHECK (direction IN ('DEBIT', 'CREDIT')),
+    amount INT NOT NULL CHECK (amount > 0),
+    type VARCHAR(50) NOT NULL,
+    balance_before INT NOT NULL,
+    balance_after INT NOT NULL,
+    related_campaign_id UUID REFERENCES campaigns(id),
+    related_task_id UUID REFERENCES tasks(id),
+    description VARCHAR(200),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- 索引
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_group ON ledger_entries(transaction_group_id);
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_account ON ledger_entries(account_kind, account_id);
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_type ON ledger_entries(type);
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_created_at ON ledger_entries(created_at);
+
+-- 注释
+COMMENT ON TABLE ledger_entries IS '复式记账分录表（同一分组内每个记账单位借贷相等）';
+COMMENT ON COLUMN ledger_entries.account_kind IS '账户类型：CREDIT-积分可用余额, CREDIT_FROZEN-积分冻结余额, SYSTEM-系统账户, CASH-现金账户';
+COMMENT ON COLUMN ledger_entries.unit IS '记账单位：CREDIT-积分, CNY_FEN-人民币分';
+COMMENT ON COLUMN ledger_entries.direction IS '方向（以账户余额为视角）：CREDIT-余额增加, DEBIT-余额减少';
+
+-- 积分流水区分可用/冻结余额
+ALTER TABLE credit_transactions ADD COLUMN IF NOT EXISTS balance_type VARCHAR(20) NOT NULL DEFAULT 'AVAILABLE'
+    CHECK (balance_type IN ('AVAILABLE', 'FROZEN'));
+COMMENT ON COLUMN credit_transactions.balance_type IS '余额类型：AVAILABLE-可用余额, FROZEN-冻结余额';
+
+-- 补充代码中已使用但未登记的交易类型
+INSERT INTO transaction_types (code, name, description, account_types, amount_direction) VALUES
+('CAMPAIGN_FREEZE', '活动冻结', '活动发布时冻结商家积分（balance→frozen_balance）', ARRAY['ORG_MERCHANT'], 'negative'),
+('CAMPAIGN_REFUND', '活动退还', '活动关闭时未完成名额积分解冻（frozen_balance→balance）', ARRAY['ORG_MERCHANT'], 'positive')
+ON CONFLICT (code) DO NOTHING;
+
+-- 记账对手方账户
+INSERT INTO system_accounts (account_type, balance, description)
+SELECT 'CREDIT_ISSUANCE', 0, '积分发行账户（充值发行、提现回收积分的对手方，余额为负表示流通中的积分）'
+WHERE NOT EXISTS (SELECT 1 FROM system_accounts WHERE account_type = 'CREDIT_ISSUANCE' AND is_active = true);
+
+INSERT INTO cash_accounts (account_type, balance, description)
+SELECT 'CLEARING', 0, '外部资金清算账户（现金流入流出平台的对手方）'
+WHERE NOT EXISTS (SELECT 1 FROM cash_accounts WHERE account_type = 'CLEARING' AND is_active = true);
+
+-- 期初余额：将现有余额作为期初分录入账，使余额与分录重放结果一致
+DO $$
+DECLARE
+    credit_group UUID := uuid_generate_v4();
+    cash_group UUID := uuid_generate_v4();
+    issuance_id UUID;
+    clearing_id UUID;
+    credit_total BIGINT;
+    cash_total BIGINT;
+    next_seq INT;
+BEGIN
+    IF EXISTS (SELECT 1 FROM ledger_entries WHERE type = 'OPENING_BALANCE') THEN
+        RETURN;
+    END IF;
+
+    SELECT id INTO issuance_id FROM system_accounts WHERE account_type = 'CREDIT_ISSUANCE' AND is_active = true;
+    SELECT id INTO clearing_id FROM cash_accounts WHERE account_type = 'CLEARING' AND is_active = true;
+
+    -- 积分：可用余额、冻结余额、系统账户
+    INSERT INTO ledger_entries (transaction_group_id, group_sequence, account_kind, account_id, unit, direction, amount, type, balance_before, balance_after, description)
+    SELECT credit_group, ROW_NUMBER() OVER (ORDER BY kind, id), kind, id, 'CREDIT',
+           CASE WHEN bal > 0 THEN 'CREDIT' ELSE 'DEBIT' END, ABS(bal), 'OPENING_BALANCE', 0, bal, '期初余额'
+    FROM (
+        SELECT 'CREDIT' AS kind, id, balance AS bal FROM credit_accounts WHERE balance <> 0
+        UNION ALL
+        SELECT 'CREDIT_FROZEN', id, frozen_balance FROM credit_accounts WHERE frozen_balance <> 0
+        UNION ALL
+        SELECT 'SYSTEM', id, balance FROM system_accounts WHERE balance <> 0 AND id <> issuance_id
+    ) opening;
+
+    SELECT COALESCE(SUM(CASE WHEN direction = 'CREDIT' THEN amount ELSE -amount END), 0), COALESCE(MAX(group_sequence), 0) + 1
+    INTO credit_total, next_seq
+    FROM ledger_entries WHERE transaction_group_id = credit_group;
+
+    IF credit_total <> 0 THEN
+        INSERT INTO ledger_entries (transaction_group_id, group_sequence, account_kind, account_id, unit, direction, amount, type, balance_before, balance_after, description)
+        VALUES (credit_group, next_seq, 'SYSTEM', issuance_id, 'CREDIT',
+                CASE WHEN credit_total > 0 THEN 'DEBIT' ELSE 'CREDIT' END, ABS(credit_total), 'OPENING_BALANCE', 0, -credit_total, '期初余额对冲');
+        UPDATE system_accounts SET balance = -credit_total, updated_at = NOW() WHERE id = issuance_id;
+    END IF;
+
+    -- 现金账户
+    INSERT INTO ledger_entries (transaction_group_id, group_sequence, account_kind, account_id, unit, direction, amount, type, balance_before, balance_after, description)
+    SELECT cash_group, ROW_NUMBER() OVER (ORDER BY id), 'CASH', id, 'CNY_FEN',
+           CASE WHEN balance > 0 THEN 'CREDIT' ELSE 'DEBIT' END, ABS(balance), 'OPENING_BALANCE', 0, balance, '期初余额'
+    FROM cash_accounts WHERE balance <> 0 AND id <> clearing_id;
+
+    SELECT COALESCE(SUM(CASE WHEN direction = 'CREDIT' THEN amount ELSE -amount END), 0), COALESCE(MAX(group_sequence), 0) + 1
+    INTO cash_total, next_seq
+    FROM ledger_entries WHERE transaction_group_id = cash_group;
+
+    IF cash_total <> 0 THEN
+        INSERT INTO ledger_entries (transaction_group_id, group_sequence, account_kind, account_id, unit, direction, amount, type, balance_before, balance_after, description)
+        VALUES (cash_group, next_seq, 'CASH', clearing_id, 'CNY_FEN',
+                CASE WHEN cash_total > 0 THEN 'DEBIT' ELSE 'CREDIT' END, ABS(cash_total), 'OPENING_BALANCE', 0, -cash_total, '期初余额对冲');
+        UPDATE cash_accounts SET balance = -cash_total, updated_at = NOW() WHERE id = clearing_id;
+    END IF;
+END $$;
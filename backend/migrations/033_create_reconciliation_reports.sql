@@ -0,0 +1,40 @@
+-- ============================================
+-- 对账报告表
+-- 用途：每日对账（cmd/reconcile 或管理端触发）的批次与差异明细
+-- ============================================
+
+CREATE TABLE IF NOT EXISTS reconciliation_runs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    status VARCHAR(20) NOT NULL DEFAULT 'running' CHECK (status IN ('running', 'completed', 'failed')),
+    triggered_by VARCHAR(255) NOT NULL,
+    checked_accounts INT NOT NULL DEFAULT 0,
+    discrepancy_count INT NOT NULL DEFAULT 0,
+    error_message TEXT,
+    started_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS reconciliation_discrepancies (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    run_id UUID NOT NULL REFERENCES reconciliation_runs(id) ON DELETE CASCADE,
+    check_type VARCHAR(50) NOT NULL,
+    account_kind VARCHAR(20),
+    account_id UUID,
+    owner_id UUID,
+    owner_type VARCHAR(50),
+    expected BIGINT NOT NULL,
+    actual BIGINT NOT NULL,
+    difference BIGINT NOT NULL,
+    detail TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- 索引
+CREATE INDEX IF NOT EXISTS idx_reconciliation_runs_started_at ON reconciliation_runs(started_at);
+CREATE INDEX IF NOT EXISTS idx_reconciliation_discrepancies_run ON reconciliation_discrepancies(run_id);
+
+-- 注释
+COMMENT ON TABLE reconciliation_runs IS '对账批次表';
+COMMENT ON TABLE reconciliation_discrepancies IS '对账差异明细表';
+COMMENT ON COLUMN reconciliation_discrepancies.check_type IS '检查项：CREDIT_BALANCE, CREDIT_FROZEN, MERCHANT_FROZEN, CASH_WITHDRAWAL, LEDGER_PROJECTION';
+COMMENT ON COLUMN reconciliation_discrepancies.difference IS '差额 = 实际值 - 期望值';
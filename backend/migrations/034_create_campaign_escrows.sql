@@ -0,0 +1,33 @@
+-- ============================================
+-- 活动托管分账表
+-- 用途：活动审核通过后积分转入 TASK_ESCROW 系统账户，按活动记录托管余额
+-- 结算从托管支出，活动关闭时剩余部分退回商家
+-- ============================================
+
+CREATE TABLE IF NOT EXISTS campaign_escrows (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    campaign_id UUID NOT NULL UNIQUE REFERENCES campaigns(id),
+    merchant_id UUID NOT NULL REFERENCES merchants(id),
+    balance INT NOT NULL DEFAULT 0 CHECK (balance >= 0),
+    funded_amount INT NOT NULL DEFAULT 0,
+    released_amount INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- 索引
+CREATE INDEX IF NOT EXISTS idx_campaign_escrows_merchant ON campaign_escrows(merchant_id);
+
+-- 注释
+COMMENT ON TABLE campaign_escrows IS '活动托管分账表（TASK_ESCROW 系统账户的按活动子账）';
+COMMENT ON COLUMN campaign_escrows.balance IS '当前托管余额（积分）';
+COMMENT ON COLUMN campaign_escrows.funded_amount IS '累计注资';
+COMMENT ON COLUMN campaign_escrows.released_amount IS '累计支出（任务结算 + 关闭退还）';
+COMMENT ON COLUMN reconciliation_discrepancies.check_type IS '检查项：CREDIT_BALANCE, CREDIT_FROZEN, MERCHANT_FROZEN, CAMPAIGN_ESCROW, CASH_WITHDRAWAL, LEDGER_PROJECTION';
+
+-- 任务托管系统账户
+INSERT INTO system_accounts (account_type, balance, description)
+SELECT 'TASK_ESCROW', 0, '任务托管账户（活动发布后的商家积分，按活动分账）'
+WHERE NOT EXISTS (SELECT 1 FROM system_accounts WHERE account_type = 'TASK_ESCROW' AND is_active = true);
+
+-- 托管上线前发布的活动仍从商家冻结余额结算/退还，无需迁移
@@ -0,0 +1,69 @@
+-- ============================================
+-- 平台手续费规则与任务结算明细
+-- 用途：任务结算时按规则扣除平台手续费，计入 PLATFORM_REVENUE 系统账户
+-- 规则优先级：活动 > 商家 > 服务商 > 全局，按生效时间取当前有效规则
+-- ============================================
+
+CREATE TABLE IF NOT EXISTS platform_fee_rules (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    scope VARCHAR(20) NOT NULL CHECK (scope IN ('GLOBAL', 'PROVIDER', 'MERCHANT', 'CAMPAIGN')),
+    scope_id UUID,
+    fee_type VARCHAR(20) NOT NULL CHECK (fee_type IN ('PERCENTAGE', 'FIXED')),
+    rate_basis INT NOT NULL DEFAULT 0 CHECK (rate_basis >= 0 AND rate_basis <= 10000),
+    fixed_amount INT NOT NULL DEFAULT 0 CHECK (fixed_amount >= 0),
+    effective_from TIMESTAMP NOT NULL,
+    effective_to TIMESTAMP,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    description TEXT,
+    created_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK ((scope = 'GLOBAL' AND scope_id IS NULL) OR (scope <> 'GLOBAL' AND scope_id IS NOT NULL)),
+    CHECK (effective_to IS NULL OR effective_to > effective_from)
+);
+
+CREATE INDEX IF NOT EXISTS idx_platform_fee_rules_scope ON platform_fee_rules(scope, scope_id);
+CREATE INDEX IF NOT EXISTS idx_platform_fee_rules_effective ON platform_fee_rules(effective_from, effective_to);
+
+COMMENT ON TABLE platform_fee_rules IS '平台手续费规则';
+COMMENT ON COLUMN platform_fee_rules.scope IS '适用范围：GLOBAL, PROVIDER, MERCHANT, CAMPAIGN';
+COMMENT ON COLUMN platform_fee_rules.scope_id IS '适用对象ID（服务商/商家/活动），GLOBAL 为空';
+COMMENT ON COLUMN platform_fee_rules.rate_basis IS '按比例收取时的万分比，500 = 5%';
+COMMENT ON COLUMN platform_fee_rules.fixed_amount IS '固定收取时每个任务的积分';
+
+CREATE TABLE IF NOT EXISTS settlement_records (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    task_id UUID NOT NULL UNIQUE REFERENCES tasks(id),
+    campaign_id UUID NOT NULL REFERENCES campaigns(id),
+    merchant_id UUID NOT NULL REFERENCES merchants(id),
+    provider_id UUID,
+    fee_rule_id UUID REFERENCES platform_fee_rules(id),
+    task_amount INT NOT NULL,
+    platform_fee INT NOT NULL DEFAULT 0,
+    creator_amount INT NOT NULL DEFAULT 0,
+    staff_referral_amount INT NOT NULL DEFAULT 0,
+    provider_amount INT NOT NULL DEFAULT 0,
+    refund_amount INT NOT NULL DEFAULT 0,
+    transaction_group_id UUID NOT NULL,
+    settled_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (platform_fee + creator_amount + staff_referral_amount + provider_amount + refund_amount = task_amount)
+);
+
+CREATE INDEX IF NOT EXISTS idx_settlement_records_campaign ON settlement_records(campaign_id);
+CREATE INDEX IF NOT EXISTS idx_settlement_records_merchant ON settlement_records(merchant_id);
+CREATE INDEX IF NOT EXISTS idx_settlement_records_provider ON settlement_records(provider_id);
+CREATE INDEX IF NOT EXISTS idx_settlement_records_settled_at ON settlement_records(settled_at);
+
+COMMENT ON TABLE settlement_records IS '任务结算明细（手续费与各方分配）';
+COMMENT ON COLUMN settlement_records.transaction_group_id IS '对应 ledger_entries 分录组';
+COMMENT ON COLUMN settlement_records.refund_amount IS '未分配退回商家的部分';
+
+-- 手续费交易类型（仅系统账户分录使用，登记以便统一查询）
+INSERT INTO transaction_types (code, name, description, account_types, amount_direction) VALUES
+('PLATFORM_FEE', '平台手续费', '任务结算时扣除的平台手续费，计入 PLATFORM_REVENUE', ARRAY['SYSTEM'], 'positive')
+ON CONFLICT (code) DO NOTHING;
+
+-- 平台收益系统账户
+INSERT INTO system_accounts (account_type, balance, description)
+SELECT 'PLATFORM_REVENUE', 0, '平台收益账户（任务结算手续费）'
+WHERE NOT EXISTS (SELECT 1 FROM system_accounts WHERE account_type = 'PLATFORM_REVENUE' AND is_active = true);
@@ -0,0 +1,15 @@
+-- ============================================
+-- 营销活动状态机：新增 PAUSED 状态
+-- 合法转换：DRAFT → PENDING_APPROVAL/OPEN，PENDING_APPROVAL → OPEN，
+--           OPEN ⇄ PAUSED，OPEN/PAUSED → CLOSED
+-- ============================================
+
+-- 初始建表时的 CHECK 未包含 PENDING_APPROVAL，一并修正
+ALTER TABLE campaigns DROP CONSTRAINT IF EXISTS campaigns_status_check;
+ALTER TABLE campaigns ADD CONSTRAINT campaigns_status_check
+    CHECK (status IN ('DRAFT', 'PENDING_APPROVAL', 'OPEN', 'PAUSED', 'CLOSED'));
+
+COMMENT ON COLUMN campaigns.status IS '活动状态：DRAFT, PENDING_APPROVAL, OPEN, PAUSED, CLOSED';
+
+-- 自动关闭过期活动的扫描索引
+CREATE INDEX IF NOT EXISTS idx_campaigns_status_submission_deadline ON campaigns(status, submission_deadline);
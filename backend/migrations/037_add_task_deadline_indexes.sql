@@ -0,0 +1,9 @@
+-- ============================================
+-- 定时任务扫描索引
+-- 释放超时未提交任务、处理审核超时任务时按状态 + 时间扫描
+-- ============================================
+
+CREATE INDEX IF NOT EXISTS idx_tasks_status_submitted_at ON tasks(status, submitted_at);
+CREATE INDEX IF NOT EXISTS idx_tasks_tags ON tasks USING GIN(tags);
+
+COMMENT ON COLUMN tasks.tags IS '任务标签，ESCALATED 表示超过审核时限已自动升级';
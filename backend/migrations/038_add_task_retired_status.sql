@@ -0,0 +1,13 @@
+-- ============================================
+-- 任务名额随活动发布创建，减少名额时下架
+-- 新增 RETIRED 状态：活动减少名额时下架的空闲名额（保留记录，不参与接单和结算）
+-- ============================================
+
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS tasks_status_check;
+ALTER TABLE tasks ADD CONSTRAINT tasks_status_check
+    CHECK (status IN ('OPEN', 'ASSIGNED', 'SUBMITTED', 'APPROVED', 'REJECTED', 'RETIRED'));
+
+COMMENT ON COLUMN tasks.status IS '任务状态：OPEN, ASSIGNED, SUBMITTED, APPROVED, REJECTED, RETIRED';
+
+-- 此前待审核活动在创建时已预建名额，审核通过时按已有名额补齐，无需迁移；
+-- 任务大厅只展示开放中活动的名额
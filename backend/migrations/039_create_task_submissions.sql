@@ -0,0 +1,56 @@
+-- ============================================
+-- 任务提交与审核历史
+-- 每次提交写入一条记录，审核结果（通过/拒绝/要求修改）写回该记录；
+-- 要求修改时任务保持 ASSIGNED，达人须在 revision_deadline 前重新提交
+-- ============================================
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS revision_deadline TIMESTAMP;
+
+COMMENT ON COLUMN tasks.revision_deadline IS '要求修改后的重新提交截止时间，为空表示按活动提交截止时间';
+
+CREATE TABLE IF NOT EXISTS task_submissions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    task_id UUID NOT NULL REFERENCES tasks(id),
+    campaign_id UUID NOT NULL REFERENCES campaigns(id),
+    creator_id UUID NOT NULL,
+    round INT NOT NULL CHECK (round > 0),
+    platform VARCHAR(50),
+    platform_url VARCHAR(500),
+    screenshots JSONB NOT NULL DEFAULT '[]',
+    notes TEXT,
+    submitted_at TIMESTAMP NOT NULL,
+    review_action VARCHAR(20) CHECK (review_action IN ('APPROVE', 'REJECT', 'REQUEST_REVISION')),
+    reviewed_by VARCHAR(255),
+    reviewed_at TIMESTAMP,
+    review_note TEXT,
+    revision_deadline TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_submissions_task ON task_submissions(task_id, submitted_at);
+CREATE INDEX IF NOT EXISTS idx_task_submissions_campaign ON task_submissions(campaign_id);
+CREATE INDEX IF NOT EXISTS idx_task_submissions_creator ON task_submissions(creator_id);
+CREATE INDEX IF NOT EXISTS idx_task_submissions_pending ON task_submissions(task_id) WHERE review_action IS NULL;
+
+COMMENT ON TABLE task_submissions IS '任务提交与审核历史';
+COMMENT ON COLUMN task_submissions.round IS '同一达人在该任务上的第几次提交';
+COMMENT ON COLUMN task_submissions.review_action IS '审核结果：APPROVE, REJECT, REQUEST_REVISION，为空表示待审核';
+
+-- 回填：已提交/已通过的任务生成第 1 轮提交记录（拒绝的任务已清空达人信息，无法回填）
+INSERT INTO task_submissions (
+    task_id, campaign_id, creator_id, round, platform, platform_url, screenshots, notes,
+    submitted_at, review_action, reviewed_by, reviewed_at, review_note
+)
+SELECT
+    t.id, t.campaign_id, t.creator_id, 1, t.platform, t.platform_url,
+    COALESCE(t.screenshots, '[]'::jsonb), t.notes, t.submitted_at,
+    CASE WHEN t.status = 'APPROVED' THEN 'APPROVE' END,
+    CASE WHEN t.status = 'APPROVED' THEN t.audited_by::text END,
+    CASE WHEN t.status = 'APPROVED' THEN t.audited_at END,
+    CASE WHEN t.status = 'APPROVED' THEN t.audit_note END
+FROM tasks t
+WHERE t.status IN ('SUBMITTED', 'APPROVED')
+  AND t.creator_id IS NOT NULL
+  AND t.submitted_at IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM task_submissions s WHERE s.task_id = t.id);
@@ -0,0 +1,30 @@
+-- ============================================
+-- 资金类接口幂等键
+-- 客户端通过 Idempotency-Key 请求头重试时返回首次请求的响应，避免重复扣款/冻结
+-- 适用接口：/credit/recharge、/recharge-orders、/withdrawals、/withdrawals/enhanced、/campaigns/:id/approve
+-- ============================================
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id VARCHAR(255) NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    method VARCHAR(10) NOT NULL,
+    path VARCHAR(500) NOT NULL,
+    request_hash VARCHAR(64) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'processing' CHECK (status IN ('processing', 'completed')),
+    response_status INT,
+    response_type VARCHAR(100),
+    response_body BYTEA,
+    expires_at TIMESTAMP NOT NULL,
+    completed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT idx_idempotency_user_key UNIQUE (user_id, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
+
+COMMENT ON TABLE idempotency_keys IS '资金类接口幂等键';
+COMMENT ON COLUMN idempotency_keys.request_hash IS '请求摘要 SHA-256(method + path + body)，同一幂等键请求内容不同时拒绝';
+COMMENT ON COLUMN idempotency_keys.status IS '状态：processing（处理中）, completed（已完成，可重放）';
+COMMENT ON COLUMN idempotency_keys.expires_at IS '过期时间，过期后由定时任务清理，幂等键可重新使用';
@@ -0,0 +1,40 @@
+-- ============================================
+-- 在线充值：支付渠道下单，签名回调入账，支持退款
+-- 线下充值（channel = offline）流程不变
+-- ============================================
+
+ALTER TABLE recharge_orders
+    ADD COLUMN IF NOT EXISTS channel VARCHAR(20) NOT NULL DEFAULT 'offline' CHECK (channel IN ('offline', 'online')),
+    ADD COLUMN IF NOT EXISTS out_trade_no VARCHAR(64),
+    ADD COLUMN IF NOT EXISTS provider_trade_no VARCHAR(64),
+    ADD COLUMN IF NOT EXISTS payment_url VARCHAR(1000),
+    ADD COLUMN IF NOT EXISTS paid_amount INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS refunded_amount INT NOT NULL DEFAULT 0 CHECK (refunded_amount >= 0),
+    ADD COLUMN IF NOT EXISTS paid_at TIMESTAMP;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_recharge_orders_out_trade_no ON recharge_orders(out_trade_no);
+
+-- 新增 refunded 状态
+ALTER TABLE recharge_orders DROP CONSTRAINT IF EXISTS recharge_orders_status_check;
+ALTER TABLE recharge_orders ADD CONSTRAINT recharge_orders_status_check
+    CHECK (status IN ('pending', 'approved', 'rejected', 'completed', 'refunded'));
+
+-- 新增 mock 模拟渠道（仅开发/测试环境启用）
+ALTER TABLE recharge_orders DROP CONSTRAINT IF EXISTS recharge_orders_payment_method_check;
+ALTER TABLE recharge_orders ADD CONSTRAINT recharge_orders_payment_method_check
+    CHECK (payment_method IN ('alipay', 'wechat', 'bank', 'mock'));
+
+-- 充值退款交易类型
+INSERT INTO transaction_types (code, name, description, account_types, amount_direction) VALUES
+('RECHARGE_REFUND', '充值退款', '在线充值原路退款，从商家balance扣回积分', ARRAY['ORG_MERCHANT'], 'negative')
+ON CONFLICT (code) DO NOTHING;
+
+COMMENT ON TABLE recharge_orders IS '充值订单表（线下充值审核 + 在线支付）';
+COMMENT ON COLUMN recharge_orders.channel IS '充值方式：offline-线下转账审核, online-在线支付';
+COMMENT ON COLUMN recharge_orders.out_trade_no IS '在线支付商户订单号（回调按此号查找订单）';
+COMMENT ON COLUMN recharge_orders.provider_trade_no IS '渠道交易号';
+COMMENT ON COLUMN recharge_orders.payment_url IS '扫码支付链接';
+COMMENT ON COLUMN recharge_orders.paid_amount IS '渠道实付金额（分）';
+COMMENT ON COLUMN recharge_orders.refunded_amount IS '已退款积分';
+COMMENT ON COLUMN recharge_orders.paid_at IS '渠道支付成功时间';
+COMMENT ON COLUMN recharge_orders.status IS '订单状态：pending-待审核/待支付, approved-已通过, rejected-已拒绝, completed-已完成, refunded-已全额退款';
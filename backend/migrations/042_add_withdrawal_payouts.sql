@@ -0,0 +1,29 @@
+-- ============================================
+-- 提现打款：审核通过后提交渠道打款（微信商家转账 / 支付宝转账 / 银行人工打款）
+-- 状态：approved → processing（打款中）→ completed / failed（打款失败，冻结积分退回）
+-- ============================================
+
+ALTER TABLE withdrawals
+    ADD COLUMN IF NOT EXISTS payout_provider VARCHAR(20),
+    ADD COLUMN IF NOT EXISTS payout_out_biz_no VARCHAR(64),
+    ADD COLUMN IF NOT EXISTS payout_order_no VARCHAR(64),
+    ADD COLUMN IF NOT EXISTS payout_cash_account_id UUID REFERENCES cash_accounts(id),
+    ADD COLUMN IF NOT EXISTS payout_fail_reason VARCHAR(500),
+    ADD COLUMN IF NOT EXISTS payout_submitted_at TIMESTAMP;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_withdrawals_payout_out_biz_no ON withdrawals(payout_out_biz_no);
+
+-- 轮询打款中的提现
+CREATE INDEX IF NOT EXISTS idx_withdrawals_processing ON withdrawals(payout_submitted_at) WHERE status = 'processing';
+
+ALTER TABLE withdrawals DROP CONSTRAINT IF EXISTS withdrawals_status_check;
+ALTER TABLE withdrawals ADD CONSTRAINT withdrawals_status_check
+    CHECK (status IN ('pending', 'approved', 'processing', 'rejected', 'completed', 'failed'));
+
+COMMENT ON COLUMN withdrawals.status IS '提现状态：pending-待审核, approved-已通过, processing-打款中, rejected-已拒绝, completed-已完成, failed-打款失败';
+COMMENT ON COLUMN withdrawals.payout_provider IS '打款渠道：wechat/alipay/manual/fake';
+COMMENT ON COLUMN withdrawals.payout_out_biz_no IS '商户打款单号，重复提交时渠道按此去重';
+COMMENT ON COLUMN withdrawals.payout_order_no IS '渠道打款单号 / 银行流水号';
+COMMENT ON COLUMN withdrawals.payout_cash_account_id IS '出款现金账户（打款失败时现金退回此账户）';
+COMMENT ON COLUMN withdrawals.payout_fail_reason IS '打款失败原因';
+COMMENT ON COLUMN withdrawals.payout_submitted_at IS '提交渠道打款时间';
@@ -0,0 +1,56 @@
+-- ============================================
+-- 统一提现：withdrawal_requests_enhanced（/withdrawals/enhanced）的记录并入 withdrawals
+-- 之后所有提现由 WithdrawalService 处理，旧表只保留历史数据，不再写入
+-- ============================================
+
+ALTER TABLE withdrawals ADD COLUMN IF NOT EXISTS description TEXT;
+
+-- 审核人改为 users.id（VARCHAR），与 recharge_orders.audited_by 一致
+ALTER TABLE withdrawals DROP CONSTRAINT IF EXISTS fk_withdrawals_audited_by;
+ALTER TABLE withdrawals ALTER COLUMN audited_by TYPE VARCHAR(255) USING audited_by::text;
+ALTER TABLE withdrawals ADD CONSTRAINT fk_withdrawals_audited_by FOREIGN KEY (audited_by) REFERENCES users(id);
+
+-- 迁移旧记录：
+--   状态统一为小写；提现方式按出款现金账户类型推断（未选择或银行转账 → BANK）
+--   到账金额取原申请的元金额（分），差额记为手续费
+--   旧表 reviewed_by 为认证中心用户ID，换算为 users.id
+--   已完成的记录在审核时已从对应现金账户出款，记录出款现金账户供对账使用
+INSERT INTO withdrawals (
+    id, account_id, amount, fee, actual_amount, method, account_info, status,
+    audit_note, description, audited_by, audited_at, completed_at,
+    payout_provider, payout_cash_account_id, created_at, updated_at
+)
+SELECT
+    r.id,
+    r.account_id,
+    r.amount,
+    GREATEST(r.amount - ROUND(r.yuan_amount * 100)::int, 0),
+    ROUND(r.yuan_amount * 100)::int,
+    CASE r.cash_account_type
+        WHEN 'ALIPAY' THEN 'ALIPAY'
+        WHEN 'WECHAT' THEN 'WECHAT'
+        ELSE 'BANK'
+    END,
+    '{}'::jsonb,
+    LOWER(r.status),
+    LEFT(r.reject_reason, 200),
+    r.description,
+    u.id,
+    r.reviewed_at,
+    r.completed_at,
+    CASE WHEN LOWER(r.status) = 'completed' THEN 'manual' END,
+    CASE WHEN LOWER(r.status) = 'completed' THEN (
+        SELECT ca.id FROM cash_accounts ca
+        WHERE ca.account_type = r.cash_account_type
+        ORDER BY ca.created_at
+        LIMIT 1
+    ) END,
+    r.created_at,
+    COALESCE(r.completed_at, r.reviewed_at, r.created_at)
+FROM withdrawal_requests_enhanced r
+LEFT JOIN users u ON u.auth_center_user_id::text = r.reviewed_by
+ON CONFLICT (id) DO NOTHING;
+
+COMMENT ON TABLE withdrawal_requests_enhanced IS '提现申请表（增强版，已迁移至 withdrawals，只读保留历史）';
+COMMENT ON COLUMN withdrawals.description IS '申请说明';
+COMMENT ON COLUMN withdrawals.audited_by IS '审核人ID（users.id）';
@@ -0,0 +1,72 @@
+-- ============================================
+-- 提现策略：单笔限额、每日/每月累计上限、首笔收入冷静期、按提现方式的手续费阶梯、风控复核规则
+-- 提现时先取账户所有者类型的生效策略，没有时取全局策略；都没有时不限制、不收手续费
+-- 手续费从提现积分中扣除，打款成功时计入 PLATFORM_REVENUE
+-- ============================================
+
+CREATE TABLE IF NOT EXISTS withdrawal_policies (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    scope VARCHAR(20) NOT NULL CHECK (scope IN ('GLOBAL', 'USER_PERSONAL', 'ORG_MERCHANT', 'ORG_PROVIDER')),
+    min_amount INT NOT NULL DEFAULT 0 CHECK (min_amount >= 0),
+    max_amount INT NOT NULL DEFAULT 0 CHECK (max_amount >= 0),
+    daily_limit INT NOT NULL DEFAULT 0 CHECK (daily_limit >= 0),
+    monthly_limit INT NOT NULL DEFAULT 0 CHECK (monthly_limit >= 0),
+    cooling_off_hours INT NOT NULL DEFAULT 0 CHECK (cooling_off_hours >= 0),
+    review_amount INT NOT NULL DEFAULT 0 CHECK (review_amount >= 0),
+    referral_window_hours INT NOT NULL DEFAULT 0 CHECK (referral_window_hours >= 0),
+    flag_new_payout_account BOOLEAN NOT NULL DEFAULT FALSE,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    description TEXT,
+    created_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (max_amount = 0 OR min_amount <= max_amount)
+);
+
+-- 每个范围同时只有一条生效策略
+CREATE UNIQUE INDEX IF NOT EXISTS idx_withdrawal_policies_active_scope ON withdrawal_policies(scope) WHERE is_active;
+CREATE INDEX IF NOT EXISTS idx_withdrawal_policies_scope ON withdrawal_policies(scope);
+
+COMMENT ON TABLE withdrawal_policies IS '提现策略（限额、冷静期、风控规则）';
+COMMENT ON COLUMN withdrawal_policies.scope IS '适用范围：GLOBAL 或账户所有者类型 USER_PERSONAL / ORG_MERCHANT / ORG_PROVIDER';
+COMMENT ON COLUMN withdrawal_policies.daily_limit IS '每个所有者每日累计提现上限（积分），0 表示不限';
+COMMENT ON COLUMN withdrawal_policies.monthly_limit IS '每个所有者每月累计提现上限（积分），0 表示不限';
+COMMENT ON COLUMN withdrawal_policies.cooling_off_hours IS '首笔收入到账后多少小时内不能提现';
+COMMENT ON COLUMN withdrawal_policies.review_amount IS '单笔达到此金额进入人工复核，0 表示不启用';
+COMMENT ON COLUMN withdrawal_policies.referral_window_hours IS '返佣到账后多少小时内提现进入人工复核，0 表示不启用';
+COMMENT ON COLUMN withdrawal_policies.flag_new_payout_account IS '使用未成功打款过的收款账户时进入人工复核';
+
+CREATE TABLE IF NOT EXISTS withdrawal_fee_tiers (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    policy_id UUID NOT NULL REFERENCES withdrawal_policies(id) ON DELETE CASCADE,
+    method VARCHAR(20) NOT NULL CHECK (method IN ('ALIPAY', 'WECHAT', 'BANK')),
+    min_amount INT NOT NULL DEFAULT 0 CHECK (min_amount >= 0),
+    rate_basis INT NOT NULL DEFAULT 0 CHECK (rate_basis >= 0 AND rate_basis <= 10000),
+    fixed_amount INT NOT NULL DEFAULT 0 CHECK (fixed_amount >= 0),
+    min_fee INT NOT NULL DEFAULT 0 CHECK (min_fee >= 0),
+    max_fee INT NOT NULL DEFAULT 0 CHECK (max_fee >= 0),
+    CHECK (max_fee = 0 OR min_fee <= max_fee)
+);
+
+CREATE INDEX IF NOT EXISTS idx_withdrawal_fee_tiers_policy ON withdrawal_fee_tiers(policy_id);
+
+COMMENT ON TABLE withdrawal_fee_tiers IS '提现手续费阶梯（同一提现方式取起始金额不超过提现金额的最高一档）';
+COMMENT ON COLUMN withdrawal_fee_tiers.rate_basis IS '万分比，60 = 0.6%';
+COMMENT ON COLUMN withdrawal_fee_tiers.max_fee IS '最高手续费，0 表示不封顶';
+
+ALTER TABLE withdrawals
+    ADD COLUMN IF NOT EXISTS policy_id UUID REFERENCES withdrawal_policies(id),
+    ADD COLUMN IF NOT EXISTS risk_flags JSONB NOT NULL DEFAULT '[]',
+    ADD COLUMN IF NOT EXISTS risk_review BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- 风控复核队列
+CREATE INDEX IF NOT EXISTS idx_withdrawals_risk_review ON withdrawals(created_at) WHERE risk_review AND status = 'pending';
+
+COMMENT ON COLUMN withdrawals.policy_id IS '申请时生效的提现策略';
+COMMENT ON COLUMN withdrawals.risk_flags IS '命中的风控规则：NEW_PAYOUT_ACCOUNT / AFTER_REFERRAL / LARGE_AMOUNT';
+COMMENT ON COLUMN withdrawals.risk_review IS '需要人工复核（审核通过时必须填写复核意见）';
+
+-- 提现手续费交易类型（仅系统账户分录使用）
+INSERT INTO transaction_types (code, name, description, account_types, amount_direction) VALUES
+('WITHDRAW_FEE', '提现手续费', '提现打款成功时从提现积分中扣除的手续费，计入 PLATFORM_REVENUE', ARRAY['SYSTEM'], 'positive')
+ON CONFLICT (code) DO NOTHING;
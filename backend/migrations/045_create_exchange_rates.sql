@@ -0,0 +1,53 @@
+-- ============================================
+-- 积分汇率：credits 积分 = cents 分，按版本新增、按生效时间选取
+-- 提现到账金额和充值应付金额由服务端按生效汇率以整数换算（提现向下取整，充值向上取整），
+-- 每笔提现和充值订单保存使用的汇率版本
+-- ============================================
+
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    version INT NOT NULL UNIQUE,
+    credits INT NOT NULL CHECK (credits > 0),
+    cents INT NOT NULL CHECK (cents > 0),
+    effective_from TIMESTAMP NOT NULL,
+    description TEXT,
+    created_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_exchange_rates_effective_from ON exchange_rates(effective_from);
+
+COMMENT ON TABLE exchange_rates IS '积分汇率（只新增不修改）';
+COMMENT ON COLUMN exchange_rates.credits IS '积分数';
+COMMENT ON COLUMN exchange_rates.cents IS '对应的现金（分）';
+COMMENT ON COLUMN exchange_rates.effective_from IS '生效时间';
+
+-- 初始汇率：1 积分 = 1 分（与此前的隐含约定一致）
+INSERT INTO exchange_rates (version, credits, cents, effective_from, description, created_by)
+VALUES (1, 1, 1, '1970-01-01 00:00:00', '初始汇率：1 积分 = 1 分', 'system')
+ON CONFLICT (version) DO NOTHING;
+
+-- 提现：记录汇率和实际打款金额（分）
+ALTER TABLE withdrawals ADD COLUMN IF NOT EXISTS exchange_rate_id UUID REFERENCES exchange_rates(id);
+ALTER TABLE withdrawals ADD COLUMN IF NOT EXISTS payout_cents INT NOT NULL DEFAULT 0 CHECK (payout_cents >= 0);
+
+COMMENT ON COLUMN withdrawals.exchange_rate_id IS '申请时生效的积分汇率';
+COMMENT ON COLUMN withdrawals.payout_cents IS '实际打款金额（分），到账积分按汇率向下取整';
+
+-- 充值订单：记录汇率和应付金额（分）
+ALTER TABLE recharge_orders ADD COLUMN IF NOT EXISTS exchange_rate_id UUID REFERENCES exchange_rates(id);
+ALTER TABLE recharge_orders ADD COLUMN IF NOT EXISTS cash_amount INT NOT NULL DEFAULT 0 CHECK (cash_amount >= 0);
+
+COMMENT ON COLUMN recharge_orders.exchange_rate_id IS '下单时生效的积分汇率';
+COMMENT ON COLUMN recharge_orders.cash_amount IS '应付金额（分），充值积分按汇率向上取整';
+
+-- 历史记录按初始汇率回填
+UPDATE withdrawals
+SET exchange_rate_id = (SELECT id FROM exchange_rates WHERE version = 1),
+    payout_cents = actual_amount
+WHERE exchange_rate_id IS NULL;
+
+UPDATE recharge_orders
+SET exchange_rate_id = (SELECT id FROM exchange_rates WHERE version = 1),
+    cash_amount = amount
+WHERE exchange_rate_id IS NULL;
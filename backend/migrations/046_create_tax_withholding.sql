@@ -0,0 +1,57 @@
+-- ============================================
+-- 达人劳务报酬个税预扣
+-- 提现时按当月累计计税收入预扣个税，预扣积分从到账积分中扣除；
+-- 打款成功时以 TAX_WITHHOLDING 计入 TAX_PAYABLE 系统账户（代扣个税，待申报缴纳）
+-- ============================================
+
+CREATE TABLE IF NOT EXISTS tax_withholding_rules (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(100) NOT NULL,
+    threshold_amount INT NOT NULL DEFAULT 0 CHECK (threshold_amount >= 0),
+    fixed_deduction INT NOT NULL DEFAULT 0 CHECK (fixed_deduction >= 0),
+    deduction_rate_basis INT NOT NULL DEFAULT 0 CHECK (deduction_rate_basis >= 0 AND deduction_rate_basis <= 10000),
+    brackets JSONB NOT NULL DEFAULT '[]',
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    description TEXT,
+    created_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_tax_withholding_rules_active ON tax_withholding_rules(is_active);
+
+COMMENT ON TABLE tax_withholding_rules IS '个税预扣规则（同一时间只有一条生效）';
+COMMENT ON COLUMN tax_withholding_rules.threshold_amount IS '每次收入不超过该金额时减除固定费用，超过时按比例减除（分）';
+COMMENT ON COLUMN tax_withholding_rules.fixed_deduction IS '固定减除费用（分）';
+COMMENT ON COLUMN tax_withholding_rules.deduction_rate_basis IS '比例减除，万分比';
+COMMENT ON COLUMN tax_withholding_rules.brackets IS '预扣率表：[{upTo, rateBasis, quickDeduction}]，金额单位分，upTo=0 表示无上限';
+
+-- 默认规则：劳务报酬所得预扣（收入不超过4000元减除800元，超过减除20%；20%/30%/40% 三级预扣率）
+INSERT INTO tax_withholding_rules (name, threshold_amount, fixed_deduction, deduction_rate_basis, brackets, description, created_by)
+SELECT '劳务报酬所得预扣', 400000, 80000, 2000,
+       '[{"upTo":2000000,"rateBasis":2000,"quickDeduction":0},{"upTo":5000000,"rateBasis":3000,"quickDeduction":200000},{"upTo":0,"rateBasis":4000,"quickDeduction":700000}]',
+       '按月累计计税收入预扣，同月多次提现合并计算', 'system'
+WHERE NOT EXISTS (SELECT 1 FROM tax_withholding_rules);
+
+-- 提现：记录预扣规则、计税收入和预扣税额
+ALTER TABLE withdrawals ADD COLUMN IF NOT EXISTS tax_rule_id UUID REFERENCES tax_withholding_rules(id);
+ALTER TABLE withdrawals ADD COLUMN IF NOT EXISTS tax_income INT NOT NULL DEFAULT 0 CHECK (tax_income >= 0);
+ALTER TABLE withdrawals ADD COLUMN IF NOT EXISTS tax_withheld INT NOT NULL DEFAULT 0 CHECK (tax_withheld >= 0);
+ALTER TABLE withdrawals ADD COLUMN IF NOT EXISTS tax_amount INT NOT NULL DEFAULT 0 CHECK (tax_amount >= 0);
+
+CREATE INDEX IF NOT EXISTS idx_withdrawals_account_created ON withdrawals(account_id, created_at);
+
+COMMENT ON COLUMN withdrawals.tax_rule_id IS '预扣使用的个税规则，为空表示未预扣';
+COMMENT ON COLUMN withdrawals.tax_income IS '本次计税收入（分），扣除手续费后的提现积分按汇率换算';
+COMMENT ON COLUMN withdrawals.tax_withheld IS '本次预扣税额（分）';
+COMMENT ON COLUMN withdrawals.tax_amount IS '预扣税额对应的积分（向上取整），从到账积分中扣除';
+
+-- 交易类型
+INSERT INTO transaction_types (code, name, description, account_types, amount_direction) VALUES
+('TAX_WITHHOLDING', '个税预扣', '达人提现打款成功时预扣的个税，计入 TAX_PAYABLE', ARRAY['SYSTEM'], 'positive')
+ON CONFLICT (code) DO NOTHING;
+
+-- 代扣个税系统账户
+INSERT INTO system_accounts (account_type, balance, description)
+SELECT 'TAX_PAYABLE', 0, '代扣个税账户（达人提现预扣的个税，待申报缴纳）'
+WHERE NOT EXISTS (SELECT 1 FROM system_accounts WHERE account_type = 'TAX_PAYABLE' AND is_active = true);
@@ -0,0 +1,76 @@
+-- ============================================
+-- 商家充值开票
+-- 商家维护开票信息，对已完成的充值订单申请开票；财务开具（登记发票号码和文件）或驳回。
+-- 每笔充值订单待开票和已开票金额合计不超过实付金额（扣除已退款部分），金额单位：分
+-- ============================================
+
+CREATE TABLE IF NOT EXISTS billing_profiles (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    merchant_id UUID NOT NULL REFERENCES merchants(id),
+    invoice_type VARCHAR(20) NOT NULL DEFAULT 'normal' CHECK (invoice_type IN ('normal', 'special')),
+    title VARCHAR(200) NOT NULL,
+    tax_id VARCHAR(50) NOT NULL,
+    address VARCHAR(300),
+    phone VARCHAR(50),
+    bank_name VARCHAR(200),
+    bank_account VARCHAR(100),
+    email VARCHAR(200),
+    is_default BOOLEAN NOT NULL DEFAULT false,
+    created_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_billing_profiles_merchant ON billing_profiles(merchant_id) WHERE deleted_at IS NULL;
+
+COMMENT ON TABLE billing_profiles IS '商家开票信息（发票抬头）';
+COMMENT ON COLUMN billing_profiles.invoice_type IS '发票类型：normal 普通发票，special 专用发票';
+COMMENT ON COLUMN billing_profiles.tax_id IS '纳税人识别号';
+
+CREATE TABLE IF NOT EXISTS invoice_requests (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    merchant_id UUID NOT NULL REFERENCES merchants(id),
+    account_id UUID NOT NULL REFERENCES credit_accounts(id),
+    billing_profile_id UUID NOT NULL REFERENCES billing_profiles(id),
+    invoice_type VARCHAR(20) NOT NULL CHECK (invoice_type IN ('normal', 'special')),
+    title VARCHAR(200) NOT NULL,
+    tax_id VARCHAR(50) NOT NULL,
+    address VARCHAR(300),
+    phone VARCHAR(50),
+    bank_name VARCHAR(200),
+    bank_account VARCHAR(100),
+    email VARCHAR(200),
+    amount INT NOT NULL CHECK (amount > 0),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'issued', 'rejected')),
+    remark VARCHAR(500),
+    invoice_no VARCHAR(50),
+    invoice_file_url VARCHAR(500),
+    rejection_note TEXT,
+    requested_by VARCHAR(255) NOT NULL,
+    processed_by VARCHAR(255),
+    processed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (status <> 'issued' OR (invoice_no IS NOT NULL AND invoice_file_url IS NOT NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_invoice_requests_merchant ON invoice_requests(merchant_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_invoice_requests_status ON invoice_requests(status);
+
+COMMENT ON TABLE invoice_requests IS '开票申请（开票信息为申请时的快照）';
+COMMENT ON COLUMN invoice_requests.amount IS '开票金额（分），等于明细合计';
+COMMENT ON COLUMN invoice_requests.invoice_file_url IS '已开具发票文件URL';
+
+CREATE TABLE IF NOT EXISTS invoice_request_items (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    invoice_request_id UUID NOT NULL REFERENCES invoice_requests(id),
+    recharge_order_id UUID NOT NULL REFERENCES recharge_orders(id),
+    amount INT NOT NULL CHECK (amount > 0),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (invoice_request_id, recharge_order_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_invoice_request_items_order ON invoice_request_items(recharge_order_id);
+
+COMMENT ON TABLE invoice_request_items IS '开票申请明细：每笔充值订单本次开票金额（分）';
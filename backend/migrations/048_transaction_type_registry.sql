@@ -0,0 +1,54 @@
+-- ============================================
+-- 交易类型注册表
+-- 记账时按 transaction_types 校验交易类型（已登记、启用、未废弃，金额方向和账户类型一致）；
+-- 每次新增、修改、废弃在 transaction_type_versions 记录一版快照。
+-- 代码中使用但缺失的内置类型由服务启动时补齐（TransactionTypeService.Seed）
+-- ============================================
+
+CREATE TABLE IF NOT EXISTS transaction_type_versions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    code VARCHAR(50) NOT NULL REFERENCES transaction_types(code),
+    version INT NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    account_types VARCHAR(20)[] NOT NULL,
+    amount_direction VARCHAR(10) NOT NULL CHECK (amount_direction IN ('positive', 'negative')),
+    is_active BOOLEAN NOT NULL,
+    change_note TEXT,
+    changed_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (code, version)
+);
+
+COMMENT ON TABLE transaction_type_versions IS '交易类型定义的历史版本（只新增不修改）';
+COMMENT ON COLUMN transaction_types.account_types IS '适用账户类型：ORG_MERCHANT/ORG_PROVIDER/USER_PERSONAL，系统账户为 SYSTEM，现金账户为 CASH';
+
+-- 现有定义记为当前版本
+INSERT INTO transaction_type_versions (code, version, name, description, account_types, amount_direction, is_active, change_note, changed_by)
+SELECT code, version, name, description, account_types, amount_direction, is_active AND deprecated_at IS NULL, '注册表上线时的定义', 'system'
+FROM transaction_types
+ON CONFLICT (code, version) DO NOTHING;
+
+-- 员工返佣按邀请人类型计入个人、服务商或商家账户
+UPDATE transaction_types
+SET account_types = ARRAY['USER_PERSONAL', 'ORG_PROVIDER', 'ORG_MERCHANT'],
+    version = version + 1,
+    updated_at = NOW()
+WHERE code = 'STAFF_REFERRAL' AND NOT ('ORG_PROVIDER' = ANY(account_types));
+
+INSERT INTO transaction_type_versions (code, version, name, description, account_types, amount_direction, is_active, change_note, changed_by)
+SELECT code, version, name, description, account_types, amount_direction, is_active, '服务商/商家员工邀请的返佣计入组织账户', 'system'
+FROM transaction_types
+WHERE code = 'STAFF_REFERRAL'
+ON CONFLICT (code, version) DO NOTHING;
+
+-- 期初余额只用于账本迁移（032），登记为已废弃，禁止新交易使用
+INSERT INTO transaction_types (code, name, description, account_types, amount_direction, is_active, deprecated_at) VALUES
+('OPENING_BALANCE', '期初余额', '账本迁移时写入的期初余额，仅用于历史分录', ARRAY['ORG_MERCHANT', 'ORG_PROVIDER', 'USER_PERSONAL', 'SYSTEM', 'CASH'], 'positive', false, NOW())
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO transaction_type_versions (code, version, name, description, account_types, amount_direction, is_active, change_note, changed_by)
+SELECT code, version, name, description, account_types, amount_direction, false, '历史类型，登记为已废弃', 'system'
+FROM transaction_types
+WHERE code = 'OPENING_BALANCE'
+ON CONFLICT (code, version) DO NOTHING;
@@ -0,0 +1,44 @@
+-- ============================================
+-- 登录会话与 token 吊销
+-- 账号中心 token 换取本系统会话（access token + 可轮换的 refresh token）；
+-- 退出登录、管理员吊销的 token/会话记入 revoked_tokens，认证中间件逐请求校验
+-- ============================================
+
+CREATE TABLE IF NOT EXISTS auth_sessions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id),
+    refresh_token_hash VARCHAR(64) NOT NULL UNIQUE,
+    previous_token_hash VARCHAR(64),
+    ip_address VARCHAR(50),
+    user_agent VARCHAR(500),
+    expires_at TIMESTAMP NOT NULL,
+    last_refreshed_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    revoked_by VARCHAR(255),
+    revoke_reason VARCHAR(500),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_auth_sessions_user ON auth_sessions(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_auth_sessions_previous_token ON auth_sessions(previous_token_hash);
+
+COMMENT ON TABLE auth_sessions IS '登录会话（refresh token 只保存 SHA-256 摘要）';
+COMMENT ON COLUMN auth_sessions.previous_token_hash IS '上一个 refresh token 的摘要，再次出现视为泄露，吊销会话';
+
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    token_id VARCHAR(100) PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id),
+    expires_at TIMESTAMP NOT NULL,
+    reason VARCHAR(500),
+    revoked_by VARCHAR(255),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires ON revoked_tokens(expires_at);
+
+COMMENT ON TABLE revoked_tokens IS 'token 吊销列表：token_id 为 jti、无 jti 的账号中心 token 摘要或会话ID';
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS tokens_revoked_before TIMESTAMP;
+
+COMMENT ON COLUMN users.tokens_revoked_before IS '早于该时间签发的 token 一律失效（吊销全部会话时设置）';
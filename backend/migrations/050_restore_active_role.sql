@@ -0,0 +1,12 @@
+-- ============================================
+-- 恢复激活角色
+-- 029 删除了 active_role，多角色用户（如同时是达人和商家员工）无法指定以哪个身份操作。
+-- 现在由用户通过 /user/active-role 切换激活角色和组织，认证中间件据此解析请求身份；
+-- 为空时按角色优先级取默认身份
+-- ============================================
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS active_role VARCHAR(50);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS active_org_id UUID;
+
+COMMENT ON COLUMN users.active_role IS '用户选择的激活角色，必须是 roles 中的角色';
+COMMENT ON COLUMN users.active_org_id IS '激活角色代表的组织（商家或服务商ID），非组织角色为空';
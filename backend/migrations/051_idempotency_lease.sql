@@ -0,0 +1,12 @@
+-- ============================================
+-- 幂等键处理租约
+-- 首次请求处理中进程崩溃时幂等键停留在 processing，重试会一直返回 409 直到过期；
+-- 占用时写入租约到期时间，超过后同一请求可以重新占用该幂等键
+-- ============================================
+
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS locked_until TIMESTAMP;
+
+UPDATE idempotency_keys SET locked_until = created_at + INTERVAL '5 minutes'
+WHERE status = 'processing' AND locked_until IS NULL;
+
+COMMENT ON COLUMN idempotency_keys.locked_until IS '处理租约到期时间，processing 状态超过该时间视为处理中断，可被相同请求重新占用';
@@ -0,0 +1,47 @@
+-- ============================================
+-- 在线充值退款记录
+-- 退款不再在记账事务内调用渠道：先冻结积分并写入 pending 记录提交，
+-- 再调用渠道；渠道受理后扣回冻结积分，拒绝时解冻，结果未知时保持 pending，
+-- 主动查单时按同一退款单号重试
+-- ============================================
+
+CREATE TABLE IF NOT EXISTS recharge_refunds (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    recharge_order_id UUID NOT NULL REFERENCES recharge_orders(id),
+    out_refund_no VARCHAR(64) NOT NULL,
+    amount INT NOT NULL CHECK (amount > 0),
+    refund_cents INT NOT NULL CHECK (refund_cents >= 0),
+    reason VARCHAR(200),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'succeeded', 'failed')),
+    provider_refund_no VARCHAR(64),
+    provider_status VARCHAR(32),
+    failure_reason TEXT,
+    operator_id VARCHAR(255) NOT NULL,
+    completed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_recharge_refunds_out_refund_no ON recharge_refunds(out_refund_no);
+CREATE INDEX IF NOT EXISTS idx_recharge_refunds_order ON recharge_refunds(recharge_order_id);
+-- 每个订单同一时间只能有一笔处理中的退款
+CREATE UNIQUE INDEX IF NOT EXISTS idx_recharge_refunds_order_pending ON recharge_refunds(recharge_order_id) WHERE status = 'pending';
+
+-- 退款冻结/解冻交易类型；充值退款改为从冻结积分扣回
+INSERT INTO transaction_types (code, name, description, account_types, amount_direction) VALUES
+('RECHARGE_REFUND_FREEZE', '充值退款冻结', '发起在线充值退款，从balance转入frozen_balance', ARRAY['ORG_MERCHANT'], 'negative'),
+('RECHARGE_REFUND_RELEASE', '充值退款失败', '渠道拒绝退款，frozen_balance转回balance', ARRAY['ORG_MERCHANT'], 'positive')
+ON CONFLICT (code) DO NOTHING;
+
+UPDATE transaction_types SET description = '在线充值原路退款，渠道受理后扣除frozen_balance'
+WHERE code = 'RECHARGE_REFUND';
+
+COMMENT ON TABLE recharge_refunds IS '在线充值退款记录';
+COMMENT ON COLUMN recharge_refunds.out_refund_no IS '商户退款单号（渠道侧幂等键，重试沿用）';
+COMMENT ON COLUMN recharge_refunds.amount IS '退款积分';
+COMMENT ON COLUMN recharge_refunds.refund_cents IS '退款金额（分），按下单汇率换算';
+COMMENT ON COLUMN recharge_refunds.status IS '状态：pending-已冻结积分待渠道确认, succeeded-渠道已受理, failed-渠道拒绝已解冻';
+COMMENT ON COLUMN recharge_refunds.provider_status IS '渠道返回的退款状态';
+COMMENT ON COLUMN recharge_refunds.failure_reason IS '渠道拒绝原因或最近一次重试错误';
+COMMENT ON COLUMN recharge_refunds.completed_at IS '成功或失败的时间';
+COMMENT ON COLUMN recharge_orders.refunded_amount IS '已退款积分（含处理中的退款）';
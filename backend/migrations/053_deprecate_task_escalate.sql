@@ -0,0 +1,20 @@
+-- ============================================
+-- 废弃 TASK_ESCALATE 交易类型
+-- 审核超时升级只提高任务优先级并打上 ESCALATED 标签，超时释放只把任务退回 OPEN，
+-- 两者都不产生积分变动，分别记录为财务审计日志 TASK_ESCALATE / TASK_RELEASE。
+-- 该交易类型没有记账代码使用，登记为已废弃，禁止新交易使用，历史分录保留
+-- ============================================
+
+UPDATE transaction_types
+SET description = '已停用：审核超时升级不产生积分变动，仅记录审计日志',
+    is_active = false,
+    deprecated_at = NOW(),
+    version = version + 1,
+    updated_at = NOW()
+WHERE code = 'TASK_ESCALATE' AND deprecated_at IS NULL;
+
+INSERT INTO transaction_type_versions (code, version, name, description, account_types, amount_direction, is_active, change_note, changed_by)
+SELECT code, version, name, description, account_types, amount_direction, false, '审核超时升级不产生积分变动，登记为已废弃', 'system'
+FROM transaction_types
+WHERE code = 'TASK_ESCALATE'
+ON CONFLICT (code, version) DO NOTHING;
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AuthSession 登录会话
+// 用账号中心 token 换取会话后，客户端持有本系统签发的 access token 和 refresh token；
+// refresh token 只保存摘要，每次刷新轮换，旧 refresh token 再次出现视为泄露并吊销会话
+type AuthSession struct {
+	ID                uuid.UUID  `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	UserID            string     `gorm:"type:varchar(255);not null;index" json:"userId"`
+	RefreshTokenHash  string     `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+	PreviousTokenHash string     `gorm:"type:varchar(64);index" json:"-"`
+	IPAddress         string     `gorm:"type:varchar(50)" json:"ipAddress"`
+	UserAgent         string     `gorm:"type:varchar(500)" json:"userAgent"`
+	ExpiresAt         time.Time  `gorm:"not null" json:"expiresAt"`
+	LastRefreshedAt   *time.Time `json:"lastRefreshedAt"`
+	RevokedAt         *time.Time `json:"revokedAt"`
+	RevokedBy         string     `gorm:"type:varchar(255)" json:"revokedBy"`
+	RevokeReason      string     `gorm:"type:varchar(500)" json:"revokeReason"`
+	CreatedAt         time.Time  `gorm:"not null;default:now()" json:"createdAt"`
+	UpdatedAt         time.Time  `gorm:"not null;default:now()" json:"updatedAt"`
+}
+
+// TableName 指定表名
+func (AuthSession) TableName() string {
+	return "auth_sessions"
+}
+
+// BeforeCreate GORM Hook
+func (s *AuthSession) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// IsActive 会话是否可用（未吊销且 refresh token 未过期）
+func (s *AuthSession) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// RevokedToken 已吊销的 token 或会话（吊销列表）
+// TokenID 为 token 的 jti（无 jti 的账号中心 token 为其摘要）或会话ID；过期后 token 本身已失效，可清理
+type RevokedToken struct {
+	TokenID   string    `gorm:"primaryKey;type:varchar(100)" json:"tokenId"`
+	UserID    string    `gorm:"type:varchar(255);not null;index" json:"userId"`
+	ExpiresAt time.Time `gorm:"not null" json:"expiresAt"`
+	Reason    string    `gorm:"type:varchar(500)" json:"reason"`
+	RevokedBy string    `gorm:"type:varchar(255)" json:"revokedBy"`
+	CreatedAt time.Time `gorm:"not null;default:now()" json:"createdAt"`
+}
+
+// TableName 指定表名
+func (RevokedToken) TableName() string {
+	return "revoked_tokens"
+}
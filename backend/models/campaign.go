@@ -11,12 +11,31 @@ import (
 type CampaignStatus string
 
 const (
-	CampaignStatusDraft          CampaignStatus = "DRAFT"           // 草稿
+	CampaignStatusDraft           CampaignStatus = "DRAFT"            // 草稿
 	CampaignStatusPendingApproval CampaignStatus = "PENDING_APPROVAL" // 待审核
-	CampaignStatusOpen           CampaignStatus = "OPEN"            // 开放中
-	CampaignStatusClosed         CampaignStatus = "CLOSED"          // 已关闭
+	CampaignStatusOpen            CampaignStatus = "OPEN"             // 开放中
+	CampaignStatusPaused          CampaignStatus = "PAUSED"           // 已暂停（不可接单，已接任务可继续提交和审核）
+	CampaignStatusClosed          CampaignStatus = "CLOSED"           // 已关闭
 )
 
+// campaignTransitions 营销活动允许的状态转换
+var campaignTransitions = map[CampaignStatus][]CampaignStatus{
+	CampaignStatusDraft:           {CampaignStatusPendingApproval, CampaignStatusOpen},
+	CampaignStatusPendingApproval: {CampaignStatusOpen},
+	CampaignStatusOpen:            {CampaignStatusPaused, CampaignStatusClosed},
+	CampaignStatusPaused:          {CampaignStatusOpen, CampaignStatusClosed},
+}
+
+// CanTransitionTo 是否允许从当前状态转换到目标状态
+func (s CampaignStatus) CanTransitionTo(target CampaignStatus) bool {
+	for _, allowed := range campaignTransitions[s] {
+		if allowed == target {
+			return true
+		}
+	}
+	return false
+}
+
 // CampaignInvitationStatus 活动邀请码状态
 type CampaignInvitationStatus string
 
@@ -50,9 +69,9 @@ type Campaign struct {
 	DeletedAt           *time.Time     `json:"deletedAt"`
 
 	// 关联
-	Merchant *Merchant    `gorm:"foreignKey:MerchantID" json:"merchant,omitempty"`
+	Merchant *Merchant        `gorm:"foreignKey:MerchantID" json:"merchant,omitempty"`
 	Provider *ServiceProvider `gorm:"foreignKey:ProviderID" json:"provider,omitempty"`
-	Tasks    []Task       `gorm:"foreignKey:CampaignID" json:"tasks,omitempty"`
+	Tasks    []Task           `gorm:"foreignKey:CampaignID" json:"tasks,omitempty"`
 }
 
 // TableName 指定表名
@@ -77,6 +96,7 @@ const (
 	TaskStatusSubmitted TaskStatus = "SUBMITTED" // 已提交
 	TaskStatusApproved  TaskStatus = "APPROVED"  // 已通过
 	TaskStatusRejected  TaskStatus = "REJECTED"  // 已拒绝
+	TaskStatusRetired   TaskStatus = "RETIRED"   // 已下架（活动减少名额）
 )
 
 // TaskPriority 任务优先级
@@ -88,6 +108,9 @@ const (
 	TaskPriorityLow    TaskPriority = "LOW"
 )
 
+// TaskTagEscalated 超过审核时限被自动升级的任务标签
+const TaskTagEscalated = "ESCALATED"
+
 // Task 任务名额模型
 type Task struct {
 	ID               uuid.UUID    `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
@@ -108,6 +131,7 @@ type Task struct {
 	InviterType      string       `gorm:"type:varchar(50);check:inviter_type IS NULL OR inviter_type IN ('SERVICE_PROVIDER_STAFF', 'SERVICE_PROVIDER_ADMIN', 'OTHER')" json:"inviterType"`
 	Priority         TaskPriority `gorm:"type:varchar(10);not null;default:'MEDIUM'" json:"priority"`
 	Tags             []string     `gorm:"type:text[]" json:"tags"`
+	RevisionDeadline *time.Time   `json:"revisionDeadline"` // 要求修改后的重新提交截止时间
 	Version          int          `gorm:"type:int;not null;default:0" json:"version"`
 	CreatedAt        time.Time    `gorm:"not null;default:now()" json:"createdAt"`
 	UpdatedAt        time.Time    `gorm:"not null;default:now()" json:"updatedAt"`
@@ -131,3 +155,46 @@ func (t *Task) BeforeCreate(tx *gorm.DB) error {
 	}
 	return nil
 }
+
+// TaskReviewAction 任务审核动作
+type TaskReviewAction string
+
+const (
+	TaskReviewActionApprove         TaskReviewAction = "APPROVE"          // 通过
+	TaskReviewActionReject          TaskReviewAction = "REJECT"           // 拒绝（名额释放）
+	TaskReviewActionRequestRevision TaskReviewAction = "REQUEST_REVISION" // 要求修改（达人保留名额并重新提交）
+)
+
+// TaskSubmission 任务提交与审核历史（每次提交一条，审核结果写回同一条）
+type TaskSubmission struct {
+	ID               uuid.UUID         `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	TaskID           uuid.UUID         `gorm:"type:uuid;not null;index" json:"taskId"`
+	CampaignID       uuid.UUID         `gorm:"type:uuid;not null;index" json:"campaignId"`
+	CreatorID        uuid.UUID         `gorm:"type:uuid;not null;index" json:"creatorId"`
+	Round            int               `gorm:"type:int;not null" json:"round"` // 同一达人在该任务上的第几次提交
+	Platform         string            `gorm:"type:varchar(50)" json:"platform"`
+	PlatformURL      string            `gorm:"type:varchar(500)" json:"platformUrl"`
+	Screenshots      string            `gorm:"type:jsonb" json:"screenshots"`
+	Notes            string            `gorm:"type:text" json:"notes"`
+	SubmittedAt      time.Time         `gorm:"not null" json:"submittedAt"`
+	ReviewAction     *TaskReviewAction `gorm:"type:varchar(20)" json:"reviewAction"` // 为空表示待审核
+	ReviewedBy       *string           `gorm:"type:varchar(255)" json:"reviewedBy"`
+	ReviewedAt       *time.Time        `json:"reviewedAt"`
+	ReviewNote       string            `gorm:"type:text" json:"reviewNote"`
+	RevisionDeadline *time.Time        `json:"revisionDeadline"`
+	CreatedAt        time.Time         `gorm:"not null;default:now()" json:"createdAt"`
+	UpdatedAt        time.Time         `gorm:"not null;default:now()" json:"updatedAt"`
+}
+
+// TableName 指定表名
+func (TaskSubmission) TableName() string {
+	return "task_submissions"
+}
+
+// BeforeCreate GORM Hook
+func (s *TaskSubmission) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
@@ -45,7 +45,7 @@ type TransactionType struct {
 	Code             string        `gorm:"primaryKey;type:varchar(50)" json:"code"`
 	Name             string        `gorm:"type:varchar(100);not null" json:"name"`
 	Description      string        `gorm:"type:text" json:"description"`
-	AccountTypes     string        `gorm:"type:varchar(20)[];not null" json:"accountTypes"` // JSON array
+	AccountTypes     string        `gorm:"type:varchar(20)[];not null" json:"accountTypes"` // PostgreSQL 数组文本，见 OwnerTypes
 	AmountDirection  string        `gorm:"type:varchar(10);not null;check:amount_direction IN ('positive', 'negative')" json:"amountDirection"`
 	IsActive         bool          `gorm:"type:boolean;not null;default:true" json:"isActive"`
 	Version          int           `gorm:"type:int;not null;default:1" json:"version"`
@@ -72,6 +72,7 @@ type CreditTransaction struct {
 	RelatedCampaignID    *uuid.UUID  `gorm:"type:uuid" json:"relatedCampaignId"`
 	RelatedTaskID        *uuid.UUID  `gorm:"type:uuid" json:"relatedTaskId"`
 	Description          string     `gorm:"type:varchar(200)" json:"description"`
+	BalanceType          string     `gorm:"type:varchar(20);not null;default:'AVAILABLE'" json:"balanceType"` // AVAILABLE-可用余额, FROZEN-冻结余额
 	CreatedAt            time.Time  `gorm:"not null;default:now();index" json:"createdAt"`
 
 	// 关联
@@ -101,7 +102,6 @@ const (
 	TransactionTaskPublish     = "TASK_PUBLISH"      // 发布任务
 	TransactionTaskAccept      = "TASK_ACCEPT"       // 接任务扣除
 	TransactionTaskReject      = "TASK_REJECT"       // 审核拒绝
-	TransactionTaskEscalate    = "TASK_ESCALATE"     // 超时拒绝
 	TransactionTaskRefund      = "TASK_REFUND"       // 任务退款
 	TransactionWithdraw        = "WITHDRAW"          // 提现
 	TransactionWithdrawFreeze  = "WITHDRAW_FREEZE"   // 提现冻结
@@ -109,4 +109,20 @@ const (
 	TransactionBonusGift       = "BONUS_GIFT"        // 系统赠送
 	TransactionCampaignFreeze  = "CAMPAIGN_FREEZE"   // 活动冻结
 	TransactionCampaignRefund  = "CAMPAIGN_REFUND"   // 活动退还
+	TransactionOpeningBalance  = "OPENING_BALANCE"   // 期初余额（账本迁移）
+	TransactionCashAdjust      = "CASH_ADJUST"       // 现金账户调整
+	TransactionSystemAdjust    = "SYSTEM_ADJUST"     // 系统账户调整
+	TransactionEscrowRelease   = "ESCROW_RELEASE"    // 托管支出（任务结算）
+	TransactionPlatformFee     = "PLATFORM_FEE"      // 平台手续费（任务结算）
+	TransactionRechargeRefund  = "RECHARGE_REFUND"   // 在线充值退款
+	TransactionRechargeRefundFreeze  = "RECHARGE_REFUND_FREEZE"  // 在线充值退款冻结
+	TransactionRechargeRefundRelease = "RECHARGE_REFUND_RELEASE" // 在线充值退款失败解冻
+	TransactionWithdrawFee     = "WITHDRAW_FEE"      // 提现手续费
+	TransactionTaxWithholding  = "TAX_WITHHOLDING"   // 个税代扣（提现时预扣）
+)
+
+// 积分流水余额类型
+const (
+	BalanceTypeAvailable = "AVAILABLE" // 可用余额
+	BalanceTypeFrozen    = "FROZEN"    // 冻结余额
 )
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RoundingMode 积分与现金换算的舍入方式
+type RoundingMode string
+
+const (
+	RoundingDown   RoundingMode = "DOWN"    // 向下取整：平台向外付款（提现到账）时使用，不多付
+	RoundingUp     RoundingMode = "UP"      // 向上取整：向平台收款（充值应付）时使用，不少收
+	RoundingHalfUp RoundingMode = "HALF_UP" // 四舍五入：仅用于展示和统计
+)
+
+// ExchangeRate 积分兑现金汇率（带版本号和生效时间）
+// 含义为 Credits 积分 = Cents 分，用两个整数表示比例，换算全程整数运算，不经过浮点数
+// 汇率只新增不修改：新版本从 EffectiveFrom 起生效，提现和充值记录保存当时使用的汇率版本
+type ExchangeRate struct {
+	ID            uuid.UUID `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	Version       int       `gorm:"type:int;not null;uniqueIndex" json:"version"`       // 递增版本号
+	Credits       int       `gorm:"type:int;not null;check:credits > 0" json:"credits"` // 积分数
+	Cents         int       `gorm:"type:int;not null;check:cents > 0" json:"cents"`     // 对应的现金（分）
+	EffectiveFrom time.Time `gorm:"not null;index" json:"effectiveFrom"`                // 生效时间
+	Description   string    `gorm:"type:text" json:"description"`
+	CreatedBy     string    `gorm:"type:varchar(255);not null" json:"createdBy"`
+	CreatedAt     time.Time `gorm:"not null;default:now()" json:"createdAt"`
+}
+
+// TableName 指定表名
+func (ExchangeRate) TableName() string {
+	return "exchange_rates"
+}
+
+// BeforeCreate GORM Hook
+func (r *ExchangeRate) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// CreditsToCents 积分换算为现金（分）
+func (r *ExchangeRate) CreditsToCents(credits int, mode RoundingMode) int {
+	return divideRounded(int64(credits)*int64(r.Cents), int64(r.Credits), mode)
+}
+
+// CentsToCredits 现金（分）换算为积分
+func (r *ExchangeRate) CentsToCredits(cents int, mode RoundingMode) int {
+	return divideRounded(int64(cents)*int64(r.Credits), int64(r.Cents), mode)
+}
+
+// divideRounded 非负整数除法，按指定方式舍入
+func divideRounded(numerator, denominator int64, mode RoundingMode) int {
+	quotient := numerator / denominator
+	remainder := numerator % denominator
+	switch mode {
+	case RoundingUp:
+		if remainder > 0 {
+			quotient++
+		}
+	case RoundingHalfUp:
+		if remainder*2 >= denominator {
+			quotient++
+		}
+	}
+	return int(quotient)
+}
@@ -73,27 +73,6 @@ func (SystemAccount) TableName() string {
 	return "system_accounts"
 }
 
-// WithdrawalRequest 提现申请（增强版-带冻结机制）
-type WithdrawalRequest struct {
-	ID              uuid.UUID        `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
-	AccountID       uuid.UUID        `gorm:"type:uuid;not null" json:"account_id"`
-	Amount          int              `gorm:"type:int;not null" json:"amount"`                    // 单位：积分
-	YuanAmount      float64          `gorm:"type:decimal(10,2);not null" json:"yuan_amount"` // 单位：元
-	Status          WithdrawalStatus `gorm:"type:varchar(50);not null;default:'pending'" json:"status"` // pending, processing, completed, rejected
-	CashAccountType string           `gorm:"type:varchar(50)" json:"cash_account_type"`        // 用于审核通过时选择扣款账户
-	Description     string           `gorm:"type:text" json:"description"`
-	RejectReason    *string          `gorm:"type:text" json:"reject_reason"`
-	ReviewedBy      string           `gorm:"type:varchar(255)" json:"reviewed_by"`
-	ReviewedAt      *time.Time       `gorm:"type:timestamp" json:"reviewed_at"`
-	CompletedAt     *time.Time       `gorm:"type:timestamp" json:"completed_at"`
-	CreatedAt       time.Time        `gorm:"type:timestamp;not null;default:now()" json:"created_at"`
-}
-
-// TableName 指定表名
-func (WithdrawalRequest) TableName() string {
-	return "withdrawal_requests_enhanced"
-}
-
 // FinancialAuditLog 财务审计日志（简化版）
 type FinancialAuditLog struct {
 	ID           uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
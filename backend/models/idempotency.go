@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// IdempotencyKeyStatus 幂等键状态
+type IdempotencyKeyStatus string
+
+const (
+	IdempotencyKeyStatusProcessing IdempotencyKeyStatus = "processing" // 首次请求处理中
+	IdempotencyKeyStatusCompleted  IdempotencyKeyStatus = "completed"  // 已完成，重放时直接返回保存的响应
+)
+
+// IdempotencyKey 资金类接口的幂等键
+// 同一用户使用同一 Idempotency-Key 重试时返回首次请求的响应；请求内容不同时拒绝
+type IdempotencyKey struct {
+	ID             uuid.UUID            `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	UserID         string               `gorm:"type:varchar(255);not null;uniqueIndex:idx_idempotency_user_key" json:"userId"`
+	Key            string               `gorm:"type:varchar(255);not null;uniqueIndex:idx_idempotency_user_key" json:"key"`
+	Method         string               `gorm:"type:varchar(10);not null" json:"method"`
+	Path           string               `gorm:"type:varchar(500);not null" json:"path"`
+	RequestHash    string               `gorm:"type:varchar(64);not null" json:"requestHash"` // SHA-256(method + path + body)
+	Status         IdempotencyKeyStatus `gorm:"type:varchar(20);not null;default:'processing'" json:"status"`
+	ResponseStatus int                  `gorm:"type:int" json:"responseStatus"`
+	ResponseType   string               `gorm:"type:varchar(100)" json:"responseType"`
+	ResponseBody   []byte               `gorm:"type:bytea" json:"-"`
+	ExpiresAt      time.Time            `gorm:"not null;index" json:"expiresAt"`
+	LockedUntil    *time.Time           `json:"lockedUntil"` // 处理租约到期时间，超过后可重新占用
+	CompletedAt    *time.Time           `json:"completedAt"`
+	CreatedAt      time.Time            `gorm:"not null;default:now()" json:"createdAt"`
+	UpdatedAt      time.Time            `gorm:"not null;default:now()" json:"updatedAt"`
+}
+
+// TableName 指定表名
+func (IdempotencyKey) TableName() string {
+	return "idempotency_keys"
+}
+
+// BeforeCreate GORM Hook
+func (k *IdempotencyKey) BeforeCreate(tx *gorm.DB) error {
+	if k.ID == uuid.Nil {
+		k.ID = uuid.New()
+	}
+	return nil
+}
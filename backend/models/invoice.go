@@ -0,0 +1,132 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// InvoiceType 发票类型
+type InvoiceType string
+
+const (
+	InvoiceTypeNormal  InvoiceType = "normal"  // 增值税普通发票
+	InvoiceTypeSpecial InvoiceType = "special" // 增值税专用发票
+)
+
+// BillingProfile 商家开票信息（发票抬头）
+type BillingProfile struct {
+	ID          uuid.UUID   `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	MerchantID  uuid.UUID   `gorm:"type:uuid;not null;index" json:"merchantId"`
+	InvoiceType InvoiceType `gorm:"type:varchar(20);not null;default:'normal'" json:"invoiceType"`
+	Title       string      `gorm:"type:varchar(200);not null" json:"title"` // 发票抬头
+	TaxID       string      `gorm:"type:varchar(50);not null" json:"taxId"`  // 纳税人识别号
+	Address     string      `gorm:"type:varchar(300)" json:"address"`        // 注册地址
+	Phone       string      `gorm:"type:varchar(50)" json:"phone"`           // 注册电话
+	BankName    string      `gorm:"type:varchar(200)" json:"bankName"`       // 开户行
+	BankAccount string      `gorm:"type:varchar(100)" json:"bankAccount"`    // 银行账号
+	Email       string      `gorm:"type:varchar(200)" json:"email"`          // 接收电子发票的邮箱
+	IsDefault   bool        `gorm:"type:boolean;not null;default:false" json:"isDefault"`
+	CreatedBy   string      `gorm:"type:varchar(255);not null" json:"createdBy"`
+	CreatedAt   time.Time   `gorm:"not null;default:now()" json:"createdAt"`
+	UpdatedAt   time.Time   `gorm:"not null;default:now()" json:"updatedAt"`
+	DeletedAt   *time.Time  `json:"deletedAt"`
+}
+
+// TableName 指定表名
+func (BillingProfile) TableName() string {
+	return "billing_profiles"
+}
+
+// BeforeCreate GORM Hook
+func (p *BillingProfile) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+// InvoiceRequestStatus 开票申请状态
+type InvoiceRequestStatus string
+
+const (
+	InvoiceRequestStatusPending  InvoiceRequestStatus = "pending"  // 待开票
+	InvoiceRequestStatusIssued   InvoiceRequestStatus = "issued"   // 已开票
+	InvoiceRequestStatusRejected InvoiceRequestStatus = "rejected" // 已驳回
+)
+
+// InvoiceRequest 开票申请（金额单位：分）
+// 商家对一笔或多笔已完成的充值订单申请开票，财务开具后上传发票文件或驳回；
+// 开票信息在申请时从开票信息快照，之后修改开票信息不影响已提交的申请
+type InvoiceRequest struct {
+	ID               uuid.UUID            `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	MerchantID       uuid.UUID            `gorm:"type:uuid;not null;index" json:"merchantId"`
+	AccountID        uuid.UUID            `gorm:"type:uuid;not null;index" json:"accountId"`
+	BillingProfileID uuid.UUID            `gorm:"type:uuid;not null" json:"billingProfileId"`
+	InvoiceType      InvoiceType          `gorm:"type:varchar(20);not null" json:"invoiceType"`
+	Title            string               `gorm:"type:varchar(200);not null" json:"title"`
+	TaxID            string               `gorm:"type:varchar(50);not null" json:"taxId"`
+	Address          string               `gorm:"type:varchar(300)" json:"address"`
+	Phone            string               `gorm:"type:varchar(50)" json:"phone"`
+	BankName         string               `gorm:"type:varchar(200)" json:"bankName"`
+	BankAccount      string               `gorm:"type:varchar(100)" json:"bankAccount"`
+	Email            string               `gorm:"type:varchar(200)" json:"email"`
+	Amount           int                  `gorm:"type:int;not null;check:amount > 0" json:"amount"` // 开票金额（分）
+	Status           InvoiceRequestStatus `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+	Remark           string               `gorm:"type:varchar(500)" json:"remark"`         // 申请备注
+	InvoiceNo        string               `gorm:"type:varchar(50)" json:"invoiceNo"`       // 发票号码
+	InvoiceFileURL   string               `gorm:"type:varchar(500)" json:"invoiceFileUrl"` // 发票文件URL
+	RejectionNote    string               `gorm:"type:text" json:"rejectionNote"`          // 驳回原因
+	RequestedBy      string               `gorm:"type:varchar(255);not null" json:"requestedBy"`
+	ProcessedBy      *string              `gorm:"type:varchar(255)" json:"processedBy"`
+	ProcessedAt      *time.Time           `json:"processedAt"`
+	CreatedAt        time.Time            `gorm:"not null;default:now()" json:"createdAt"`
+	UpdatedAt        time.Time            `gorm:"not null;default:now()" json:"updatedAt"`
+
+	// 关联
+	Items []InvoiceRequestItem `gorm:"foreignKey:InvoiceRequestID" json:"items,omitempty"`
+}
+
+// TableName 指定表名
+func (InvoiceRequest) TableName() string {
+	return "invoice_requests"
+}
+
+// BeforeCreate GORM Hook
+func (r *InvoiceRequest) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// CanProcess 检查是否可以开票或驳回
+func (r *InvoiceRequest) CanProcess() bool {
+	return r.Status == InvoiceRequestStatusPending
+}
+
+// InvoiceRequestItem 开票申请明细：每笔充值订单本次开票的金额（分）
+type InvoiceRequestItem struct {
+	ID               uuid.UUID `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	InvoiceRequestID uuid.UUID `gorm:"type:uuid;not null;index" json:"invoiceRequestId"`
+	RechargeOrderID  uuid.UUID `gorm:"type:uuid;not null;index" json:"rechargeOrderId"`
+	Amount           int       `gorm:"type:int;not null;check:amount > 0" json:"amount"`
+	CreatedAt        time.Time `gorm:"not null;default:now()" json:"createdAt"`
+
+	// 关联
+	RechargeOrder *RechargeOrder `gorm:"foreignKey:RechargeOrderID" json:"rechargeOrder,omitempty"`
+}
+
+// TableName 指定表名
+func (InvoiceRequestItem) TableName() string {
+	return "invoice_request_items"
+}
+
+// BeforeCreate GORM Hook
+func (i *InvoiceRequestItem) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
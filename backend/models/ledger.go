@@ -0,0 +1,118 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// LedgerAccountKind 分录账户类型（决定分录作用于哪张表的哪个余额字段）
+type LedgerAccountKind string
+
+const (
+	LedgerAccountCredit       LedgerAccountKind = "CREDIT"        // 积分账户可用余额 credit_accounts.balance
+	LedgerAccountCreditFrozen LedgerAccountKind = "CREDIT_FROZEN" // 积分账户冻结余额 credit_accounts.frozen_balance
+	LedgerAccountSystem       LedgerAccountKind = "SYSTEM"        // 系统账户 system_accounts.balance（积分）
+	LedgerAccountCash         LedgerAccountKind = "CASH"          // 现金账户 cash_accounts.balance（分）
+)
+
+// LedgerUnit 记账单位，同一分组内每个单位的借贷必须相等
+type LedgerUnit string
+
+const (
+	LedgerUnitCredit LedgerUnit = "CREDIT"  // 积分
+	LedgerUnitCNYFen LedgerUnit = "CNY_FEN" // 人民币（分）
+)
+
+// Unit 返回账户类型对应的记账单位
+func (k LedgerAccountKind) Unit() LedgerUnit {
+	if k == LedgerAccountCash {
+		return LedgerUnitCNYFen
+	}
+	return LedgerUnitCredit
+}
+
+// IsCredit 是否为积分账户（可用或冻结）
+func (k LedgerAccountKind) IsCredit() bool {
+	return k == LedgerAccountCredit || k == LedgerAccountCreditFrozen
+}
+
+// LedgerDirection 记账方向（以账户余额为视角：CREDIT 增加余额，DEBIT 减少余额）
+type LedgerDirection string
+
+const (
+	LedgerDirectionDebit  LedgerDirection = "DEBIT"  // 借：余额减少
+	LedgerDirectionCredit LedgerDirection = "CREDIT" // 贷：余额增加
+)
+
+// LedgerEntry 复式记账分录
+// 同一 TransactionGroupID 下，每个记账单位的借方合计必须等于贷方合计；
+// 各账户余额字段是分录的投影，可由分录重放得到
+type LedgerEntry struct {
+	ID                 uuid.UUID         `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	TransactionGroupID uuid.UUID         `gorm:"type:uuid;not null;index" json:"transactionGroupId"`
+	GroupSequence      int               `gorm:"type:int;not null" json:"groupSequence"`
+	AccountKind        LedgerAccountKind `gorm:"type:varchar(20);not null;index:idx_ledger_entries_account" json:"accountKind"`
+	AccountID          uuid.UUID         `gorm:"type:uuid;not null;index:idx_ledger_entries_account" json:"accountId"`
+	Unit               LedgerUnit        `gorm:"type:varchar(10);not null" json:"unit"`
+	Direction          LedgerDirection   `gorm:"type:varchar(10);not null" json:"direction"`
+	Amount             int               `gorm:"type:int;not null;check:amount > 0" json:"amount"`
+	Type               string            `gorm:"type:varchar(50);not null;index" json:"type"`
+	BalanceBefore      int               `gorm:"type:int;not null" json:"balanceBefore"`
+	BalanceAfter       int               `gorm:"type:int;not null" json:"balanceAfter"`
+	RelatedCampaignID  *uuid.UUID        `gorm:"type:uuid" json:"relatedCampaignId"`
+	RelatedTaskID      *uuid.UUID        `gorm:"type:uuid" json:"relatedTaskId"`
+	Description        string            `gorm:"type:varchar(200)" json:"description"`
+	CreatedAt          time.Time         `gorm:"not null;default:now();index" json:"createdAt"`
+}
+
+// TableName 指定表名
+func (LedgerEntry) TableName() string {
+	return "ledger_entries"
+}
+
+// BeforeCreate GORM Hook
+func (e *LedgerEntry) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+// SignedAmount 带符号的余额变动量
+func (e *LedgerEntry) SignedAmount() int {
+	if e.Direction == LedgerDirectionDebit {
+		return -e.Amount
+	}
+	return e.Amount
+}
+
+// CampaignEscrow 活动托管分账（TASK_ESCROW 系统账户按活动拆分的子账）
+// 活动审核通过时注资，任务结算时支出，活动关闭时退还剩余部分
+type CampaignEscrow struct {
+	ID             uuid.UUID `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	CampaignID     uuid.UUID `gorm:"type:uuid;not null;uniqueIndex" json:"campaignId"`
+	MerchantID     uuid.UUID `gorm:"type:uuid;not null;index" json:"merchantId"`
+	Balance        int       `gorm:"type:int;not null;default:0;check:balance >= 0" json:"balance"`
+	FundedAmount   int       `gorm:"type:int;not null;default:0" json:"fundedAmount"`   // 累计注资
+	ReleasedAmount int       `gorm:"type:int;not null;default:0" json:"releasedAmount"` // 累计支出（结算+退还）
+	CreatedAt      time.Time `gorm:"not null;default:now()" json:"createdAt"`
+	UpdatedAt      time.Time `gorm:"not null;default:now()" json:"updatedAt"`
+
+	// 关联
+	Campaign *Campaign `gorm:"foreignKey:CampaignID" json:"campaign,omitempty"`
+}
+
+// TableName 指定表名
+func (CampaignEscrow) TableName() string {
+	return "campaign_escrows"
+}
+
+// BeforeCreate GORM Hook
+func (e *CampaignEscrow) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PlatformFeeScope 平台手续费规则适用范围
+type PlatformFeeScope string
+
+const (
+	PlatformFeeScopeGlobal   PlatformFeeScope = "GLOBAL"   // 全局默认
+	PlatformFeeScopeProvider PlatformFeeScope = "PROVIDER" // 指定服务商
+	PlatformFeeScopeMerchant PlatformFeeScope = "MERCHANT" // 指定商家
+	PlatformFeeScopeCampaign PlatformFeeScope = "CAMPAIGN" // 指定活动
+)
+
+// PlatformFeeType 平台手续费计算方式
+type PlatformFeeType string
+
+const (
+	PlatformFeeTypePercentage PlatformFeeType = "PERCENTAGE" // 按任务金额比例（万分比）
+	PlatformFeeTypeFixed      PlatformFeeType = "FIXED"      // 每个任务固定积分
+)
+
+// PlatformFeeRule 平台手续费规则
+// 同一任务按 活动 > 商家 > 服务商 > 全局 的优先级取当前生效的规则
+type PlatformFeeRule struct {
+	ID            uuid.UUID        `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	Scope         PlatformFeeScope `gorm:"type:varchar(20);not null;index:idx_platform_fee_rules_scope" json:"scope"`
+	ScopeID       *uuid.UUID       `gorm:"type:uuid;index:idx_platform_fee_rules_scope" json:"scopeId"` // GLOBAL 为空
+	FeeType       PlatformFeeType  `gorm:"type:varchar(20);not null" json:"feeType"`
+	RateBasis     int              `gorm:"type:int;not null;default:0" json:"rateBasis"`   // 万分比，500 = 5%
+	FixedAmount   int              `gorm:"type:int;not null;default:0" json:"fixedAmount"` // 固定积分
+	EffectiveFrom time.Time        `gorm:"not null" json:"effectiveFrom"`
+	EffectiveTo   *time.Time       `json:"effectiveTo"` // 为空表示长期有效
+	IsActive      bool             `gorm:"type:boolean;not null;default:true" json:"isActive"`
+	Description   string           `gorm:"type:text" json:"description"`
+	CreatedBy     string           `gorm:"type:varchar(255);not null" json:"createdBy"`
+	CreatedAt     time.Time        `gorm:"not null;default:now()" json:"createdAt"`
+	UpdatedAt     time.Time        `gorm:"not null;default:now()" json:"updatedAt"`
+}
+
+// TableName 指定表名
+func (PlatformFeeRule) TableName() string {
+	return "platform_fee_rules"
+}
+
+// BeforeCreate GORM Hook
+func (r *PlatformFeeRule) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// CalculateFee 计算单个任务的手续费（不超过任务金额，比例按四舍五入取整）
+func (r *PlatformFeeRule) CalculateFee(taskAmount int) int {
+	fee := 0
+	switch r.FeeType {
+	case PlatformFeeTypePercentage:
+		fee = (taskAmount*r.RateBasis + 5000) / 10000
+	case PlatformFeeTypeFixed:
+		fee = r.FixedAmount
+	}
+	if fee > taskAmount {
+		fee = taskAmount
+	}
+	if fee < 0 {
+		fee = 0
+	}
+	return fee
+}
+
+// SettlementRecord 任务结算明细（每个任务一条，记录手续费与各方分配）
+type SettlementRecord struct {
+	ID                  uuid.UUID  `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	TaskID              uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex" json:"taskId"`
+	CampaignID          uuid.UUID  `gorm:"type:uuid;not null;index" json:"campaignId"`
+	MerchantID          uuid.UUID  `gorm:"type:uuid;not null;index" json:"merchantId"`
+	ProviderID          *uuid.UUID `gorm:"type:uuid;index" json:"providerId"`
+	FeeRuleID           *uuid.UUID `gorm:"type:uuid" json:"feeRuleId"`
+	TaskAmount          int        `gorm:"type:int;not null" json:"taskAmount"`
+	PlatformFee         int        `gorm:"type:int;not null;default:0" json:"platformFee"`
+	CreatorAmount       int        `gorm:"type:int;not null;default:0" json:"creatorAmount"`
+	StaffReferralAmount int        `gorm:"type:int;not null;default:0" json:"staffReferralAmount"`
+	ProviderAmount      int        `gorm:"type:int;not null;default:0" json:"providerAmount"`
+	RefundAmount        int        `gorm:"type:int;not null;default:0" json:"refundAmount"` // 未分配退回商家
+	TransactionGroupID  uuid.UUID  `gorm:"type:uuid;not null" json:"transactionGroupId"`
+	SettledAt           time.Time  `gorm:"not null;default:now();index" json:"settledAt"`
+}
+
+// TableName 指定表名
+func (SettlementRecord) TableName() string {
+	return "settlement_records"
+}
+
+// BeforeCreate GORM Hook
+func (r *SettlementRecord) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
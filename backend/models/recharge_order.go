@@ -15,18 +15,37 @@ const (
 	RechargeOrderStatusApproved  RechargeOrderStatus = "approved"  // 已通过
 	RechargeOrderStatusRejected  RechargeOrderStatus = "rejected"  // 已拒绝
 	RechargeOrderStatusCompleted RechargeOrderStatus = "completed" // 已完成
+	RechargeOrderStatusRefunded  RechargeOrderStatus = "refunded"  // 已全额退款（在线支付）
+)
+
+// RechargeChannel 充值渠道
+type RechargeChannel string
+
+const (
+	RechargeChannelOffline RechargeChannel = "offline" // 线下转账，上传凭证后由超管审核
+	RechargeChannelOnline  RechargeChannel = "online"  // 在线支付，渠道回调后自动入账
 )
 
 // RechargeOrder 充值订单模型
-// 用于线下充值流程：用户提交订单 → 超管审核 → 充值入账
+// 线下充值：用户提交订单 → 超管审核 → 充值入账
+// 在线支付：创建订单并在渠道下单 → 渠道回调验签 → 充值入账（每个订单只入账一次）
 type RechargeOrder struct {
 	ID             uuid.UUID            `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
 	UserID         string               `gorm:"type:varchar(255);not null;index" json:"userId"`
 	AccountID      uuid.UUID            `gorm:"type:uuid;not null;index" json:"accountId"`
 	Amount         int                  `gorm:"type:int;not null;check:amount > 0" json:"amount"`
-	PaymentMethod  string               `gorm:"type:varchar(20);not null" json:"paymentMethod"` // 支付方式：alipay/wechat/bank
+	PaymentMethod  string               `gorm:"type:varchar(20);not null" json:"paymentMethod"` // 支付方式：alipay/wechat/bank/mock
+	Channel         RechargeChannel      `gorm:"type:varchar(20);not null;default:'offline'" json:"channel"`
+	OutTradeNo      *string              `gorm:"type:varchar(64);uniqueIndex" json:"outTradeNo"`      // 在线支付商户订单号
+	ProviderTradeNo string               `gorm:"type:varchar(64)" json:"providerTradeNo"`             // 渠道交易号
+	PaymentURL      string               `gorm:"type:varchar(1000)" json:"paymentUrl"`                // 扫码支付链接
+	ExchangeRateID  *uuid.UUID           `gorm:"type:uuid" json:"exchangeRateId"`                      // 下单时生效的积分汇率
+	CashAmount      int                  `gorm:"type:int;not null;default:0" json:"cashAmount"`        // 应付金额（分），充值积分按汇率向上取整
+	PaidAmount      int                  `gorm:"type:int;not null;default:0" json:"paidAmount"`        // 渠道实付金额（分）
+	RefundedAmount  int                  `gorm:"type:int;not null;default:0" json:"refundedAmount"`    // 已退款积分
+	PaidAt          *time.Time           `json:"paidAt"`
 	PaymentProof  string               `gorm:"type:varchar(500)" json:"paymentProof"`              // 支付凭证URL
-	Status        RechargeOrderStatus   `gorm:"type:varchar(20);not null;default:'pending';check:status IN ('pending', 'approved', 'rejected', 'completed')" json:"status"`
+	Status        RechargeOrderStatus   `gorm:"type:varchar(20);not null;default:'pending';check:status IN ('pending', 'approved', 'rejected', 'completed', 'refunded')" json:"status"`
 	RejectionNote string               `gorm:"type:text" json:"rejectionNote"`                 // 拒绝原因
 	AuditedBy     *string              `gorm:"type:varchar(255)" json:"auditedBy"`          // 审核人ID
 	AuditedAt      *time.Time           `json:"auditedAt"`                                    // 审核时间
@@ -53,12 +72,54 @@ func (ro *RechargeOrder) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
-// CanAudit 检查是否可以审核
+// CanAudit 检查是否可以审核（在线支付订单由渠道回调入账，不走人工审核）
 func (ro *RechargeOrder) CanAudit() bool {
-	return ro.Status == RechargeOrderStatusPending
+	return ro.Status == RechargeOrderStatusPending && ro.Channel != RechargeChannelOnline
 }
 
 // CanProcess 检查是否可以处理完成
 func (ro *RechargeOrder) CanProcess() bool {
 	return ro.Status == RechargeOrderStatusApproved
 }
+
+// RechargeRefundStatus 充值退款状态
+type RechargeRefundStatus string
+
+const (
+	RechargeRefundStatusPending   RechargeRefundStatus = "pending"   // 已冻结积分，渠道结果未确认
+	RechargeRefundStatusSucceeded RechargeRefundStatus = "succeeded" // 渠道已受理，积分已扣回
+	RechargeRefundStatusFailed    RechargeRefundStatus = "failed"    // 渠道拒绝，冻结积分已退回
+)
+
+// RechargeRefund 在线充值退款记录
+// 先冻结积分并写入 pending 记录，提交后再调用渠道；渠道结果未知时保持 pending，
+// 按同一退款单号重试（渠道侧幂等）
+type RechargeRefund struct {
+	ID               uuid.UUID            `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	RechargeOrderID  uuid.UUID            `gorm:"type:uuid;not null;index" json:"rechargeOrderId"`
+	OutRefundNo      string               `gorm:"type:varchar(64);not null;uniqueIndex" json:"outRefundNo"` // 商户退款单号
+	Amount           int                  `gorm:"type:int;not null" json:"amount"`                          // 退款积分
+	RefundCents      int                  `gorm:"type:int;not null" json:"refundCents"`                     // 退款金额（分）
+	Reason           string               `gorm:"type:varchar(200)" json:"reason"`
+	Status           RechargeRefundStatus `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+	ProviderRefundNo string               `gorm:"type:varchar(64)" json:"providerRefundNo"` // 渠道退款单号
+	ProviderStatus   string               `gorm:"type:varchar(32)" json:"providerStatus"`   // 渠道返回的退款状态
+	FailureReason    string               `gorm:"type:text" json:"failureReason"`
+	OperatorID       string               `gorm:"type:varchar(255);not null" json:"operatorId"`
+	CompletedAt      *time.Time           `json:"completedAt"`
+	CreatedAt        time.Time            `gorm:"not null;default:now()" json:"createdAt"`
+	UpdatedAt        time.Time            `gorm:"not null;default:now()" json:"updatedAt"`
+}
+
+// TableName 指定表名
+func (RechargeRefund) TableName() string {
+	return "recharge_refunds"
+}
+
+// BeforeCreate GORM Hook
+func (r *RechargeRefund) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ReconciliationRunStatus 对账批次状态
+type ReconciliationRunStatus string
+
+const (
+	ReconciliationRunStatusRunning   ReconciliationRunStatus = "running"   // 执行中
+	ReconciliationRunStatusCompleted ReconciliationRunStatus = "completed" // 已完成
+	ReconciliationRunStatusFailed    ReconciliationRunStatus = "failed"    // 执行失败
+)
+
+// 对账检查项
+const (
+	ReconcileCheckCreditBalance    = "CREDIT_BALANCE"    // 积分流水重放 vs 可用余额
+	ReconcileCheckCreditFrozen     = "CREDIT_FROZEN"     // 积分流水重放 vs 冻结余额
+	ReconcileCheckMerchantFrozen   = "MERCHANT_FROZEN"   // 商家冻结余额 vs 开放活动未结算名额
+	ReconcileCheckCashWithdrawal   = "CASH_WITHDRAWAL"   // 现金账户出款 vs 已完成提现申请
+	ReconcileCheckLedgerProjection = "LEDGER_PROJECTION" // 分录重放 vs 账户余额
+	ReconcileCheckCampaignEscrow   = "CAMPAIGN_ESCROW"   // 活动托管分账 vs 未结算名额
+)
+
+// ReconciliationRun 对账批次
+type ReconciliationRun struct {
+	ID               uuid.UUID               `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	Status           ReconciliationRunStatus `gorm:"type:varchar(20);not null;default:'running'" json:"status"`
+	TriggeredBy      string                  `gorm:"type:varchar(255);not null" json:"triggeredBy"`
+	CheckedAccounts  int                     `gorm:"type:int;not null;default:0" json:"checkedAccounts"`
+	DiscrepancyCount int                     `gorm:"type:int;not null;default:0" json:"discrepancyCount"`
+	ErrorMessage     string                  `gorm:"type:text" json:"errorMessage"`
+	StartedAt        time.Time               `gorm:"not null;default:now()" json:"startedAt"`
+	FinishedAt       *time.Time              `json:"finishedAt"`
+
+	// 关联
+	Discrepancies []ReconciliationDiscrepancy `gorm:"foreignKey:RunID" json:"discrepancies,omitempty"`
+}
+
+// TableName 指定表名
+func (ReconciliationRun) TableName() string {
+	return "reconciliation_runs"
+}
+
+// BeforeCreate GORM Hook
+func (r *ReconciliationRun) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// ReconciliationDiscrepancy 对账差异明细
+type ReconciliationDiscrepancy struct {
+	ID          uuid.UUID  `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	RunID       uuid.UUID  `gorm:"type:uuid;not null;index" json:"runId"`
+	CheckType   string     `gorm:"type:varchar(50);not null" json:"checkType"`
+	AccountKind string     `gorm:"type:varchar(20)" json:"accountKind"` // CREDIT / SYSTEM / CASH
+	AccountID   *uuid.UUID `gorm:"type:uuid" json:"accountId"`
+	OwnerID     *uuid.UUID `gorm:"type:uuid" json:"ownerId"`
+	OwnerType   string     `gorm:"type:varchar(50)" json:"ownerType"`
+	Expected    int64      `gorm:"type:bigint;not null" json:"expected"`
+	Actual      int64      `gorm:"type:bigint;not null" json:"actual"`
+	Difference  int64      `gorm:"type:bigint;not null" json:"difference"` // actual - expected
+	Detail      string     `gorm:"type:text" json:"detail"`
+	CreatedAt   time.Time  `gorm:"not null;default:now()" json:"createdAt"`
+}
+
+// TableName 指定表名
+func (ReconciliationDiscrepancy) TableName() string {
+	return "reconciliation_discrepancies"
+}
+
+// BeforeCreate GORM Hook
+func (d *ReconciliationDiscrepancy) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// 组织类型（与 utils.GetOrganizationTypeByRole 一致）
+const (
+	OrgTypeMerchant        = "merchant"
+	OrgTypeServiceProvider = "service_provider"
+)
+
+// RoleContext 当前请求的身份：用户以哪个角色、代表哪个组织操作
+// 由认证中间件按用户选择的激活角色解析，授权判断基于该身份而不是用户全部角色的并集
+type RoleContext struct {
+	Role    string     `json:"role"`
+	OrgType string     `json:"orgType,omitempty"`
+	OrgID   *uuid.UUID `json:"orgId,omitempty"`
+	OrgName string     `json:"orgName,omitempty"`
+	// StaffID 员工角色对应的员工记录，用于查询员工权限
+	StaffID *uuid.UUID `json:"-"`
+}
+
+// Is 当前角色是否为其中之一
+func (r *RoleContext) Is(roles ...string) bool {
+	for _, role := range roles {
+		if strings.EqualFold(r.Role, role) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasOrg 是否代表组织操作
+func (r *RoleContext) HasOrg() bool {
+	return r.OrgID != nil
+}
+
+// Matches 是否为同一身份（角色相同且组织相同）
+func (r *RoleContext) Matches(role string, orgID *uuid.UUID) bool {
+	if !strings.EqualFold(r.Role, role) {
+		return false
+	}
+	if orgID == nil || r.OrgID == nil {
+		return orgID == nil && r.OrgID == nil
+	}
+	return *orgID == *r.OrgID
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SettlementJobStatus 结算任务状态
+type SettlementJobStatus string
+
+const (
+	SettlementJobStatusPending   SettlementJobStatus = "pending"   // 待执行（含等待重试）
+	SettlementJobStatusRunning   SettlementJobStatus = "running"   // 执行中
+	SettlementJobStatusSucceeded SettlementJobStatus = "succeeded" // 已结算
+	SettlementJobStatusDead      SettlementJobStatus = "dead"      // 死信（重试次数耗尽，需人工处理）
+	SettlementJobStatusCancelled SettlementJobStatus = "cancelled" // 已取消
+)
+
+// SettlementJob 结算任务（持久化队列）
+// 任务审核通过时与任务状态在同一事务内写入，由后台 worker 执行结算，失败按指数退避重试
+type SettlementJob struct {
+	ID            uuid.UUID           `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	TaskID        uuid.UUID           `gorm:"type:uuid;not null;uniqueIndex" json:"taskId"`
+	CampaignID    uuid.UUID           `gorm:"type:uuid;not null;index" json:"campaignId"`
+	AuditorUserID string              `gorm:"type:varchar(255);not null" json:"auditorUserId"`
+	Status        SettlementJobStatus `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"`
+	Attempts      int                 `gorm:"type:int;not null;default:0" json:"attempts"`
+	MaxAttempts   int                 `gorm:"type:int;not null;default:8" json:"maxAttempts"`
+	NextRunAt     time.Time           `gorm:"not null;default:now();index" json:"nextRunAt"`
+	LockedAt      *time.Time          `json:"lockedAt"`
+	LockedBy      string              `gorm:"type:varchar(100)" json:"lockedBy"`
+	LastError     string              `gorm:"type:text" json:"lastError"`
+	CompletedAt   *time.Time          `json:"completedAt"`
+	CancelledBy   string              `gorm:"type:varchar(255)" json:"cancelledBy"`
+	CancelReason  string              `gorm:"type:text" json:"cancelReason"`
+	CreatedAt     time.Time           `gorm:"not null;default:now()" json:"createdAt"`
+	UpdatedAt     time.Time           `gorm:"not null;default:now()" json:"updatedAt"`
+
+	// 关联
+	Task *Task `gorm:"foreignKey:TaskID" json:"task,omitempty"`
+}
+
+// TableName 指定表名
+func (SettlementJob) TableName() string {
+	return "settlement_jobs"
+}
+
+// BeforeCreate GORM Hook
+func (j *SettlementJob) BeforeCreate(tx *gorm.DB) error {
+	if j.ID == uuid.Nil {
+		j.ID = uuid.New()
+	}
+	return nil
+}
+
+// CanRetry 是否可以人工重试
+func (j *SettlementJob) CanRetry() bool {
+	return j.Status == SettlementJobStatusDead || j.Status == SettlementJobStatusCancelled || j.Status == SettlementJobStatusPending
+}
+
+// CanCancel 是否可以取消
+func (j *SettlementJob) CanCancel() bool {
+	return j.Status == SettlementJobStatusPending || j.Status == SettlementJobStatusDead
+}
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TaxBracket 预扣率表的一档（金额单位：分）
+type TaxBracket struct {
+	UpTo           int `json:"upTo"`           // 应纳税所得额上限（含），0 表示无上限
+	RateBasis      int `json:"rateBasis"`      // 预扣率，万分比，2000 = 20%
+	QuickDeduction int `json:"quickDeduction"` // 速算扣除数
+}
+
+// TaxBrackets 预扣率表，支持 JSON 序列化
+type TaxBrackets []TaxBracket
+
+// Scan 实现 sql.Scanner 接口
+func (b *TaxBrackets) Scan(value interface{}) error {
+	if value == nil {
+		*b = TaxBrackets{}
+		return nil
+	}
+
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return nil
+	}
+
+	return json.Unmarshal(bytes, b)
+}
+
+// Value 实现 driver.Valuer 接口
+func (b TaxBrackets) Value() (driver.Value, error) {
+	if len(b) == 0 {
+		return "[]", nil
+	}
+	return json.Marshal(b)
+}
+
+// TaxWithholdingRule 个人劳务报酬个税预扣规则（金额单位：分）
+// 收入额：每月收入不超过 ThresholdAmount 时减除 FixedDeduction，超过时减除收入的 DeductionRateBasis 万分比；
+// 应纳税所得额按预扣率表计算预扣税额。同一时间只有一条生效规则，没有生效规则时不预扣
+type TaxWithholdingRule struct {
+	ID                 uuid.UUID   `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	Name               string      `gorm:"type:varchar(100);not null" json:"name"`
+	ThresholdAmount    int         `gorm:"type:int;not null;default:0" json:"thresholdAmount"`    // 固定减除与比例减除的分界（分）
+	FixedDeduction     int         `gorm:"type:int;not null;default:0" json:"fixedDeduction"`     // 固定减除费用（分）
+	DeductionRateBasis int         `gorm:"type:int;not null;default:0" json:"deductionRateBasis"` // 比例减除，万分比
+	Brackets           TaxBrackets `gorm:"type:jsonb;not null;default:'[]'" json:"brackets"`
+	IsActive           bool        `gorm:"type:boolean;not null;default:true" json:"isActive"`
+	Description        string      `gorm:"type:text" json:"description"`
+	CreatedBy          string      `gorm:"type:varchar(255);not null" json:"createdBy"`
+	CreatedAt          time.Time   `gorm:"not null;default:now()" json:"createdAt"`
+	UpdatedAt          time.Time   `gorm:"not null;default:now()" json:"updatedAt"`
+}
+
+// TableName 指定表名
+func (TaxWithholdingRule) TableName() string {
+	return "tax_withholding_rules"
+}
+
+// BeforeCreate GORM Hook
+func (r *TaxWithholdingRule) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// Calculate 按收入计算应纳税所得额和预扣税额（分，比例部分四舍五入）
+func (r *TaxWithholdingRule) Calculate(income int) (taxable int, tax int) {
+	if income <= 0 {
+		return 0, 0
+	}
+
+	if income <= r.ThresholdAmount {
+		taxable = income - r.FixedDeduction
+	} else {
+		taxable = income - int((int64(income)*int64(r.DeductionRateBasis)+5000)/10000)
+	}
+	if taxable <= 0 {
+		return 0, 0
+	}
+
+	for _, bracket := range r.Brackets {
+		if bracket.UpTo > 0 && taxable > bracket.UpTo {
+			continue
+		}
+		tax = int((int64(taxable)*int64(bracket.RateBasis)+5000)/10000) - bracket.QuickDeduction
+		break
+	}
+	if tax < 0 {
+		tax = 0
+	}
+	return taxable, tax
+}
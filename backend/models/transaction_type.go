@@ -0,0 +1,82 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 交易类型的金额方向
+const (
+	AmountDirectionPositive = "positive" // 增加余额
+	AmountDirectionNegative = "negative" // 减少余额
+)
+
+// 交易类型适用的非积分账户（与 OwnerType 一起出现在 TransactionType.AccountTypes 中）
+const (
+	TransactionAccountSystem = "SYSTEM" // 系统账户
+	TransactionAccountCash   = "CASH"   // 现金账户
+)
+
+// OwnerTypes 解析适用账户类型（AccountTypes 为 PostgreSQL 数组文本，如 {ORG_MERCHANT,ORG_PROVIDER}）
+func (t *TransactionType) OwnerTypes() []string {
+	value := strings.Trim(t.AccountTypes, "{}")
+	if value == "" {
+		return nil
+	}
+	parts := strings.Split(value, ",")
+	for i, part := range parts {
+		parts[i] = strings.Trim(part, `" `)
+	}
+	return parts
+}
+
+// AllowsOwner 交易类型是否适用于该账户类型
+func (t *TransactionType) AllowsOwner(ownerType string) bool {
+	for _, allowed := range t.OwnerTypes() {
+		if allowed == ownerType {
+			return true
+		}
+	}
+	return false
+}
+
+// IsUsable 是否可用于新交易（启用且未废弃）
+func (t *TransactionType) IsUsable() bool {
+	return t.IsActive && t.DeprecatedAt == nil
+}
+
+// FormatAccountTypes 将账户类型列表格式化为 PostgreSQL 数组文本
+func FormatAccountTypes(ownerTypes []string) string {
+	return "{" + strings.Join(ownerTypes, ",") + "}"
+}
+
+// TransactionTypeVersion 交易类型定义的历史版本（每次新增、修改、废弃记录一版快照）
+type TransactionTypeVersion struct {
+	ID              uuid.UUID `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	Code            string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_transaction_type_version" json:"code"`
+	Version         int       `gorm:"type:int;not null;uniqueIndex:idx_transaction_type_version" json:"version"`
+	Name            string    `gorm:"type:varchar(100);not null" json:"name"`
+	Description     string    `gorm:"type:text" json:"description"`
+	AccountTypes    string    `gorm:"type:varchar(20)[];not null" json:"accountTypes"`
+	AmountDirection string    `gorm:"type:varchar(10);not null" json:"amountDirection"`
+	IsActive        bool      `gorm:"type:boolean;not null" json:"isActive"`
+	ChangeNote      string    `gorm:"type:text" json:"changeNote"`
+	ChangedBy       string    `gorm:"type:varchar(255);not null" json:"changedBy"`
+	CreatedAt       time.Time `gorm:"not null;default:now()" json:"createdAt"`
+}
+
+// TableName 指定表名
+func (TransactionTypeVersion) TableName() string {
+	return "transaction_type_versions"
+}
+
+// BeforeCreate GORM Hook
+func (v *TransactionTypeVersion) BeforeCreate(tx *gorm.DB) error {
+	if v.ID == uuid.Nil {
+		v.ID = uuid.New()
+	}
+	return nil
+}
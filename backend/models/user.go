@@ -71,6 +71,13 @@ func (p Profile) Value() (driver.Value, error) {
 	return json.Marshal(p)
 }
 
+// 用户状态
+const (
+	UserStatusActive   = "active"
+	UserStatusBanned   = "banned"
+	UserStatusInactive = "inactive"
+)
+
 // User 用户模型
 type User struct {
 	ID               string         `gorm:"primaryKey;type:varchar(255)" json:"id"`
@@ -82,6 +89,9 @@ type User struct {
 	AvatarURL        string         `gorm:"type:varchar(500)" json:"avatarUrl"`
 	Profile          Profile        `gorm:"type:jsonb;default:'{}'" json:"profile"`
 	Roles            Roles          `gorm:"type:jsonb;default:'[]'" json:"roles"`
+	// 用户选择的激活角色及代表的组织，为空时按角色优先级取默认身份
+	ActiveRole       string         `gorm:"type:varchar(50)" json:"activeRole"`
+	ActiveOrgID      *uuid.UUID     `gorm:"type:uuid" json:"activeOrgId"`
 	InvitedBy        string         `gorm:"type:varchar(255)" json:"invitedBy"`
 	InvitationCodeID string         `gorm:"type:uuid" json:"invitationCodeId"`
 	// 每个用户的固定邀请码（格式：INV-{user_id后8位}）
@@ -89,6 +99,8 @@ type User struct {
 	Status           string         `gorm:"type:varchar(20);not null;default:'active';check:status IN ('active', 'banned', 'inactive')" json:"status"`
 	LastLoginAt      *time.Time     `json:"lastLoginAt"`
 	LastLoginIP      string         `gorm:"type:varchar(50)" json:"lastLoginIp"`
+	// 早于该时间签发的 token 一律失效（管理员吊销全部会话时设置）
+	TokensRevokedBefore *time.Time  `json:"-"`
 	CreatedAt        time.Time      `gorm:"not null;default:now()" json:"createdAt"`
 	UpdatedAt        time.Time      `gorm:"not null;default:now()" json:"updatedAt"`
 	DeletedAt        *time.Time     `json:"deletedAt"`
@@ -11,10 +11,12 @@ import (
 type WithdrawalStatus string
 
 const (
-	WithdrawalStatusPending   WithdrawalStatus = "pending"   // 待审核
-	WithdrawalStatusApproved  WithdrawalStatus = "approved"  // 已通过
-	WithdrawalStatusRejected  WithdrawalStatus = "rejected"  // 已拒绝
-	WithdrawalStatusCompleted WithdrawalStatus = "completed" // 已完成
+	WithdrawalStatusPending    WithdrawalStatus = "pending"    // 待审核
+	WithdrawalStatusApproved   WithdrawalStatus = "approved"   // 已通过
+	WithdrawalStatusProcessing WithdrawalStatus = "processing" // 打款中（已提交渠道，等待结果）
+	WithdrawalStatusRejected   WithdrawalStatus = "rejected"   // 已拒绝
+	WithdrawalStatusCompleted  WithdrawalStatus = "completed"  // 已完成
+	WithdrawalStatusFailed     WithdrawalStatus = "failed"     // 打款失败（冻结积分已退回）
 )
 
 // WithdrawalMethod 提现方式
@@ -38,9 +40,33 @@ type Withdrawal struct {
 	AccountInfoHash string            `gorm:"type:varchar(64)" json:"accountInfoHash"`            // 账户信息哈希（用于去重）
 	Status          WithdrawalStatus  `gorm:"type:varchar(20);not null;default:'pending';index:idx_withdrawals_status" json:"status"`
 	AuditNote       string            `gorm:"type:varchar(200)" json:"auditNote"`                 // 审核备注
-	AuditedBy       *uuid.UUID        `gorm:"type:uuid" json:"auditedBy"`                        // 审核人ID
+	Description     string            `gorm:"type:text" json:"description"`                       // 申请说明
+	AuditedBy       *string           `gorm:"type:varchar(255)" json:"auditedBy"`                // 审核人ID（users.id）
 	AuditedAt       *time.Time        `gorm:"type:timestamp" json:"auditedAt"`                   // 审核时间
 	CompletedAt     *time.Time        `gorm:"type:timestamp" json:"completedAt"`                 // 完成时间
+
+	// 提现策略与风控
+	PolicyID   *uuid.UUID          `gorm:"type:uuid" json:"policyId"`                              // 申请时生效的提现策略（计算手续费和限额）
+	RiskFlags  WithdrawalRiskFlags `gorm:"type:jsonb;not null;default:'[]'" json:"riskFlags"`      // 命中的风控规则
+	RiskReview bool                `gorm:"type:boolean;not null;default:false" json:"riskReview"` // 需要人工复核（审核通过时必须填写复核意见）
+
+	// 汇率：到账金额由服务端按申请时生效的汇率换算，不接受客户端传入
+	ExchangeRateID *uuid.UUID `gorm:"type:uuid" json:"exchangeRateId"`            // 申请时生效的积分汇率
+	PayoutCents    int        `gorm:"type:int;not null;default:0" json:"payoutCents"` // 实际打款金额（分），到账积分按汇率向下取整
+
+	// 个税预扣（仅个人账户）：按当月累计劳务报酬收入计算，预扣积分从到账积分中扣除
+	TaxRuleID   *uuid.UUID `gorm:"type:uuid" json:"taxRuleId"`                     // 申请时生效的预扣规则
+	TaxIncome   int        `gorm:"type:int;not null;default:0" json:"taxIncome"`   // 本次计税收入（分）
+	TaxWithheld int        `gorm:"type:int;not null;default:0" json:"taxWithheld"` // 本次预扣税额（分）
+	TaxAmount   int        `gorm:"type:int;not null;default:0" json:"taxAmount"`   // 预扣税额对应的积分（向上取整）
+
+	// 打款信息
+	PayoutProvider      string     `gorm:"type:varchar(20)" json:"payoutProvider"`               // 打款渠道：wechat/alipay/manual/fake
+	PayoutOutBizNo      *string    `gorm:"type:varchar(64);uniqueIndex" json:"payoutOutBizNo"`   // 商户打款单号（重复提交时渠道按此去重）
+	PayoutOrderNo       string     `gorm:"type:varchar(64)" json:"payoutOrderNo"`                // 渠道打款单号
+	PayoutCashAccountID *uuid.UUID `gorm:"type:uuid" json:"payoutCashAccountId"`                 // 出款现金账户
+	PayoutFailReason    string     `gorm:"type:varchar(500)" json:"payoutFailReason"`            // 打款失败原因
+	PayoutSubmittedAt   *time.Time `gorm:"type:timestamp" json:"payoutSubmittedAt"`              // 提交渠道时间
 	CreatedAt       time.Time         `gorm:"type:timestamp;not null;default:NOW();index:idx_withdrawals_created_at" json:"createdAt"`
 	UpdatedAt       time.Time         `gorm:"type:timestamp;not null;default:NOW()" json:"updatedAt"`
 
@@ -74,6 +100,11 @@ func (w *Withdrawal) IsApproved() bool {
 	return w.Status == WithdrawalStatusApproved
 }
 
+// IsProcessing 是否打款中
+func (w *Withdrawal) IsProcessing() bool {
+	return w.Status == WithdrawalStatusProcessing
+}
+
 // IsRejected 是否已拒绝
 func (w *Withdrawal) IsRejected() bool {
 	return w.Status == WithdrawalStatusRejected
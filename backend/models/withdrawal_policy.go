@@ -0,0 +1,141 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WithdrawalPolicyScopeGlobal 全局提现策略；其余取值为账户所有者类型（OwnerType）
+const WithdrawalPolicyScopeGlobal = "GLOBAL"
+
+// 提现风控标记
+const (
+	WithdrawalRiskNewPayoutAccount = "NEW_PAYOUT_ACCOUNT" // 首次使用该收款账户
+	WithdrawalRiskAfterReferral    = "AFTER_REFERRAL"     // 刚收到返佣后立即提现
+	WithdrawalRiskLargeAmount      = "LARGE_AMOUNT"       // 单笔金额达到人工复核阈值
+)
+
+// WithdrawalPolicy 提现策略（限额、冷静期、手续费阶梯、风控规则）
+// 提现时先取账户所有者类型的生效策略，没有时取全局策略；都没有时不限制、不收手续费
+// 每个范围同时只有一条生效策略，新建策略会停用旧策略（旧策略保留以便追溯历史提现）
+type WithdrawalPolicy struct {
+	ID                   uuid.UUID           `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	Scope                string              `gorm:"type:varchar(20);not null;index" json:"scope"`                    // GLOBAL / USER_PERSONAL / ORG_MERCHANT / ORG_PROVIDER
+	MinAmount            int                 `gorm:"type:int;not null;default:0" json:"minAmount"`                    // 单笔最低积分，0 表示不限
+	MaxAmount            int                 `gorm:"type:int;not null;default:0" json:"maxAmount"`                    // 单笔最高积分，0 表示不限
+	DailyLimit           int                 `gorm:"type:int;not null;default:0" json:"dailyLimit"`                   // 每人每日累计上限，0 表示不限
+	MonthlyLimit         int                 `gorm:"type:int;not null;default:0" json:"monthlyLimit"`                 // 每人每月累计上限，0 表示不限
+	CoolingOffHours      int                 `gorm:"type:int;not null;default:0" json:"coolingOffHours"`              // 首笔收入到账后多少小时内不能提现
+	ReviewAmount         int                 `gorm:"type:int;not null;default:0" json:"reviewAmount"`                 // 单笔达到此金额进入人工复核，0 表示不启用
+	ReferralWindowHours  int                 `gorm:"type:int;not null;default:0" json:"referralWindowHours"`          // 返佣到账后多少小时内提现进入人工复核
+	FlagNewPayoutAccount bool                `gorm:"type:boolean;not null;default:false" json:"flagNewPayoutAccount"` // 新收款账户进入人工复核
+	IsActive             bool                `gorm:"type:boolean;not null;default:true" json:"isActive"`
+	Description          string              `gorm:"type:text" json:"description"`
+	CreatedBy            string              `gorm:"type:varchar(255);not null" json:"createdBy"`
+	CreatedAt            time.Time           `gorm:"not null;default:now()" json:"createdAt"`
+	UpdatedAt            time.Time           `gorm:"not null;default:now()" json:"updatedAt"`
+	FeeTiers             []WithdrawalFeeTier `gorm:"foreignKey:PolicyID" json:"feeTiers"`
+}
+
+// TableName 指定表名
+func (WithdrawalPolicy) TableName() string {
+	return "withdrawal_policies"
+}
+
+// BeforeCreate GORM Hook
+func (p *WithdrawalPolicy) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+// WithdrawalFeeTier 提现手续费阶梯
+// 同一提现方式按 MinAmount 取不超过提现金额的最高一档
+type WithdrawalFeeTier struct {
+	ID          uuid.UUID        `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	PolicyID    uuid.UUID        `gorm:"type:uuid;not null;index" json:"policyId"`
+	Method      WithdrawalMethod `gorm:"type:varchar(20);not null" json:"method"`
+	MinAmount   int              `gorm:"type:int;not null;default:0" json:"minAmount"`   // 本档起始金额（含）
+	RateBasis   int              `gorm:"type:int;not null;default:0" json:"rateBasis"`   // 万分比，60 = 0.6%
+	FixedAmount int              `gorm:"type:int;not null;default:0" json:"fixedAmount"` // 每笔固定积分
+	MinFee      int              `gorm:"type:int;not null;default:0" json:"minFee"`      // 最低手续费
+	MaxFee      int              `gorm:"type:int;not null;default:0" json:"maxFee"`      // 最高手续费，0 表示不封顶
+}
+
+// TableName 指定表名
+func (WithdrawalFeeTier) TableName() string {
+	return "withdrawal_fee_tiers"
+}
+
+// BeforeCreate GORM Hook
+func (t *WithdrawalFeeTier) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+// CalculateFee 按提现方式和金额计算手续费（比例部分四舍五入，结果不超过提现金额）
+func (p *WithdrawalPolicy) CalculateFee(method WithdrawalMethod, amount int) int {
+	var tier *WithdrawalFeeTier
+	for i := range p.FeeTiers {
+		t := &p.FeeTiers[i]
+		if t.Method != method || t.MinAmount > amount {
+			continue
+		}
+		if tier == nil || t.MinAmount > tier.MinAmount {
+			tier = t
+		}
+	}
+	if tier == nil {
+		return 0
+	}
+
+	fee := tier.FixedAmount + (amount*tier.RateBasis+5000)/10000
+	if fee < tier.MinFee {
+		fee = tier.MinFee
+	}
+	if tier.MaxFee > 0 && fee > tier.MaxFee {
+		fee = tier.MaxFee
+	}
+	if fee > amount {
+		fee = amount
+	}
+	return fee
+}
+
+// WithdrawalRiskFlags 提现风控标记数组，支持 JSON 序列化
+type WithdrawalRiskFlags []string
+
+// Scan 实现 sql.Scanner 接口
+func (f *WithdrawalRiskFlags) Scan(value interface{}) error {
+	if value == nil {
+		*f = []string{}
+		return nil
+	}
+
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return nil
+	}
+
+	return json.Unmarshal(bytes, f)
+}
+
+// Value 实现 driver.Valuer 接口
+func (f WithdrawalRiskFlags) Value() (driver.Value, error) {
+	if len(f) == 0 {
+		return "[]", nil
+	}
+	return json.Marshal(f)
+}
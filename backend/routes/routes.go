@@ -1,10 +1,16 @@
 package routes
 
 import (
+	"context"
+	"log"
 	"pr-business/config"
+	"pr-business/constants"
 	"pr-business/controllers"
 	"pr-business/middlewares"
+	"pr-business/models"
 	"pr-business/services"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -19,34 +25,101 @@ func SetupRoutes(r *gin.Engine, db *gorm.DB, redisClient interface{}, cfg *confi
 
 	// 初始化服务层
 	validatorService := services.NewValidatorService(db)
-	cashAccountService := services.NewCashAccountService(db, validatorService)
-	systemAccountService := services.NewSystemAccountService(db, validatorService)
+	ledgerService := services.NewLedgerService(db)
+	transactionTypeService := services.NewTransactionTypeService(db)
+	if err := transactionTypeService.Seed(); err != nil {
+		log.Printf("登记内置交易类型失败: %v", err)
+	}
+	cashAccountService := services.NewCashAccountService(db, validatorService, ledgerService)
+	systemAccountService := services.NewSystemAccountService(db, validatorService, ledgerService)
 	auditService := services.NewAuditService(db)
-	withdrawalEnhancedService := services.NewWithdrawalEnhancedService(
+	permissionService := services.NewAccountPermissionService(db)
+	platformFeeService := services.NewPlatformFeeService(db)
+	settlementService := services.NewSettlementService(db, permissionService, validatorService, cashAccountService, ledgerService, platformFeeService)
+	settlementJobService := services.NewSettlementJobService(
+		db,
+		settlementService,
+		cfg.SettlementWorkerCount,
+		cfg.SettlementMaxAttempts,
+		cfg.SettlementPollInterval,
+	)
+
+	campaignLifecycleService := services.NewCampaignLifecycleService(db, settlementService, auditService)
+	taskReviewService := services.NewTaskReviewService(db)
+	idempotencyService := services.NewIdempotencyService(db, cfg.IdempotencyTTL, cfg.IdempotencyLockTTL)
+	wechatPay := wechatPayGateway(cfg)
+	alipay := alipayGateway(cfg)
+	exchangeRateService := services.NewExchangeRateService(db)
+	paymentService := services.NewPaymentService(db, ledgerService, auditService, exchangeRateService, paymentGateways(cfg, wechatPay, alipay)...)
+	payoutService := services.NewPayoutService(db, ledgerService, auditService, payoutProviders(cfg, wechatPay, alipay))
+	withdrawalPolicyService := services.NewWithdrawalPolicyService(db)
+	taxWithholdingService := services.NewTaxWithholdingService(db)
+	withdrawalService := services.NewWithdrawalService(db, ledgerService, auditService, withdrawalPolicyService, exchangeRateService, taxWithholdingService, payoutService)
+
+	// 启动结算 worker 池
+	settlementJobService.Start(context.Background())
+
+	// 请求认证：本地校验 token，后台同步账号中心资料；会话 token 可刷新、吊销
+	tokenVerifier := newTokenVerifier(cfg)
+	// 认证路径的用户短时缓存，修改用户角色、吊销、切换身份的地方负责失效
+	userCache := services.NewUserCache(cfg.AuthIdentityCacheSize, cfg.AuthUserCacheTTL)
+	authIdentityService := services.NewAuthIdentityService(db, newAuthCenterClient(cfg), tokenVerifier, userCache, services.AuthIdentityOptions{
+		VerifyMode:          cfg.AuthTokenVerifyMode,
+		CacheSize:           cfg.AuthIdentityCacheSize,
+		CacheTTL:            cfg.AuthIdentityCacheTTL,
+		ProfileSyncInterval: cfg.AuthProfileSyncInterval,
+	})
+	authIdentityService.Start(context.Background())
+	sessionService := services.NewSessionService(db, tokenVerifier, userCache, cfg.JWTAccessTokenExpire, cfg.JWTRefreshTokenExpire)
+	roleContextService := services.NewRoleContextService(db, userCache)
+	authorizationService := services.NewAuthorizationService(db, services.DefaultPolicyRules)
+	authMiddleware := middlewares.AuthCenterMiddleware(authIdentityService, sessionService, roleContextService)
+
+	// 启动定时任务：释放超时任务、处理审核超时、关闭过期活动、清理过期幂等键和会话
+	schedulerService := services.NewSchedulerService(db)
+	taskDeadlineService := services.NewTaskDeadlineService(
 		db,
-		validatorService,
-		cashAccountService,
-		systemAccountService,
+		settlementJobService,
+		campaignLifecycleService,
+		taskReviewService,
+		auditService,
+		cfg.ReviewSLA,
+		cfg.ReviewSLAAction,
 	)
+	taskDeadlineService.RegisterJobs(schedulerService, cfg.SchedulerInterval)
+	idempotencyService.RegisterJobs(schedulerService, time.Hour)
+	payoutService.RegisterJobs(schedulerService, cfg.PayoutPollInterval)
+	sessionService.RegisterJobs(schedulerService, time.Hour)
+	schedulerService.Start(context.Background())
 
 	// 初始化controllers
-	authController := controllers.NewAuthController(cfg, db)
-	invitationController := controllers.NewInvitationController(db, cfg)
-	merchantController := controllers.NewMerchantController(db)
-	serviceProviderController := controllers.NewServiceProviderController(db)
+	authController := controllers.NewAuthController(cfg, db, sessionService, roleContextService, authorizationService, auditService)
+	invitationController := controllers.NewInvitationController(db, cfg, userCache)
+	merchantController := controllers.NewMerchantController(db, userCache)
+	serviceProviderController := controllers.NewServiceProviderController(db, userCache)
 	creatorController := controllers.NewCreatorController(db)
-	campaignController := controllers.NewCampaignController(db)
-	taskController := controllers.NewTaskController(db)
+	campaignController := controllers.NewCampaignController(db, campaignLifecycleService)
+	taskController := controllers.NewTaskController(db, settlementJobService, settlementService, taskReviewService)
 	creditController := controllers.NewCreditController(db)
-	withdrawalController := controllers.NewWithdrawalController(db)
-	taskInvitationController := controllers.NewTaskInvitationController(db)
-	rechargeOrderController := controllers.NewRechargeOrderController(db)
+	withdrawalController := controllers.NewWithdrawalController(db, withdrawalService, payoutService)
+	taskInvitationController := controllers.NewTaskInvitationController(db, userCache)
+	rechargeOrderController := controllers.NewRechargeOrderController(db, paymentService)
+	paymentController := controllers.NewPaymentController(db, paymentService)
 
 	// 新增：财务相关控制器
-	withdrawalEnhancedController := controllers.NewWithdrawalEnhancedController(db, withdrawalEnhancedService, auditService)
+	withdrawalEnhancedController := controllers.NewWithdrawalEnhancedController(withdrawalController)
 	cashAccountController := controllers.NewCashAccountController(cashAccountService, auditService)
 	systemAccountController := controllers.NewSystemAccountController(systemAccountService, auditService)
 	financialAuditController := controllers.NewFinancialAuditController(auditService)
+	settlementJobController := controllers.NewSettlementJobController(settlementJobService, auditService)
+	reconciliationController := controllers.NewReconciliationController(services.NewReconciliationService(db), auditService)
+	platformFeeController := controllers.NewPlatformFeeController(platformFeeService, auditService)
+	withdrawalPolicyController := controllers.NewWithdrawalPolicyController(withdrawalPolicyService, auditService)
+	exchangeRateController := controllers.NewExchangeRateController(exchangeRateService, auditService)
+	statementController := controllers.NewStatementController(db, services.NewStatementService(db))
+	taxController := controllers.NewTaxController(db, taxWithholdingService, auditService)
+	transactionTypeController := controllers.NewTransactionTypeController(transactionTypeService, auditService)
+	invoiceController := controllers.NewInvoiceController(db, services.NewInvoiceService(db, auditService, exchangeRateService))
 
 	// API路由组
 	v1 := r.Group("/api/v1")
@@ -56,27 +129,45 @@ func SetupRoutes(r *gin.Engine, db *gorm.DB, redisClient interface{}, cfg *confi
 		{
 			// 发起微信登录
 			auth.GET("/wechat/login", authController.WeChatLoginRedirect)
+			// 账号中心 token 换取会话
+			auth.POST("/session", authMiddleware, authController.CreateSession)
+			// 刷新会话（refresh token 轮换）
+			auth.POST("/refresh", authController.RefreshSession)
+			// 退出登录
+			auth.POST("/logout", authMiddleware, authController.Logout)
 		}
 
+		// 支付渠道回调（无需认证，由渠道签名校验）
+		v1.POST("/payments/callback/:provider", paymentController.PaymentCallback)
+		v1.POST("/payouts/callback/:provider", withdrawalController.PayoutCallback)
+
 		// 用户路由（需要认证）
 		user := v1.Group("/user")
-		user.Use(middlewares.AuthCenterMiddleware(cfg, db))
+		user.Use(authMiddleware)
 		{
 			// 获取当前用户信息
 			user.GET("/me", authController.GetCurrentUser)
+			// 查询、切换激活角色
+			user.GET("/active-role", authController.GetActiveRole)
+			user.PUT("/active-role", authController.SwitchActiveRole)
+			// 当前身份可执行的操作（前端据此显示或隐藏按钮）
+			user.GET("/me/capabilities", authController.GetCapabilities)
 		}
 
 		// 用户管理路由（需要认证+超级管理员权限）
 		users := v1.Group("/users")
-		users.Use(middlewares.AuthCenterMiddleware(cfg, db))
+		users.Use(authMiddleware)
 		{
 			// 获取用户列表（仅超级管理员）
 			users.GET("", authController.GetUsers)
+			// 查询、吊销用户会话（仅超级管理员）
+			users.GET("/:id/sessions", authController.GetUserSessions)
+			users.DELETE("/:id/sessions", authController.RevokeUserSessions)
 		}
 
 		// 邀请码路由（需要认证）
 		invitations := v1.Group("/invitations")
-		invitations.Use(middlewares.AuthCenterMiddleware(cfg, db))
+		invitations.Use(authMiddleware)
 		{
 			// 获取我的固定邀请码列表（人邀请人）
 			invitations.GET("/fixed-codes", invitationController.GetMyFixedInvitationCodes)
@@ -94,53 +185,69 @@ func SetupRoutes(r *gin.Engine, db *gorm.DB, redisClient interface{}, cfg *confi
 
 		// 需要认证的路由
 		protected := v1.Group("")
-		protected.Use(middlewares.AuthCenterMiddleware(cfg, db))
+		protected.Use(authMiddleware)
+
+		// 资金类接口支持 Idempotency-Key，客户端重试时不会重复扣款/冻结
+		idempotent := middlewares.Idempotency(idempotencyService)
+		// 按授权策略表校验操作，带资源加载器的同时校验资源归属
+		authorize := func(action string, loader services.PolicyResourceLoader) gin.HandlerFunc {
+			return middlewares.Authorize(authorizationService, action, loader)
+		}
+		campaignResource := authorizationService.CampaignResource
+		taskResource := authorizationService.TaskResource
+		creatorResource := authorizationService.CreatorResource
+		merchantResource := authorizationService.MerchantResource
+		serviceProviderResource := authorizationService.ServiceProviderResource
 		{
 
 
 			// 商家管理
-			protected.POST("/merchants", merchantController.CreateMerchant)
+			protected.POST("/merchants", authorize(constants.ActionMerchantCreate, nil), merchantController.CreateMerchant)
 			protected.GET("/merchants", merchantController.GetMerchants)
-			protected.GET("/merchants/:id", merchantController.GetMerchant)
-			protected.PUT("/merchants/:id", merchantController.UpdateMerchant)
-			protected.DELETE("/merchants/:id", merchantController.DeleteMerchant)
+			protected.GET("/merchants/:id", authorize(constants.ActionMerchantView, merchantResource), merchantController.GetMerchant)
+			protected.PUT("/merchants/:id", authorize(constants.ActionMerchantUpdate, merchantResource), merchantController.UpdateMerchant)
+			protected.DELETE("/merchants/:id", authorize(constants.ActionMerchantDelete, merchantResource), merchantController.DeleteMerchant)
 			protected.GET("/merchant/me", merchantController.GetMyMerchant)
-			protected.POST("/merchants/:id/staff", merchantController.AddMerchantStaff)
-			protected.GET("/merchants/:id/staff", merchantController.GetMerchantStaff)
-			protected.PUT("/merchants/:id/staff/:staff_id/permissions", merchantController.UpdateMerchantStaffPermission)
-			protected.DELETE("/merchants/:id/staff/:staff_id", merchantController.DeleteMerchantStaff)
+			protected.POST("/merchants/:id/staff", authorize(constants.ActionMerchantStaffManage, merchantResource), merchantController.AddMerchantStaff)
+			protected.GET("/merchants/:id/staff", authorize(constants.ActionMerchantView, merchantResource), merchantController.GetMerchantStaff)
+			protected.PUT("/merchants/:id/staff/:staff_id/permissions", authorize(constants.ActionMerchantStaffManage, merchantResource), merchantController.UpdateMerchantStaffPermission)
+			protected.DELETE("/merchants/:id/staff/:staff_id", authorize(constants.ActionMerchantStaffManage, merchantResource), merchantController.DeleteMerchantStaff)
 			protected.GET("/merchants/permissions", merchantController.GetPermissions)
 
 			// 服务商管理
-			protected.POST("/service-providers", serviceProviderController.CreateServiceProvider)
+			protected.POST("/service-providers", authorize(constants.ActionServiceProviderCreate, nil), serviceProviderController.CreateServiceProvider)
 			protected.GET("/service-providers", serviceProviderController.GetServiceProviders)
 			protected.GET("/service-providers/:id", serviceProviderController.GetServiceProvider)
-			protected.PUT("/service-providers/:id", serviceProviderController.UpdateServiceProvider)
-			protected.DELETE("/service-providers/:id", serviceProviderController.DeleteServiceProvider)
+			protected.PUT("/service-providers/:id", authorize(constants.ActionServiceProviderUpdate, serviceProviderResource), serviceProviderController.UpdateServiceProvider)
+			protected.DELETE("/service-providers/:id", authorize(constants.ActionServiceProviderDelete, serviceProviderResource), serviceProviderController.DeleteServiceProvider)
 			protected.GET("/service-provider/me", serviceProviderController.GetMyServiceProvider)
-			protected.POST("/service-providers/:id/staff", serviceProviderController.AddServiceProviderStaff)
+			protected.POST("/service-providers/:id/staff", authorize(constants.ActionServiceProviderStaffManage, serviceProviderResource), serviceProviderController.AddServiceProviderStaff)
 			protected.GET("/service-providers/:id/staff", serviceProviderController.GetServiceProviderStaff)
-			protected.PUT("/service-providers/:id/staff/:staff_id/permissions", serviceProviderController.UpdateServiceProviderStaffPermission)
-			protected.DELETE("/service-providers/:id/staff/:staff_id", serviceProviderController.DeleteServiceProviderStaff)
+			protected.PUT("/service-providers/:id/staff/:staff_id/permissions", authorize(constants.ActionServiceProviderStaffManage, serviceProviderResource), serviceProviderController.UpdateServiceProviderStaffPermission)
+			protected.DELETE("/service-providers/:id/staff/:staff_id", authorize(constants.ActionServiceProviderStaffManage, serviceProviderResource), serviceProviderController.DeleteServiceProviderStaff)
 			protected.GET("/service-providers/permissions", serviceProviderController.GetPermissions)
 
 			// 达人管理
-			protected.GET("/creators", creatorController.GetCreators)
+			protected.GET("/creators", authorize(constants.ActionCreatorList, nil), creatorController.GetCreators)
 			protected.GET("/creators/stats/level", creatorController.GetCreatorLevelStats)
 			protected.GET("/creators/:id", creatorController.GetCreator)
-			protected.PUT("/creators/:id", creatorController.UpdateCreator)
+			protected.PUT("/creators/:id", authorize(constants.ActionCreatorUpdate, creatorResource), creatorController.UpdateCreator)
 			protected.GET("/creators/:id/inviter", creatorController.GetCreatorInviterRelationship)
 			protected.POST("/creators/:id/break-relationship", creatorController.BreakInviterRelationship)
 			protected.GET("/creator/me", creatorController.GetMyCreatorProfile)
 			protected.PUT("/creator/me", creatorController.UpdateMyCreatorProfile)
 
 			// 营销活动管理
-			protected.POST("/campaigns", campaignController.CreateCampaign)
+			protected.POST("/campaigns", authorize(constants.ActionCampaignCreate, nil), campaignController.CreateCampaign)
 			protected.GET("/campaigns", campaignController.GetCampaigns)
 			protected.GET("/campaigns/:id", campaignController.GetCampaign)
-			protected.POST("/campaigns/:id/approve", campaignController.ApproveCampaign)
-			protected.PUT("/campaigns/:id", campaignController.UpdateCampaign)
-			protected.DELETE("/campaigns/:id", campaignController.DeleteCampaign)
+			protected.POST("/campaigns/:id/approve", authorize(constants.ActionCampaignApprove, campaignResource), idempotent, campaignController.ApproveCampaign)
+			protected.POST("/campaigns/:id/close", authorize(constants.ActionCampaignManage, campaignResource), campaignController.CloseCampaign)
+			protected.POST("/campaigns/:id/pause", authorize(constants.ActionCampaignManage, campaignResource), campaignController.PauseCampaign)
+			protected.POST("/campaigns/:id/resume", authorize(constants.ActionCampaignManage, campaignResource), campaignController.ResumeCampaign)
+			protected.PUT("/campaigns/:id/quota", authorize(constants.ActionCampaignManage, campaignResource), campaignController.ChangeCampaignQuota)
+			protected.PUT("/campaigns/:id", authorize(constants.ActionCampaignUpdate, campaignResource), campaignController.UpdateCampaign)
+			protected.DELETE("/campaigns/:id", authorize(constants.ActionCampaignDelete, campaignResource), campaignController.DeleteCampaign)
 			protected.GET("/campaigns/my", campaignController.GetMyCampaigns)
 
 			// 任务邀请管理
@@ -150,50 +257,242 @@ func SetupRoutes(r *gin.Engine, db *gorm.DB, redisClient interface{}, cfg *confi
 
 			// 任务管理
 			protected.GET("/tasks", taskController.GetTasks)
-			protected.GET("/tasks/hall", taskController.GetTaskHall)
+			protected.GET("/tasks/hall", authorize(constants.ActionTaskHall, nil), taskController.GetTaskHall)
 			protected.GET("/tasks/my", taskController.GetMyTasks)
-			protected.GET("/tasks/pending-review", taskController.GetTasksForReview)
+			protected.GET("/tasks/pending-review", authorize(constants.ActionTaskReviewQueue, nil), taskController.GetTasksForReview)
 			protected.GET("/tasks/:id", taskController.GetTask)
-			protected.POST("/tasks/:id/accept", taskController.AcceptTask)
-			protected.POST("/tasks/:id/submit", taskController.SubmitTask)
-			protected.POST("/tasks/:id/audit", taskController.AuditTask)
+			protected.POST("/tasks/:id/accept", authorize(constants.ActionTaskAccept, nil), taskController.AcceptTask)
+			protected.POST("/tasks/:id/submit", authorize(constants.ActionTaskSubmit, taskResource), taskController.SubmitTask)
+			protected.POST("/tasks/:id/audit", authorize(constants.ActionTaskReview, taskResource), taskController.AuditTask)
+			protected.GET("/tasks/:id/timeline", authorize(constants.ActionTaskView, taskResource), taskController.GetTaskTimeline)
 
 			// 积分管理
 			protected.GET("/credit/accounts", creditController.GetUserAccounts)
 			protected.GET("/credit/balance", creditController.GetAccountBalance)
 			protected.GET("/credit/transactions", creditController.GetTransactions)
-			protected.POST("/credit/recharge", creditController.Recharge)
 
 			// 充值订单管理（线下充值流程）
-			protected.POST("/recharge-orders", rechargeOrderController.CreateRechargeOrder)
+			protected.POST("/recharge-orders", idempotent, rechargeOrderController.CreateRechargeOrder)
 			protected.GET("/recharge-orders", rechargeOrderController.GetRechargeOrders)
-			protected.POST("/recharge-orders/:id/audit", rechargeOrderController.AuditRechargeOrder)
+			protected.POST("/recharge-orders/:id/audit", authorize(constants.ActionRechargeAudit, nil), rechargeOrderController.AuditRechargeOrder)
+
+			// 在线充值（支付渠道下单，回调入账）
+			protected.GET("/payments/providers", paymentController.GetPaymentProviders)
+			protected.POST("/recharge-orders/online", idempotent, rechargeOrderController.CreateOnlineRecharge)
+			protected.POST("/recharge-orders/:id/sync", rechargeOrderController.SyncRechargeOrder)
+			protected.POST("/recharge-orders/:id/refund", authorize(constants.ActionRechargeRefund, nil), idempotent, rechargeOrderController.RefundRechargeOrder)
+			if cfg.PaymentMockEnabled {
+				protected.POST("/payments/mock/:id/pay", paymentController.SimulateMockPayment)
+			}
 
 			// 提现管理
-			protected.POST("/withdrawals", withdrawalController.CreateWithdrawal)
+			protected.POST("/withdrawals", idempotent, withdrawalController.CreateWithdrawal)
 			protected.GET("/withdrawals", withdrawalController.GetWithdrawals)
+			protected.GET("/withdrawals/quote", withdrawalController.QuoteWithdrawal)
+			protected.GET("/withdrawals/review-queue", authorize(constants.ActionWithdrawalReview, nil), withdrawalController.GetWithdrawalReviewQueue)
 			protected.GET("/withdrawals/:id", withdrawalController.GetWithdrawal)
-			protected.POST("/withdrawals/:id/audit", withdrawalController.AuditWithdrawal)
-			protected.POST("/withdrawals/:id/process", withdrawalController.ProcessWithdrawal)
+			protected.POST("/withdrawals/:id/audit", authorize(constants.ActionWithdrawalReview, nil), withdrawalController.AuditWithdrawal)
+			protected.POST("/withdrawals/:id/process", authorize(constants.ActionWithdrawalPayout, nil), withdrawalController.ProcessWithdrawal)
+			protected.POST("/withdrawals/:id/sync", authorize(constants.ActionWithdrawalPayout, nil), withdrawalController.SyncWithdrawalPayout)
+			protected.POST("/withdrawals/:id/payout-result", authorize(constants.ActionWithdrawalPayout, nil), withdrawalController.ConfirmWithdrawalPayout)
 
-			// 新增：增强提现管理（带冻结机制）
-			protected.POST("/withdrawals/enhanced", withdrawalEnhancedController.CreateWithdrawalRequest)
+			// 旧版增强提现接口（兼容保留，统一由 WithdrawalService 处理）
+			protected.POST("/withdrawals/enhanced", idempotent, withdrawalEnhancedController.CreateWithdrawalRequest)
 			protected.GET("/withdrawals/enhanced", withdrawalEnhancedController.GetWithdrawalRequests)
 			protected.GET("/withdrawals/enhanced/:id", withdrawalEnhancedController.GetWithdrawalRequest)
-			protected.POST("/withdrawals/enhanced/:id/approve", withdrawalEnhancedController.ApproveWithdrawalRequest)
-			protected.POST("/withdrawals/enhanced/:id/reject", withdrawalEnhancedController.RejectWithdrawalRequest)
+			protected.POST("/withdrawals/enhanced/:id/approve", authorize(constants.ActionWithdrawalReview, nil), authorize(constants.ActionWithdrawalPayout, nil), withdrawalEnhancedController.ApproveWithdrawalRequest)
+			protected.POST("/withdrawals/enhanced/:id/reject", authorize(constants.ActionWithdrawalReview, nil), withdrawalEnhancedController.RejectWithdrawalRequest)
+
+			// 提现策略（限额、手续费阶梯、风控规则，仅超管）
+			protected.GET("/withdrawal-policies", authorize(constants.ActionWithdrawalPolicyManage, nil), withdrawalPolicyController.GetWithdrawalPolicies)
+			protected.POST("/withdrawal-policies", authorize(constants.ActionWithdrawalPolicyManage, nil), withdrawalPolicyController.CreateWithdrawalPolicy)
+			protected.POST("/withdrawal-policies/:id/deactivate", authorize(constants.ActionWithdrawalPolicyManage, nil), withdrawalPolicyController.DeactivateWithdrawalPolicy)
+
+			// 积分汇率（当前汇率所有用户可查，历史和新增仅超管）
+			protected.GET("/exchange-rates/current", exchangeRateController.GetCurrentExchangeRate)
+			protected.GET("/exchange-rates", authorize(constants.ActionExchangeRateManage, nil), exchangeRateController.GetExchangeRates)
+			protected.POST("/exchange-rates", authorize(constants.ActionExchangeRateManage, nil), exchangeRateController.CreateExchangeRate)
+
+			// 个税预扣（规则仅超管；年度凭证达人查本人，超管可查任意个人账户，支持 CSV / PDF 导出）
+			protected.GET("/tax/withholding-rules", authorize(constants.ActionTaxRuleManage, nil), taxController.GetTaxRules)
+			protected.POST("/tax/withholding-rules", authorize(constants.ActionTaxRuleManage, nil), taxController.CreateTaxRule)
+			protected.GET("/tax/certificates/:year", taxController.GetTaxCertificate)
+			protected.GET("/tax/certificates/:year/accounts/:accountId", authorize(constants.ActionTaxCertificateView, nil), taxController.GetAccountTaxCertificate)
+
+			// 充值开票（开票信息和申请由商家管理员维护，开具和驳回仅超管）
+			protected.GET("/billing-profiles", invoiceController.GetBillingProfiles)
+			protected.POST("/billing-profiles", invoiceController.CreateBillingProfile)
+			protected.PUT("/billing-profiles/:id", invoiceController.UpdateBillingProfile)
+			protected.DELETE("/billing-profiles/:id", invoiceController.DeleteBillingProfile)
+			protected.GET("/invoices/invoiceable-orders", invoiceController.GetInvoiceableOrders)
+			protected.GET("/invoices", invoiceController.GetInvoices)
+			protected.POST("/invoices", invoiceController.CreateInvoice)
+			protected.GET("/invoices/:id", invoiceController.GetInvoice)
+			protected.POST("/invoices/:id/issue", authorize(constants.ActionInvoiceIssue, nil), invoiceController.IssueInvoice)
+			protected.POST("/invoices/:id/reject", authorize(constants.ActionInvoiceIssue, nil), invoiceController.RejectInvoice)
+
+			// 交易类型注册表（列表所有用户可查，新增/修改/废弃和版本历史仅超管）
+			protected.GET("/transaction-types", transactionTypeController.GetTransactionTypes)
+			protected.GET("/transaction-types/:code/versions", authorize(constants.ActionTransactionTypeManage, nil), transactionTypeController.GetTransactionTypeVersions)
+			protected.POST("/transaction-types", authorize(constants.ActionTransactionTypeManage, nil), transactionTypeController.DefineTransactionType)
+			protected.POST("/transaction-types/:code/deprecate", authorize(constants.ActionTransactionTypeManage, nil), transactionTypeController.DeprecateTransactionType)
 
 			// 新增：现金账户管理
-			protected.GET("/cash-accounts", cashAccountController.GetCashAccounts)
-			protected.POST("/cash-accounts", cashAccountController.CreateCashAccount)
-			protected.POST("/cash-accounts/:id/balance", cashAccountController.UpdateCashAccountBalance)
+			protected.GET("/cash-accounts", authorize(constants.ActionCashAccountView, nil), cashAccountController.GetCashAccounts)
+			protected.POST("/cash-accounts", authorize(constants.ActionCashAccountManage, nil), cashAccountController.CreateCashAccount)
+			protected.POST("/cash-accounts/:id/balance", authorize(constants.ActionCashAccountManage, nil), cashAccountController.UpdateCashAccountBalance)
 
 			// 新增：系统账户管理
-			protected.GET("/system-accounts", systemAccountController.GetSystemAccounts)
-			protected.GET("/system-accounts/summary", systemAccountController.GetFinancialSummary)
+			protected.GET("/system-accounts", authorize(constants.ActionSystemAccountView, nil), systemAccountController.GetSystemAccounts)
+			protected.GET("/system-accounts/summary", authorize(constants.ActionSystemAccountView, nil), systemAccountController.GetFinancialSummary)
+			protected.GET("/system-accounts/task-escrow/campaigns", authorize(constants.ActionSystemAccountView, nil), systemAccountController.GetCampaignEscrows)
+
+			// 月末对账单（需要查看财务报表权限，全平台汇总仅超管，支持 CSV / XLSX 导出）
+			viewFinancialReports := authorize(constants.ActionStatementView, nil)
+			protected.GET("/statements", authorize(constants.ActionStatementSummary, nil), statementController.GetStatementSummaries)
+			protected.GET("/statements/me", viewFinancialReports, statementController.GetMyStatement)
+			protected.GET("/statements/accounts/:accountId", viewFinancialReports, statementController.GetAccountStatement)
 
 			// 新增：财务审计日志
-			protected.GET("/financial-audit-logs", financialAuditController.GetAuditLogs)
+			protected.GET("/financial-audit-logs", authorize(constants.ActionFinancialAuditView, nil), financialAuditController.GetAuditLogs)
+
+			// 新增：结算任务管理（重试/取消卡住的结算）
+			protected.GET("/settlement-jobs", authorize(constants.ActionSettlementJobManage, nil), settlementJobController.GetSettlementJobs)
+			protected.POST("/settlement-jobs/:id/retry", authorize(constants.ActionSettlementJobManage, nil), settlementJobController.RetrySettlementJob)
+			protected.POST("/settlement-jobs/:id/cancel", authorize(constants.ActionSettlementJobManage, nil), settlementJobController.CancelSettlementJob)
+
+			// 新增：对账报告（超管）
+			protected.POST("/reconciliation/runs", authorize(constants.ActionReconciliationManage, nil), reconciliationController.RunReconciliation)
+			protected.GET("/reconciliation/runs", authorize(constants.ActionReconciliationManage, nil), reconciliationController.GetReconciliationRuns)
+			protected.GET("/reconciliation/runs/:id", authorize(constants.ActionReconciliationManage, nil), reconciliationController.GetReconciliationRun)
+			protected.GET("/reconciliation/runs/:id/csv", authorize(constants.ActionReconciliationManage, nil), reconciliationController.DownloadReconciliationCSV)
+
+			// 新增：平台手续费规则与收益报表（超管）
+			protected.GET("/platform-fee-rules", authorize(constants.ActionPlatformFeeManage, nil), platformFeeController.GetPlatformFeeRules)
+			protected.POST("/platform-fee-rules", authorize(constants.ActionPlatformFeeManage, nil), platformFeeController.CreatePlatformFeeRule)
+			protected.POST("/platform-fee-rules/:id/end", authorize(constants.ActionPlatformFeeManage, nil), platformFeeController.EndPlatformFeeRule)
+			protected.GET("/platform-fees/settlements", authorize(constants.ActionPlatformFeeManage, nil), platformFeeController.GetSettlementRecords)
+			protected.GET("/platform-fees/revenue", authorize(constants.ActionPlatformFeeManage, nil), platformFeeController.GetPlatformRevenue)
 		}
 	}
 }
+
+// notifyURL 渠道回调地址
+func notifyURL(cfg *config.Config, path string) string {
+	return strings.TrimRight(cfg.PaymentNotifyBaseURL, "/") + "/api/v1" + path
+}
+
+// wechatPayGateway 按配置创建微信支付商户客户端，未配置或配置错误时返回 nil
+func wechatPayGateway(cfg *config.Config) *services.WechatPayGateway {
+	if cfg.WechatPayMchID == "" {
+		return nil
+	}
+	gateway, err := services.NewWechatPayGateway(services.WechatPayConfig{
+		AppID:            cfg.WechatPayAppID,
+		MchID:            cfg.WechatPayMchID,
+		MerchantSerialNo: cfg.WechatPaySerialNo,
+		MerchantKeyPath:  cfg.WechatPayPrivateKeyPath,
+		PlatformKeyPath:  cfg.WechatPayPlatformKeyPath,
+		PlatformSerialNo: cfg.WechatPayPlatformSerialNo,
+		APIv3Key:         cfg.WechatPayAPIv3Key,
+		NotifyURL:        notifyURL(cfg, "/payments/callback/"+services.PaymentProviderWechat),
+	})
+	if err != nil {
+		log.Printf("微信支付未启用: %v", err)
+		return nil
+	}
+	return gateway
+}
+
+// alipayGateway 按配置创建支付宝客户端，未配置或配置错误时返回 nil
+func alipayGateway(cfg *config.Config) *services.AlipayGateway {
+	if cfg.AlipayAppID == "" {
+		return nil
+	}
+	gateway, err := services.NewAlipayGateway(services.AlipayConfig{
+		GatewayURL:          cfg.AlipayGatewayURL,
+		AppID:               cfg.AlipayAppID,
+		AppPrivateKeyPath:   cfg.AlipayPrivateKeyPath,
+		AlipayPublicKeyPath: cfg.AlipayPublicKeyPath,
+		NotifyURL:           notifyURL(cfg, "/payments/callback/"+services.PaymentProviderAlipay),
+	})
+	if err != nil {
+		log.Printf("支付宝未启用: %v", err)
+		return nil
+	}
+	return gateway
+}
+
+// paymentGateways 已启用的在线支付渠道
+func paymentGateways(cfg *config.Config, wechat *services.WechatPayGateway, alipay *services.AlipayGateway) []services.PaymentGateway {
+	var gateways []services.PaymentGateway
+	if wechat != nil {
+		gateways = append(gateways, wechat)
+	}
+	if alipay != nil {
+		gateways = append(gateways, alipay)
+	}
+
+	if cfg.PaymentMockEnabled {
+		if cfg.PaymentMockSecret == "" {
+			log.Printf("模拟支付渠道未启用: 未配置 PAYMENT_MOCK_SECRET")
+		} else {
+			gateways = append(gateways, services.NewMockPaymentGateway(cfg.PaymentMockSecret))
+		}
+	}
+
+	return gateways
+}
+
+// payoutProviders 各提现方式的打款渠道；银行转账固定为人工打款
+// 启用模拟打款时所有提现方式都走模拟渠道
+func payoutProviders(cfg *config.Config, wechat *services.WechatPayGateway, alipay *services.AlipayGateway) map[models.WithdrawalMethod]services.PayoutProvider {
+	if cfg.PayoutFakeEnabled {
+		fake := services.NewFakePayoutProvider(cfg.PayoutFakeSecret, cfg.PayoutFakeDelay)
+		return map[models.WithdrawalMethod]services.PayoutProvider{
+			models.WithdrawalMethodWechat: fake,
+			models.WithdrawalMethodAlipay: fake,
+			models.WithdrawalMethodBank:   fake,
+		}
+	}
+
+	providers := map[models.WithdrawalMethod]services.PayoutProvider{
+		models.WithdrawalMethodBank: services.NewManualPayoutProvider(),
+	}
+	if wechat != nil {
+		providers[models.WithdrawalMethodWechat] = services.NewWechatPayoutProvider(wechat, services.WechatPayoutConfig{
+			NotifyURL:       notifyURL(cfg, "/payouts/callback/"+services.PayoutProviderWechat),
+			TransferSceneID: cfg.WechatPayTransferSceneID,
+		})
+	}
+	if alipay != nil {
+		providers[models.WithdrawalMethodAlipay] = services.NewAlipayPayoutProvider(alipay,
+			notifyURL(cfg, "/payouts/callback/"+services.PayoutProviderAlipay))
+	}
+	return providers
+}
+
+// placeholderJWTSecrets config 默认值和 .env.example 中的 JWT_SECRET 占位值，生产环境不能用于校验和签发 token
+var placeholderJWTSecrets = map[string]bool{
+	"change-me-in-production":                             true,
+	"change-this-to-a-strong-random-string-in-production": true,
+}
+
+// newAuthCenterClient 按配置创建账号中心客户端
+func newAuthCenterClient(cfg *config.Config) *services.HTTPAuthCenterClient {
+	return services.NewHTTPAuthCenterClient(cfg.AuthCenterURL, cfg.AuthJWKSURL, cfg.AuthCenterTimeout)
+}
+
+// newTokenVerifier 按配置创建 token 校验器（同时用于签发会话 token）
+func newTokenVerifier(cfg *config.Config) *services.TokenVerifier {
+	secret := cfg.JWTSecret
+	if cfg.AppEnv == "production" && placeholderJWTSecrets[secret] {
+		log.Printf("JWT_SECRET 仍为默认值，已禁用 HS256 token 校验和会话签发")
+		secret = ""
+	}
+	var keyClient services.AuthCenterClient
+	if cfg.AuthJWKSURL != "" {
+		keyClient = newAuthCenterClient(cfg)
+	}
+	return services.NewTokenVerifier(secret, cfg.AuthTokenIssuer, keyClient, cfg.AuthJWKSRefreshInterval)
+}
@@ -20,6 +20,11 @@ func NewAuditService(db *gorm.DB) *AuditService {
 	}
 }
 
+// WithTx 返回使用指定事务的审计服务，审计日志与业务变更一同提交或回滚
+func (s *AuditService) WithTx(tx *gorm.DB) *AuditService {
+	return &AuditService{db: tx}
+}
+
 // LogFinancialOperation 记录关键财务操作
 func (s *AuditService) LogFinancialOperation(
 	userID string,
@@ -42,7 +47,13 @@ func (s *AuditService) LogFinancialOperation(
 		CreatedAt:    time.Now(),
 	}
 
-	if err := s.db.Create(&log).Error; err != nil {
+	// 系统任务没有来源 IP，ip_address 为 inet 类型，不能写入空字符串
+	db := s.db
+	if ipAddress == "" {
+		db = db.Omit("IPAddress")
+	}
+
+	if err := db.Create(&log).Error; err != nil {
 		return fmt.Errorf("创建审计日志失败: %w", err)
 	}
 
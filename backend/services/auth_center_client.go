@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// AuthCenterClient 账号中心接口
+// 请求路径只在鉴权兜底（远程校验模式）、拉取 JWKS 公钥和异步同步资料时调用，测试时可替换为本地桩服务
+type AuthCenterClient interface {
+	// VerifyToken 由账号中心校验 token，返回账号中心用户ID
+	VerifyToken(ctx context.Context, token string) (*AuthCenterIdentity, error)
+	// GetUserInfo 以用户 token 获取账号中心用户资料
+	GetUserInfo(ctx context.Context, token string) (*AuthCenterUserInfo, error)
+	// GetJWKS 获取账号中心签发 token 的公钥集，未配置 JWKS 地址时返回 ErrJWKSNotConfigured
+	GetJWKS(ctx context.Context) (*JSONWebKeySet, error)
+}
+
+// AuthCenterIdentity 账号中心校验 token 的结果
+type AuthCenterIdentity struct {
+	UserID  string
+	UnionID string
+}
+
+// AuthCenterUserInfo 账号中心用户资料
+type AuthCenterUserInfo struct {
+	UserID      string `json:"userId"`
+	UnionID     string `json:"unionId"`
+	PhoneNumber string `json:"phoneNumber"`
+	Email       string `json:"email"`
+	Nickname    string `json:"nickname"`
+	AvatarURL   string `json:"avatarUrl"`
+}
+
+// HTTPAuthCenterClient 通过 HTTP 调用账号中心
+type HTTPAuthCenterClient struct {
+	baseURL    string
+	jwksURL    string
+	httpClient *http.Client
+}
+
+// NewHTTPAuthCenterClient 创建账号中心客户端；jwksURL 为空时不支持 GetJWKS
+func NewHTTPAuthCenterClient(baseURL, jwksURL string, timeout time.Duration) *HTTPAuthCenterClient {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &HTTPAuthCenterClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		jwksURL:    jwksURL,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// VerifyToken 调用 /api/auth/verify-token 校验 token
+func (c *HTTPAuthCenterClient) VerifyToken(ctx context.Context, token string) (*AuthCenterIdentity, error) {
+	payload, _ := json.Marshal(map[string]string{"token": token})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/auth/verify-token", strings.NewReader(string(payload)))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var result struct {
+		Success bool `json:"success"`
+		Data    struct {
+			UserID  string `json:"userId"`
+			UnionID string `json:"unionId"`
+		} `json:"data"`
+		Error string `json:"error"`
+	}
+	if err := c.do(req, &result); err != nil {
+		return nil, err
+	}
+	if !result.Success || result.Data.UserID == "" {
+		return nil, ErrInvalidToken
+	}
+
+	return &AuthCenterIdentity{UserID: result.Data.UserID, UnionID: result.Data.UnionID}, nil
+}
+
+// GetUserInfo 调用 /api/auth/user-info 获取用户资料
+func (c *HTTPAuthCenterClient) GetUserInfo(ctx context.Context, token string) (*AuthCenterUserInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/api/auth/user-info", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	var result struct {
+		Success bool `json:"success"`
+		Data    struct {
+			UserID      string `json:"userId"`
+			UnionID     string `json:"unionId"`
+			PhoneNumber string `json:"phoneNumber"`
+			Email       string `json:"email"`
+			Profile     struct {
+				Nickname  string `json:"nickname"`
+				AvatarURL string `json:"avatarUrl"`
+			} `json:"profile"`
+		} `json:"data"`
+	}
+	if err := c.do(req, &result); err != nil {
+		return nil, err
+	}
+	if !result.Success {
+		return nil, fmt.Errorf("获取用户信息失败")
+	}
+
+	return &AuthCenterUserInfo{
+		UserID:      result.Data.UserID,
+		UnionID:     result.Data.UnionID,
+		PhoneNumber: result.Data.PhoneNumber,
+		Email:       result.Data.Email,
+		Nickname:    result.Data.Profile.Nickname,
+		AvatarURL:   result.Data.Profile.AvatarURL,
+	}, nil
+}
+
+// GetJWKS 获取 JWKS 公钥集
+func (c *HTTPAuthCenterClient) GetJWKS(ctx context.Context) (*JSONWebKeySet, error) {
+	if c.jwksURL == "" {
+		return nil, ErrJWKSNotConfigured
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.jwksURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var keySet JSONWebKeySet
+	if err := c.do(req, &keySet); err != nil {
+		return nil, err
+	}
+	return &keySet, nil
+}
+
+// do 发送请求并解析 JSON 响应
+func (c *HTTPAuthCenterClient) do(req *http.Request, out interface{}) error {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求账号中心失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("读取账号中心响应失败: %w", err)
+	}
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("账号中心返回 %d", resp.StatusCode)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,343 @@
+package services
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"pr-business/constants"
+	"pr-business/models"
+)
+
+// token 校验方式
+const (
+	TokenVerifyModeLocal  = "local"  // 本地校验 JWT 签名（JWT_SECRET / JWKS）
+	TokenVerifyModeRemote = "remote" // 本地无法校验时调用账号中心校验，结果按 token 缓存
+)
+
+const (
+	// profileSyncQueueSize 资料同步队列长度，队列满时本次不同步，下次请求再尝试
+	profileSyncQueueSize = 256
+	// profileSyncRetryInterval 同步失败后的重试间隔
+	profileSyncRetryInterval = time.Minute
+)
+
+// AuthIdentityOptions 认证身份服务配置
+type AuthIdentityOptions struct {
+	VerifyMode          string        // local / remote
+	CacheSize           int           // token 和资料缓存的最大条数
+	CacheTTL            time.Duration // 远程校验结果的缓存时长
+	ProfileSyncInterval time.Duration // 同一用户两次同步账号中心资料的最小间隔
+}
+
+// AuthIdentityService 请求认证：校验 token、加载本地用户、异步同步账号中心资料
+// 认证路径不调用账号中心（远程校验模式下仅缓存未命中时调用），账号中心不可用时未过期的 token 仍可使用
+type AuthIdentityService struct {
+	db                  *gorm.DB
+	client              AuthCenterClient
+	verifier            *TokenVerifier
+	verifyMode          string
+	cacheTTL            time.Duration
+	profileSyncInterval time.Duration
+
+	tokens   *boundedCache // 远程校验结果：token 摘要 → *TokenClaims
+	profiles *boundedCache // 已同步的账号中心资料：账号中心用户ID → *AuthCenterUserInfo，过期即需要重新同步
+	users    *UserCache    // 本地用户短时缓存，认证路径命中时不查询 users 表
+
+	syncQueue chan profileSyncJob
+	pendingMu sync.Mutex
+	pending   map[string]bool
+}
+
+// profileSyncJob 资料同步任务
+type profileSyncJob struct {
+	authCenterUserID string
+	userID           string
+	token            string
+}
+
+// NewAuthIdentityService 创建认证身份服务；userCache 为空时每次请求都读取数据库
+func NewAuthIdentityService(db *gorm.DB, client AuthCenterClient, verifier *TokenVerifier, userCache *UserCache, opts AuthIdentityOptions) *AuthIdentityService {
+	if opts.VerifyMode != TokenVerifyModeRemote {
+		opts.VerifyMode = TokenVerifyModeLocal
+	}
+	if opts.CacheSize <= 0 {
+		opts.CacheSize = 10000
+	}
+	if opts.CacheTTL <= 0 {
+		opts.CacheTTL = 5 * time.Minute
+	}
+	if opts.ProfileSyncInterval <= 0 {
+		opts.ProfileSyncInterval = time.Hour
+	}
+
+	return &AuthIdentityService{
+		db:                  db,
+		client:              client,
+		verifier:            verifier,
+		verifyMode:          opts.VerifyMode,
+		cacheTTL:            opts.CacheTTL,
+		profileSyncInterval: opts.ProfileSyncInterval,
+		tokens:              newBoundedCache(opts.CacheSize),
+		profiles:            newBoundedCache(opts.CacheSize),
+		users:               userCache,
+		syncQueue:           make(chan profileSyncJob, profileSyncQueueSize),
+		pending:             map[string]bool{},
+	}
+}
+
+// Start 启动资料同步 worker
+func (s *AuthIdentityService) Start(ctx context.Context) {
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case job := <-s.syncQueue:
+				s.syncProfile(ctx, job)
+			}
+		}
+	}()
+	log.Printf("[AuthIdentity] started (verify mode: %s)", s.verifyMode)
+}
+
+// Authenticate 校验 token 并返回本地用户，首次登录的用户自动创建
+func (s *AuthIdentityService) Authenticate(ctx context.Context, token string) (*models.User, *TokenClaims, error) {
+	claims, err := s.verify(ctx, token)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user, err := s.loadUser(claims.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.scheduleProfileSync(claims.UserID, user.ID, token)
+	return user, claims, nil
+}
+
+// verify 按配置的方式校验 token
+func (s *AuthIdentityService) verify(ctx context.Context, token string) (*TokenClaims, error) {
+	digest := sha256.Sum256([]byte(token))
+	key := hex.EncodeToString(digest[:])
+
+	claims, err := s.verifier.Verify(ctx, token)
+	if err == nil && claims.TokenID == "" && claims.SessionID == "" {
+		// 没有 jti 的账号中心 token 同样以摘要作为吊销列表中的 token ID，退出登录才能吊销
+		claims.TokenID = "sha256:" + key
+	}
+	if s.verifyMode == TokenVerifyModeLocal || !errors.Is(err, ErrInvalidToken) {
+		return claims, err
+	}
+
+	// 远程校验模式：本地无法校验的 token 交给账号中心，结果按 token 摘要缓存
+	if cached, ok := s.tokens.Get(key); ok {
+		return cached.(*TokenClaims), nil
+	}
+
+	identity, err := s.client.VerifyToken(ctx, token)
+	if err != nil {
+		if errors.Is(err, ErrInvalidToken) {
+			return nil, err
+		}
+		log.Printf("[AuthIdentity] verify token via auth center failed: %v", err)
+		return nil, ErrAuthCenterUnavailable
+	}
+
+	// 账号中心 token 没有 jti 时以摘要作为吊销列表中的 token ID
+	claims = &TokenClaims{UserID: identity.UserID, UnionID: identity.UnionID, TokenID: "sha256:" + key}
+	s.tokens.Set(key, claims, time.Now().Add(s.cacheTTL))
+	return claims, nil
+}
+
+// userAccessState 每次请求都从数据库读取的用户状态
+type userAccessState struct {
+	Status              string
+	TokensRevokedBefore *time.Time
+}
+
+// loadUser 按账号中心用户ID读取本地用户（优先读短时缓存），不存在时创建（并发创建冲突时重新读取）
+// 缓存命中时仍单独读取 status 和 tokens_revoked_before：封禁和吊销可能由其他实例或直接改库写入，必须立即生效
+func (s *AuthIdentityService) loadUser(authCenterUserID string) (*models.User, error) {
+	if cached, ok := s.users.Get(authCenterUserID); ok {
+		var state userAccessState
+		err := s.db.Model(&models.User{}).
+			Select("status", "tokens_revoked_before").
+			Where("auth_center_user_id = ?", authCenterUserID).
+			Take(&state).Error
+		if err == nil {
+			cached.Status = state.Status
+			cached.TokensRevokedBefore = state.TokensRevokedBefore
+			return cached, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		s.users.Invalidate(authCenterUserID)
+	}
+
+	var user models.User
+	err := s.db.Where("auth_center_user_id = ?", authCenterUserID).First(&user).Error
+	if err == nil {
+		s.users.Set(&user)
+		return &user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	user = models.User{
+		AuthCenterUserID: authCenterUserID,
+		Roles:            models.Roles{constants.RoleBasicUser},
+		Status:           models.UserStatusActive,
+	}
+	if err := s.db.Create(&user).Error; err != nil {
+		var existing models.User
+		if s.db.Where("auth_center_user_id = ?", authCenterUserID).First(&existing).Error == nil {
+			return &existing, nil
+		}
+		return nil, err
+	}
+	log.Printf("[AuthIdentity] created local user %s for %s", user.ID, authCenterUserID)
+	return &user, nil
+}
+
+// scheduleProfileSync 资料未同步或已超过同步间隔时放入同步队列，同一用户同时只排队一次
+func (s *AuthIdentityService) scheduleProfileSync(authCenterUserID, userID, token string) {
+	if _, ok := s.profiles.Get(authCenterUserID); ok {
+		return
+	}
+
+	s.pendingMu.Lock()
+	defer s.pendingMu.Unlock()
+	if s.pending[authCenterUserID] {
+		return
+	}
+
+	select {
+	case s.syncQueue <- profileSyncJob{authCenterUserID: authCenterUserID, userID: userID, token: token}:
+		s.pending[authCenterUserID] = true
+	default:
+	}
+}
+
+// syncProfile 从账号中心拉取资料，昵称、头像有变化时写回本地用户
+func (s *AuthIdentityService) syncProfile(ctx context.Context, job profileSyncJob) {
+	defer func() {
+		s.pendingMu.Lock()
+		delete(s.pending, job.authCenterUserID)
+		s.pendingMu.Unlock()
+	}()
+
+	info, err := s.client.GetUserInfo(ctx, job.token)
+	if err != nil {
+		log.Printf("[AuthIdentity] sync profile for %s failed: %v", job.authCenterUserID, err)
+		s.profiles.Set(job.authCenterUserID, &AuthCenterUserInfo{}, time.Now().Add(profileSyncRetryInterval))
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if info.Nickname != "" {
+		updates["nickname"] = info.Nickname
+	}
+	if info.AvatarURL != "" {
+		updates["avatar_url"] = info.AvatarURL
+	}
+	if len(updates) > 0 {
+		updates["updated_at"] = time.Now()
+		err := s.db.Model(&models.User{}).
+			Where("id = ?", job.userID).
+			Where("nickname IS DISTINCT FROM ? OR avatar_url IS DISTINCT FROM ?", info.Nickname, info.AvatarURL).
+			Updates(updates).Error
+		if err != nil {
+			log.Printf("[AuthIdentity] save profile for %s failed: %v", job.userID, err)
+			s.profiles.Set(job.authCenterUserID, info, time.Now().Add(profileSyncRetryInterval))
+			return
+		}
+		s.users.Invalidate(job.authCenterUserID)
+	}
+
+	s.profiles.Set(job.authCenterUserID, info, time.Now().Add(s.profileSyncInterval))
+}
+
+// boundedCache 有容量上限的 LRU 缓存，每个条目单独设置过期时间
+type boundedCache struct {
+	mu       sync.Mutex
+	capacity int
+	items    map[string]*list.Element
+	order    *list.List
+}
+
+// boundedCacheEntry 缓存条目
+type boundedCacheEntry struct {
+	key       string
+	value     interface{}
+	expiresAt time.Time
+}
+
+func newBoundedCache(capacity int) *boundedCache {
+	return &boundedCache{
+		capacity: capacity,
+		items:    map[string]*list.Element{},
+		order:    list.New(),
+	}
+}
+
+// Get 读取未过期的条目
+func (c *boundedCache) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*boundedCacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.order.Remove(element)
+		delete(c.items, key)
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return entry.value, true
+}
+
+// Delete 删除条目
+func (c *boundedCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.items[key]; ok {
+		c.order.Remove(element)
+		delete(c.items, key)
+	}
+}
+
+// Set 写入条目，超过容量时淘汰最久未使用的条目
+func (c *boundedCache) Set(key string, value interface{}, expiresAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.items[key]; ok {
+		entry := element.Value.(*boundedCacheEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&boundedCacheEntry{key: key, value: value, expiresAt: expiresAt})
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*boundedCacheEntry).key)
+	}
+}
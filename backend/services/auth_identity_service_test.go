@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"pr-business/constants"
+	"pr-business/models"
+)
+
+// stubAuthCenter 本地账号中心桩：校验 token、返回 JWKS，并统计调用次数
+type stubAuthCenter struct {
+	server      *httptest.Server
+	key         *rsa.PrivateKey
+	validTokens map[string]string // token → 账号中心用户ID
+	verifyCalls int32
+	jwksCalls   int32
+}
+
+func newStubAuthCenter(t *testing.T, validTokens map[string]string) *stubAuthCenter {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stub := &stubAuthCenter{key: key, validTokens: validTokens}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/auth/verify-token", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&stub.verifyCalls, 1)
+		var req struct {
+			Token string `json:"token"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		userID, ok := stub.validTokens[req.Token]
+		if !ok {
+			writeStubJSON(w, map[string]interface{}{"success": false, "error": "invalid token"})
+			return
+		}
+		writeStubJSON(w, map[string]interface{}{"success": true, "data": map[string]string{"userId": userID}})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&stub.jwksCalls, 1)
+		writeStubJSON(w, JSONWebKeySet{Keys: []JSONWebKey{{
+			Kty: "RSA",
+			Kid: "stub-key",
+			Use: "sig",
+			Alg: TokenAlgRS256,
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	stub.server = httptest.NewServer(mux)
+	t.Cleanup(stub.server.Close)
+	return stub
+}
+
+func writeStubJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func (s *stubAuthCenter) client() *HTTPAuthCenterClient {
+	return NewHTTPAuthCenterClient(s.server.URL, s.server.URL+"/jwks", time.Second)
+}
+
+// signRS256 以账号中心私钥签发 token
+func (s *stubAuthCenter) signRS256(t *testing.T, userID string) string {
+	t.Helper()
+	input := encodeTestSegment(t, map[string]string{"alg": TokenAlgRS256, "kid": "stub-key", "typ": "JWT"}) + "." +
+		encodeTestSegment(t, testTokenPayload(userID))
+	signature, err := signSHA256WithRSA(s.key, []byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// signHS256 以共享的 JWT_SECRET 签发 token
+func signHS256(t *testing.T, secret, userID string) string {
+	t.Helper()
+	input := encodeTestSegment(t, map[string]string{"alg": TokenAlgHS256, "typ": "JWT"}) + "." +
+		encodeTestSegment(t, testTokenPayload(userID))
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(input))
+	return input + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func testTokenPayload(userID string) map[string]interface{} {
+	now := time.Now()
+	return map[string]interface{}{"userId": userID, "jti": "jti-" + userID, "iat": now.Unix(), "exp": now.Add(time.Hour).Unix()}
+}
+
+func encodeTestSegment(t *testing.T, v interface{}) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func TestAuthIdentityLocalVerifyWithoutAuthCenter(t *testing.T) {
+	stub := newStubAuthCenter(t, nil)
+	client := stub.client()
+	verifier := NewTokenVerifier("shared-secret", "", client, time.Hour)
+	service := NewAuthIdentityService(nil, client, verifier, nil, AuthIdentityOptions{VerifyMode: TokenVerifyModeLocal})
+	ctx := context.Background()
+
+	hsToken := signHS256(t, "shared-secret", "user-hs")
+	rsToken := stub.signRS256(t, "user-rs")
+
+	claims, err := service.verify(ctx, hsToken)
+	if err != nil || claims.UserID != "user-hs" {
+		t.Fatalf("HS256 claims = %+v, err = %v", claims, err)
+	}
+	claims, err = service.verify(ctx, rsToken)
+	if err != nil || claims.UserID != "user-rs" {
+		t.Fatalf("RS256 claims = %+v, err = %v", claims, err)
+	}
+	if calls := atomic.LoadInt32(&stub.verifyCalls); calls != 0 {
+		t.Fatalf("local mode called verify-token %d times", calls)
+	}
+	if calls := atomic.LoadInt32(&stub.jwksCalls); calls != 1 {
+		t.Fatalf("JWKS fetched %d times, want 1", calls)
+	}
+
+	// 账号中心不可用：未过期的 token 仍然可用（JWKS 公钥已缓存）
+	stub.server.Close()
+	if _, err := service.verify(ctx, hsToken); err != nil {
+		t.Fatalf("HS256 after auth center down: %v", err)
+	}
+	if _, err := service.verify(ctx, rsToken); err != nil {
+		t.Fatalf("RS256 after auth center down: %v", err)
+	}
+
+	// 签名不正确的 token 不会交给账号中心
+	if _, err := service.verify(ctx, signHS256(t, "wrong-secret", "user-hs")); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("forged token err = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestAuthIdentityRemoteVerifyCachesResult(t *testing.T) {
+	stub := newStubAuthCenter(t, map[string]string{"opaque-token": "user-remote"})
+	client := stub.client()
+	verifier := NewTokenVerifier("", "", client, time.Hour)
+	service := NewAuthIdentityService(nil, client, verifier, nil, AuthIdentityOptions{VerifyMode: TokenVerifyModeRemote, CacheTTL: time.Minute})
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		claims, err := service.verify(ctx, "opaque-token")
+		if err != nil || claims.UserID != "user-remote" {
+			t.Fatalf("claims = %+v, err = %v", claims, err)
+		}
+		if claims.TokenID == "" {
+			t.Fatal("remote token without jti should be revocable by digest")
+		}
+	}
+	if calls := atomic.LoadInt32(&stub.verifyCalls); calls != 1 {
+		t.Fatalf("verify-token called %d times, want 1", calls)
+	}
+
+	if _, err := service.verify(ctx, "unknown-token"); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("unknown token err = %v, want ErrInvalidToken", err)
+	}
+
+	// 账号中心不可用：已缓存的校验结果仍可用，未缓存的 token 返回不可用而不是无效
+	stub.server.Close()
+	if _, err := service.verify(ctx, "opaque-token"); err != nil {
+		t.Fatalf("cached token after auth center down: %v", err)
+	}
+	if _, err := service.verify(ctx, "another-token"); !errors.Is(err, ErrAuthCenterUnavailable) {
+		t.Fatalf("uncached token err = %v, want ErrAuthCenterUnavailable", err)
+	}
+}
+
+func TestUserCacheReturnsCopies(t *testing.T) {
+	cache := NewUserCache(10, time.Minute)
+	cache.Set(&models.User{AuthCenterUserID: "user-1", Roles: models.Roles{constants.RoleCreator}, Status: models.UserStatusActive})
+
+	first, ok := cache.Get("user-1")
+	if !ok {
+		t.Fatal("cache miss")
+	}
+	first.Status = models.UserStatusBanned
+	first.Roles[0] = constants.RoleSuperAdmin
+
+	second, _ := cache.Get("user-1")
+	if second.Status != models.UserStatusActive || second.Roles[0] != constants.RoleCreator {
+		t.Fatalf("cached user mutated by caller: %+v", second)
+	}
+
+	cache.Invalidate("user-1")
+	if _, ok := cache.Get("user-1"); ok {
+		t.Fatal("invalidated user still cached")
+	}
+	if NewUserCache(10, 0) != nil {
+		t.Fatal("zero TTL should disable the cache")
+	}
+}
@@ -0,0 +1,368 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"pr-business/constants"
+	"pr-business/models"
+)
+
+// 资源归属范围
+const (
+	PolicyScopeAny   = ""      // 不校验资源归属
+	PolicyScopeOrg   = "org"   // 资源属于当前身份所代表的商家或服务商
+	PolicyScopeOwner = "owner" // 资源属于当前用户本人
+)
+
+// PolicyRule 授权规则：哪些角色（员工另需哪个权限码）可以对哪个范围内的资源执行操作
+// 同一操作可以有多条规则，满足任一条即允许；没有规则的操作一律拒绝
+type PolicyRule struct {
+	Action     string
+	Roles      []string // 为空表示任意角色
+	Permission string   // 员工角色需要的权限码，管理员角色不校验
+	Scope      string
+}
+
+// PolicySubject 授权主体：当前身份和员工权限
+type PolicySubject struct {
+	RoleContext *models.RoleContext
+	UserIDs     []string        // 本地用户ID和账号中心用户ID（历史数据两种都有）
+	Permissions map[string]bool // 员工身份拥有的权限码
+}
+
+// PolicyResource 被操作的资源及其归属
+type PolicyResource struct {
+	Type       string
+	ID         string
+	MerchantID *uuid.UUID
+	ProviderID *uuid.UUID
+	OwnerID    string // 资源所属用户（本地用户ID或账号中心用户ID）
+}
+
+// PolicyDecision 授权结果
+type PolicyDecision struct {
+	Allowed    bool
+	Reason     string // 拒绝原因
+	OutOfScope bool   // 角色和权限满足但资源不属于本组织或本人，对外按资源不存在处理
+}
+
+// PolicyResourceLoader 按路由参数加载资源归属
+type PolicyResourceLoader func(id string) (*PolicyResource, error)
+
+// staffRoles 需要校验权限码的员工角色
+var staffRoles = []string{constants.RoleServiceProviderStaff, constants.RoleMerchantStaff}
+
+// orgRoles 代表商家或服务商操作的角色
+var orgRoles = []string{
+	constants.RoleMerchantAdmin, constants.RoleMerchantStaff,
+	constants.RoleServiceProviderAdmin, constants.RoleServiceProviderStaff,
+}
+
+// DefaultPolicyRules 系统授权策略表
+var DefaultPolicyRules = []PolicyRule{
+	// 营销活动：商家提交待审核，服务商创建直接发布；服务商只能管理本服务商的活动
+	{Action: constants.ActionCampaignCreate, Roles: []string{constants.RoleMerchantAdmin, constants.RoleServiceProviderAdmin}},
+	{Action: constants.ActionCampaignUpdate, Roles: []string{constants.RoleSuperAdmin}},
+	{Action: constants.ActionCampaignUpdate, Roles: []string{constants.RoleServiceProviderAdmin}, Scope: PolicyScopeOrg},
+	{Action: constants.ActionCampaignDelete, Roles: []string{constants.RoleSuperAdmin}},
+	{Action: constants.ActionCampaignDelete, Roles: []string{constants.RoleServiceProviderAdmin}, Scope: PolicyScopeOrg},
+	{Action: constants.ActionCampaignApprove, Roles: []string{constants.RoleServiceProviderAdmin}, Scope: PolicyScopeOrg},
+	{Action: constants.ActionCampaignManage, Roles: []string{constants.RoleSuperAdmin}},
+	{Action: constants.ActionCampaignManage, Roles: []string{constants.RoleServiceProviderAdmin}, Scope: PolicyScopeOrg},
+
+	// 任务：本组织活动的任务和达人自己的任务可以查看；达人接任务；服务商和有审核权限的员工审核本组织活动的任务
+	{Action: constants.ActionTaskView, Roles: []string{constants.RoleSuperAdmin}},
+	{Action: constants.ActionTaskView, Roles: orgRoles, Scope: PolicyScopeOrg},
+	{Action: constants.ActionTaskView, Roles: []string{constants.RoleCreator}, Scope: PolicyScopeOwner},
+	{Action: constants.ActionTaskHall, Roles: []string{constants.RoleCreator}},
+	{Action: constants.ActionTaskAccept, Roles: []string{constants.RoleCreator}},
+	{Action: constants.ActionTaskSubmit, Roles: []string{constants.RoleCreator}, Scope: PolicyScopeOwner},
+	{Action: constants.ActionTaskReview, Roles: []string{constants.RoleSuperAdmin}},
+	{Action: constants.ActionTaskReview, Roles: []string{constants.RoleServiceProviderAdmin}, Scope: PolicyScopeOrg},
+	{Action: constants.ActionTaskReview, Roles: staffRoles, Permission: constants.PermissionReviewTask, Scope: PolicyScopeOrg},
+	{Action: constants.ActionTaskReviewQueue, Roles: []string{constants.RoleSuperAdmin, constants.RoleServiceProviderAdmin, constants.RoleServiceProviderStaff}},
+
+	// 达人：服务商查看本组织达人（列表内按组织过滤）；达人本人可以编辑自己的资料
+	{Action: constants.ActionCreatorList, Roles: []string{constants.RoleSuperAdmin, constants.RoleServiceProviderAdmin, constants.RoleServiceProviderStaff}},
+	{Action: constants.ActionCreatorUpdate, Roles: []string{constants.RoleSuperAdmin, constants.RoleServiceProviderAdmin}},
+	{Action: constants.ActionCreatorUpdate, Roles: []string{constants.RoleServiceProviderStaff}, Permission: constants.PermissionEditCreatorInfo},
+	{Action: constants.ActionCreatorUpdate, Scope: PolicyScopeOwner},
+
+	// 组织：服务商管理本服务商下的商家，商家管理员管理本商家和员工，删除商家仅超管；
+	// 服务商由超管创建和删除，服务商管理员修改本服务商信息、管理员工
+	{Action: constants.ActionMerchantCreate, Roles: []string{constants.RoleSuperAdmin, constants.RoleServiceProviderAdmin}},
+	{Action: constants.ActionMerchantView, Roles: []string{constants.RoleSuperAdmin}},
+	{Action: constants.ActionMerchantView, Roles: orgRoles, Scope: PolicyScopeOrg},
+	{Action: constants.ActionMerchantUpdate, Roles: []string{constants.RoleSuperAdmin}},
+	{Action: constants.ActionMerchantUpdate, Roles: []string{constants.RoleServiceProviderAdmin, constants.RoleMerchantAdmin}, Scope: PolicyScopeOrg},
+	{Action: constants.ActionMerchantDelete, Roles: []string{constants.RoleSuperAdmin}},
+	{Action: constants.ActionMerchantStaffManage, Roles: []string{constants.RoleSuperAdmin}},
+	{Action: constants.ActionMerchantStaffManage, Roles: []string{constants.RoleMerchantAdmin}, Scope: PolicyScopeOrg},
+	{Action: constants.ActionServiceProviderCreate, Roles: []string{constants.RoleSuperAdmin}},
+	{Action: constants.ActionServiceProviderUpdate, Roles: []string{constants.RoleSuperAdmin}},
+	{Action: constants.ActionServiceProviderUpdate, Roles: []string{constants.RoleServiceProviderAdmin}, Scope: PolicyScopeOrg},
+	{Action: constants.ActionServiceProviderDelete, Roles: []string{constants.RoleSuperAdmin}},
+	{Action: constants.ActionServiceProviderStaffManage, Roles: []string{constants.RoleSuperAdmin}},
+	{Action: constants.ActionServiceProviderStaffManage, Roles: []string{constants.RoleServiceProviderAdmin}, Scope: PolicyScopeOrg},
+
+	// 财务：对账单按身份查看，汇总、审核、打款、开票和平台账户仅超管（平台账户和资金审计日志服务商管理员可查看）
+	{Action: constants.ActionStatementView, Roles: []string{constants.RoleSuperAdmin, constants.RoleServiceProviderAdmin, constants.RoleMerchantAdmin}},
+	{Action: constants.ActionStatementView, Roles: staffRoles, Permission: constants.PermissionViewFinancialReports},
+	{Action: constants.ActionStatementSummary, Roles: []string{constants.RoleSuperAdmin}},
+	{Action: constants.ActionRechargeAudit, Roles: []string{constants.RoleSuperAdmin}},
+	{Action: constants.ActionRechargeRefund, Roles: []string{constants.RoleSuperAdmin}},
+	{Action: constants.ActionWithdrawalReview, Roles: []string{constants.RoleSuperAdmin}},
+	{Action: constants.ActionWithdrawalPayout, Roles: []string{constants.RoleSuperAdmin}},
+	{Action: constants.ActionInvoiceIssue, Roles: []string{constants.RoleSuperAdmin}},
+	{Action: constants.ActionTaxCertificateView, Roles: []string{constants.RoleSuperAdmin}},
+	{Action: constants.ActionFinancialAuditView, Roles: []string{constants.RoleSuperAdmin, constants.RoleServiceProviderAdmin}},
+	{Action: constants.ActionSystemAccountView, Roles: []string{constants.RoleSuperAdmin, constants.RoleServiceProviderAdmin}},
+	{Action: constants.ActionCashAccountView, Roles: []string{constants.RoleSuperAdmin, constants.RoleServiceProviderAdmin}},
+	{Action: constants.ActionCashAccountManage, Roles: []string{constants.RoleSuperAdmin}},
+
+	// 平台配置与运维：仅超级管理员
+	{Action: constants.ActionTaxRuleManage, Roles: []string{constants.RoleSuperAdmin}},
+	{Action: constants.ActionSettlementJobManage, Roles: []string{constants.RoleSuperAdmin}},
+	{Action: constants.ActionReconciliationManage, Roles: []string{constants.RoleSuperAdmin}},
+	{Action: constants.ActionWithdrawalPolicyManage, Roles: []string{constants.RoleSuperAdmin}},
+	{Action: constants.ActionExchangeRateManage, Roles: []string{constants.RoleSuperAdmin}},
+	{Action: constants.ActionTransactionTypeManage, Roles: []string{constants.RoleSuperAdmin}},
+	{Action: constants.ActionPlatformFeeManage, Roles: []string{constants.RoleSuperAdmin}},
+}
+
+// EvaluatePolicy 按策略表判断主体能否对资源执行操作
+// resource 为空时不校验资源归属（集合类接口，或查询能力时表示"本范围内的资源"）
+func EvaluatePolicy(rules []PolicyRule, subject PolicySubject, action string, resource *PolicyResource) PolicyDecision {
+	reason := "无权限"
+	defined := false
+	outOfScope := false
+
+	for _, rule := range rules {
+		if rule.Action != action {
+			continue
+		}
+		defined = true
+
+		rc := subject.RoleContext
+		if rc == nil || (len(rule.Roles) > 0 && !rc.Is(rule.Roles...)) {
+			continue
+		}
+		if rule.Permission != "" && rc.Is(staffRoles...) && !subject.Permissions[rule.Permission] {
+			reason = fmt.Sprintf("缺少权限 %s", rule.Permission)
+			continue
+		}
+		if resource != nil && !inScope(rule.Scope, subject, resource) {
+			reason = "无权操作其他组织或其他用户的资源"
+			outOfScope = true
+			continue
+		}
+		return PolicyDecision{Allowed: true}
+	}
+
+	if !defined {
+		return PolicyDecision{Reason: "未定义授权策略的操作"}
+	}
+	return PolicyDecision{Reason: reason, OutOfScope: outOfScope}
+}
+
+// inScope 资源是否在规则的归属范围内
+func inScope(scope string, subject PolicySubject, resource *PolicyResource) bool {
+	switch scope {
+	case PolicyScopeAny:
+		return true
+	case PolicyScopeOrg:
+		rc := subject.RoleContext
+		if rc.OrgID == nil {
+			return false
+		}
+		switch rc.OrgType {
+		case models.OrgTypeMerchant:
+			return resource.MerchantID != nil && *resource.MerchantID == *rc.OrgID
+		case models.OrgTypeServiceProvider:
+			return resource.ProviderID != nil && *resource.ProviderID == *rc.OrgID
+		}
+		return false
+	case PolicyScopeOwner:
+		for _, id := range subject.UserIDs {
+			if id != "" && id == resource.OwnerID {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+// AuthorizationService 集中授权：按策略表判断操作，提供资源归属加载器和当前身份的能力列表
+type AuthorizationService struct {
+	db    *gorm.DB
+	rules []PolicyRule
+}
+
+// NewAuthorizationService 创建授权服务
+func NewAuthorizationService(db *gorm.DB, rules []PolicyRule) *AuthorizationService {
+	return &AuthorizationService{db: db, rules: rules}
+}
+
+// Subject 构造授权主体，员工身份加载权限码
+func (s *AuthorizationService) Subject(user *models.User, rc *models.RoleContext) (PolicySubject, error) {
+	subject := PolicySubject{
+		RoleContext: rc,
+		UserIDs:     []string{user.ID, user.AuthCenterUserID},
+		Permissions: map[string]bool{},
+	}
+	if rc == nil || rc.StaffID == nil {
+		return subject, nil
+	}
+
+	var codes []string
+	var err error
+	switch {
+	case rc.Is(constants.RoleServiceProviderStaff):
+		err = s.db.Model(&models.ServiceProviderStaffPermission{}).Where("staff_id = ?", *rc.StaffID).Pluck("permission_code", &codes).Error
+	case rc.Is(constants.RoleMerchantStaff):
+		err = s.db.Model(&models.MerchantStaffPermission{}).Where("staff_id = ?", *rc.StaffID).Pluck("permission_code", &codes).Error
+	}
+	if err != nil {
+		return subject, err
+	}
+	for _, code := range codes {
+		subject.Permissions[code] = true
+	}
+	return subject, nil
+}
+
+// Authorize 判断主体能否对资源执行操作
+func (s *AuthorizationService) Authorize(subject PolicySubject, action string, resource *PolicyResource) PolicyDecision {
+	return EvaluatePolicy(s.rules, subject, action, resource)
+}
+
+// Capabilities 主体可以执行的操作，按名称排序
+// 不看具体资源：限定组织或本人范围的操作表示可以对本组织或本人的资源执行
+func (s *AuthorizationService) Capabilities(subject PolicySubject) []string {
+	seen := map[string]bool{}
+	actions := []string{}
+	for _, rule := range s.rules {
+		if seen[rule.Action] {
+			continue
+		}
+		seen[rule.Action] = true
+		if EvaluatePolicy(s.rules, subject, rule.Action, nil).Allowed {
+			actions = append(actions, rule.Action)
+		}
+	}
+	sort.Strings(actions)
+	return actions
+}
+
+// CampaignResource 活动归属加载器
+func (s *AuthorizationService) CampaignResource(id string) (*PolicyResource, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrCampaignNotFound
+	}
+	var campaign models.Campaign
+	if err := s.db.Select("id", "merchant_id", "provider_id", "created_by").Where("id = ?", id).First(&campaign).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCampaignNotFound
+		}
+		return nil, err
+	}
+	return &PolicyResource{
+		Type:       "campaign",
+		ID:         campaign.ID.String(),
+		MerchantID: &campaign.MerchantID,
+		ProviderID: campaign.ProviderID,
+		OwnerID:    campaign.CreatedBy,
+	}, nil
+}
+
+// TaskResource 任务归属加载器：组织归属取所属活动，本人为接任务的达人
+func (s *AuthorizationService) TaskResource(id string) (*PolicyResource, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrTaskNotFound
+	}
+	var row struct {
+		MerchantID uuid.UUID
+		ProviderID *uuid.UUID
+		UserID     *string
+	}
+	result := s.db.Table("tasks").
+		Select("campaigns.merchant_id, campaigns.provider_id, creators.user_id").
+		Joins("JOIN campaigns ON campaigns.id = tasks.campaign_id").
+		Joins("LEFT JOIN creators ON creators.id = tasks.creator_id").
+		Where("tasks.id = ?", id).
+		Limit(1).
+		Scan(&row)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrTaskNotFound
+	}
+
+	resource := &PolicyResource{Type: "task", ID: id, MerchantID: &row.MerchantID, ProviderID: row.ProviderID}
+	if row.UserID != nil {
+		resource.OwnerID = *row.UserID
+	}
+	return resource, nil
+}
+
+// CreatorResource 达人归属加载器：本人为达人对应的用户
+func (s *AuthorizationService) CreatorResource(id string) (*PolicyResource, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrCreatorNotFound
+	}
+	var creator models.Creator
+	if err := s.db.Select("id", "user_id").Where("id = ?", id).First(&creator).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCreatorNotFound
+		}
+		return nil, err
+	}
+	return &PolicyResource{Type: "creator", ID: creator.ID.String(), OwnerID: creator.UserID}, nil
+}
+
+// MerchantResource 商家归属加载器：商家本身及其所属服务商，本人为商家管理员
+func (s *AuthorizationService) MerchantResource(id string) (*PolicyResource, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrMerchantNotFound
+	}
+	var merchant models.Merchant
+	if err := s.db.Select("id", "provider_id", "admin_id").Where("id = ?", id).First(&merchant).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMerchantNotFound
+		}
+		return nil, err
+	}
+	return &PolicyResource{
+		Type:       "merchant",
+		ID:         merchant.ID.String(),
+		MerchantID: &merchant.ID,
+		ProviderID: &merchant.ProviderID,
+		OwnerID:    merchant.AdminID,
+	}, nil
+}
+
+// ServiceProviderResource 服务商归属加载器：服务商本身，本人为服务商管理员
+func (s *AuthorizationService) ServiceProviderResource(id string) (*PolicyResource, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrServiceProviderNotFound
+	}
+	var provider models.ServiceProvider
+	if err := s.db.Select("id", "admin_id").Where("id = ?", id).First(&provider).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrServiceProviderNotFound
+		}
+		return nil, err
+	}
+	resource := &PolicyResource{Type: "service_provider", ID: provider.ID.String(), ProviderID: &provider.ID}
+	if provider.AdminID != nil {
+		resource.OwnerID = *provider.AdminID
+	}
+	return resource, nil
+}
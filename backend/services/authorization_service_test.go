@@ -0,0 +1,169 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+
+	"pr-business/constants"
+	"pr-business/models"
+)
+
+func TestEvaluatePolicy(t *testing.T) {
+	providerA, providerB := uuid.New(), uuid.New()
+	merchantA, merchantB := uuid.New(), uuid.New()
+	staffID := uuid.New()
+
+	subject := func(role, orgType string, orgID *uuid.UUID, permissions ...string) PolicySubject {
+		s := PolicySubject{
+			RoleContext: &models.RoleContext{Role: role, OrgType: orgType, OrgID: orgID},
+			UserIDs:     []string{"user-1", "auth-user-1"},
+			Permissions: map[string]bool{},
+		}
+		if role == constants.RoleServiceProviderStaff || role == constants.RoleMerchantStaff {
+			s.RoleContext.StaffID = &staffID
+		}
+		for _, code := range permissions {
+			s.Permissions[code] = true
+		}
+		return s
+	}
+	superAdmin := subject(constants.RoleSuperAdmin, "", nil)
+	providerAdmin := subject(constants.RoleServiceProviderAdmin, models.OrgTypeServiceProvider, &providerA)
+	providerStaff := subject(constants.RoleServiceProviderStaff, models.OrgTypeServiceProvider, &providerA)
+	providerReviewer := subject(constants.RoleServiceProviderStaff, models.OrgTypeServiceProvider, &providerA, constants.PermissionReviewTask)
+	merchantAdmin := subject(constants.RoleMerchantAdmin, models.OrgTypeMerchant, &merchantA)
+	merchantStaff := subject(constants.RoleMerchantStaff, models.OrgTypeMerchant, &merchantA, constants.PermissionViewFinancialReports)
+	creator := subject(constants.RoleCreator, "", nil)
+	// 同时拥有服务商管理员角色，但当前激活的是达人身份
+	inactiveProviderAdmin := subject(constants.RoleCreator, "", nil)
+
+	campaignOf := func(merchantID, providerID uuid.UUID, owner string) *PolicyResource {
+		return &PolicyResource{Type: "campaign", ID: uuid.NewString(), MerchantID: &merchantID, ProviderID: &providerID, OwnerID: owner}
+	}
+	ownCampaign := campaignOf(merchantA, providerA, "user-1")
+	otherCampaign := campaignOf(merchantB, providerB, "user-1")
+	ownTask := &PolicyResource{Type: "task", ID: uuid.NewString(), MerchantID: &merchantA, ProviderID: &providerA, OwnerID: "auth-user-1"}
+	otherTask := &PolicyResource{Type: "task", ID: uuid.NewString(), MerchantID: &merchantA, ProviderID: &providerA, OwnerID: "user-2"}
+	ownCreator := &PolicyResource{Type: "creator", ID: uuid.NewString(), OwnerID: "user-1"}
+	otherCreator := &PolicyResource{Type: "creator", ID: uuid.NewString(), OwnerID: "user-2"}
+	ownMerchant := &PolicyResource{Type: "merchant", ID: merchantA.String(), MerchantID: &merchantA, ProviderID: &providerA}
+	otherMerchant := &PolicyResource{Type: "merchant", ID: merchantB.String(), MerchantID: &merchantB, ProviderID: &providerB}
+	ownProvider := &PolicyResource{Type: "service_provider", ID: providerA.String(), ProviderID: &providerA}
+	otherProvider := &PolicyResource{Type: "service_provider", ID: providerB.String(), ProviderID: &providerB}
+
+	tests := []struct {
+		name       string
+		subject    PolicySubject
+		action     string
+		resource   *PolicyResource
+		allowed    bool
+		outOfScope bool
+	}{
+		// 角色
+		{"超管修改任意活动", superAdmin, constants.ActionCampaignUpdate, otherCampaign, true, false},
+		{"服务商管理员修改本服务商活动", providerAdmin, constants.ActionCampaignUpdate, ownCampaign, true, false},
+		{"商家管理员不能修改活动", merchantAdmin, constants.ActionCampaignUpdate, ownCampaign, false, false},
+		{"达人不能审核活动", creator, constants.ActionCampaignApprove, ownCampaign, false, false},
+		{"服务商管理员不能管理个税规则", providerAdmin, constants.ActionTaxRuleManage, nil, false, false},
+		{"超管管理个税规则", superAdmin, constants.ActionTaxRuleManage, nil, true, false},
+		{"超管审核充值订单", superAdmin, constants.ActionRechargeAudit, nil, true, false},
+		{"商家管理员不能审核充值订单", merchantAdmin, constants.ActionRechargeAudit, nil, false, false},
+		{"超管审核提现", superAdmin, constants.ActionWithdrawalReview, nil, true, false},
+		{"服务商管理员不能审核提现", providerAdmin, constants.ActionWithdrawalReview, nil, false, false},
+		{"商家管理员不能提交打款", merchantAdmin, constants.ActionWithdrawalPayout, nil, false, false},
+		{"商家管理员不能开具发票", merchantAdmin, constants.ActionInvoiceIssue, nil, false, false},
+		{"商家管理员不能退款", merchantAdmin, constants.ActionRechargeRefund, nil, false, false},
+		{"服务商管理员不能管理手续费规则", providerAdmin, constants.ActionPlatformFeeManage, nil, false, false},
+		{"商家员工不能查看对账单汇总", merchantStaff, constants.ActionStatementSummary, nil, false, false},
+		{"达人不能查看他人个税凭证", creator, constants.ActionTaxCertificateView, nil, false, false},
+		{"服务商管理员查看平台账户", providerAdmin, constants.ActionSystemAccountView, nil, true, false},
+		{"服务商管理员不能调整现金账户", providerAdmin, constants.ActionCashAccountManage, nil, false, false},
+		{"商家管理员不能查看资金审计日志", merchantAdmin, constants.ActionFinancialAuditView, nil, false, false},
+		{"服务商管理员不能创建服务商", providerAdmin, constants.ActionServiceProviderCreate, nil, false, false},
+		{"没有身份", PolicySubject{UserIDs: []string{"user-1"}}, constants.ActionTaskHall, nil, false, false},
+		{"未定义的操作", superAdmin, "campaign.unknown", nil, false, false},
+
+		// 非激活角色：权限只看当前激活的身份
+		{"非激活的服务商管理员不能审核活动", inactiveProviderAdmin, constants.ActionCampaignApprove, ownCampaign, false, false},
+		{"非激活的服务商管理员不能审核任务", inactiveProviderAdmin, constants.ActionTaskReview, ownTask, false, false},
+		{"非激活的服务商管理员不能查看平台账户", inactiveProviderAdmin, constants.ActionSystemAccountView, nil, false, false},
+
+		// 员工权限码
+		{"服务商员工缺少审核权限", providerStaff, constants.ActionTaskReview, ownTask, false, false},
+		{"服务商员工有审核权限", providerReviewer, constants.ActionTaskReview, ownTask, true, false},
+		{"商家员工有财务报表权限", merchantStaff, constants.ActionStatementView, nil, true, false},
+		{"服务商员工缺少财务报表权限", providerStaff, constants.ActionStatementView, nil, false, false},
+
+		// 组织归属
+		{"服务商管理员不能修改其他服务商的活动", providerAdmin, constants.ActionCampaignUpdate, otherCampaign, false, true},
+		{"有审核权限的员工不能审核其他组织的任务", providerReviewer, constants.ActionTaskReview, &PolicyResource{Type: "task", MerchantID: &merchantB, ProviderID: &providerB}, false, true},
+		{"商家管理员查看本商家活动的任务", merchantAdmin, constants.ActionTaskView, ownTask, true, false},
+		{"商家管理员管理本商家员工", merchantAdmin, constants.ActionMerchantStaffManage, ownMerchant, true, false},
+		{"商家管理员不能管理其他商家员工", merchantAdmin, constants.ActionMerchantStaffManage, otherMerchant, false, true},
+		{"服务商管理员修改本服务商下的商家", providerAdmin, constants.ActionMerchantUpdate, ownMerchant, true, false},
+		{"服务商管理员不能修改其他服务商的商家", providerAdmin, constants.ActionMerchantUpdate, otherMerchant, false, true},
+		{"服务商管理员不能删除商家", providerAdmin, constants.ActionMerchantDelete, ownMerchant, false, false},
+		{"服务商管理员管理本服务商员工", providerAdmin, constants.ActionServiceProviderStaffManage, ownProvider, true, false},
+		{"服务商管理员不能管理其他服务商员工", providerAdmin, constants.ActionServiceProviderStaffManage, otherProvider, false, true},
+		{"服务商管理员不能修改其他服务商", providerAdmin, constants.ActionServiceProviderUpdate, otherProvider, false, true},
+		{"服务商员工不能管理员工", providerStaff, constants.ActionServiceProviderStaffManage, ownProvider, false, false},
+
+		// 本人归属
+		{"达人提交自己的任务", creator, constants.ActionTaskSubmit, ownTask, true, false},
+		{"达人不能提交别人的任务", creator, constants.ActionTaskSubmit, otherTask, false, true},
+		{"达人查看自己的任务", creator, constants.ActionTaskView, ownTask, true, false},
+		{"达人不能查看别人的任务", creator, constants.ActionTaskView, otherTask, false, true},
+		{"达人编辑自己的资料", creator, constants.ActionCreatorUpdate, ownCreator, true, false},
+		{"达人不能编辑别人的资料", creator, constants.ActionCreatorUpdate, otherCreator, false, true},
+		{"服务商管理员不能代达人提交任务", providerAdmin, constants.ActionTaskSubmit, ownTask, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := EvaluatePolicy(DefaultPolicyRules, tt.subject, tt.action, tt.resource)
+			if decision.Allowed != tt.allowed {
+				t.Fatalf("Allowed = %v, want %v (reason: %s)", decision.Allowed, tt.allowed, decision.Reason)
+			}
+			if decision.OutOfScope != tt.outOfScope {
+				t.Fatalf("OutOfScope = %v, want %v (reason: %s)", decision.OutOfScope, tt.outOfScope, decision.Reason)
+			}
+			if !decision.Allowed && decision.Reason == "" {
+				t.Fatal("denied without reason")
+			}
+		})
+	}
+}
+
+func TestCapabilitiesFollowActiveRole(t *testing.T) {
+	authz := NewAuthorizationService(nil, DefaultPolicyRules)
+	orgID := uuid.New()
+
+	creator := authz.Capabilities(PolicySubject{
+		RoleContext: &models.RoleContext{Role: constants.RoleCreator},
+		UserIDs:     []string{"user-1"},
+	})
+	providerAdmin := authz.Capabilities(PolicySubject{
+		RoleContext: &models.RoleContext{Role: constants.RoleServiceProviderAdmin, OrgType: models.OrgTypeServiceProvider, OrgID: &orgID},
+		UserIDs:     []string{"user-1"},
+	})
+
+	if !contains(creator, constants.ActionTaskSubmit) || contains(creator, constants.ActionCampaignApprove) {
+		t.Fatalf("creator capabilities = %v", creator)
+	}
+	if !contains(providerAdmin, constants.ActionCampaignApprove) || contains(providerAdmin, constants.ActionTaskSubmit) {
+		t.Fatalf("provider admin capabilities = %v", providerAdmin)
+	}
+	if contains(providerAdmin, constants.ActionSettlementJobManage) {
+		t.Fatalf("provider admin should not manage settlement jobs: %v", providerAdmin)
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,320 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"pr-business/constants"
+	"pr-business/models"
+)
+
+// CampaignLifecycleService 营销活动状态机
+// 所有状态变更在同一事务内完成：锁定活动 → 校验转换 → 执行附带动作（关闭时退款）→ 更新状态 → 写审计日志
+type CampaignLifecycleService struct {
+	db                *gorm.DB
+	settlementService *SettlementService
+	auditService      *AuditService
+}
+
+// NewCampaignLifecycleService 创建营销活动状态机服务
+func NewCampaignLifecycleService(db *gorm.DB, settlementService *SettlementService, auditService *AuditService) *CampaignLifecycleService {
+	return &CampaignLifecycleService{
+		db:                db,
+		settlementService: settlementService,
+		auditService:      auditService,
+	}
+}
+
+// CampaignTransitionActor 发起状态变更的操作者信息（写入审计日志）
+type CampaignTransitionActor struct {
+	UserID    string
+	Reason    string
+	IPAddress string
+	UserAgent string
+}
+
+// systemActor 自动任务使用的操作者
+func systemActor(reason string) CampaignTransitionActor {
+	return CampaignTransitionActor{UserID: "system", Reason: reason}
+}
+
+// campaignTransitionActions 状态变更接口可以变更到的目标状态及对应的审计动作
+var campaignTransitionActions = map[models.CampaignStatus]string{
+	models.CampaignStatusPendingApproval: constants.AuditActionCampaignSubmit,
+	models.CampaignStatusOpen:            constants.AuditActionCampaignResume,
+	models.CampaignStatusPaused:          constants.AuditActionCampaignPause,
+	models.CampaignStatusClosed:          constants.AuditActionCampaignClose,
+}
+
+// Transition 变更活动状态，是否允许由活动状态机（CampaignStatus.CanTransitionTo）决定
+func (s *CampaignLifecycleService) Transition(campaignID string, target models.CampaignStatus, actor CampaignTransitionActor) (*models.Campaign, error) {
+	action, ok := campaignTransitionActions[target]
+	if !ok {
+		return nil, fmt.Errorf("%w: 不能变更为 %s", ErrInvalidCampaignTransition, target)
+	}
+	return s.transition(campaignID, target, action, actor)
+}
+
+// Pause 暂停活动：停止接单，已接任务可继续提交和审核
+func (s *CampaignLifecycleService) Pause(campaignID string, actor CampaignTransitionActor) (*models.Campaign, error) {
+	return s.transition(campaignID, models.CampaignStatusPaused, constants.AuditActionCampaignPause, actor)
+}
+
+// Resume 恢复已暂停的活动
+func (s *CampaignLifecycleService) Resume(campaignID string, actor CampaignTransitionActor) (*models.Campaign, error) {
+	return s.transition(campaignID, models.CampaignStatusOpen, constants.AuditActionCampaignResume, actor)
+}
+
+// Close 关闭活动并在同一事务内退还未完成名额的积分
+func (s *CampaignLifecycleService) Close(campaignID string, actor CampaignTransitionActor) (*models.Campaign, error) {
+	return s.transition(campaignID, models.CampaignStatusClosed, constants.AuditActionCampaignClose, actor)
+}
+
+// transition 在独立事务中执行状态转换
+func (s *CampaignLifecycleService) transition(campaignID string, target models.CampaignStatus, action string, actor CampaignTransitionActor) (*models.Campaign, error) {
+	id, err := uuid.Parse(campaignID)
+	if err != nil {
+		return nil, ErrCampaignNotFound
+	}
+
+	var campaign models.Campaign
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND deleted_at IS NULL", id).
+			First(&campaign).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrCampaignNotFound
+			}
+			return err
+		}
+
+		// 发布活动需要托管积分，只能走审核接口；这里变更为 OPEN 仅用于恢复已暂停的活动
+		if target == models.CampaignStatusOpen && campaign.Status != models.CampaignStatusPaused {
+			return fmt.Errorf("%w: %s 活动请使用审核接口发布", ErrInvalidCampaignTransition, campaign.Status)
+		}
+
+		return s.TransitionTx(tx, &campaign, target, action, actor)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &campaign, nil
+}
+
+// TransitionTx 在调用方事务内执行状态转换，campaign 必须已在该事务内加锁读取
+func (s *CampaignLifecycleService) TransitionTx(tx *gorm.DB, campaign *models.Campaign, target models.CampaignStatus, action string, actor CampaignTransitionActor) error {
+	from := campaign.Status
+	if !from.CanTransitionTo(target) {
+		return fmt.Errorf("%w: %s → %s", ErrInvalidCampaignTransition, from, target)
+	}
+
+	// 关闭活动：退还未完成名额的积分
+	if target == models.CampaignStatusClosed {
+		if err := s.settlementService.SettleCampaignAfterCloseTx(tx, campaign); err != nil {
+			return fmt.Errorf("活动关闭退款失败: %w", err)
+		}
+	}
+
+	if err := tx.Model(campaign).Updates(map[string]interface{}{
+		"status":     target,
+		"updated_at": time.Now(),
+	}).Error; err != nil {
+		return fmt.Errorf("更新活动状态失败: %w", err)
+	}
+	campaign.Status = target
+
+	changes := map[string]interface{}{
+		"from": from,
+		"to":   target,
+	}
+	if actor.Reason != "" {
+		changes["reason"] = actor.Reason
+	}
+	return s.auditService.WithTx(tx).LogFinancialOperation(
+		actor.UserID,
+		action,
+		constants.AuditResourceCampaign,
+		campaign.ID.String(),
+		changes,
+		actor.IPAddress,
+		actor.UserAgent,
+	)
+}
+
+// CloseExpiredCampaigns 关闭已过提交截止时间的活动，返回关闭数量
+// 单个活动失败不影响其他活动，下一轮会重试
+func (s *CampaignLifecycleService) CloseExpiredCampaigns(now time.Time) (int, error) {
+	var ids []uuid.UUID
+	if err := s.db.Model(&models.Campaign{}).
+		Where("status IN ? AND submission_deadline < ? AND deleted_at IS NULL",
+			[]models.CampaignStatus{models.CampaignStatusOpen, models.CampaignStatusPaused}, now).
+		Pluck("id", &ids).Error; err != nil {
+		return 0, fmt.Errorf("查询过期活动失败: %w", err)
+	}
+
+	closed := 0
+	for _, id := range ids {
+		if _, err := s.Close(id.String(), systemActor("提交截止时间已过，自动关闭")); err != nil {
+			// 并发关闭（如手动关闭）时状态已变，忽略
+			if errors.Is(err, ErrInvalidCampaignTransition) {
+				continue
+			}
+			log.Printf("自动关闭活动 %s 失败: %v", id, err)
+			continue
+		}
+		closed++
+	}
+
+	return closed, nil
+}
+
+// MaterializeTaskSlots 补齐活动的任务名额，使未下架的名额数等于 Quota，返回新建数量
+// 在活动发布（审核通过或服务商直接发布）的同一事务内调用；重复调用不会多建
+func (s *CampaignLifecycleService) MaterializeTaskSlots(tx *gorm.DB, campaign *models.Campaign) (int, error) {
+	var stats struct {
+		Active  int
+		MaxSlot int
+	}
+	if err := tx.Model(&models.Task{}).
+		Select("COUNT(*) FILTER (WHERE status <> ?) AS active, COALESCE(MAX(task_slot_number), 0) AS max_slot", models.TaskStatusRetired).
+		Where("campaign_id = ?", campaign.ID).
+		Scan(&stats).Error; err != nil {
+		return 0, fmt.Errorf("统计任务名额失败: %w", err)
+	}
+
+	missing := campaign.Quota - stats.Active
+	for i := 1; i <= missing; i++ {
+		task := models.Task{
+			CampaignID:     campaign.ID,
+			TaskSlotNumber: stats.MaxSlot + i,
+			Status:         models.TaskStatusOpen,
+		}
+		if err := tx.Create(&task).Error; err != nil {
+			return 0, fmt.Errorf("创建任务名额失败: %w", err)
+		}
+	}
+
+	if missing < 0 {
+		missing = 0
+	}
+	return missing, nil
+}
+
+// ChangeQuota 调整开放中（或暂停中）活动的名额
+// 增加：从商家可用余额托管差额并新建名额；减少：下架编号最大的空闲名额并退还差额
+func (s *CampaignLifecycleService) ChangeQuota(campaignID string, quota int, actor CampaignTransitionActor) (*models.Campaign, error) {
+	id, err := uuid.Parse(campaignID)
+	if err != nil {
+		return nil, ErrCampaignNotFound
+	}
+	if quota <= 0 {
+		return nil, errors.New("名额必须大于0")
+	}
+
+	var campaign models.Campaign
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND deleted_at IS NULL", id).
+			First(&campaign).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrCampaignNotFound
+			}
+			return err
+		}
+
+		if campaign.Status != models.CampaignStatusOpen && campaign.Status != models.CampaignStatusPaused {
+			return fmt.Errorf("%w: 只能调整开放中或已暂停活动的名额", ErrInvalidCampaignTransition)
+		}
+
+		oldQuota := campaign.Quota
+		delta := quota - oldQuota
+		if delta == 0 {
+			return nil
+		}
+
+		if delta > 0 {
+			if err := s.settlementService.FundCampaignSlots(tx, &campaign, delta,
+				fmt.Sprintf("活动增加名额托管积分：%s（%d → %d）", campaign.Title, oldQuota, quota)); err != nil {
+				return err
+			}
+		} else {
+			if err := s.retireOpenSlots(tx, &campaign, -delta); err != nil {
+				return err
+			}
+			if err := s.settlementService.RefundCampaignSlots(tx, &campaign, -delta,
+				fmt.Sprintf("活动减少名额退还积分：%s（%d → %d）", campaign.Title, oldQuota, quota)); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&campaign).Updates(map[string]interface{}{
+			"quota":           quota,
+			"campaign_amount": campaign.TaskAmount * quota,
+			"updated_at":      time.Now(),
+		}).Error; err != nil {
+			return fmt.Errorf("更新活动名额失败: %w", err)
+		}
+		campaign.Quota = quota
+		campaign.CampaignAmount = campaign.TaskAmount * quota
+
+		if delta > 0 {
+			if _, err := s.MaterializeTaskSlots(tx, &campaign); err != nil {
+				return err
+			}
+		}
+
+		changes := map[string]interface{}{
+			"from_quota": oldQuota,
+			"to_quota":   quota,
+			"amount":     delta * campaign.TaskAmount,
+		}
+		if actor.Reason != "" {
+			changes["reason"] = actor.Reason
+		}
+		return s.auditService.WithTx(tx).LogFinancialOperation(
+			actor.UserID,
+			constants.AuditActionCampaignQuota,
+			constants.AuditResourceCampaign,
+			campaign.ID.String(),
+			changes,
+			actor.IPAddress,
+			actor.UserAgent,
+		)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &campaign, nil
+}
+
+// retireOpenSlots 下架编号最大的 count 个空闲名额；空闲名额不足时返回 ErrQuotaBelowCommitted
+func (s *CampaignLifecycleService) retireOpenSlots(tx *gorm.DB, campaign *models.Campaign, count int) error {
+	// 只更新仍为 OPEN 的名额，与并发接单互斥：被接走的名额不会被下架
+	result := tx.Exec(`
+		UPDATE tasks SET status = ?, version = version + 1, updated_at = ?
+		WHERE id IN (
+			SELECT id FROM tasks
+			WHERE campaign_id = ? AND status = ?
+			ORDER BY task_slot_number DESC
+			LIMIT ?
+			FOR UPDATE
+		) AND status = ?`,
+		models.TaskStatusRetired, time.Now(),
+		campaign.ID, models.TaskStatusOpen, count,
+		models.TaskStatusOpen)
+	if result.Error != nil {
+		return fmt.Errorf("下架任务名额失败: %w", result.Error)
+	}
+	if int(result.RowsAffected) < count {
+		return ErrQuotaBelowCommitted
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"testing"
+
+	"pr-business/models"
+)
+
+func TestCampaignTransitionActionsFollowStateMachine(t *testing.T) {
+	statuses := []models.CampaignStatus{
+		models.CampaignStatusDraft,
+		models.CampaignStatusPendingApproval,
+		models.CampaignStatusOpen,
+		models.CampaignStatusPaused,
+		models.CampaignStatusClosed,
+	}
+
+	// 状态变更接口的每个目标状态都至少能从一个状态到达
+	for target := range campaignTransitionActions {
+		reachable := false
+		for _, from := range statuses {
+			if from.CanTransitionTo(target) {
+				reachable = true
+			}
+		}
+		if !reachable {
+			t.Errorf("target %s is not reachable in the state machine", target)
+		}
+	}
+
+	tests := []struct {
+		from, to models.CampaignStatus
+		allowed  bool
+	}{
+		{models.CampaignStatusDraft, models.CampaignStatusPendingApproval, true},
+		{models.CampaignStatusPendingApproval, models.CampaignStatusOpen, true},
+		{models.CampaignStatusPendingApproval, models.CampaignStatusClosed, false},
+		{models.CampaignStatusOpen, models.CampaignStatusPendingApproval, false},
+		{models.CampaignStatusPaused, models.CampaignStatusOpen, true},
+		{models.CampaignStatusClosed, models.CampaignStatusOpen, false},
+	}
+	for _, tt := range tests {
+		if got := tt.from.CanTransitionTo(tt.to); got != tt.allowed {
+			t.Errorf("%s → %s allowed = %v, want %v", tt.from, tt.to, got, tt.allowed)
+		}
+	}
+
+	if _, ok := campaignTransitionActions[models.CampaignStatusDraft]; ok {
+		t.Error("campaigns cannot be moved back to draft")
+	}
+}
@@ -3,7 +3,6 @@ package services
 import (
 	"errors"
 	"fmt"
-	"time"
 	"pr-business/models"
 
 	"github.com/google/uuid"
@@ -14,12 +13,14 @@ import (
 type CashAccountService struct {
 	db               *gorm.DB
 	validatorService *ValidatorService
+	ledgerService    *LedgerService
 }
 
-func NewCashAccountService(db *gorm.DB, validatorService *ValidatorService) *CashAccountService {
+func NewCashAccountService(db *gorm.DB, validatorService *ValidatorService, ledgerService *LedgerService) *CashAccountService {
 	return &CashAccountService{
 		db:               db,
 		validatorService: validatorService,
+		ledgerService:    ledgerService,
 	}
 }
 
@@ -33,44 +34,76 @@ func (s *CashAccountService) GetActiveCashAccounts(accountType string) ([]models
 	return accounts, nil
 }
 
-// UpdateBalance 更新现金账户余额
+// UpdateBalance 更新现金账户余额（对手方为外部资金清算账户）
+// tx 为空时自动开启事务
 func (s *CashAccountService) UpdateBalance(accountID string, amount int, description string, tx *gorm.DB) error {
 	id, err := uuid.Parse(accountID)
 	if err != nil {
 		return fmt.Errorf("无效的账户ID: %w", err)
 	}
 
-	account := models.CashAccount{ID: id}
-	if err := tx.First(&account).Error; err != nil {
-		return err
-	}
-
 	// 验证金额
+	if amount == 0 {
+		return ErrInvalidAmount
+	}
 	if amount < 0 && amount < -1000000 {
 		return errors.New("金额超出允许范围")
 	}
 
-	account.Balance += amount
-	account.UpdatedAt = time.Now()
+	if tx == nil {
+		return s.db.Transaction(func(tx *gorm.DB) error {
+			return s.postAdjustment(tx, id, amount, description)
+		})
+	}
+	return s.postAdjustment(tx, id, amount, description)
+}
 
-	if err := tx.Save(&account).Error; err != nil {
+// postAdjustment 记录现金账户调整分录
+func (s *CashAccountService) postAdjustment(tx *gorm.DB, accountID uuid.UUID, amount int, description string) error {
+	clearingID, err := s.ledgerService.ClearingCashAccountID(tx)
+	if err != nil {
 		return err
 	}
+	if clearingID == accountID {
+		return errors.New("不能直接调整清算账户")
+	}
 
-	return nil
+	_, err = s.ledgerService.Post(tx, &LedgerTransfer{
+		Type:        models.TransactionCashAdjust,
+		Description: description,
+		Postings: []LedgerPosting{
+			CashPosting(accountID, amount),
+			CashPosting(clearingID, -amount),
+		},
+	})
+	return err
 }
 
 // CreateCashAccount 创建现金账户
 func (s *CashAccountService) CreateCashAccount(accountType string, description string, operatorID string, initialBalance int) (*models.CashAccount, error) {
 	account := models.CashAccount{
+		ID:          uuid.New(),
 		AccountType: accountType,
-		Balance:     initialBalance,
 		Description: description,
 		IsActive:    true,
 	}
 
-	if err := s.db.Create(&account).Error; err != nil {
-		return nil, fmt.Errorf("创建现金账户失败: %w", err)
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&account).Error; err != nil {
+			return fmt.Errorf("创建现金账户失败: %w", err)
+		}
+
+		// 期初余额同样通过分录入账
+		if initialBalance != 0 {
+			if err := s.postAdjustment(tx, account.ID, initialBalance, "期初余额"); err != nil {
+				return err
+			}
+			account.Balance = initialBalance
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return &account, nil
@@ -30,4 +30,187 @@ var (
 
 	// ErrInvalidCreditType 无效积分类型
 	ErrInvalidCreditType = errors.New("无效积分类型")
+
+	// ErrUnbalancedLedgerTransfer 分录借贷不平衡
+	ErrUnbalancedLedgerTransfer = errors.New("分录借贷不平衡")
+
+	// ErrSystemAccountBalanceInsufficient 系统账户余额不足
+	ErrSystemAccountBalanceInsufficient = errors.New("系统账户余额不足")
+
+	// ErrInsufficientEscrowBalance 活动托管余额不足
+	ErrInsufficientEscrowBalance = errors.New("活动托管余额不足")
+
+	// ErrSettlementJobNotFound 结算任务不存在
+	ErrSettlementJobNotFound = errors.New("结算任务不存在")
+
+	// ErrInvalidSettlementJobStatus 结算任务状态不正确
+	ErrInvalidSettlementJobStatus = errors.New("结算任务状态不正确")
+
+	// ErrReconciliationRunNotFound 对账批次不存在
+	ErrReconciliationRunNotFound = errors.New("对账批次不存在")
+
+	// ErrPlatformFeeRuleNotFound 手续费规则不存在
+	ErrPlatformFeeRuleNotFound = errors.New("手续费规则不存在")
+
+	// ErrCampaignNotFound 营销活动不存在
+	ErrCampaignNotFound = errors.New("活动不存在")
+
+	// ErrInvalidCampaignTransition 营销活动状态转换不合法
+	ErrInvalidCampaignTransition = errors.New("活动状态不允许此操作")
+
+	// ErrQuotaBelowCommitted 名额不能少于已被接单的数量
+	ErrQuotaBelowCommitted = errors.New("名额不能少于已接单的任务数")
+
+	// ErrRevisionOnClosedCampaign 活动已关闭时不能要求达人修改
+	ErrRevisionOnClosedCampaign = errors.New("活动已关闭，无法要求修改")
+
+	// ErrTaskVersionConflict 任务已被其他请求修改（版本不一致）
+	ErrTaskVersionConflict = errors.New("任务已被其他操作修改，请刷新后重试")
+
+	// ErrIdempotencyKeyMismatch 幂等键已用于内容不同的请求
+	ErrIdempotencyKeyMismatch = errors.New("Idempotency-Key 已用于内容不同的请求")
+
+	// ErrPaymentProviderNotSupported 支付渠道未启用
+	ErrPaymentProviderNotSupported = errors.New("支付渠道未启用")
+
+	// ErrPaymentSignatureInvalid 支付回调签名校验失败
+	ErrPaymentSignatureInvalid = errors.New("支付回调签名校验失败")
+
+	// ErrPaymentGatewayUnavailable 支付渠道请求失败（网络错误等，可重试）
+	ErrPaymentGatewayUnavailable = errors.New("支付渠道暂不可用")
+
+	// ErrPaymentGatewayRejected 支付渠道返回业务错误
+	ErrPaymentGatewayRejected = errors.New("支付渠道拒绝请求")
+
+	// ErrPaymentAmountMismatch 渠道实付金额与订单金额不一致
+	ErrPaymentAmountMismatch = errors.New("实付金额与订单金额不一致")
+
+	// ErrRechargeOrderNotFound 充值订单不存在
+	ErrRechargeOrderNotFound = errors.New("充值订单不存在")
+
+	// ErrRechargeOrderNotOnline 不是在线支付订单
+	ErrRechargeOrderNotOnline = errors.New("该订单不是在线支付订单")
+
+	// ErrInvalidRechargeOrderStatus 充值订单状态不允许此操作
+	ErrInvalidRechargeOrderStatus = errors.New("充值订单状态不允许此操作")
+
+	// ErrRefundExceedsPaid 退款金额超过可退金额
+	ErrRefundExceedsPaid = errors.New("退款金额超过可退金额")
+
+	// ErrRefundInProgress 订单有处理中的退款
+	ErrRefundInProgress = errors.New("该订单有处理中的退款，请稍后查单重试")
+
+	// ErrRefundPending 渠道退款结果未确认，积分已冻结
+	ErrRefundPending = errors.New("渠道退款结果未确认，已冻结退款积分，请稍后查单重试")
+
+	// ErrPayoutProviderNotConfigured 该提现方式未配置打款渠道
+	ErrPayoutProviderNotConfigured = errors.New("该提现方式未配置打款渠道")
+
+	// ErrPayoutAccountInfoInvalid 收款账户信息不完整，无法打款
+	ErrPayoutAccountInfoInvalid = errors.New("收款账户信息不完整")
+
+	// ErrPayoutOrderNotFound 渠道侧不存在该打款单（可用同一单号重新提交）
+	ErrPayoutOrderNotFound = errors.New("打款单不存在")
+
+	// ErrPayoutNotManual 非人工打款渠道，不能人工确认结果
+	ErrPayoutNotManual = errors.New("该提现不是人工打款，不能人工确认结果")
+
+	// ErrWithdrawalPolicyNotFound 提现策略不存在
+	ErrWithdrawalPolicyNotFound = errors.New("提现策略不存在")
+
+	// ErrWithdrawalPolicyViolation 提现不满足策略（金额限制、累计上限、冷静期）
+	ErrWithdrawalPolicyViolation = errors.New("提现不符合提现规则")
+
+	// ErrWithdrawalReviewNoteRequired 风控复核的提现审核通过时必须填写复核意见
+	ErrWithdrawalReviewNoteRequired = errors.New("该提现命中风控规则，审核通过需填写复核意见")
+
+	// ErrExchangeRateNotFound 当前时间没有生效的积分汇率
+	ErrExchangeRateNotFound = errors.New("没有生效的积分汇率")
+
+	// ErrInvalidExchangeRate 汇率参数不正确（比例必须为正数，生效时间不能早于当前时间）
+	ErrInvalidExchangeRate = errors.New("汇率参数不正确")
+
+	// ErrInvalidStatementQuery 对账单查询参数不正确（月份格式、账户类型）
+	ErrInvalidStatementQuery = errors.New("对账单查询参数不正确")
+
+	// ErrInvalidTaxRule 个税预扣规则参数不正确
+	ErrInvalidTaxRule = errors.New("个税预扣规则参数不正确")
+
+	// ErrTaxCertificateUnavailable 只有个人账户有收入及个税预扣凭证
+	ErrTaxCertificateUnavailable = errors.New("只有个人账户有个税预扣凭证")
+
+	// ErrBillingProfileNotFound 开票信息不存在
+	ErrBillingProfileNotFound = errors.New("开票信息不存在")
+
+	// ErrInvalidBillingProfile 开票信息不完整
+	ErrInvalidBillingProfile = errors.New("开票信息不完整")
+
+	// ErrInvoiceRequestNotFound 开票申请不存在
+	ErrInvoiceRequestNotFound = errors.New("开票申请不存在")
+
+	// ErrInvalidInvoiceRequest 开票申请参数不正确（未选择订单、开票时缺少发票号码或文件）
+	ErrInvalidInvoiceRequest = errors.New("开票申请参数不正确")
+
+	// ErrInvalidInvoiceRequestStatus 开票申请状态不允许该操作
+	ErrInvalidInvoiceRequestStatus = errors.New("开票申请状态不正确")
+
+	// ErrInvoiceExceedsRecharge 开票金额超过充值实付金额（含退款后已开票金额超出的情况）
+	ErrInvoiceExceedsRecharge = errors.New("开票金额不能超过充值金额")
+
+	// ErrTransactionTypeNotFound 交易类型未在注册表登记
+	ErrTransactionTypeNotFound = errors.New("交易类型未登记")
+
+	// ErrTransactionTypeInactive 交易类型已停用或废弃，不能用于新交易
+	ErrTransactionTypeInactive = errors.New("交易类型已停用或废弃")
+
+	// ErrTransactionTypeMismatch 分录的金额方向或账户类型与交易类型定义不符
+	ErrTransactionTypeMismatch = errors.New("交易与交易类型定义不符")
+
+	// ErrInvalidTransactionType 交易类型定义不正确
+	ErrInvalidTransactionType = errors.New("交易类型定义不正确")
+
+	// ErrInvalidToken 登录凭证无效（签名、签发方或格式不正确）
+	ErrInvalidToken = errors.New("Token 无效")
+
+	// ErrTokenExpired 登录凭证已过期
+	ErrTokenExpired = errors.New("Token 已过期")
+
+	// ErrJWKSNotConfigured 未配置账号中心 JWKS 地址
+	ErrJWKSNotConfigured = errors.New("未配置 JWKS 地址")
+
+	// ErrAuthCenterUnavailable 账号中心不可用（远程校验模式下无法校验未缓存的 token）
+	ErrAuthCenterUnavailable = errors.New("账号中心暂不可用")
+
+	// ErrTokenRevoked 登录凭证已吊销（退出登录或管理员吊销会话）
+	ErrTokenRevoked = errors.New("Token 已吊销")
+
+	// ErrTokenNotRevocable 登录凭证既没有 token ID 也不属于会话，无法吊销
+	ErrTokenNotRevocable = errors.New("Token 无法吊销")
+
+	// ErrInvalidRefreshToken refresh token 无效、已过期或会话已吊销
+	ErrInvalidRefreshToken = errors.New("refresh token 无效或已过期")
+
+	// ErrSessionSigningDisabled 未配置可用的 JWT_SECRET，不能签发会话
+	ErrSessionSigningDisabled = errors.New("未配置 JWT_SECRET，不能签发会话")
+
+	// ErrUserBanned 用户已被封禁
+	ErrUserBanned = errors.New("账号已被封禁")
+
+	// ErrRoleNotHeld 用户未拥有要切换的角色
+	ErrRoleNotHeld = errors.New("未拥有该角色")
+
+	// ErrRoleOrganizationNotFound 角色下不存在指定的组织（未加入或已停用）
+	ErrRoleOrganizationNotFound = errors.New("该角色下不存在此组织")
+
+	// ErrTaskNotFound 任务不存在
+	ErrTaskNotFound = errors.New("任务不存在")
+
+	// ErrCreatorNotFound 达人不存在
+	ErrCreatorNotFound = errors.New("达人不存在")
+
+	// ErrMerchantNotFound 商家不存在
+	ErrMerchantNotFound = errors.New("商家不存在")
+
+	// ErrServiceProviderNotFound 服务商不存在
+	ErrServiceProviderNotFound = errors.New("服务商不存在")
 )
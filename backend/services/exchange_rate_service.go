@@ -0,0 +1,134 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"pr-business/models"
+)
+
+// 积分与现金换算的舍入规则（统一在此定义，业务代码不自行选择舍入方式）
+const (
+	// WithdrawalPayoutRounding 提现到账：积分换算为现金向下取整，平台不多付
+	WithdrawalPayoutRounding = models.RoundingDown
+	// RechargeChargeRounding 充值应付：积分换算为现金向上取整，平台不少收
+	RechargeChargeRounding = models.RoundingUp
+	// TaxWithholdingRounding 预扣个税：税额换算为积分向上取整，预扣积分足以覆盖税额
+	TaxWithholdingRounding = models.RoundingUp
+)
+
+// ExchangeRateService 积分汇率服务
+// 汇率按版本新增、按生效时间选取；提现到账金额和充值应付金额只能由服务端按生效汇率计算
+type ExchangeRateService struct {
+	db *gorm.DB
+}
+
+// NewExchangeRateService 创建积分汇率服务
+func NewExchangeRateService(db *gorm.DB) *ExchangeRateService {
+	return &ExchangeRateService{db: db}
+}
+
+// CreateExchangeRateRequest 新增汇率的输入参数
+type CreateExchangeRateRequest struct {
+	Credits       int
+	Cents         int
+	EffectiveFrom *time.Time // 为空表示立即生效
+	Description   string
+}
+
+// Resolve 取指定时间生效的汇率（生效时间不晚于 at 的最新版本）
+func (s *ExchangeRateService) Resolve(tx *gorm.DB, at time.Time) (*models.ExchangeRate, error) {
+	var rate models.ExchangeRate
+	err := tx.Where("effective_from <= ?", at).
+		Order("effective_from DESC, version DESC").
+		First(&rate).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrExchangeRateNotFound
+		}
+		return nil, fmt.Errorf("查询积分汇率失败: %w", err)
+	}
+	return &rate, nil
+}
+
+// Current 当前生效的汇率
+func (s *ExchangeRateService) Current() (*models.ExchangeRate, error) {
+	return s.Resolve(s.db, time.Now())
+}
+
+// Get 按ID查询汇率（用于按记录保存的汇率版本换算）
+func (s *ExchangeRateService) Get(tx *gorm.DB, id string) (*models.ExchangeRate, error) {
+	var rate models.ExchangeRate
+	if err := tx.Where("id = ?", id).First(&rate).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrExchangeRateNotFound
+		}
+		return nil, fmt.Errorf("查询积分汇率失败: %w", err)
+	}
+	return &rate, nil
+}
+
+// CreateRate 新增汇率版本；生效时间不能早于当前时间，已发生的提现和充值不受影响
+func (s *ExchangeRateService) CreateRate(req *CreateExchangeRateRequest, operatorID string) (*models.ExchangeRate, error) {
+	if req.Credits <= 0 || req.Cents <= 0 {
+		return nil, fmt.Errorf("%w: 积分数和现金金额必须大于0", ErrInvalidExchangeRate)
+	}
+
+	now := time.Now()
+	effectiveFrom := now
+	if req.EffectiveFrom != nil {
+		if req.EffectiveFrom.Before(now) {
+			return nil, fmt.Errorf("%w: 生效时间不能早于当前时间", ErrInvalidExchangeRate)
+		}
+		effectiveFrom = *req.EffectiveFrom
+	}
+
+	rate := models.ExchangeRate{
+		Credits:       req.Credits,
+		Cents:         req.Cents,
+		EffectiveFrom: effectiveFrom,
+		Description:   req.Description,
+		CreatedBy:     operatorID,
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// 锁定最新版本，并发新增时版本号串行递增
+		var latest models.ExchangeRate
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Order("version DESC").First(&latest).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("查询积分汇率失败: %w", err)
+		}
+		rate.Version = latest.Version + 1
+
+		if err := tx.Create(&rate).Error; err != nil {
+			return fmt.Errorf("创建积分汇率失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &rate, nil
+}
+
+// ListRates 按版本倒序查询汇率
+func (s *ExchangeRateService) ListRates(limit int, offset int) ([]models.ExchangeRate, int64, error) {
+	query := s.db.Model(&models.ExchangeRate{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计积分汇率失败: %w", err)
+	}
+
+	var rates []models.ExchangeRate
+	if err := query.Order("version DESC").Limit(limit).Offset(offset).Find(&rates).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询积分汇率失败: %w", err)
+	}
+
+	return rates, total, nil
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"pr-business/models"
+)
+
+// IdempotencyService 资金类接口的幂等键存储
+// 首次请求占用幂等键并保存响应；同一用户用同一幂等键重试时返回保存的响应
+// 首次请求处理中进程崩溃时，处理租约到期后相同请求可以重新占用幂等键
+type IdempotencyService struct {
+	db      *gorm.DB
+	ttl     time.Duration
+	lockTTL time.Duration
+}
+
+// NewIdempotencyService 创建幂等键服务；ttl 为幂等键保留时长，lockTTL 为首次请求的处理租约
+func NewIdempotencyService(db *gorm.DB, ttl, lockTTL time.Duration) *IdempotencyService {
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	if lockTTL <= 0 {
+		lockTTL = 5 * time.Minute
+	}
+	return &IdempotencyService{db: db, ttl: ttl, lockTTL: lockTTL}
+}
+
+// Begin 占用幂等键
+// 返回 created=true 表示首次请求，调用方处理完成后必须调用 Complete 或 Release；
+// created=false 时返回已有记录（处理中或已完成），请求摘要不一致时返回 ErrIdempotencyKeyMismatch；
+// 处理中的记录租约已到期时由本次请求重新占用，返回 created=true
+func (s *IdempotencyService) Begin(userID, key, method, path, requestHash string) (*models.IdempotencyKey, bool, error) {
+	// 过期记录删除后重新占用，最多重试一次
+	for attempt := 0; attempt < 2; attempt++ {
+		now := time.Now()
+		lockedUntil := now.Add(s.lockTTL)
+		record := models.IdempotencyKey{
+			UserID:      userID,
+			Key:         key,
+			Method:      method,
+			Path:        path,
+			RequestHash: requestHash,
+			Status:      models.IdempotencyKeyStatusProcessing,
+			ExpiresAt:   now.Add(s.ttl),
+			LockedUntil: &lockedUntil,
+		}
+
+		result := s.db.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "key"}},
+			DoNothing: true,
+		}).Create(&record)
+		if result.Error != nil {
+			return nil, false, fmt.Errorf("保存幂等键失败: %w", result.Error)
+		}
+		if result.RowsAffected == 1 {
+			return &record, true, nil
+		}
+
+		var existing models.IdempotencyKey
+		if err := s.db.Where("user_id = ? AND key = ?", userID, key).First(&existing).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				// 并发清理后记录已不存在，重新占用
+				continue
+			}
+			return nil, false, fmt.Errorf("查询幂等键失败: %w", err)
+		}
+
+		if existing.ExpiresAt.Before(now) {
+			if err := s.db.Where("id = ? AND expires_at < ?", existing.ID, now).
+				Delete(&models.IdempotencyKey{}).Error; err != nil {
+				return nil, false, fmt.Errorf("清理过期幂等键失败: %w", err)
+			}
+			continue
+		}
+
+		if existing.RequestHash != requestHash {
+			return &existing, false, ErrIdempotencyKeyMismatch
+		}
+
+		// 处理租约到期（首次请求处理中断），重新占用；并发重试时只有一个请求能占用成功
+		if existing.Status == models.IdempotencyKeyStatusProcessing && (existing.LockedUntil == nil || existing.LockedUntil.Before(now)) {
+			result := s.db.Model(&models.IdempotencyKey{}).
+				Where("id = ? AND status = ? AND (locked_until IS NULL OR locked_until < ?)", existing.ID, models.IdempotencyKeyStatusProcessing, now).
+				Updates(map[string]interface{}{
+					"locked_until": lockedUntil,
+					"updated_at":   now,
+				})
+			if result.Error != nil {
+				return nil, false, fmt.Errorf("重新占用幂等键失败: %w", result.Error)
+			}
+			if result.RowsAffected == 1 {
+				existing.LockedUntil = &lockedUntil
+				return &existing, true, nil
+			}
+		}
+		return &existing, false, nil
+	}
+
+	return nil, false, errors.New("幂等键占用失败，请重试")
+}
+
+// Complete 保存首次请求的响应，之后的重放直接返回该响应
+func (s *IdempotencyService) Complete(id uuid.UUID, status int, contentType string, body []byte) error {
+	now := time.Now()
+	if err := s.db.Model(&models.IdempotencyKey{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":          models.IdempotencyKeyStatusCompleted,
+			"response_status": status,
+			"response_type":   contentType,
+			"response_body":   body,
+			"completed_at":    now,
+			"updated_at":      now,
+		}).Error; err != nil {
+		return fmt.Errorf("保存幂等响应失败: %w", err)
+	}
+	return nil
+}
+
+// Release 释放处理中的幂等键（服务端错误时调用，允许客户端用同一幂等键重试）
+func (s *IdempotencyService) Release(id uuid.UUID) error {
+	if err := s.db.Where("id = ? AND status = ?", id, models.IdempotencyKeyStatusProcessing).
+		Delete(&models.IdempotencyKey{}).Error; err != nil {
+		return fmt.Errorf("释放幂等键失败: %w", err)
+	}
+	return nil
+}
+
+// PurgeExpired 删除已过期的幂等键，返回删除数量
+func (s *IdempotencyService) PurgeExpired(now time.Time) (int64, error) {
+	result := s.db.Where("expires_at < ?", now).Delete(&models.IdempotencyKey{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("清理过期幂等键失败: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// RegisterJobs 注册过期幂等键清理任务
+func (s *IdempotencyService) RegisterJobs(scheduler *SchedulerService, interval time.Duration) {
+	scheduler.Register(ScheduledJob{
+		Name:     "purge-idempotency-keys",
+		Interval: interval,
+		Run: func(ctx context.Context, now time.Time) error {
+			purged, err := s.PurgeExpired(now)
+			if purged > 0 {
+				log.Printf("已清理 %d 个过期幂等键", purged)
+			}
+			return err
+		},
+	})
+}
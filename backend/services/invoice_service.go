@@ -0,0 +1,505 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"pr-business/constants"
+	"pr-business/models"
+)
+
+// InvoiceActor 开票操作人（用于审计日志）
+type InvoiceActor struct {
+	UserID    string // 认证中心用户ID
+	IPAddress string
+	UserAgent string
+}
+
+// InvoiceService 商家充值开票服务
+// 商家维护开票信息，对已完成的充值订单申请开票 → 财务开具并上传发票文件，或驳回；
+// 每笔充值订单的待开票和已开票金额合计不超过实付金额（扣除已退款部分），每一步写审计日志
+type InvoiceService struct {
+	db           *gorm.DB
+	auditService *AuditService
+	rateService  *ExchangeRateService
+}
+
+// NewInvoiceService 创建开票服务
+func NewInvoiceService(db *gorm.DB, auditService *AuditService, rateService *ExchangeRateService) *InvoiceService {
+	return &InvoiceService{
+		db:           db,
+		auditService: auditService,
+		rateService:  rateService,
+	}
+}
+
+// BillingProfileInput 开票信息的输入参数
+type BillingProfileInput struct {
+	InvoiceType models.InvoiceType
+	Title       string
+	TaxID       string
+	Address     string
+	Phone       string
+	BankName    string
+	BankAccount string
+	Email       string
+	IsDefault   bool
+}
+
+// CreateInvoiceRequestInput 开票申请的输入参数
+type CreateInvoiceRequestInput struct {
+	BillingProfileID string
+	RechargeOrderIDs []string
+	Remark           string
+}
+
+// InvoiceableOrder 可开票的充值订单（金额单位：分）
+type InvoiceableOrder struct {
+	Order       models.RechargeOrder `json:"order"`
+	Recharged   int                  `json:"recharged"`   // 实付金额扣除已退款部分
+	Invoiced    int                  `json:"invoiced"`    // 待开票和已开票金额
+	Invoiceable int                  `json:"invoiceable"` // 剩余可开票金额
+}
+
+// InvoiceRequestFilter 开票申请查询条件
+type InvoiceRequestFilter struct {
+	MerchantID *uuid.UUID // 为空表示不限商家（财务）
+	Status     string
+	Page       int
+	PageSize   int
+}
+
+// ListBillingProfiles 查询商家的开票信息
+func (s *InvoiceService) ListBillingProfiles(merchantID uuid.UUID) ([]models.BillingProfile, error) {
+	var profiles []models.BillingProfile
+	if err := s.db.Where("merchant_id = ? AND deleted_at IS NULL", merchantID).
+		Order("is_default DESC, created_at DESC").
+		Find(&profiles).Error; err != nil {
+		return nil, fmt.Errorf("查询开票信息失败: %w", err)
+	}
+	return profiles, nil
+}
+
+// SaveBillingProfile 创建（profileID 为空）或修改开票信息；设为默认时取消其他默认
+func (s *InvoiceService) SaveBillingProfile(merchantID uuid.UUID, profileID string, input *BillingProfileInput, actor InvoiceActor) (*models.BillingProfile, error) {
+	if err := validateBillingProfile(input); err != nil {
+		return nil, err
+	}
+
+	var profile models.BillingProfile
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		action := constants.AuditActionBillingProfileCreate
+		if profileID != "" {
+			action = constants.AuditActionBillingProfileUpdate
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("id = ? AND merchant_id = ? AND deleted_at IS NULL", profileID, merchantID).
+				First(&profile).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return ErrBillingProfileNotFound
+				}
+				return err
+			}
+		} else {
+			profile.MerchantID = merchantID
+			profile.CreatedBy = actor.UserID
+		}
+
+		profile.InvoiceType = input.InvoiceType
+		profile.Title = input.Title
+		profile.TaxID = input.TaxID
+		profile.Address = input.Address
+		profile.Phone = input.Phone
+		profile.BankName = input.BankName
+		profile.BankAccount = input.BankAccount
+		profile.Email = input.Email
+		profile.IsDefault = input.IsDefault
+		profile.UpdatedAt = time.Now()
+
+		if profile.IsDefault {
+			if err := tx.Model(&models.BillingProfile{}).
+				Where("merchant_id = ? AND id <> ? AND is_default = ?", merchantID, profile.ID, true).
+				Update("is_default", false).Error; err != nil {
+				return fmt.Errorf("更新默认开票信息失败: %w", err)
+			}
+		}
+		if err := tx.Save(&profile).Error; err != nil {
+			return fmt.Errorf("保存开票信息失败: %w", err)
+		}
+
+		return s.auditService.WithTx(tx).LogFinancialOperation(
+			actor.UserID,
+			action,
+			constants.AuditResourceBillingProfile,
+			profile.ID.String(),
+			map[string]interface{}{
+				"merchant_id":  merchantID,
+				"invoice_type": profile.InvoiceType,
+				"title":        profile.Title,
+				"tax_id":       profile.TaxID,
+				"is_default":   profile.IsDefault,
+			},
+			actor.IPAddress,
+			actor.UserAgent,
+		)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// DeleteBillingProfile 删除开票信息（软删除，已提交的申请保留快照）
+func (s *InvoiceService) DeleteBillingProfile(merchantID uuid.UUID, profileID string, actor InvoiceActor) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&models.BillingProfile{}).
+			Where("id = ? AND merchant_id = ? AND deleted_at IS NULL", profileID, merchantID).
+			Updates(map[string]interface{}{"deleted_at": now, "is_default": false, "updated_at": now})
+		if result.Error != nil {
+			return fmt.Errorf("删除开票信息失败: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrBillingProfileNotFound
+		}
+
+		return s.auditService.WithTx(tx).LogFinancialOperation(
+			actor.UserID,
+			constants.AuditActionBillingProfileDelete,
+			constants.AuditResourceBillingProfile,
+			profileID,
+			map[string]interface{}{"merchant_id": merchantID},
+			actor.IPAddress,
+			actor.UserAgent,
+		)
+	})
+}
+
+// ListInvoiceableOrders 查询商家账户已完成（含部分退款）且仍有可开票金额的充值订单
+func (s *InvoiceService) ListInvoiceableOrders(accountID uuid.UUID) ([]InvoiceableOrder, error) {
+	var orders []models.RechargeOrder
+	if err := s.db.Where("account_id = ? AND status = ?", accountID, models.RechargeOrderStatusCompleted).
+		Order("created_at DESC").
+		Find(&orders).Error; err != nil {
+		return nil, fmt.Errorf("查询充值订单失败: %w", err)
+	}
+
+	result := make([]InvoiceableOrder, 0, len(orders))
+	for _, order := range orders {
+		item, err := s.invoiceable(s.db, &order)
+		if err != nil {
+			return nil, err
+		}
+		if item.Invoiceable > 0 {
+			result = append(result, *item)
+		}
+	}
+	return result, nil
+}
+
+// CreateRequest 商家对一笔或多笔充值订单申请开票，每笔订单按剩余可开票金额全额开具
+func (s *InvoiceService) CreateRequest(merchantID uuid.UUID, accountID uuid.UUID, input *CreateInvoiceRequestInput, actor InvoiceActor) (*models.InvoiceRequest, error) {
+	if len(input.RechargeOrderIDs) == 0 {
+		return nil, fmt.Errorf("%w: 请选择充值订单", ErrInvalidInvoiceRequest)
+	}
+
+	var request models.InvoiceRequest
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var profile models.BillingProfile
+		if err := tx.Where("id = ? AND merchant_id = ? AND deleted_at IS NULL", input.BillingProfileID, merchantID).
+			First(&profile).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrBillingProfileNotFound
+			}
+			return err
+		}
+
+		// 锁定充值订单，并发申请和退款时可开票金额不会重复计算
+		var orders []models.RechargeOrder
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ? AND account_id = ?", input.RechargeOrderIDs, accountID).
+			Order("id").
+			Find(&orders).Error; err != nil {
+			return fmt.Errorf("查询充值订单失败: %w", err)
+		}
+		if len(orders) != len(uniqueStrings(input.RechargeOrderIDs)) {
+			return ErrRechargeOrderNotFound
+		}
+
+		request = models.InvoiceRequest{
+			MerchantID:       merchantID,
+			AccountID:        accountID,
+			BillingProfileID: profile.ID,
+			InvoiceType:      profile.InvoiceType,
+			Title:            profile.Title,
+			TaxID:            profile.TaxID,
+			Address:          profile.Address,
+			Phone:            profile.Phone,
+			BankName:         profile.BankName,
+			BankAccount:      profile.BankAccount,
+			Email:            profile.Email,
+			Status:           models.InvoiceRequestStatusPending,
+			Remark:           input.Remark,
+			RequestedBy:      actor.UserID,
+		}
+		for _, order := range orders {
+			if order.Status != models.RechargeOrderStatusCompleted {
+				return fmt.Errorf("%w: 充值订单 %s 状态为 %s", ErrInvalidRechargeOrderStatus, order.ID, order.Status)
+			}
+			item, err := s.invoiceable(tx, &order)
+			if err != nil {
+				return err
+			}
+			if item.Invoiceable <= 0 {
+				return fmt.Errorf("%w: 充值订单 %s 已无可开票金额", ErrInvoiceExceedsRecharge, order.ID)
+			}
+			request.Items = append(request.Items, models.InvoiceRequestItem{
+				RechargeOrderID: order.ID,
+				Amount:          item.Invoiceable,
+			})
+			request.Amount += item.Invoiceable
+		}
+
+		if err := tx.Create(&request).Error; err != nil {
+			return fmt.Errorf("创建开票申请失败: %w", err)
+		}
+
+		orderIDs := make([]uuid.UUID, len(orders))
+		for i, order := range orders {
+			orderIDs[i] = order.ID
+		}
+		return s.audit(tx, actor, constants.AuditActionInvoiceRequest, &request, map[string]interface{}{
+			"amount":             request.Amount,
+			"title":              request.Title,
+			"tax_id":             request.TaxID,
+			"recharge_order_ids": orderIDs,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// Issue 财务开具发票：登记发票号码和发票文件
+func (s *InvoiceService) Issue(id string, invoiceNo string, fileURL string, actor InvoiceActor) (*models.InvoiceRequest, error) {
+	if invoiceNo == "" || fileURL == "" {
+		return nil, fmt.Errorf("%w: 需要填写发票号码并上传发票文件", ErrInvalidInvoiceRequest)
+	}
+
+	var request models.InvoiceRequest
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockInvoiceRequest(tx, id, &request); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		request.Status = models.InvoiceRequestStatusIssued
+		request.InvoiceNo = invoiceNo
+		request.InvoiceFileURL = fileURL
+		request.ProcessedBy = &actor.UserID
+		request.ProcessedAt = &now
+		if err := tx.Model(&request).Updates(map[string]interface{}{
+			"status":           request.Status,
+			"invoice_no":       invoiceNo,
+			"invoice_file_url": fileURL,
+			"processed_by":     actor.UserID,
+			"processed_at":     now,
+			"updated_at":       now,
+		}).Error; err != nil {
+			return fmt.Errorf("更新开票申请失败: %w", err)
+		}
+
+		return s.audit(tx, actor, constants.AuditActionInvoiceIssue, &request, map[string]interface{}{
+			"amount":           request.Amount,
+			"invoice_no":       invoiceNo,
+			"invoice_file_url": fileURL,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// Reject 财务驳回开票申请，申请占用的可开票金额释放
+func (s *InvoiceService) Reject(id string, reason string, actor InvoiceActor) (*models.InvoiceRequest, error) {
+	var request models.InvoiceRequest
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockInvoiceRequest(tx, id, &request); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		request.Status = models.InvoiceRequestStatusRejected
+		request.RejectionNote = reason
+		request.ProcessedBy = &actor.UserID
+		request.ProcessedAt = &now
+		if err := tx.Model(&request).Updates(map[string]interface{}{
+			"status":         request.Status,
+			"rejection_note": reason,
+			"processed_by":   actor.UserID,
+			"processed_at":   now,
+			"updated_at":     now,
+		}).Error; err != nil {
+			return fmt.Errorf("更新开票申请失败: %w", err)
+		}
+
+		return s.audit(tx, actor, constants.AuditActionInvoiceReject, &request, map[string]interface{}{
+			"amount": request.Amount,
+			"reason": reason,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// Get 查询开票申请（含明细和充值订单）
+func (s *InvoiceService) Get(id string) (*models.InvoiceRequest, error) {
+	var request models.InvoiceRequest
+	if err := s.db.Preload("Items.RechargeOrder").Where("id = ?", id).First(&request).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvoiceRequestNotFound
+		}
+		return nil, err
+	}
+	return &request, nil
+}
+
+// List 分页查询开票申请
+func (s *InvoiceService) List(filter InvoiceRequestFilter) ([]models.InvoiceRequest, int64, error) {
+	query := s.db.Model(&models.InvoiceRequest{})
+	if filter.MerchantID != nil {
+		query = query.Where("merchant_id = ?", *filter.MerchantID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var requests []models.InvoiceRequest
+	offset := (filter.Page - 1) * filter.PageSize
+	if err := query.Preload("Items").Order("created_at DESC").Offset(offset).Limit(filter.PageSize).Find(&requests).Error; err != nil {
+		return nil, 0, err
+	}
+	return requests, total, nil
+}
+
+// invoiceable 计算充值订单的可开票金额
+func (s *InvoiceService) invoiceable(tx *gorm.DB, order *models.RechargeOrder) (*InvoiceableOrder, error) {
+	recharged, err := rechargedCents(tx, s.rateService, order, order.RefundedAmount)
+	if err != nil {
+		return nil, err
+	}
+	invoiced, err := invoicedCents(tx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	item := &InvoiceableOrder{Order: *order, Recharged: recharged, Invoiced: invoiced}
+	if recharged > invoiced {
+		item.Invoiceable = recharged - invoiced
+	}
+	return item, nil
+}
+
+// audit 在事务内记录开票审计日志
+func (s *InvoiceService) audit(tx *gorm.DB, actor InvoiceActor, action string, request *models.InvoiceRequest, changes map[string]interface{}) error {
+	changes["status"] = request.Status
+	changes["merchant_id"] = request.MerchantID
+	return s.auditService.WithTx(tx).LogFinancialOperation(
+		actor.UserID,
+		action,
+		constants.AuditResourceInvoiceRequest,
+		request.ID.String(),
+		changes,
+		actor.IPAddress,
+		actor.UserAgent,
+	)
+}
+
+// rechargedCents 充值订单扣除退款后的实付金额（分）
+// 退款按累计退款积分以下单汇率向上取整换算，与 PaymentService.Refund 的退款金额一致
+func rechargedCents(tx *gorm.DB, rateService *ExchangeRateService, order *models.RechargeOrder, refunded int) (int, error) {
+	if refunded <= 0 {
+		return order.CashAmount, nil
+	}
+	if order.ExchangeRateID == nil {
+		return 0, ErrExchangeRateNotFound
+	}
+	rate, err := rateService.Get(tx, order.ExchangeRateID.String())
+	if err != nil {
+		return 0, err
+	}
+	remaining := order.CashAmount - rate.CreditsToCents(refunded, RechargeChargeRounding)
+	if remaining < 0 {
+		remaining = 0
+	}
+	return remaining, nil
+}
+
+// invoicedCents 充值订单待开票和已开票的金额合计（分）
+func invoicedCents(tx *gorm.DB, orderID uuid.UUID) (int, error) {
+	var total int64
+	if err := tx.Model(&models.InvoiceRequestItem{}).
+		Joins("JOIN invoice_requests ON invoice_requests.id = invoice_request_items.invoice_request_id").
+		Where("invoice_request_items.recharge_order_id = ? AND invoice_requests.status IN ?", orderID,
+			[]models.InvoiceRequestStatus{models.InvoiceRequestStatusPending, models.InvoiceRequestStatusIssued}).
+		Select("COALESCE(SUM(invoice_request_items.amount), 0)").
+		Scan(&total).Error; err != nil {
+		return 0, fmt.Errorf("统计已开票金额失败: %w", err)
+	}
+	return int(total), nil
+}
+
+// lockInvoiceRequest 在事务内锁定待处理的开票申请
+func lockInvoiceRequest(tx *gorm.DB, id string, request *models.InvoiceRequest) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(request).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvoiceRequestNotFound
+		}
+		return err
+	}
+	if !request.CanProcess() {
+		return fmt.Errorf("%w: 当前状态为 %s", ErrInvalidInvoiceRequestStatus, request.Status)
+	}
+	return nil
+}
+
+// validateBillingProfile 校验开票信息；专用发票需要完整的地址、电话和开户行信息
+func validateBillingProfile(input *BillingProfileInput) error {
+	if input.InvoiceType != models.InvoiceTypeNormal && input.InvoiceType != models.InvoiceTypeSpecial {
+		return fmt.Errorf("%w: 发票类型无效", ErrInvalidBillingProfile)
+	}
+	if input.Title == "" || input.TaxID == "" {
+		return fmt.Errorf("%w: 发票抬头和纳税人识别号不能为空", ErrInvalidBillingProfile)
+	}
+	if input.InvoiceType == models.InvoiceTypeSpecial &&
+		(input.Address == "" || input.Phone == "" || input.BankName == "" || input.BankAccount == "") {
+		return fmt.Errorf("%w: 专用发票需要填写地址、电话、开户行和银行账号", ErrInvalidBillingProfile)
+	}
+	return nil
+}
+
+// uniqueStrings 去重
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,510 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"pr-business/constants"
+	"pr-business/models"
+)
+
+// LedgerService 复式记账服务
+// 所有积分、托管、现金余额的变动都必须通过 Post 写入一组平衡分录，
+// 余额字段只是分录的投影，禁止在其他地方直接修改；分录的交易类型必须符合交易类型注册表
+type LedgerService struct {
+	db    *gorm.DB
+	types *TransactionTypeService
+}
+
+// NewLedgerService 创建记账服务
+func NewLedgerService(db *gorm.DB) *LedgerService {
+	return &LedgerService{db: db, types: NewTransactionTypeService(db)}
+}
+
+// LedgerPosting 单条分录
+// Amount 为对应余额的变动量：正数增加余额，负数减少余额
+type LedgerPosting struct {
+	Kind        models.LedgerAccountKind
+	AccountID   uuid.UUID
+	Amount      int
+	Type        string // 为空时使用 LedgerTransfer.Type
+	Description string // 为空时使用 LedgerTransfer.Description
+}
+
+// LedgerTransfer 一组必须同时成功的分录（同一 TransactionGroupID）
+type LedgerTransfer struct {
+	Type              string
+	Description       string
+	RelatedCampaignID *uuid.UUID
+	RelatedTaskID     *uuid.UUID
+	Postings          []LedgerPosting
+}
+
+// CreditPosting 积分可用余额分录
+func CreditPosting(accountID uuid.UUID, amount int) LedgerPosting {
+	return LedgerPosting{Kind: models.LedgerAccountCredit, AccountID: accountID, Amount: amount}
+}
+
+// FrozenPosting 积分冻结余额分录
+func FrozenPosting(accountID uuid.UUID, amount int) LedgerPosting {
+	return LedgerPosting{Kind: models.LedgerAccountCreditFrozen, AccountID: accountID, Amount: amount}
+}
+
+// SystemPosting 系统账户分录
+func SystemPosting(accountID uuid.UUID, amount int) LedgerPosting {
+	return LedgerPosting{Kind: models.LedgerAccountSystem, AccountID: accountID, Amount: amount}
+}
+
+// CashPosting 现金账户分录（单位：分）
+func CashPosting(accountID uuid.UUID, amount int) LedgerPosting {
+	return LedgerPosting{Kind: models.LedgerAccountCash, AccountID: accountID, Amount: amount}
+}
+
+// FreezePostings 冻结积分：可用余额 → 冻结余额
+func FreezePostings(accountID uuid.UUID, amount int) []LedgerPosting {
+	return []LedgerPosting{
+		CreditPosting(accountID, -amount),
+		FrozenPosting(accountID, amount),
+	}
+}
+
+// UnfreezePostings 解冻积分：冻结余额 → 可用余额
+func UnfreezePostings(accountID uuid.UUID, amount int) []LedgerPosting {
+	return []LedgerPosting{
+		FrozenPosting(accountID, -amount),
+		CreditPosting(accountID, amount),
+	}
+}
+
+// Post 在事务 tx 内写入一组平衡分录并更新各账户余额，返回分组ID
+// 校验：每个记账单位借贷相等；交易类型已登记且方向、账户类型与定义一致；
+// 积分账户、现金账户、系统账户余额不能为负（清算/发行账户除外）
+func (s *LedgerService) Post(tx *gorm.DB, transfer *LedgerTransfer) (uuid.UUID, error) {
+	if err := validateTransfer(transfer); err != nil {
+		return uuid.Nil, err
+	}
+
+	// 1. 按表、按ID排序加锁，避免并发转账死锁
+	creditAccounts, err := lockCreditAccounts(tx, collectAccountIDs(transfer, models.LedgerAccountCredit, models.LedgerAccountCreditFrozen))
+	if err != nil {
+		return uuid.Nil, err
+	}
+	systemAccounts, err := lockSystemAccounts(tx, collectAccountIDs(transfer, models.LedgerAccountSystem))
+	if err != nil {
+		return uuid.Nil, err
+	}
+	cashAccounts, err := lockCashAccounts(tx, collectAccountIDs(transfer, models.LedgerAccountCash))
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := s.types.ValidateTransfer(tx, transfer, creditAccounts); err != nil {
+		return uuid.Nil, err
+	}
+
+	// 任务托管账户按活动分账：涉及 TASK_ESCROW 的分录必须关联活动
+	var escrow *models.CampaignEscrow
+	if touchesTaskEscrow(transfer, systemAccounts) {
+		if transfer.RelatedCampaignID == nil {
+			return uuid.Nil, errors.New("任务托管分录必须关联活动")
+		}
+		escrow, err = lockCampaignEscrow(tx, *transfer.RelatedCampaignID)
+		if err != nil {
+			return uuid.Nil, err
+		}
+	}
+
+	// 2. 依次应用分录
+	groupID := uuid.New()
+	now := time.Now()
+	entries := make([]models.LedgerEntry, 0, len(transfer.Postings))
+	creditTransactions := make([]models.CreditTransaction, 0, len(transfer.Postings))
+
+	for i, posting := range transfer.Postings {
+		txType := posting.Type
+		if txType == "" {
+			txType = transfer.Type
+		}
+		description := posting.Description
+		if description == "" {
+			description = transfer.Description
+		}
+
+		var before, after int
+		switch posting.Kind {
+		case models.LedgerAccountCredit:
+			account := creditAccounts[posting.AccountID]
+			before = account.Balance
+			after = before + posting.Amount
+			if after < 0 {
+				return uuid.Nil, ErrInsufficientBalance
+			}
+			account.Balance = after
+		case models.LedgerAccountCreditFrozen:
+			account := creditAccounts[posting.AccountID]
+			before = account.FrozenBalance
+			after = before + posting.Amount
+			if after < 0 {
+				return uuid.Nil, ErrInsufficientFrozenBalance
+			}
+			account.FrozenBalance = after
+		case models.LedgerAccountSystem:
+			account := systemAccounts[posting.AccountID]
+			before = account.Balance
+			after = before + posting.Amount
+			if after < 0 && account.AccountType != constants.SystemAccountTypeCreditIssuance {
+				return uuid.Nil, ErrSystemAccountBalanceInsufficient
+			}
+			account.Balance = after
+			if account.AccountType == constants.SystemAccountTypeTaskEscrow {
+				if escrow.Balance+posting.Amount < 0 {
+					return uuid.Nil, ErrInsufficientEscrowBalance
+				}
+				escrow.Balance += posting.Amount
+				if posting.Amount > 0 {
+					escrow.FundedAmount += posting.Amount
+				} else {
+					escrow.ReleasedAmount -= posting.Amount
+				}
+			}
+		case models.LedgerAccountCash:
+			account := cashAccounts[posting.AccountID]
+			before = account.Balance
+			after = before + posting.Amount
+			if after < 0 && account.AccountType != constants.CashAccountTypeClearing {
+				return uuid.Nil, ErrCashAccountBalanceInsufficient
+			}
+			account.Balance = after
+		}
+
+		direction := models.LedgerDirectionCredit
+		amount := posting.Amount
+		if amount < 0 {
+			direction = models.LedgerDirectionDebit
+			amount = -amount
+		}
+
+		entries = append(entries, models.LedgerEntry{
+			TransactionGroupID: groupID,
+			GroupSequence:      i + 1,
+			AccountKind:        posting.Kind,
+			AccountID:          posting.AccountID,
+			Unit:               posting.Kind.Unit(),
+			Direction:          direction,
+			Amount:             amount,
+			Type:               txType,
+			BalanceBefore:      before,
+			BalanceAfter:       after,
+			RelatedCampaignID:  transfer.RelatedCampaignID,
+			RelatedTaskID:      transfer.RelatedTaskID,
+			Description:        description,
+			CreatedAt:          now,
+		})
+
+		// 积分账户同时写入用户可见的积分流水
+		if posting.Kind.IsCredit() {
+			balanceType := models.BalanceTypeAvailable
+			if posting.Kind == models.LedgerAccountCreditFrozen {
+				balanceType = models.BalanceTypeFrozen
+			}
+			creditTransactions = append(creditTransactions, models.CreditTransaction{
+				AccountID:          posting.AccountID,
+				Type:               txType,
+				Amount:             posting.Amount,
+				BalanceBefore:      before,
+				BalanceAfter:       after,
+				TransactionGroupID: &groupID,
+				GroupSequence:      intPtr(i + 1),
+				RelatedCampaignID:  transfer.RelatedCampaignID,
+				RelatedTaskID:      transfer.RelatedTaskID,
+				Description:        description,
+				BalanceType:        balanceType,
+				CreatedAt:          now,
+			})
+		}
+	}
+
+	// 3. 持久化余额投影
+	for _, account := range creditAccounts {
+		if err := tx.Model(&models.CreditAccount{}).Where("id = ?", account.ID).Updates(map[string]interface{}{
+			"balance":        account.Balance,
+			"frozen_balance": account.FrozenBalance,
+			"updated_at":     now,
+		}).Error; err != nil {
+			return uuid.Nil, fmt.Errorf("更新积分账户余额失败: %w", err)
+		}
+	}
+	for _, account := range systemAccounts {
+		if err := tx.Model(&models.SystemAccount{}).Where("id = ?", account.ID).Updates(map[string]interface{}{
+			"balance":    account.Balance,
+			"updated_at": now,
+		}).Error; err != nil {
+			return uuid.Nil, fmt.Errorf("更新系统账户余额失败: %w", err)
+		}
+	}
+	for _, account := range cashAccounts {
+		if err := tx.Model(&models.CashAccount{}).Where("id = ?", account.ID).Updates(map[string]interface{}{
+			"balance":    account.Balance,
+			"updated_at": now,
+		}).Error; err != nil {
+			return uuid.Nil, fmt.Errorf("更新现金账户余额失败: %w", err)
+		}
+	}
+
+	if escrow != nil {
+		if err := tx.Model(&models.CampaignEscrow{}).Where("id = ?", escrow.ID).Updates(map[string]interface{}{
+			"balance":         escrow.Balance,
+			"funded_amount":   escrow.FundedAmount,
+			"released_amount": escrow.ReleasedAmount,
+			"updated_at":      now,
+		}).Error; err != nil {
+			return uuid.Nil, fmt.Errorf("更新活动托管分账失败: %w", err)
+		}
+	}
+
+	// 4. 写入分录和积分流水
+	if err := tx.Create(&entries).Error; err != nil {
+		return uuid.Nil, fmt.Errorf("记录分录失败: %w", err)
+	}
+	if len(creditTransactions) > 0 {
+		if err := tx.Create(&creditTransactions).Error; err != nil {
+			return uuid.Nil, fmt.Errorf("记录积分流水失败: %w", err)
+		}
+	}
+
+	return groupID, nil
+}
+
+// SystemAccountID 获取指定类型的激活系统账户ID，不存在时创建
+func (s *LedgerService) SystemAccountID(tx *gorm.DB, accountType string) (uuid.UUID, error) {
+	var account models.SystemAccount
+	err := tx.Where("account_type = ? AND is_active = ?", accountType, true).First(&account).Error
+	if err == nil {
+		return account.ID, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return uuid.Nil, fmt.Errorf("查询系统账户失败: %w", err)
+	}
+
+	account = models.SystemAccount{
+		ID:          uuid.New(),
+		AccountType: accountType,
+		IsActive:    true,
+	}
+	if err := tx.Create(&account).Error; err != nil {
+		return uuid.Nil, fmt.Errorf("创建系统账户失败: %w", err)
+	}
+	return account.ID, nil
+}
+
+// ClearingCashAccountID 获取现金清算账户ID，不存在时创建
+func (s *LedgerService) ClearingCashAccountID(tx *gorm.DB) (uuid.UUID, error) {
+	var account models.CashAccount
+	err := tx.Where("account_type = ? AND is_active = ?", constants.CashAccountTypeClearing, true).First(&account).Error
+	if err == nil {
+		return account.ID, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return uuid.Nil, fmt.Errorf("查询清算账户失败: %w", err)
+	}
+
+	account = models.CashAccount{
+		ID:          uuid.New(),
+		AccountType: constants.CashAccountTypeClearing,
+		Description: "外部资金清算账户",
+		IsActive:    true,
+	}
+	if err := tx.Create(&account).Error; err != nil {
+		return uuid.Nil, fmt.Errorf("创建清算账户失败: %w", err)
+	}
+	return account.ID, nil
+}
+
+// FindCampaignEscrow 查询活动托管分账，未注资过的活动返回 nil
+func (s *LedgerService) FindCampaignEscrow(tx *gorm.DB, campaignID uuid.UUID) (*models.CampaignEscrow, error) {
+	var escrow models.CampaignEscrow
+	err := tx.Where("campaign_id = ?", campaignID).First(&escrow).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询活动托管分账失败: %w", err)
+	}
+	return &escrow, nil
+}
+
+// ProjectedBalance 由分录重放得到的账户余额
+func (s *LedgerService) ProjectedBalance(kind models.LedgerAccountKind, accountID uuid.UUID) (int, error) {
+	var balance int
+	err := s.db.Model(&models.LedgerEntry{}).
+		Select("COALESCE(SUM(CASE WHEN direction = ? THEN amount ELSE -amount END), 0)", models.LedgerDirectionCredit).
+		Where("account_kind = ? AND account_id = ?", kind, accountID).
+		Scan(&balance).Error
+	if err != nil {
+		return 0, fmt.Errorf("计算分录余额失败: %w", err)
+	}
+	return balance, nil
+}
+
+// GetGroupEntries 查询一组分录
+func (s *LedgerService) GetGroupEntries(groupID uuid.UUID) ([]models.LedgerEntry, error) {
+	var entries []models.LedgerEntry
+	if err := s.db.Where("transaction_group_id = ?", groupID).Order("group_sequence ASC").Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("查询分录失败: %w", err)
+	}
+	return entries, nil
+}
+
+// validateTransfer 校验分录完整且每个记账单位借贷相等
+func validateTransfer(transfer *LedgerTransfer) error {
+	if transfer == nil || len(transfer.Postings) < 2 {
+		return fmt.Errorf("%w: 至少需要两条分录", ErrUnbalancedLedgerTransfer)
+	}
+
+	sums := map[models.LedgerUnit]int{}
+	for _, posting := range transfer.Postings {
+		if posting.Amount == 0 {
+			return ErrInvalidAmount
+		}
+		if posting.AccountID == uuid.Nil {
+			return fmt.Errorf("分录账户ID为空")
+		}
+		if posting.Type == "" && transfer.Type == "" {
+			return fmt.Errorf("分录交易类型为空")
+		}
+		switch posting.Kind {
+		case models.LedgerAccountCredit, models.LedgerAccountCreditFrozen, models.LedgerAccountSystem, models.LedgerAccountCash:
+		default:
+			return fmt.Errorf("未知的分录账户类型: %s", posting.Kind)
+		}
+		sums[posting.Kind.Unit()] += posting.Amount
+	}
+
+	for unit, sum := range sums {
+		if sum != 0 {
+			return fmt.Errorf("%w: %s 差额 %d", ErrUnbalancedLedgerTransfer, unit, sum)
+		}
+	}
+	return nil
+}
+
+// collectAccountIDs 收集指定账户类型涉及的账户ID（去重、排序）
+func collectAccountIDs(transfer *LedgerTransfer, kinds ...models.LedgerAccountKind) []uuid.UUID {
+	seen := map[uuid.UUID]bool{}
+	ids := []uuid.UUID{}
+	for _, posting := range transfer.Postings {
+		for _, kind := range kinds {
+			if posting.Kind == kind && !seen[posting.AccountID] {
+				seen[posting.AccountID] = true
+				ids = append(ids, posting.AccountID)
+			}
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i].String() < ids[j].String() })
+	return ids
+}
+
+// touchesTaskEscrow 分录中是否包含任务托管账户
+func touchesTaskEscrow(transfer *LedgerTransfer, systemAccounts map[uuid.UUID]*models.SystemAccount) bool {
+	for _, posting := range transfer.Postings {
+		if posting.Kind != models.LedgerAccountSystem {
+			continue
+		}
+		if account := systemAccounts[posting.AccountID]; account != nil && account.AccountType == constants.SystemAccountTypeTaskEscrow {
+			return true
+		}
+	}
+	return false
+}
+
+// lockCampaignEscrow 行锁读取活动托管分账，不存在时创建
+func lockCampaignEscrow(tx *gorm.DB, campaignID uuid.UUID) (*models.CampaignEscrow, error) {
+	var escrow models.CampaignEscrow
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("campaign_id = ?", campaignID).First(&escrow).Error
+	if err == nil {
+		return &escrow, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("锁定活动托管分账失败: %w", err)
+	}
+
+	var campaign models.Campaign
+	if err := tx.Select("id", "merchant_id").Where("id = ?", campaignID).First(&campaign).Error; err != nil {
+		return nil, fmt.Errorf("获取活动失败: %w", err)
+	}
+
+	escrow = models.CampaignEscrow{
+		CampaignID: campaignID,
+		MerchantID: campaign.MerchantID,
+	}
+	if err := tx.Create(&escrow).Error; err != nil {
+		return nil, fmt.Errorf("创建活动托管分账失败: %w", err)
+	}
+	return &escrow, nil
+}
+
+// lockCreditAccounts 行锁读取积分账户
+func lockCreditAccounts(tx *gorm.DB, ids []uuid.UUID) (map[uuid.UUID]*models.CreditAccount, error) {
+	result := map[uuid.UUID]*models.CreditAccount{}
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	var accounts []models.CreditAccount
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id IN ?", ids).Order("id").Find(&accounts).Error; err != nil {
+		return nil, fmt.Errorf("锁定积分账户失败: %w", err)
+	}
+	for i := range accounts {
+		result[accounts[i].ID] = &accounts[i]
+	}
+	if len(result) != len(ids) {
+		return nil, ErrCreditAccountNotFound
+	}
+	return result, nil
+}
+
+// lockSystemAccounts 行锁读取系统账户
+func lockSystemAccounts(tx *gorm.DB, ids []uuid.UUID) (map[uuid.UUID]*models.SystemAccount, error) {
+	result := map[uuid.UUID]*models.SystemAccount{}
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	var accounts []models.SystemAccount
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id IN ?", ids).Order("id").Find(&accounts).Error; err != nil {
+		return nil, fmt.Errorf("锁定系统账户失败: %w", err)
+	}
+	for i := range accounts {
+		result[accounts[i].ID] = &accounts[i]
+	}
+	if len(result) != len(ids) {
+		return nil, errors.New("系统账户不存在")
+	}
+	return result, nil
+}
+
+// lockCashAccounts 行锁读取现金账户
+func lockCashAccounts(tx *gorm.DB, ids []uuid.UUID) (map[uuid.UUID]*models.CashAccount, error) {
+	result := map[uuid.UUID]*models.CashAccount{}
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	var accounts []models.CashAccount
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id IN ?", ids).Order("id").Find(&accounts).Error; err != nil {
+		return nil, fmt.Errorf("锁定现金账户失败: %w", err)
+	}
+	for i := range accounts {
+		result[accounts[i].ID] = &accounts[i]
+	}
+	if len(result) != len(ids) {
+		return nil, ErrCashAccountNotFound
+	}
+	return result, nil
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+// 在线支付渠道
+const (
+	PaymentProviderWechat = "wechat"
+	PaymentProviderAlipay = "alipay"
+	PaymentProviderMock   = "mock"
+)
+
+// PaymentGateway 在线支付渠道适配器
+// 金额统一使用整数分；充值积分按下单时生效的积分汇率换算为应付金额（见 RechargeOrder.CashAmount）
+type PaymentGateway interface {
+	// Provider 渠道标识，与回调地址 /payments/callback/:provider 对应
+	Provider() string
+	// CreateOrder 在渠道侧下单，返回付款链接（扫码支付的二维码内容）
+	CreateOrder(ctx context.Context, req PaymentOrderRequest) (*PaymentOrderResult, error)
+	// QueryOrder 主动查询渠道侧订单状态（回调丢失时补单）
+	QueryOrder(ctx context.Context, outTradeNo string) (*PaymentNotification, error)
+	// VerifyCallback 校验回调签名并解析支付结果，签名不正确时返回 ErrPaymentSignatureInvalid
+	VerifyCallback(r *http.Request, body []byte) (*PaymentNotification, error)
+	// Refund 发起退款
+	Refund(ctx context.Context, req PaymentRefundRequest) (*PaymentRefundResult, error)
+	// CallbackAck 渠道要求的回调应答
+	CallbackAck(success bool, message string) (contentType string, body []byte)
+}
+
+// PaymentOrderRequest 下单请求
+type PaymentOrderRequest struct {
+	OutTradeNo  string // 商户订单号（充值订单号）
+	Amount      int    // 金额（分）
+	Description string
+	ClientIP    string
+	ExpireAt    time.Time
+}
+
+// PaymentOrderResult 下单结果
+type PaymentOrderResult struct {
+	PaymentURL string `json:"paymentUrl"` // 扫码支付链接
+	PrepayID   string `json:"prepayId,omitempty"`
+}
+
+// PaymentNotification 渠道支付结果（回调或主动查询）
+type PaymentNotification struct {
+	Provider        string
+	OutTradeNo      string
+	ProviderTradeNo string
+	Paid            bool // 是否已支付成功
+	Amount          int  // 实付金额（分）
+	PaidAt          time.Time
+}
+
+// PaymentRefundRequest 退款请求
+type PaymentRefundRequest struct {
+	OutTradeNo  string
+	OutRefundNo string
+	Amount      int // 退款金额（分）
+	TotalAmount int // 原订单金额（分）
+	Reason      string
+}
+
+// PaymentRefundResult 退款结果
+type PaymentRefundResult struct {
+	ProviderRefundNo string
+	Status           string // 渠道返回的退款状态
+}
+
+// loadRSAPrivateKey 从 PEM 文件读取商户私钥（PKCS#8 或 PKCS#1）
+func loadRSAPrivateKey(path string) (*rsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取私钥失败: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("私钥格式错误")
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, errors.New("私钥不是 RSA 密钥")
+		}
+		return rsaKey, nil
+	}
+	return x509.ParsePKCS1PrivateKey(block.Bytes)
+}
+
+// loadRSAPublicKey 从 PEM 文件读取渠道公钥（PKIX 公钥或证书）
+func loadRSAPublicKey(path string) (*rsa.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取公钥失败: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("公钥格式错误")
+	}
+
+	var key interface{}
+	if block.Type == "CERTIFICATE" {
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("解析证书失败: %w", err)
+		}
+		key = cert.PublicKey
+	} else {
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("解析公钥失败: %w", err)
+		}
+	}
+
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("公钥不是 RSA 密钥")
+	}
+	return rsaKey, nil
+}
+
+// signSHA256WithRSA RSA-SHA256 签名
+func signSHA256WithRSA(key *rsa.PrivateKey, message []byte) ([]byte, error) {
+	digest := sha256.Sum256(message)
+	return rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+}
+
+// verifySHA256WithRSA RSA-SHA256 验签
+func verifySHA256WithRSA(key *rsa.PublicKey, message, signature []byte) error {
+	digest := sha256.Sum256(message)
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return ErrPaymentSignatureInvalid
+	}
+	return nil
+}
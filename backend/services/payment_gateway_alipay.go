@@ -0,0 +1,326 @@
+package services
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AlipayConfig 支付宝开放平台配置
+type AlipayConfig struct {
+	GatewayURL          string // 默认 https://openapi.alipay.com/gateway.do
+	AppID               string
+	AppPrivateKeyPath   string // 应用私钥 PEM（RSA2）
+	AlipayPublicKeyPath string // 支付宝公钥 PEM（回调验签）
+	NotifyURL           string
+}
+
+// AlipayGateway 支付宝当面付（扫码）适配器
+type AlipayGateway struct {
+	cfg       AlipayConfig
+	appKey    *rsa.PrivateKey
+	alipayKey *rsa.PublicKey
+	client    *http.Client
+}
+
+// NewAlipayGateway 创建支付宝适配器
+func NewAlipayGateway(cfg AlipayConfig) (*AlipayGateway, error) {
+	if cfg.AppID == "" {
+		return nil, fmt.Errorf("支付宝配置不完整")
+	}
+	if cfg.GatewayURL == "" {
+		cfg.GatewayURL = "https://openapi.alipay.com/gateway.do"
+	}
+
+	appKey, err := loadRSAPrivateKey(cfg.AppPrivateKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("支付宝应用私钥: %w", err)
+	}
+	alipayKey, err := loadRSAPublicKey(cfg.AlipayPublicKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("支付宝公钥: %w", err)
+	}
+
+	return &AlipayGateway{
+		cfg:       cfg,
+		appKey:    appKey,
+		alipayKey: alipayKey,
+		client:    &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// Provider 渠道标识
+func (g *AlipayGateway) Provider() string {
+	return PaymentProviderAlipay
+}
+
+// CreateOrder 当面付预下单（alipay.trade.precreate），返回二维码链接
+func (g *AlipayGateway) CreateOrder(ctx context.Context, req PaymentOrderRequest) (*PaymentOrderResult, error) {
+	bizContent := map[string]interface{}{
+		"out_trade_no": req.OutTradeNo,
+		"total_amount": formatYuan(req.Amount),
+		"subject":      req.Description,
+	}
+	if !req.ExpireAt.IsZero() {
+		bizContent["time_expire"] = req.ExpireAt.Format("2006-01-02 15:04:05")
+	}
+
+	var resp struct {
+		QRCode string `json:"qr_code"`
+	}
+	if err := g.call(ctx, "alipay.trade.precreate", bizContent, g.cfg.NotifyURL, &resp); err != nil {
+		return nil, err
+	}
+
+	return &PaymentOrderResult{PaymentURL: resp.QRCode}, nil
+}
+
+// QueryOrder 查询订单（alipay.trade.query）
+func (g *AlipayGateway) QueryOrder(ctx context.Context, outTradeNo string) (*PaymentNotification, error) {
+	var resp struct {
+		OutTradeNo  string `json:"out_trade_no"`
+		TradeNo     string `json:"trade_no"`
+		TradeStatus string `json:"trade_status"`
+		TotalAmount string `json:"total_amount"`
+		SendPayDate string `json:"send_pay_date"`
+	}
+	if err := g.call(ctx, "alipay.trade.query", map[string]interface{}{"out_trade_no": outTradeNo}, "", &resp); err != nil {
+		return nil, err
+	}
+
+	return g.notification(resp.OutTradeNo, resp.TradeNo, resp.TradeStatus, resp.TotalAmount, resp.SendPayDate)
+}
+
+// VerifyCallback 校验异步通知签名（form 表单，RSA2）
+func (g *AlipayGateway) VerifyCallback(r *http.Request, body []byte) (*PaymentNotification, error) {
+	values, err := g.verifyNotify(body)
+	if err != nil {
+		return nil, err
+	}
+
+	return g.notification(values.Get("out_trade_no"), values.Get("trade_no"), values.Get("trade_status"),
+		values.Get("total_amount"), values.Get("gmt_payment"))
+}
+
+// verifyNotify 校验异步通知签名并确认通知属于本应用，返回通知参数
+func (g *AlipayGateway) verifyNotify(body []byte) (url.Values, error) {
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, ErrPaymentSignatureInvalid
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(values.Get("sign"))
+	if err != nil || len(signature) == 0 {
+		return nil, ErrPaymentSignatureInvalid
+	}
+	if values.Get("sign_type") != "" && values.Get("sign_type") != "RSA2" {
+		return nil, ErrPaymentSignatureInvalid
+	}
+
+	values.Del("sign")
+	values.Del("sign_type")
+	if err := verifySHA256WithRSA(g.alipayKey, []byte(alipaySignContent(values)), signature); err != nil {
+		return nil, err
+	}
+
+	// 通知必须属于本应用
+	if values.Get("app_id") != g.cfg.AppID {
+		return nil, ErrPaymentSignatureInvalid
+	}
+
+	return values, nil
+}
+
+// Refund 退款（alipay.trade.refund），同一 out_request_no 重复请求只退一次
+func (g *AlipayGateway) Refund(ctx context.Context, req PaymentRefundRequest) (*PaymentRefundResult, error) {
+	bizContent := map[string]interface{}{
+		"out_trade_no":   req.OutTradeNo,
+		"out_request_no": req.OutRefundNo,
+		"refund_amount":  formatYuan(req.Amount),
+		"refund_reason":  req.Reason,
+	}
+
+	var resp struct {
+		TradeNo    string `json:"trade_no"`
+		FundChange string `json:"fund_change"`
+	}
+	if err := g.call(ctx, "alipay.trade.refund", bizContent, "", &resp); err != nil {
+		return nil, err
+	}
+
+	return &PaymentRefundResult{ProviderRefundNo: resp.TradeNo, Status: resp.FundChange}, nil
+}
+
+// CallbackAck 支付宝要求返回纯文本 success，否则会重试通知
+func (g *AlipayGateway) CallbackAck(success bool, message string) (string, []byte) {
+	if success {
+		return "text/plain; charset=utf-8", []byte("success")
+	}
+	return "text/plain; charset=utf-8", []byte("fail")
+}
+
+// notification 转换为统一的支付结果
+func (g *AlipayGateway) notification(outTradeNo, tradeNo, tradeStatus, totalAmount, paidAt string) (*PaymentNotification, error) {
+	amount, err := parseYuan(totalAmount)
+	if err != nil {
+		return nil, fmt.Errorf("支付宝金额格式错误: %w", err)
+	}
+
+	n := &PaymentNotification{
+		Provider:        PaymentProviderAlipay,
+		OutTradeNo:      outTradeNo,
+		ProviderTradeNo: tradeNo,
+		Paid:            tradeStatus == "TRADE_SUCCESS" || tradeStatus == "TRADE_FINISHED",
+		Amount:          amount,
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04:05", paidAt, time.Local); err == nil {
+		n.PaidAt = t
+	}
+	return n, nil
+}
+
+// call 调用开放平台接口；notifyURL 非空时上送异步通知地址
+func (g *AlipayGateway) call(ctx context.Context, method string, bizContent map[string]interface{}, notifyURL string, out interface{}) error {
+	content, err := json.Marshal(bizContent)
+	if err != nil {
+		return err
+	}
+
+	params := url.Values{}
+	params.Set("app_id", g.cfg.AppID)
+	params.Set("method", method)
+	params.Set("format", "JSON")
+	params.Set("charset", "utf-8")
+	params.Set("sign_type", "RSA2")
+	params.Set("timestamp", time.Now().Format("2006-01-02 15:04:05"))
+	params.Set("version", "1.0")
+	params.Set("biz_content", string(content))
+	if notifyURL != "" {
+		params.Set("notify_url", notifyURL)
+	}
+
+	signature, err := signSHA256WithRSA(g.appKey, []byte(alipaySignContent(params)))
+	if err != nil {
+		return err
+	}
+	params.Set("sign", base64.StdEncoding.EncodeToString(signature))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.cfg.GatewayURL, strings.NewReader(params.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded;charset=utf-8")
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrPaymentGatewayUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrPaymentGatewayUnavailable, err)
+	}
+
+	// 响应节点名为 method 中的 . 替换为 _ 再加 _response
+	var envelope map[string]json.RawMessage
+	if err := json.Unmarshal(respBody, &envelope); err != nil {
+		return fmt.Errorf("解析支付宝响应失败: %w", err)
+	}
+	node, ok := envelope[strings.ReplaceAll(method, ".", "_")+"_response"]
+	if !ok {
+		return fmt.Errorf("%w: 支付宝响应缺少 %s 节点", ErrPaymentGatewayRejected, method)
+	}
+
+	var result struct {
+		Code    string `json:"code"`
+		Msg     string `json:"msg"`
+		SubCode string `json:"sub_code"`
+		SubMsg  string `json:"sub_msg"`
+	}
+	if err := json.Unmarshal(node, &result); err != nil {
+		return fmt.Errorf("解析支付宝响应失败: %w", err)
+	}
+	if result.Code != "10000" {
+		return &alipayAPIError{Code: result.Code, SubCode: result.SubCode, SubMsg: result.SubMsg}
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(node, out); err != nil {
+			return fmt.Errorf("解析支付宝响应失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// alipayAPIError 支付宝接口业务错误
+type alipayAPIError struct {
+	Code    string
+	SubCode string
+	SubMsg  string
+}
+
+func (e *alipayAPIError) Error() string {
+	return fmt.Sprintf("%v: 支付宝返回 %s %s %s", ErrPaymentGatewayRejected, e.Code, e.SubCode, e.SubMsg)
+}
+
+// Unwrap 业务错误统一视为渠道拒绝
+func (e *alipayAPIError) Unwrap() error {
+	return ErrPaymentGatewayRejected
+}
+
+// alipaySignContent 待签名字符串：参数按键名排序，去掉空值，以 k=v 用 & 连接（不做 URL 编码）
+func alipaySignContent(values url.Values) string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		if values.Get(k) != "" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+values.Get(k))
+	}
+	return strings.Join(pairs, "&")
+}
+
+// formatYuan 分转为元字符串（两位小数）
+func formatYuan(fen int) string {
+	return fmt.Sprintf("%d.%02d", fen/100, fen%100)
+}
+
+// parseYuan 元字符串转为分（最多两位小数，按整数解析避免浮点误差）
+func parseYuan(yuan string) (int, error) {
+	parts := strings.SplitN(strings.TrimSpace(yuan), ".", 2)
+	whole, err := strconv.Atoi(parts[0])
+	if err != nil || whole < 0 {
+		return 0, fmt.Errorf("金额格式错误: %s", yuan)
+	}
+
+	fraction := 0
+	if len(parts) == 2 {
+		digits := parts[1]
+		if len(digits) == 0 || len(digits) > 2 {
+			return 0, fmt.Errorf("金额格式错误: %s", yuan)
+		}
+		if len(digits) == 1 {
+			digits += "0"
+		}
+		if fraction, err = strconv.Atoi(digits); err != nil || fraction < 0 {
+			return 0, fmt.Errorf("金额格式错误: %s", yuan)
+		}
+	}
+
+	return whole*100 + fraction, nil
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// MockPaymentSignatureHeader 模拟渠道回调签名请求头（HMAC-SHA256(secret, body) 的十六进制）
+const MockPaymentSignatureHeader = "X-Mock-Signature"
+
+// mockPaymentOrder 模拟渠道内存中的订单
+type mockPaymentOrder struct {
+	amount   int
+	tradeNo  string
+	paid     bool
+	paidAt   time.Time
+	refunded int
+}
+
+// MockPaymentGateway 内存模拟支付渠道，用于开发和测试
+// 下单后调用 MarkPaid 模拟用户付款，返回带签名的回调报文
+type MockPaymentGateway struct {
+	secret string
+	mu     sync.Mutex
+	orders map[string]*mockPaymentOrder
+}
+
+// mockCallbackPayload 模拟渠道回调报文
+type mockCallbackPayload struct {
+	OutTradeNo string    `json:"outTradeNo"`
+	TradeNo    string    `json:"tradeNo"`
+	Status     string    `json:"status"` // SUCCESS / PENDING
+	Amount     int       `json:"amount"`
+	PaidAt     time.Time `json:"paidAt"`
+}
+
+// NewMockPaymentGateway 创建模拟支付渠道；secret 用于回调签名
+func NewMockPaymentGateway(secret string) *MockPaymentGateway {
+	return &MockPaymentGateway{
+		secret: secret,
+		orders: make(map[string]*mockPaymentOrder),
+	}
+}
+
+// Provider 渠道标识
+func (g *MockPaymentGateway) Provider() string {
+	return PaymentProviderMock
+}
+
+// CreateOrder 记录订单并返回模拟付款链接
+func (g *MockPaymentGateway) CreateOrder(ctx context.Context, req PaymentOrderRequest) (*PaymentOrderResult, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, exists := g.orders[req.OutTradeNo]; !exists {
+		g.orders[req.OutTradeNo] = &mockPaymentOrder{
+			amount:  req.Amount,
+			tradeNo: "MOCK" + req.OutTradeNo,
+		}
+	}
+	return &PaymentOrderResult{PaymentURL: "mock://pay/" + req.OutTradeNo}, nil
+}
+
+// QueryOrder 查询模拟订单
+func (g *MockPaymentGateway) QueryOrder(ctx context.Context, outTradeNo string) (*PaymentNotification, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	order, exists := g.orders[outTradeNo]
+	if !exists {
+		return nil, fmt.Errorf("%w: 模拟渠道订单不存在", ErrPaymentGatewayRejected)
+	}
+	return &PaymentNotification{
+		Provider:        PaymentProviderMock,
+		OutTradeNo:      outTradeNo,
+		ProviderTradeNo: order.tradeNo,
+		Paid:            order.paid,
+		Amount:          order.amount,
+		PaidAt:          order.paidAt,
+	}, nil
+}
+
+// VerifyCallback 校验 HMAC 签名并解析回调
+func (g *MockPaymentGateway) VerifyCallback(r *http.Request, body []byte) (*PaymentNotification, error) {
+	signature, err := hex.DecodeString(r.Header.Get(MockPaymentSignatureHeader))
+	if err != nil || !hmac.Equal(signature, g.sign(body)) {
+		return nil, ErrPaymentSignatureInvalid
+	}
+
+	var payload mockCallbackPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("解析模拟渠道回调失败: %w", err)
+	}
+	return &PaymentNotification{
+		Provider:        PaymentProviderMock,
+		OutTradeNo:      payload.OutTradeNo,
+		ProviderTradeNo: payload.TradeNo,
+		Paid:            payload.Status == "SUCCESS",
+		Amount:          payload.Amount,
+		PaidAt:          payload.PaidAt,
+	}, nil
+}
+
+// Refund 模拟退款，累计退款不能超过订单金额
+func (g *MockPaymentGateway) Refund(ctx context.Context, req PaymentRefundRequest) (*PaymentRefundResult, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	order, exists := g.orders[req.OutTradeNo]
+	if !exists || !order.paid {
+		return nil, fmt.Errorf("%w: 模拟渠道订单未支付", ErrPaymentGatewayRejected)
+	}
+	if order.refunded+req.Amount > order.amount {
+		return nil, fmt.Errorf("%w: 退款金额超过订单金额", ErrPaymentGatewayRejected)
+	}
+	order.refunded += req.Amount
+
+	return &PaymentRefundResult{ProviderRefundNo: "MOCK" + req.OutRefundNo, Status: "SUCCESS"}, nil
+}
+
+// CallbackAck 模拟渠道应答
+func (g *MockPaymentGateway) CallbackAck(success bool, message string) (string, []byte) {
+	body, _ := json.Marshal(map[string]interface{}{"success": success, "message": message})
+	return "application/json", body
+}
+
+// MarkPaid 模拟用户付款成功，返回可直接 POST 到回调地址的报文和签名
+func (g *MockPaymentGateway) MarkPaid(outTradeNo string) (body []byte, signature string, err error) {
+	g.mu.Lock()
+	order, exists := g.orders[outTradeNo]
+	if exists && !order.paid {
+		order.paid = true
+		order.paidAt = time.Now()
+	}
+	g.mu.Unlock()
+
+	if !exists {
+		return nil, "", fmt.Errorf("模拟渠道订单不存在: %s", outTradeNo)
+	}
+	return g.signCallback(mockCallbackPayload{
+		OutTradeNo: outTradeNo,
+		TradeNo:    order.tradeNo,
+		Status:     "SUCCESS",
+		Amount:     order.amount,
+		PaidAt:     order.paidAt,
+	})
+}
+
+// signCallback 生成带签名的回调报文
+func (g *MockPaymentGateway) signCallback(payload mockCallbackPayload) ([]byte, string, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, "", err
+	}
+	return body, hex.EncodeToString(g.sign(body)), nil
+}
+
+// sign HMAC-SHA256 签名
+func (g *MockPaymentGateway) sign(body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(g.secret))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"pr-business/models"
+)
+
+func newMockCallback(t *testing.T, body []byte, signature string) *http.Request {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, "/payments/callback/"+PaymentProviderMock, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set(MockPaymentSignatureHeader, signature)
+	return req
+}
+
+func TestMockPaymentCallbackSignature(t *testing.T) {
+	gateway := NewMockPaymentGateway("callback-secret")
+	if _, err := gateway.CreateOrder(context.Background(), PaymentOrderRequest{OutTradeNo: "RC001", Amount: 1000}); err != nil {
+		t.Fatal(err)
+	}
+	body, signature, err := gateway.MarkPaid("RC001")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notification, err := gateway.VerifyCallback(newMockCallback(t, body, signature), body)
+	if err != nil {
+		t.Fatalf("valid callback rejected: %v", err)
+	}
+	if !notification.Paid || notification.OutTradeNo != "RC001" || notification.Amount != 1000 || notification.ProviderTradeNo == "" {
+		t.Fatalf("notification = %+v", notification)
+	}
+
+	tampered := bytes.Replace(body, []byte(`"amount":1000`), []byte(`"amount":1`), 1)
+	forged, forgedSignature, err := NewMockPaymentGateway("other-secret").signCallback(mockCallbackPayload{OutTradeNo: "RC001", Status: "SUCCESS", Amount: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		body      []byte
+		signature string
+	}{
+		{"报文被篡改", tampered, signature},
+		{"缺少签名", body, ""},
+		{"签名不是十六进制", body, "not-hex"},
+		{"其他密钥签名", forged, forgedSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := gateway.VerifyCallback(newMockCallback(t, tt.body, tt.signature), tt.body); !errors.Is(err, ErrPaymentSignatureInvalid) {
+				t.Fatalf("err = %v, want ErrPaymentSignatureInvalid", err)
+			}
+		})
+	}
+
+	// 验签失败的回调在入账前被拒绝
+	service := NewPaymentService(nil, nil, nil, nil, gateway)
+	if _, err := service.HandleCallback(PaymentProviderMock, newMockCallback(t, tampered, signature), tampered); !errors.Is(err, ErrPaymentSignatureInvalid) {
+		t.Fatalf("HandleCallback err = %v, want ErrPaymentSignatureInvalid", err)
+	}
+}
+
+func TestMockPaymentDuplicateNotification(t *testing.T) {
+	gateway := NewMockPaymentGateway("callback-secret")
+	if _, err := gateway.CreateOrder(context.Background(), PaymentOrderRequest{OutTradeNo: "RC002", Amount: 1000}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 渠道重复推送同一笔支付结果
+	first, firstSignature, err := gateway.MarkPaid("RC002")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, secondSignature, err := gateway.MarkPaid("RC002")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(first) != string(second) || firstSignature != secondSignature {
+		t.Fatal("duplicate notification should carry the same payment result")
+	}
+
+	order := &models.RechargeOrder{PaymentMethod: PaymentProviderMock, CashAmount: 1000, Status: models.RechargeOrderStatusPending}
+	credited := 0
+	for _, body := range [][]byte{first, second} {
+		notification, err := gateway.VerifyCallback(newMockCallback(t, body, firstSignature), body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		credit, err := shouldCreditPaidOrder(order, notification)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if credit {
+			credited++
+			order.Status = models.RechargeOrderStatusCompleted
+		}
+	}
+	if credited != 1 {
+		t.Fatalf("credited %d times, want 1", credited)
+	}
+
+	// 已退款的订单再收到通知也不入账
+	order.Status = models.RechargeOrderStatusRefunded
+	notification, _ := gateway.VerifyCallback(newMockCallback(t, first, firstSignature), first)
+	if credit, err := shouldCreditPaidOrder(order, notification); credit || err != nil {
+		t.Fatalf("refunded order: credit = %v, err = %v", credit, err)
+	}
+}
+
+func TestShouldCreditPaidOrderRejects(t *testing.T) {
+	notification := &PaymentNotification{Provider: PaymentProviderMock, OutTradeNo: "RC003", Paid: true, Amount: 1000}
+
+	tests := []struct {
+		name  string
+		order models.RechargeOrder
+		want  error
+	}{
+		{"渠道不符", models.RechargeOrder{PaymentMethod: PaymentProviderWechat, CashAmount: 1000, Status: models.RechargeOrderStatusPending}, ErrPaymentSignatureInvalid},
+		{"金额不一致", models.RechargeOrder{PaymentMethod: PaymentProviderMock, CashAmount: 999, Status: models.RechargeOrderStatusPending}, ErrPaymentAmountMismatch},
+		{"订单已拒绝", models.RechargeOrder{PaymentMethod: PaymentProviderMock, CashAmount: 1000, Status: models.RechargeOrderStatusRejected}, ErrInvalidRechargeOrderStatus},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if credit, err := shouldCreditPaidOrder(&tt.order, notification); credit || !errors.Is(err, tt.want) {
+				t.Fatalf("credit = %v, err = %v, want %v", credit, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMockPaymentRefundLimit(t *testing.T) {
+	gateway := NewMockPaymentGateway("callback-secret")
+	ctx := context.Background()
+	if _, err := gateway.CreateOrder(ctx, PaymentOrderRequest{OutTradeNo: "RC004", Amount: 1000}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 未支付的订单不能退款
+	if _, err := gateway.Refund(ctx, PaymentRefundRequest{OutTradeNo: "RC004", OutRefundNo: "RC004R1", Amount: 100}); !errors.Is(err, ErrPaymentGatewayRejected) {
+		t.Fatalf("unpaid refund err = %v", err)
+	}
+	if _, _, err := gateway.MarkPaid("RC004"); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := gateway.Refund(ctx, PaymentRefundRequest{OutTradeNo: "RC004", OutRefundNo: "RC004R2", Amount: 600, TotalAmount: 1000})
+	if err != nil || result.Status != "SUCCESS" {
+		t.Fatalf("refund result = %+v, err = %v", result, err)
+	}
+	// 累计退款超过实付金额时渠道拒绝，PaymentService 据此解冻积分
+	if _, err := gateway.Refund(ctx, PaymentRefundRequest{OutTradeNo: "RC004", OutRefundNo: "RC004R3", Amount: 500, TotalAmount: 1000}); !errors.Is(err, ErrPaymentGatewayRejected) {
+		t.Fatalf("over refund err = %v", err)
+	}
+}
@@ -0,0 +1,357 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WechatPayConfig 微信支付（APIv3）配置
+type WechatPayConfig struct {
+	BaseURL           string // 默认 https://api.mch.weixin.qq.com
+	AppID             string
+	MchID             string
+	MerchantSerialNo  string // 商户 API 证书序列号
+	MerchantKeyPath   string // 商户 API 私钥 PEM
+	PlatformKeyPath   string // 微信支付平台公钥/证书 PEM（回调验签、敏感信息加密）
+	PlatformSerialNo  string // 微信支付平台公钥 ID/证书序列号（上送加密字段时必填）
+	APIv3Key          string // APIv3 密钥（回调报文解密）
+	NotifyURL         string
+	CallbackTolerance time.Duration // 回调时间戳允许偏差，防重放
+}
+
+// WechatPayGateway 微信支付 Native（扫码）适配器
+type WechatPayGateway struct {
+	cfg         WechatPayConfig
+	merchantKey *rsa.PrivateKey
+	platformKey *rsa.PublicKey
+	client      *http.Client
+}
+
+// NewWechatPayGateway 创建微信支付适配器
+func NewWechatPayGateway(cfg WechatPayConfig) (*WechatPayGateway, error) {
+	if cfg.MchID == "" || cfg.AppID == "" || cfg.APIv3Key == "" {
+		return nil, fmt.Errorf("微信支付配置不完整")
+	}
+	if len(cfg.APIv3Key) != 32 {
+		return nil, fmt.Errorf("微信支付 APIv3 密钥长度必须为 32")
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = "https://api.mch.weixin.qq.com"
+	}
+	if cfg.CallbackTolerance <= 0 {
+		cfg.CallbackTolerance = 5 * time.Minute
+	}
+
+	merchantKey, err := loadRSAPrivateKey(cfg.MerchantKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("微信支付商户私钥: %w", err)
+	}
+	platformKey, err := loadRSAPublicKey(cfg.PlatformKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("微信支付平台公钥: %w", err)
+	}
+
+	return &WechatPayGateway{
+		cfg:         cfg,
+		merchantKey: merchantKey,
+		platformKey: platformKey,
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// Provider 渠道标识
+func (g *WechatPayGateway) Provider() string {
+	return PaymentProviderWechat
+}
+
+// wechatAmount 微信支付金额结构
+type wechatAmount struct {
+	Total    int    `json:"total,omitempty"`
+	Refund   int    `json:"refund,omitempty"`
+	Currency string `json:"currency,omitempty"`
+}
+
+// wechatTransaction 微信支付订单（查询结果与回调解密后的报文结构相同）
+type wechatTransaction struct {
+	OutTradeNo    string       `json:"out_trade_no"`
+	TransactionID string       `json:"transaction_id"`
+	TradeState    string       `json:"trade_state"`
+	SuccessTime   string       `json:"success_time"`
+	Amount        wechatAmount `json:"amount"`
+}
+
+// CreateOrder Native 下单，返回 code_url
+func (g *WechatPayGateway) CreateOrder(ctx context.Context, req PaymentOrderRequest) (*PaymentOrderResult, error) {
+	payload := map[string]interface{}{
+		"appid":        g.cfg.AppID,
+		"mchid":        g.cfg.MchID,
+		"description":  req.Description,
+		"out_trade_no": req.OutTradeNo,
+		"notify_url":   g.cfg.NotifyURL,
+		"amount":       wechatAmount{Total: req.Amount, Currency: "CNY"},
+	}
+	if !req.ExpireAt.IsZero() {
+		payload["time_expire"] = req.ExpireAt.Format(time.RFC3339)
+	}
+	if req.ClientIP != "" {
+		payload["scene_info"] = map[string]string{"payer_client_ip": req.ClientIP}
+	}
+
+	var resp struct {
+		CodeURL string `json:"code_url"`
+	}
+	if err := g.do(ctx, http.MethodPost, "/v3/pay/transactions/native", payload, &resp); err != nil {
+		return nil, err
+	}
+
+	return &PaymentOrderResult{PaymentURL: resp.CodeURL}, nil
+}
+
+// QueryOrder 按商户订单号查询
+func (g *WechatPayGateway) QueryOrder(ctx context.Context, outTradeNo string) (*PaymentNotification, error) {
+	path := fmt.Sprintf("/v3/pay/transactions/out-trade-no/%s?mchid=%s", url.PathEscape(outTradeNo), url.QueryEscape(g.cfg.MchID))
+
+	var txn wechatTransaction
+	if err := g.do(ctx, http.MethodGet, path, nil, &txn); err != nil {
+		return nil, err
+	}
+	return g.notification(&txn), nil
+}
+
+// VerifyCallback 校验 Wechatpay-Signature 并解密回调报文
+func (g *WechatPayGateway) VerifyCallback(r *http.Request, body []byte) (*PaymentNotification, error) {
+	plaintext, err := g.decryptNotify(r, body)
+	if err != nil {
+		return nil, err
+	}
+
+	var txn wechatTransaction
+	if err := json.Unmarshal(plaintext, &txn); err != nil {
+		return nil, fmt.Errorf("解析微信支付订单失败: %w", err)
+	}
+	return g.notification(&txn), nil
+}
+
+// decryptNotify 校验回调签名、时间戳并解密 resource，返回明文
+// 支付、退款、商家转账等回调使用相同的报文格式
+func (g *WechatPayGateway) decryptNotify(r *http.Request, body []byte) ([]byte, error) {
+	timestamp := r.Header.Get("Wechatpay-Timestamp")
+	nonce := r.Header.Get("Wechatpay-Nonce")
+	signature := r.Header.Get("Wechatpay-Signature")
+	if timestamp == "" || nonce == "" || signature == "" {
+		return nil, ErrPaymentSignatureInvalid
+	}
+
+	// 拒绝时间戳偏差过大的回调，防止重放
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, ErrPaymentSignatureInvalid
+	}
+	if skew := time.Since(time.Unix(ts, 0)); skew > g.cfg.CallbackTolerance || skew < -g.cfg.CallbackTolerance {
+		return nil, ErrPaymentSignatureInvalid
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return nil, ErrPaymentSignatureInvalid
+	}
+	message := timestamp + "\n" + nonce + "\n" + string(body) + "\n"
+	if err := verifySHA256WithRSA(g.platformKey, []byte(message), sig); err != nil {
+		return nil, err
+	}
+
+	var notify struct {
+		EventType string `json:"event_type"`
+		Resource  struct {
+			Algorithm      string `json:"algorithm"`
+			Ciphertext     string `json:"ciphertext"`
+			AssociatedData string `json:"associated_data"`
+			Nonce          string `json:"nonce"`
+		} `json:"resource"`
+	}
+	if err := json.Unmarshal(body, &notify); err != nil {
+		return nil, fmt.Errorf("解析微信支付回调失败: %w", err)
+	}
+
+	return g.decryptResource(notify.Resource.Ciphertext, notify.Resource.Nonce, notify.Resource.AssociatedData)
+}
+
+// Refund 申请退款
+func (g *WechatPayGateway) Refund(ctx context.Context, req PaymentRefundRequest) (*PaymentRefundResult, error) {
+	payload := map[string]interface{}{
+		"out_trade_no":  req.OutTradeNo,
+		"out_refund_no": req.OutRefundNo,
+		"reason":        req.Reason,
+		"amount":        wechatAmount{Refund: req.Amount, Total: req.TotalAmount, Currency: "CNY"},
+	}
+
+	var resp struct {
+		RefundID string `json:"refund_id"`
+		Status   string `json:"status"`
+	}
+	if err := g.do(ctx, http.MethodPost, "/v3/refund/domestic/refunds", payload, &resp); err != nil {
+		return nil, err
+	}
+
+	return &PaymentRefundResult{ProviderRefundNo: resp.RefundID, Status: resp.Status}, nil
+}
+
+// CallbackAck 微信支付要求的应答：成功返回 2xx 即可，失败返回 FAIL
+func (g *WechatPayGateway) CallbackAck(success bool, message string) (string, []byte) {
+	code := "SUCCESS"
+	if !success {
+		code = "FAIL"
+	}
+	body, _ := json.Marshal(map[string]string{"code": code, "message": message})
+	return "application/json", body
+}
+
+// notification 转换为统一的支付结果
+func (g *WechatPayGateway) notification(txn *wechatTransaction) *PaymentNotification {
+	n := &PaymentNotification{
+		Provider:        PaymentProviderWechat,
+		OutTradeNo:      txn.OutTradeNo,
+		ProviderTradeNo: txn.TransactionID,
+		Paid:            txn.TradeState == "SUCCESS",
+		Amount:          txn.Amount.Total,
+	}
+	if paidAt, err := time.Parse(time.RFC3339, txn.SuccessTime); err == nil {
+		n.PaidAt = paidAt
+	}
+	return n
+}
+
+// decryptResource AEAD_AES_256_GCM 解密回调报文
+func (g *WechatPayGateway) decryptResource(ciphertext, nonce, associatedData string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return nil, ErrPaymentSignatureInvalid
+	}
+
+	block, err := aes.NewCipher([]byte(g.cfg.APIv3Key))
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(nonce))
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := gcm.Open(nil, []byte(nonce), data, []byte(associatedData))
+	if err != nil {
+		return nil, ErrPaymentSignatureInvalid
+	}
+	return plaintext, nil
+}
+
+// encryptSensitive 使用平台公钥加密敏感字段（RSA-OAEP），如收款人姓名
+func (g *WechatPayGateway) encryptSensitive(plaintext string) (string, error) {
+	ciphertext, err := rsa.EncryptOAEP(sha1.New(), rand.Reader, g.platformKey, []byte(plaintext), nil)
+	if err != nil {
+		return "", fmt.Errorf("加密敏感信息失败: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// wechatAPIError 微信支付接口业务错误
+type wechatAPIError struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *wechatAPIError) Error() string {
+	return fmt.Sprintf("%v: 微信支付返回 %d %s %s", ErrPaymentGatewayRejected, e.StatusCode, e.Code, e.Message)
+}
+
+// Unwrap 业务错误统一视为渠道拒绝
+func (e *wechatAPIError) Unwrap() error {
+	return ErrPaymentGatewayRejected
+}
+
+// do 发送带商户签名的请求
+func (g *WechatPayGateway) do(ctx context.Context, method, path string, payload interface{}, out interface{}) error {
+	var body []byte
+	if payload != nil {
+		var err error
+		if body, err = json.Marshal(payload); err != nil {
+			return err
+		}
+	}
+
+	authorization, err := g.authorization(method, path, body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, g.cfg.BaseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+	if g.cfg.PlatformSerialNo != "" {
+		req.Header.Set("Wechatpay-Serial", g.cfg.PlatformSerialNo)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrPaymentGatewayUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrPaymentGatewayUnavailable, err)
+	}
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(respBody, &apiErr)
+		return &wechatAPIError{StatusCode: resp.StatusCode, Code: apiErr.Code, Message: apiErr.Message}
+	}
+
+	if out != nil && len(respBody) > 0 {
+		if err := json.Unmarshal(respBody, out); err != nil {
+			return fmt.Errorf("解析微信支付响应失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// authorization 生成 WECHATPAY2-SHA256-RSA2048 认证头
+func (g *WechatPayGateway) authorization(method, path string, body []byte) (string, error) {
+	nonceBytes := make([]byte, 16)
+	if _, err := rand.Read(nonceBytes); err != nil {
+		return "", err
+	}
+	nonce := strings.ToUpper(hex.EncodeToString(nonceBytes))
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	message := method + "\n" + path + "\n" + timestamp + "\n" + nonce + "\n" + string(body) + "\n"
+	signature, err := signSHA256WithRSA(g.merchantKey, []byte(message))
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(`WECHATPAY2-SHA256-RSA2048 mchid="%s",nonce_str="%s",signature="%s",timestamp="%s",serial_no="%s"`,
+		g.cfg.MchID, nonce, base64.StdEncoding.EncodeToString(signature), timestamp, g.cfg.MerchantSerialNo), nil
+}
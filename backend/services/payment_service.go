@@ -0,0 +1,621 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"pr-business/constants"
+	"pr-business/models"
+)
+
+// onlineRechargeExpiry 在线支付订单的付款有效期
+const onlineRechargeExpiry = 2 * time.Hour
+
+// PaymentService 在线充值：渠道下单、回调入账、主动查单和退款
+// 入账以充值订单行锁 + 状态判断保证每个订单只入账一次，回调和查单可以重复到达
+type PaymentService struct {
+	db            *gorm.DB
+	ledgerService *LedgerService
+	auditService  *AuditService
+	rateService   *ExchangeRateService
+	gateways      map[string]PaymentGateway
+}
+
+// NewPaymentService 创建在线充值服务，gateways 为已启用的支付渠道
+func NewPaymentService(db *gorm.DB, ledgerService *LedgerService, auditService *AuditService, rateService *ExchangeRateService, gateways ...PaymentGateway) *PaymentService {
+	registry := make(map[string]PaymentGateway, len(gateways))
+	for _, gateway := range gateways {
+		registry[gateway.Provider()] = gateway
+	}
+	return &PaymentService{
+		db:            db,
+		ledgerService: ledgerService,
+		auditService:  auditService,
+		rateService:   rateService,
+		gateways:      registry,
+	}
+}
+
+// Gateway 按渠道标识获取支付渠道
+func (s *PaymentService) Gateway(provider string) (PaymentGateway, error) {
+	gateway, ok := s.gateways[provider]
+	if !ok {
+		return nil, ErrPaymentProviderNotSupported
+	}
+	return gateway, nil
+}
+
+// Providers 已启用的支付渠道
+func (s *PaymentService) Providers() []string {
+	providers := make([]string, 0, len(s.gateways))
+	for provider := range s.gateways {
+		providers = append(providers, provider)
+	}
+	return providers
+}
+
+// QuoteRecharge 按当前生效的积分汇率计算充值应付金额（分）
+func (s *PaymentService) QuoteRecharge(amount int) (*models.ExchangeRate, int, error) {
+	rate, err := s.rateService.Current()
+	if err != nil {
+		return nil, 0, err
+	}
+	return rate, rate.CreditsToCents(amount, RechargeChargeRounding), nil
+}
+
+// CreateOnlineRecharge 创建在线充值订单并在渠道下单，返回带付款链接的订单
+// amount 为充值积分，渠道下单金额按当前汇率换算
+func (s *PaymentService) CreateOnlineRecharge(ctx context.Context, userID string, accountID uuid.UUID, amount int, provider string, clientIP string) (*models.RechargeOrder, error) {
+	gateway, err := s.Gateway(provider)
+	if err != nil {
+		return nil, err
+	}
+	rate, cashAmount, err := s.QuoteRecharge(amount)
+	if err != nil {
+		return nil, err
+	}
+
+	order := models.RechargeOrder{
+		ID:             uuid.New(),
+		UserID:         userID,
+		AccountID:      accountID,
+		Amount:         amount,
+		ExchangeRateID: &rate.ID,
+		CashAmount:     cashAmount,
+		PaymentMethod:  provider,
+		Channel:        models.RechargeChannelOnline,
+		Status:         models.RechargeOrderStatusPending,
+	}
+	// 商户订单号：订单 ID 去掉连字符（32 位，满足各渠道长度限制）
+	outTradeNo := strings.ReplaceAll(order.ID.String(), "-", "")
+	order.OutTradeNo = &outTradeNo
+
+	if err := s.db.Create(&order).Error; err != nil {
+		return nil, fmt.Errorf("创建充值订单失败: %w", err)
+	}
+
+	result, err := gateway.CreateOrder(ctx, PaymentOrderRequest{
+		OutTradeNo:  outTradeNo,
+		Amount:      cashAmount,
+		Description: fmt.Sprintf("积分充值 %d", amount),
+		ClientIP:    clientIP,
+		ExpireAt:    time.Now().Add(onlineRechargeExpiry),
+	})
+	if err != nil {
+		// 渠道下单失败，订单作废
+		s.db.Model(&order).Updates(map[string]interface{}{
+			"status":         models.RechargeOrderStatusRejected,
+			"rejection_note": "渠道下单失败",
+			"updated_at":     time.Now(),
+		})
+		return nil, err
+	}
+
+	order.PaymentURL = result.PaymentURL
+	if err := s.db.Model(&order).Updates(map[string]interface{}{
+		"payment_url": result.PaymentURL,
+		"updated_at":  time.Now(),
+	}).Error; err != nil {
+		return nil, fmt.Errorf("保存付款链接失败: %w", err)
+	}
+
+	return &order, nil
+}
+
+// HandleCallback 校验渠道回调并入账；未支付成功的通知直接忽略
+func (s *PaymentService) HandleCallback(provider string, r *http.Request, body []byte) (*models.RechargeOrder, error) {
+	gateway, err := s.Gateway(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	notification, err := gateway.VerifyCallback(r, body)
+	if err != nil {
+		return nil, err
+	}
+	if !notification.Paid {
+		return nil, nil
+	}
+
+	return s.CompletePaidOrder(notification)
+}
+
+// SyncOrder 主动向渠道查询订单状态，已支付则入账（回调丢失时由前端轮询或管理员触发）；
+// 已入账订单有结果未知的退款时按原退款单号重新提交
+func (s *PaymentService) SyncOrder(ctx context.Context, order *models.RechargeOrder) (*models.RechargeOrder, error) {
+	if order.Channel != models.RechargeChannelOnline || order.OutTradeNo == nil {
+		return nil, ErrRechargeOrderNotOnline
+	}
+	// 已入账的订单：补提交结果未知的退款
+	if order.Status == models.RechargeOrderStatusCompleted {
+		return s.retryPendingRefund(ctx, order)
+	}
+	if order.Status != models.RechargeOrderStatusPending {
+		return order, nil
+	}
+
+	gateway, err := s.Gateway(order.PaymentMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	notification, err := gateway.QueryOrder(ctx, *order.OutTradeNo)
+	if err != nil {
+		return nil, err
+	}
+	if !notification.Paid {
+		return order, nil
+	}
+
+	return s.CompletePaidOrder(notification)
+}
+
+// CompletePaidOrder 按渠道支付结果完成充值订单并入账
+// 已完成的订单重复通知时直接返回；金额不一致时拒绝入账
+func (s *PaymentService) CompletePaidOrder(notification *PaymentNotification) (*models.RechargeOrder, error) {
+	var order models.RechargeOrder
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("out_trade_no = ?", notification.OutTradeNo).
+			First(&order).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRechargeOrderNotFound
+			}
+			return err
+		}
+
+		credit, err := shouldCreditPaidOrder(&order, notification)
+		if err != nil || !credit {
+			return err
+		}
+
+		issuanceID, err := s.ledgerService.SystemAccountID(tx, constants.SystemAccountTypeCreditIssuance)
+		if err != nil {
+			return err
+		}
+		if _, err := s.ledgerService.Post(tx, &LedgerTransfer{
+			Type:        models.TransactionRecharge,
+			Description: fmt.Sprintf("在线充值：%s（%s）", order.ID, notification.Provider),
+			Postings: []LedgerPosting{
+				SystemPosting(issuanceID, -order.Amount),
+				CreditPosting(order.AccountID, order.Amount),
+			},
+		}); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		paidAt := notification.PaidAt
+		if paidAt.IsZero() {
+			paidAt = now
+		}
+		if err := tx.Model(&order).Updates(map[string]interface{}{
+			"status":            models.RechargeOrderStatusCompleted,
+			"provider_trade_no": notification.ProviderTradeNo,
+			"paid_amount":       notification.Amount,
+			"paid_at":           paidAt,
+			"processed_at":      now,
+			"updated_at":        now,
+		}).Error; err != nil {
+			return fmt.Errorf("更新充值订单失败: %w", err)
+		}
+		order.Status = models.RechargeOrderStatusCompleted
+		order.ProviderTradeNo = notification.ProviderTradeNo
+		order.PaidAmount = notification.Amount
+		order.PaidAt = &paidAt
+		order.ProcessedAt = &now
+
+		return s.auditService.WithTx(tx).LogFinancialOperation(
+			"system",
+			constants.AuditActionRechargeOnline,
+			constants.AuditResourceRechargeOrder,
+			order.ID.String(),
+			map[string]interface{}{
+				"provider":          notification.Provider,
+				"out_trade_no":      notification.OutTradeNo,
+				"provider_trade_no": notification.ProviderTradeNo,
+				"amount":            notification.Amount,
+			},
+			"",
+			"",
+		)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &order, nil
+}
+
+// shouldCreditPaidOrder 判断渠道支付结果是否需要为订单入账
+// 重复通知（订单已入账或已退款）返回 false；渠道不符、金额不一致或状态不允许时返回错误
+func shouldCreditPaidOrder(order *models.RechargeOrder, notification *PaymentNotification) (bool, error) {
+	if order.PaymentMethod != notification.Provider {
+		return false, fmt.Errorf("%w: 订单渠道为 %s", ErrPaymentSignatureInvalid, order.PaymentMethod)
+	}
+
+	// 重复通知：已入账
+	if order.Status == models.RechargeOrderStatusCompleted || order.Status == models.RechargeOrderStatusRefunded {
+		return false, nil
+	}
+	if order.Status != models.RechargeOrderStatusPending {
+		return false, fmt.Errorf("%w: 订单状态为 %s", ErrInvalidRechargeOrderStatus, order.Status)
+	}
+
+	// 实付金额必须等于下单时按汇率换算的应付金额
+	if notification.Amount != order.CashAmount {
+		return false, fmt.Errorf("%w: 订单 %d 分，实付 %d 分", ErrPaymentAmountMismatch, order.CashAmount, notification.Amount)
+	}
+	return true, nil
+}
+
+// Refund 在线充值退款：先冻结退款积分并写入 pending 退款记录后提交，再向渠道发起退款；
+// 渠道受理后扣回冻结积分，渠道拒绝时解冻，结果未知时保持 pending，由 SyncOrder 按同一退款单号重试
+func (s *PaymentService) Refund(ctx context.Context, orderID string, amount int, reason string, operatorID string) (*models.RechargeOrder, error) {
+	refund, err := s.reserveRefund(orderID, amount, reason, operatorID)
+	if err != nil {
+		return nil, err
+	}
+	return s.submitRefund(ctx, refund)
+}
+
+// reserveRefund 锁定订单校验可退金额，冻结退款积分并写入 pending 退款记录
+// 处理中的退款计入 refunded_amount，渠道拒绝时再扣减
+func (s *PaymentService) reserveRefund(orderID string, amount int, reason string, operatorID string) (*models.RechargeRefund, error) {
+	var refund *models.RechargeRefund
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var order models.RechargeOrder
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", orderID).
+			First(&order).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRechargeOrderNotFound
+			}
+			return err
+		}
+
+		if order.Channel != models.RechargeChannelOnline || order.OutTradeNo == nil {
+			return ErrRechargeOrderNotOnline
+		}
+		if order.Status != models.RechargeOrderStatusCompleted {
+			return fmt.Errorf("%w: 订单状态为 %s", ErrInvalidRechargeOrderStatus, order.Status)
+		}
+		if amount <= 0 || order.RefundedAmount+amount > order.Amount {
+			return ErrRefundExceedsPaid
+		}
+		if _, err := s.Gateway(order.PaymentMethod); err != nil {
+			return err
+		}
+
+		// 同一订单同一时间只处理一笔退款，按累计退款积分换算的分差额才能首尾相接
+		var attempts, pending int64
+		if err := tx.Model(&models.RechargeRefund{}).Where("recharge_order_id = ?", order.ID).Count(&attempts).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.RechargeRefund{}).
+			Where("recharge_order_id = ? AND status = ?", order.ID, models.RechargeRefundStatusPending).
+			Count(&pending).Error; err != nil {
+			return err
+		}
+		if pending > 0 {
+			return ErrRefundInProgress
+		}
+
+		// 退款金额按下单时的汇率换算；按累计退款积分换算后取差额，全额退款时合计等于应付金额
+		if order.ExchangeRateID == nil {
+			return ErrExchangeRateNotFound
+		}
+		rate, err := s.rateService.Get(tx, order.ExchangeRateID.String())
+		if err != nil {
+			return err
+		}
+		refundedAfter := order.RefundedAmount + amount
+		refundCents := rate.CreditsToCents(refundedAfter, RechargeChargeRounding) -
+			rate.CreditsToCents(order.RefundedAmount, RechargeChargeRounding)
+
+		// 已申请或已开具的发票金额不能超过退款后的实付金额，需先驳回开票申请或红冲发票
+		remaining, err := rechargedCents(tx, s.rateService, &order, refundedAfter)
+		if err != nil {
+			return err
+		}
+		invoiced, err := invoicedCents(tx, order.ID)
+		if err != nil {
+			return err
+		}
+		if invoiced > remaining {
+			return fmt.Errorf("%w: 订单已开票 %s 元，退款后实付 %s 元", ErrInvoiceExceedsRecharge, formatYuan(invoiced), formatYuan(remaining))
+		}
+
+		// 冻结退款积分：渠道结果确认前商家不能再使用这部分积分
+		if _, err := s.ledgerService.Post(tx, &LedgerTransfer{
+			Type:        models.TransactionRechargeRefundFreeze,
+			Description: fmt.Sprintf("充值退款冻结：%s", order.ID),
+			Postings:    FreezePostings(order.AccountID, amount),
+		}); err != nil {
+			return err
+		}
+
+		refund = &models.RechargeRefund{
+			RechargeOrderID: order.ID,
+			OutRefundNo:     fmt.Sprintf("%sR%d", *order.OutTradeNo, attempts+1),
+			Amount:          amount,
+			RefundCents:     refundCents,
+			Reason:          reason,
+			Status:          models.RechargeRefundStatusPending,
+			OperatorID:      operatorID,
+		}
+		if err := tx.Create(refund).Error; err != nil {
+			return fmt.Errorf("创建退款记录失败: %w", err)
+		}
+
+		return tx.Model(&order).Updates(map[string]interface{}{
+			"refunded_amount": refundedAfter,
+			"updated_at":      time.Now(),
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return refund, nil
+}
+
+// submitRefund 向渠道提交退款并按结果完成退款记录；同一退款单号重复提交时渠道只退一次
+func (s *PaymentService) submitRefund(ctx context.Context, refund *models.RechargeRefund) (*models.RechargeOrder, error) {
+	var order models.RechargeOrder
+	if err := s.db.Where("id = ?", refund.RechargeOrderID).First(&order).Error; err != nil {
+		return nil, err
+	}
+	gateway, err := s.Gateway(order.PaymentMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := gateway.Refund(ctx, PaymentRefundRequest{
+		OutTradeNo:  *order.OutTradeNo,
+		OutRefundNo: refund.OutRefundNo,
+		Amount:      refund.RefundCents,
+		TotalAmount: order.PaidAmount,
+		Reason:      refund.Reason,
+	})
+	if err != nil {
+		if errors.Is(err, ErrPaymentGatewayRejected) {
+			if failErr := s.failRefund(refund.ID, err.Error()); failErr != nil {
+				return nil, failErr
+			}
+			return nil, err
+		}
+
+		// 网络错误等结果未知：保留冻结和 pending 记录，查单时重试
+		log.Printf("[PaymentService] 退款 %s 结果未知，等待重试: %v", refund.OutRefundNo, err)
+		if updateErr := s.db.Model(&models.RechargeRefund{}).
+			Where("id = ? AND status = ?", refund.ID, models.RechargeRefundStatusPending).
+			Updates(map[string]interface{}{"failure_reason": err.Error(), "updated_at": time.Now()}).Error; updateErr != nil {
+			log.Printf("[PaymentService] 记录退款错误失败: %v", updateErr)
+		}
+		return nil, fmt.Errorf("%w: %v", ErrRefundPending, err)
+	}
+
+	return s.completeRefund(refund.ID, result)
+}
+
+// lockRefund 在事务内依次锁定充值订单和退款记录（与 reserveRefund 的加锁顺序一致）
+func lockRefund(tx *gorm.DB, refundID uuid.UUID) (*models.RechargeOrder, *models.RechargeRefund, error) {
+	var refund models.RechargeRefund
+	if err := tx.Where("id = ?", refundID).First(&refund).Error; err != nil {
+		return nil, nil, err
+	}
+	var order models.RechargeOrder
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", refund.RechargeOrderID).
+		First(&order).Error; err != nil {
+		return nil, nil, err
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", refundID).
+		First(&refund).Error; err != nil {
+		return nil, nil, err
+	}
+	return &order, &refund, nil
+}
+
+// completeRefund 渠道已受理：扣回冻结积分，全额退款时订单标记为已退款
+func (s *PaymentService) completeRefund(refundID uuid.UUID, result *PaymentRefundResult) (*models.RechargeOrder, error) {
+	var order *models.RechargeOrder
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var refund *models.RechargeRefund
+		var err error
+		order, refund, err = lockRefund(tx, refundID)
+		if err != nil {
+			return err
+		}
+		// 并发重试已处理
+		if refund.Status != models.RechargeRefundStatusPending {
+			return nil
+		}
+
+		issuanceID, err := s.ledgerService.SystemAccountID(tx, constants.SystemAccountTypeCreditIssuance)
+		if err != nil {
+			return err
+		}
+		if _, err := s.ledgerService.Post(tx, &LedgerTransfer{
+			Type:        models.TransactionRechargeRefund,
+			Description: fmt.Sprintf("充值退款：%s", order.ID),
+			Postings: []LedgerPosting{
+				FrozenPosting(order.AccountID, -refund.Amount),
+				SystemPosting(issuanceID, refund.Amount),
+			},
+		}); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(refund).Updates(map[string]interface{}{
+			"status":             models.RechargeRefundStatusSucceeded,
+			"provider_refund_no": result.ProviderRefundNo,
+			"provider_status":    result.Status,
+			"failure_reason":     "",
+			"completed_at":       now,
+			"updated_at":         now,
+		}).Error; err != nil {
+			return fmt.Errorf("更新退款记录失败: %w", err)
+		}
+
+		if order.RefundedAmount == order.Amount {
+			order.Status = models.RechargeOrderStatusRefunded
+			if err := tx.Model(order).Updates(map[string]interface{}{
+				"status":     order.Status,
+				"updated_at": now,
+			}).Error; err != nil {
+				return fmt.Errorf("更新充值订单失败: %w", err)
+			}
+		}
+
+		return s.auditService.WithTx(tx).LogFinancialOperation(
+			refund.OperatorID,
+			constants.AuditActionRechargeRefund,
+			constants.AuditResourceRechargeOrder,
+			order.ID.String(),
+			map[string]interface{}{
+				"refund_id":          refund.ID,
+				"out_refund_no":      refund.OutRefundNo,
+				"amount":             refund.Amount,
+				"refund_cents":       refund.RefundCents,
+				"reason":             refund.Reason,
+				"provider_refund_no": result.ProviderRefundNo,
+				"provider_status":    result.Status,
+			},
+			"",
+			"",
+		)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// failRefund 渠道拒绝退款：解冻退款积分并扣减订单已退款积分
+func (s *PaymentService) failRefund(refundID uuid.UUID, reason string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		order, refund, err := lockRefund(tx, refundID)
+		if err != nil {
+			return err
+		}
+		if refund.Status != models.RechargeRefundStatusPending {
+			return nil
+		}
+
+		if _, err := s.ledgerService.Post(tx, &LedgerTransfer{
+			Type:        models.TransactionRechargeRefundRelease,
+			Description: fmt.Sprintf("充值退款失败：%s", order.ID),
+			Postings:    UnfreezePostings(order.AccountID, refund.Amount),
+		}); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(refund).Updates(map[string]interface{}{
+			"status":         models.RechargeRefundStatusFailed,
+			"failure_reason": reason,
+			"completed_at":   now,
+			"updated_at":     now,
+		}).Error; err != nil {
+			return fmt.Errorf("更新退款记录失败: %w", err)
+		}
+		if err := tx.Model(order).Updates(map[string]interface{}{
+			"refunded_amount": order.RefundedAmount - refund.Amount,
+			"updated_at":      now,
+		}).Error; err != nil {
+			return fmt.Errorf("更新充值订单失败: %w", err)
+		}
+
+		return s.auditService.WithTx(tx).LogFinancialOperation(
+			refund.OperatorID,
+			constants.AuditActionRechargeRefundFail,
+			constants.AuditResourceRechargeOrder,
+			order.ID.String(),
+			map[string]interface{}{
+				"refund_id":     refund.ID,
+				"out_refund_no": refund.OutRefundNo,
+				"amount":        refund.Amount,
+				"reason":        reason,
+			},
+			"",
+			"",
+		)
+	})
+}
+
+// retryPendingRefund 重新提交订单处理中的退款（上次调用渠道结果未知）
+func (s *PaymentService) retryPendingRefund(ctx context.Context, order *models.RechargeOrder) (*models.RechargeOrder, error) {
+	var refund models.RechargeRefund
+	err := s.db.Where("recharge_order_id = ? AND status = ?", order.ID, models.RechargeRefundStatusPending).
+		First(&refund).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return order, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.submitRefund(ctx, &refund)
+}
+
+// SimulateMockPayment 模拟用户付款（仅模拟渠道），走与真实回调相同的验签和入账流程
+func (s *PaymentService) SimulateMockPayment(outTradeNo string) (*models.RechargeOrder, error) {
+	gateway, err := s.Gateway(PaymentProviderMock)
+	if err != nil {
+		return nil, err
+	}
+	mock := gateway.(*MockPaymentGateway)
+
+	body, signature, err := mock.MarkPaid(outTradeNo)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, "/payments/callback/"+PaymentProviderMock, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(MockPaymentSignatureHeader, signature)
+
+	order, err := s.HandleCallback(PaymentProviderMock, req, body)
+	if err != nil {
+		log.Printf("[PaymentService] 模拟付款入账失败: %v", err)
+	}
+	return order, err
+}
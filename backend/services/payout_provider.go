@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"pr-business/models"
+)
+
+// 打款渠道
+const (
+	PayoutProviderWechat = "wechat"
+	PayoutProviderAlipay = "alipay"
+	PayoutProviderManual = "manual"
+	PayoutProviderFake   = "fake"
+)
+
+// PayoutStatus 渠道打款状态
+type PayoutStatus string
+
+const (
+	PayoutStatusProcessing PayoutStatus = "PROCESSING" // 渠道处理中
+	PayoutStatusSucceeded  PayoutStatus = "SUCCEEDED"  // 已到账
+	PayoutStatusFailed     PayoutStatus = "FAILED"     // 打款失败
+)
+
+// PayoutProvider 提现打款渠道适配器
+// 打款是异步的：Transfer 只代表渠道受理，最终结果通过回调或 QueryTransfer 获得
+// 同一 OutBizNo 重复提交时渠道只打款一次
+type PayoutProvider interface {
+	// Name 渠道标识，与回调地址 /payouts/callback/:provider 对应
+	Name() string
+	// Transfer 提交打款
+	Transfer(ctx context.Context, req PayoutRequest) (*PayoutResult, error)
+	// QueryTransfer 查询打款结果，渠道侧没有该单时返回 ErrPayoutOrderNotFound
+	QueryTransfer(ctx context.Context, outBizNo string) (*PayoutResult, error)
+	// VerifyCallback 校验打款结果回调签名并解析
+	VerifyCallback(r *http.Request, body []byte) (*PayoutResult, error)
+	// CallbackAck 渠道要求的回调应答
+	CallbackAck(success bool, message string) (contentType string, body []byte)
+}
+
+// PayoutRequest 打款请求
+type PayoutRequest struct {
+	OutBizNo string // 商户打款单号
+	Amount   int    // 打款金额（分）
+	Payee    PayoutPayee
+	Remark   string
+}
+
+// PayoutResult 打款结果
+type PayoutResult struct {
+	OutBizNo   string
+	OrderNo    string // 渠道打款单号
+	Status     PayoutStatus
+	FailReason string
+}
+
+// PayoutPayee 收款账户，由提现记录的 AccountInfo 解析
+type PayoutPayee struct {
+	Account  string // 支付宝账号 / 银行卡号
+	OpenID   string // 微信 openid（商家转账只能转到 openid）
+	Name     string // 真实姓名 / 持卡人
+	BankName string
+	Branch   string
+}
+
+// ParsePayoutPayee 解析提现账户信息并按提现方式校验必填项
+func ParsePayoutPayee(method models.WithdrawalMethod, accountInfo string) (PayoutPayee, error) {
+	var info map[string]interface{}
+	if err := json.Unmarshal([]byte(accountInfo), &info); err != nil {
+		return PayoutPayee{}, fmt.Errorf("%w: %v", ErrPayoutAccountInfoInvalid, err)
+	}
+	field := func(key string) string {
+		value, _ := info[key].(string)
+		return strings.TrimSpace(value)
+	}
+
+	payee := PayoutPayee{
+		Account:  field("account"),
+		OpenID:   field("openid"),
+		Name:     field("name"),
+		BankName: field("bankName"),
+		Branch:   field("branch"),
+	}
+
+	switch method {
+	case models.WithdrawalMethodAlipay:
+		if payee.Account == "" || payee.Name == "" {
+			return payee, fmt.Errorf("%w: 支付宝提现需要账号和真实姓名", ErrPayoutAccountInfoInvalid)
+		}
+	case models.WithdrawalMethodWechat:
+		if payee.OpenID == "" {
+			return payee, fmt.Errorf("%w: 微信提现需要绑定 openid", ErrPayoutAccountInfoInvalid)
+		}
+	case models.WithdrawalMethodBank:
+		if payee.Account == "" || payee.Name == "" || payee.BankName == "" {
+			return payee, fmt.Errorf("%w: 银行提现需要开户银行、卡号和持卡人姓名", ErrPayoutAccountInfoInvalid)
+		}
+	default:
+		return payee, fmt.Errorf("%w: 不支持的提现方式 %s", ErrPayoutAccountInfoInvalid, method)
+	}
+	return payee, nil
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// AlipayPayoutProvider 支付宝单笔转账到支付宝账户
+// 复用 AlipayGateway 的请求签名和通知验签
+type AlipayPayoutProvider struct {
+	gateway   *AlipayGateway
+	notifyURL string
+}
+
+// NewAlipayPayoutProvider 创建支付宝转账渠道
+func NewAlipayPayoutProvider(gateway *AlipayGateway, notifyURL string) *AlipayPayoutProvider {
+	return &AlipayPayoutProvider{gateway: gateway, notifyURL: notifyURL}
+}
+
+// Name 渠道标识
+func (p *AlipayPayoutProvider) Name() string {
+	return PayoutProviderAlipay
+}
+
+// alipayTransferOrder 转账单（受理结果、查询结果与通知 biz_content 字段相同）
+type alipayTransferOrder struct {
+	OutBizNo   string `json:"out_biz_no"`
+	OrderID    string `json:"order_id"`
+	Status     string `json:"status"`
+	FailReason string `json:"fail_reason"`
+	ErrorCode  string `json:"error_code"`
+}
+
+// Transfer 单笔转账（alipay.fund.trans.uni.transfer）
+func (p *AlipayPayoutProvider) Transfer(ctx context.Context, req PayoutRequest) (*PayoutResult, error) {
+	bizContent := map[string]interface{}{
+		"out_biz_no":   req.OutBizNo,
+		"trans_amount": formatYuan(req.Amount),
+		"product_code": "TRANS_ACCOUNT_NO_PWD",
+		"biz_scene":    "DIRECT_TRANSFER",
+		"order_title":  req.Remark,
+		"payee_info": map[string]string{
+			"identity":      req.Payee.Account,
+			"identity_type": "ALIPAY_LOGON_ID",
+			"name":          req.Payee.Name,
+		},
+	}
+
+	var order alipayTransferOrder
+	if err := p.gateway.call(ctx, "alipay.fund.trans.uni.transfer", bizContent, p.notifyURL, &order); err != nil {
+		return nil, err
+	}
+	return p.result(&order), nil
+}
+
+// QueryTransfer 查询转账单（alipay.fund.trans.common.query）
+func (p *AlipayPayoutProvider) QueryTransfer(ctx context.Context, outBizNo string) (*PayoutResult, error) {
+	bizContent := map[string]interface{}{
+		"out_biz_no":   outBizNo,
+		"product_code": "TRANS_ACCOUNT_NO_PWD",
+		"biz_scene":    "DIRECT_TRANSFER",
+	}
+
+	var order alipayTransferOrder
+	if err := p.gateway.call(ctx, "alipay.fund.trans.common.query", bizContent, "", &order); err != nil {
+		var apiErr *alipayAPIError
+		if errors.As(err, &apiErr) && apiErr.SubCode == "ORDER_NOT_EXIST" {
+			return nil, ErrPayoutOrderNotFound
+		}
+		return nil, err
+	}
+	order.OutBizNo = outBizNo
+	return p.result(&order), nil
+}
+
+// VerifyCallback 校验转账状态变更通知（alipay.fund.trans.order.changed）
+func (p *AlipayPayoutProvider) VerifyCallback(r *http.Request, body []byte) (*PayoutResult, error) {
+	values, err := p.gateway.verifyNotify(body)
+	if err != nil {
+		return nil, err
+	}
+
+	var order alipayTransferOrder
+	if err := json.Unmarshal([]byte(values.Get("biz_content")), &order); err != nil {
+		return nil, fmt.Errorf("解析支付宝转账通知失败: %w", err)
+	}
+	return p.result(&order), nil
+}
+
+// CallbackAck 与支付通知相同
+func (p *AlipayPayoutProvider) CallbackAck(success bool, message string) (string, []byte) {
+	return p.gateway.CallbackAck(success, message)
+}
+
+// result 转换为统一的打款结果；DEALING 等状态视为处理中
+func (p *AlipayPayoutProvider) result(order *alipayTransferOrder) *PayoutResult {
+	result := &PayoutResult{
+		OutBizNo: order.OutBizNo,
+		OrderNo:  order.OrderID,
+		Status:   PayoutStatusProcessing,
+	}
+	switch order.Status {
+	case "SUCCESS":
+		result.Status = PayoutStatusSucceeded
+	case "FAIL", "CLOSED", "REFUND":
+		result.Status = PayoutStatusFailed
+		result.FailReason = order.FailReason
+		if result.FailReason == "" {
+			result.FailReason = order.Status + " " + order.ErrorCode
+		}
+	}
+	return result
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FakePayoutSignatureHeader 模拟打款回调签名请求头（HMAC-SHA256(secret, body) 的十六进制）
+const FakePayoutSignatureHeader = "X-Fake-Payout-Signature"
+
+// fakeTransfer 模拟渠道内存中的打款单
+type fakeTransfer struct {
+	orderNo     string
+	fail        bool
+	submittedAt time.Time
+}
+
+// FakePayoutProvider 内存模拟打款渠道，用于本地开发和测试
+// 提交后经过 delay 才出结果（用于验证轮询）；收款账号或姓名包含 "fail" 时打款失败
+type FakePayoutProvider struct {
+	secret    string
+	delay     time.Duration
+	mu        sync.Mutex
+	transfers map[string]*fakeTransfer
+}
+
+// fakePayoutCallback 模拟渠道回调报文
+type fakePayoutCallback struct {
+	OutBizNo   string       `json:"outBizNo"`
+	OrderNo    string       `json:"orderNo"`
+	Status     PayoutStatus `json:"status"`
+	FailReason string       `json:"failReason,omitempty"`
+}
+
+// NewFakePayoutProvider 创建模拟打款渠道；secret 用于回调签名
+func NewFakePayoutProvider(secret string, delay time.Duration) *FakePayoutProvider {
+	return &FakePayoutProvider{
+		secret:    secret,
+		delay:     delay,
+		transfers: make(map[string]*fakeTransfer),
+	}
+}
+
+// Name 渠道标识
+func (p *FakePayoutProvider) Name() string {
+	return PayoutProviderFake
+}
+
+// Transfer 受理打款，同一单号重复提交只记录一次
+func (p *FakePayoutProvider) Transfer(ctx context.Context, req PayoutRequest) (*PayoutResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	transfer, exists := p.transfers[req.OutBizNo]
+	if !exists {
+		payee := strings.ToLower(req.Payee.Account + req.Payee.OpenID + req.Payee.Name)
+		transfer = &fakeTransfer{
+			orderNo:     "FAKE" + req.OutBizNo,
+			fail:        strings.Contains(payee, "fail"),
+			submittedAt: time.Now(),
+		}
+		p.transfers[req.OutBizNo] = transfer
+	}
+	return p.result(req.OutBizNo, transfer), nil
+}
+
+// QueryTransfer 查询模拟打款单
+func (p *FakePayoutProvider) QueryTransfer(ctx context.Context, outBizNo string) (*PayoutResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	transfer, exists := p.transfers[outBizNo]
+	if !exists {
+		return nil, ErrPayoutOrderNotFound
+	}
+	return p.result(outBizNo, transfer), nil
+}
+
+// VerifyCallback 校验 HMAC 签名并解析回调
+func (p *FakePayoutProvider) VerifyCallback(r *http.Request, body []byte) (*PayoutResult, error) {
+	signature, err := hex.DecodeString(r.Header.Get(FakePayoutSignatureHeader))
+	if err != nil || !hmac.Equal(signature, p.sign(body)) {
+		return nil, ErrPaymentSignatureInvalid
+	}
+
+	var payload fakePayoutCallback
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("解析模拟打款回调失败: %w", err)
+	}
+	return &PayoutResult{
+		OutBizNo:   payload.OutBizNo,
+		OrderNo:    payload.OrderNo,
+		Status:     payload.Status,
+		FailReason: payload.FailReason,
+	}, nil
+}
+
+// CallbackAck JSON 应答
+func (p *FakePayoutProvider) CallbackAck(success bool, message string) (string, []byte) {
+	body, _ := json.Marshal(map[string]interface{}{"success": success, "message": message})
+	return "application/json", body
+}
+
+// SignedCallback 生成某笔打款当前结果的回调报文和签名，可直接 POST 到回调地址
+func (p *FakePayoutProvider) SignedCallback(outBizNo string) (body []byte, signature string, err error) {
+	result, err := p.QueryTransfer(context.Background(), outBizNo)
+	if err != nil {
+		return nil, "", err
+	}
+
+	body, err = json.Marshal(fakePayoutCallback{
+		OutBizNo:   result.OutBizNo,
+		OrderNo:    result.OrderNo,
+		Status:     result.Status,
+		FailReason: result.FailReason,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return body, hex.EncodeToString(p.sign(body)), nil
+}
+
+// result 未到 delay 时处理中，之后按收款人决定成功或失败
+func (p *FakePayoutProvider) result(outBizNo string, transfer *fakeTransfer) *PayoutResult {
+	result := &PayoutResult{OutBizNo: outBizNo, OrderNo: transfer.orderNo, Status: PayoutStatusProcessing}
+	if time.Since(transfer.submittedAt) < p.delay {
+		return result
+	}
+	if transfer.fail {
+		result.Status = PayoutStatusFailed
+		result.FailReason = "模拟打款失败"
+	} else {
+		result.Status = PayoutStatusSucceeded
+	}
+	return result
+}
+
+// sign HMAC-SHA256 签名
+func (p *FakePayoutProvider) sign(body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(p.secret))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// ManualPayoutProvider 人工打款（银行转账）
+// 银企直连未接入：提交后保持打款中，由财务线下转账后通过
+// /withdrawals/:id/payout-result 人工确认结果
+type ManualPayoutProvider struct{}
+
+// NewManualPayoutProvider 创建人工打款渠道
+func NewManualPayoutProvider() *ManualPayoutProvider {
+	return &ManualPayoutProvider{}
+}
+
+// Name 渠道标识
+func (p *ManualPayoutProvider) Name() string {
+	return PayoutProviderManual
+}
+
+// Transfer 登记待人工打款
+func (p *ManualPayoutProvider) Transfer(ctx context.Context, req PayoutRequest) (*PayoutResult, error) {
+	return &PayoutResult{OutBizNo: req.OutBizNo, Status: PayoutStatusProcessing}, nil
+}
+
+// QueryTransfer 人工打款没有渠道侧状态，始终处理中
+func (p *ManualPayoutProvider) QueryTransfer(ctx context.Context, outBizNo string) (*PayoutResult, error) {
+	return &PayoutResult{OutBizNo: outBizNo, Status: PayoutStatusProcessing}, nil
+}
+
+// VerifyCallback 人工打款没有渠道回调
+func (p *ManualPayoutProvider) VerifyCallback(r *http.Request, body []byte) (*PayoutResult, error) {
+	return nil, ErrPaymentSignatureInvalid
+}
+
+// CallbackAck JSON 应答
+func (p *ManualPayoutProvider) CallbackAck(success bool, message string) (string, []byte) {
+	body, _ := json.Marshal(map[string]interface{}{"success": success, "message": message})
+	return "application/json", body
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// WechatPayoutConfig 微信支付商家转账配置
+type WechatPayoutConfig struct {
+	NotifyURL       string
+	TransferSceneID string // 转账场景 ID，默认 1005（佣金报酬）
+}
+
+// WechatPayoutProvider 微信支付商家转账（转账到零钱）
+// 复用 WechatPayGateway 的商户签名、回调验签和解密
+type WechatPayoutProvider struct {
+	gateway *WechatPayGateway
+	cfg     WechatPayoutConfig
+}
+
+// NewWechatPayoutProvider 创建微信商家转账渠道
+func NewWechatPayoutProvider(gateway *WechatPayGateway, cfg WechatPayoutConfig) *WechatPayoutProvider {
+	if cfg.TransferSceneID == "" {
+		cfg.TransferSceneID = "1005"
+	}
+	return &WechatPayoutProvider{gateway: gateway, cfg: cfg}
+}
+
+// Name 渠道标识
+func (p *WechatPayoutProvider) Name() string {
+	return PayoutProviderWechat
+}
+
+// wechatTransferBill 商家转账单（受理结果、查询结果与回调解密后的报文结构相同）
+type wechatTransferBill struct {
+	OutBillNo      string `json:"out_bill_no"`
+	TransferBillNo string `json:"transfer_bill_no"`
+	State          string `json:"state"`
+	FailReason     string `json:"fail_reason"`
+}
+
+// Transfer 发起转账（/v3/fund-app/mch-transfer/transfer-bills）
+func (p *WechatPayoutProvider) Transfer(ctx context.Context, req PayoutRequest) (*PayoutResult, error) {
+	payload := map[string]interface{}{
+		"appid":             p.gateway.cfg.AppID,
+		"out_bill_no":       req.OutBizNo,
+		"transfer_scene_id": p.cfg.TransferSceneID,
+		"openid":            req.Payee.OpenID,
+		"transfer_amount":   req.Amount,
+		"transfer_remark":   req.Remark,
+		"notify_url":        p.cfg.NotifyURL,
+		"transfer_scene_report_infos": []map[string]string{
+			{"info_type": "岗位类型", "info_content": "推广达人"},
+			{"info_type": "报酬说明", "info_content": "任务佣金提现"},
+		},
+	}
+	// 收款人姓名需用平台公钥加密，未配置平台公钥 ID 时不校验姓名
+	if req.Payee.Name != "" && p.gateway.cfg.PlatformSerialNo != "" {
+		userName, err := p.gateway.encryptSensitive(req.Payee.Name)
+		if err != nil {
+			return nil, err
+		}
+		payload["user_name"] = userName
+	}
+
+	var bill wechatTransferBill
+	if err := p.gateway.do(ctx, http.MethodPost, "/v3/fund-app/mch-transfer/transfer-bills", payload, &bill); err != nil {
+		return nil, err
+	}
+	return p.result(&bill), nil
+}
+
+// QueryTransfer 按商户单号查询转账单
+func (p *WechatPayoutProvider) QueryTransfer(ctx context.Context, outBizNo string) (*PayoutResult, error) {
+	path := "/v3/fund-app/mch-transfer/transfer-bills/out-bill-no/" + url.PathEscape(outBizNo)
+
+	var bill wechatTransferBill
+	if err := p.gateway.do(ctx, http.MethodGet, path, nil, &bill); err != nil {
+		var apiErr *wechatAPIError
+		if errors.As(err, &apiErr) && apiErr.Code == "NOT_FOUND" {
+			return nil, ErrPayoutOrderNotFound
+		}
+		return nil, err
+	}
+	return p.result(&bill), nil
+}
+
+// VerifyCallback 校验并解密转账结果回调（MCHTRANSFER.BILL.FINISHED）
+func (p *WechatPayoutProvider) VerifyCallback(r *http.Request, body []byte) (*PayoutResult, error) {
+	plaintext, err := p.gateway.decryptNotify(r, body)
+	if err != nil {
+		return nil, err
+	}
+
+	var bill wechatTransferBill
+	if err := json.Unmarshal(plaintext, &bill); err != nil {
+		return nil, fmt.Errorf("解析微信转账回调失败: %w", err)
+	}
+	return p.result(&bill), nil
+}
+
+// CallbackAck 与支付回调相同
+func (p *WechatPayoutProvider) CallbackAck(success bool, message string) (string, []byte) {
+	return p.gateway.CallbackAck(success, message)
+}
+
+// result 转换为统一的打款结果；WAIT_USER_CONFIRM 等中间状态都视为处理中
+func (p *WechatPayoutProvider) result(bill *wechatTransferBill) *PayoutResult {
+	result := &PayoutResult{
+		OutBizNo: bill.OutBillNo,
+		OrderNo:  bill.TransferBillNo,
+		Status:   PayoutStatusProcessing,
+	}
+	switch bill.State {
+	case "SUCCESS":
+		result.Status = PayoutStatusSucceeded
+	case "FAIL", "CANCELLED":
+		result.Status = PayoutStatusFailed
+		result.FailReason = bill.FailReason
+		if result.FailReason == "" {
+			result.FailReason = bill.State
+		}
+	}
+	return result
+}
@@ -0,0 +1,473 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"pr-business/constants"
+	"pr-business/models"
+)
+
+// payoutPollBatchSize 每次轮询处理的打款中提现数量
+const payoutPollBatchSize = 50
+
+// PayoutService 提现打款：审核通过的提现提交渠道打款，回调或轮询得到结果后完成或退回
+//
+// 状态：approved → processing → completed / failed
+//   - 提交打款时现金从出款账户划到清算账户，积分保持冻结
+//   - 打款成功：冻结积分回收到积分发行账户
+//   - 打款失败：冻结积分退回可用余额，现金从清算账户退回出款账户
+type PayoutService struct {
+	db            *gorm.DB
+	ledgerService *LedgerService
+	auditService  *AuditService
+	providers     map[models.WithdrawalMethod]PayoutProvider
+}
+
+// NewPayoutService 创建打款服务，providers 为各提现方式使用的打款渠道
+func NewPayoutService(db *gorm.DB, ledgerService *LedgerService, auditService *AuditService, providers map[models.WithdrawalMethod]PayoutProvider) *PayoutService {
+	return &PayoutService{
+		db:            db,
+		ledgerService: ledgerService,
+		auditService:  auditService,
+		providers:     providers,
+	}
+}
+
+// Provider 按提现方式获取打款渠道
+func (s *PayoutService) Provider(method models.WithdrawalMethod) (PayoutProvider, error) {
+	provider, ok := s.providers[method]
+	if !ok {
+		return nil, ErrPayoutProviderNotConfigured
+	}
+	return provider, nil
+}
+
+// ProviderByName 按渠道标识获取打款渠道（回调地址中的 :provider）
+func (s *PayoutService) ProviderByName(name string) (PayoutProvider, error) {
+	for _, provider := range s.providers {
+		if provider.Name() == name {
+			return provider, nil
+		}
+	}
+	return nil, ErrPayoutProviderNotConfigured
+}
+
+// Dispatch 提交打款：锁定已通过的提现，划出现金并置为打款中，然后向渠道提交
+// 渠道明确拒绝时立即按失败处理（退回积分）；网络错误时保持打款中，由轮询补提交
+func (s *PayoutService) Dispatch(ctx context.Context, withdrawalID string, operatorID string) (*models.Withdrawal, error) {
+	var withdrawal models.Withdrawal
+	if err := s.db.Where("id = ?", withdrawalID).First(&withdrawal).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWithdrawalNotFound
+		}
+		return nil, err
+	}
+
+	provider, err := s.Provider(withdrawal.Method)
+	if err != nil {
+		return nil, err
+	}
+	payee, err := ParsePayoutPayee(withdrawal.Method, withdrawal.AccountInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", withdrawal.ID).
+			First(&withdrawal).Error; err != nil {
+			return err
+		}
+		if !withdrawal.CanProcess() {
+			return ErrInvalidWithdrawalStatus
+		}
+
+		cashAccountID, err := s.payoutCashAccountID(tx, withdrawal.Method)
+		if err != nil {
+			return err
+		}
+		clearingID, err := s.ledgerService.ClearingCashAccountID(tx)
+		if err != nil {
+			return err
+		}
+		if _, err := s.ledgerService.Post(tx, &LedgerTransfer{
+			Type:        models.TransactionWithdraw,
+			Description: fmt.Sprintf("提现打款出款：%s", withdrawal.ID),
+			Postings: []LedgerPosting{
+				CashPosting(cashAccountID, -withdrawal.PayoutCents),
+				CashPosting(clearingID, withdrawal.PayoutCents),
+			},
+		}); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		outBizNo := strings.ReplaceAll(withdrawal.ID.String(), "-", "")
+		if err := tx.Model(&withdrawal).Updates(map[string]interface{}{
+			"status":                 models.WithdrawalStatusProcessing,
+			"payout_provider":        provider.Name(),
+			"payout_out_biz_no":      outBizNo,
+			"payout_cash_account_id": cashAccountID,
+			"payout_submitted_at":    now,
+			"updated_at":             now,
+		}).Error; err != nil {
+			return fmt.Errorf("更新提现状态失败: %w", err)
+		}
+		withdrawal.Status = models.WithdrawalStatusProcessing
+		withdrawal.PayoutProvider = provider.Name()
+		withdrawal.PayoutOutBizNo = &outBizNo
+		withdrawal.PayoutCashAccountID = &cashAccountID
+		withdrawal.PayoutSubmittedAt = &now
+
+		return s.auditService.WithTx(tx).LogFinancialOperation(
+			operatorID,
+			constants.AuditActionWithdrawalPayout,
+			constants.AuditResourceWithdrawal,
+			withdrawal.ID.String(),
+			map[string]interface{}{
+				"provider":      provider.Name(),
+				"out_biz_no":    outBizNo,
+				"actual_amount": withdrawal.ActualAmount,
+				"payout_cents":  withdrawal.PayoutCents,
+			},
+			"",
+			"",
+		)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.submit(ctx, provider, &withdrawal, payee)
+}
+
+// submit 向渠道提交打款并处理受理结果
+func (s *PayoutService) submit(ctx context.Context, provider PayoutProvider, withdrawal *models.Withdrawal, payee PayoutPayee) (*models.Withdrawal, error) {
+	result, err := provider.Transfer(ctx, PayoutRequest{
+		OutBizNo: *withdrawal.PayoutOutBizNo,
+		Amount:   withdrawal.PayoutCents,
+		Payee:    payee,
+		Remark:   "提现",
+	})
+	if err != nil {
+		if errors.Is(err, ErrPaymentGatewayRejected) {
+			return s.ApplyResult(&PayoutResult{
+				OutBizNo:   *withdrawal.PayoutOutBizNo,
+				Status:     PayoutStatusFailed,
+				FailReason: err.Error(),
+			})
+		}
+		// 结果未知：保持打款中，轮询时查单或用同一单号重新提交
+		log.Printf("[PayoutService] 提现 %s 提交打款失败，等待轮询: %v", withdrawal.ID, err)
+		return withdrawal, nil
+	}
+
+	return s.ApplyResult(result)
+}
+
+// ApplyResult 应用渠道打款结果；只处理打款中的提现，重复结果直接返回当前记录
+func (s *PayoutService) ApplyResult(result *PayoutResult) (*models.Withdrawal, error) {
+	var withdrawal models.Withdrawal
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("payout_out_biz_no = ?", result.OutBizNo).
+			First(&withdrawal).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrWithdrawalNotFound
+			}
+			return err
+		}
+		if !withdrawal.IsProcessing() {
+			return nil
+		}
+
+		now := time.Now()
+		switch result.Status {
+		case PayoutStatusSucceeded:
+			return s.completePayout(tx, &withdrawal, result, now)
+		case PayoutStatusFailed:
+			return s.failPayout(tx, &withdrawal, result, now)
+		default:
+			if result.OrderNo != "" && result.OrderNo != withdrawal.PayoutOrderNo {
+				withdrawal.PayoutOrderNo = result.OrderNo
+				return tx.Model(&withdrawal).Updates(map[string]interface{}{
+					"payout_order_no": result.OrderNo,
+					"updated_at":      now,
+				}).Error
+			}
+			return nil
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &withdrawal, nil
+}
+
+// completePayout 打款成功：冻结积分中到账部分回收到积分发行账户，手续费计入平台收益，预扣个税计入代扣个税账户
+func (s *PayoutService) completePayout(tx *gorm.DB, withdrawal *models.Withdrawal, result *PayoutResult, now time.Time) error {
+	issuanceID, err := s.ledgerService.SystemAccountID(tx, constants.SystemAccountTypeCreditIssuance)
+	if err != nil {
+		return err
+	}
+	postings := []LedgerPosting{
+		FrozenPosting(withdrawal.AccountID, -withdrawal.Amount),
+		SystemPosting(issuanceID, withdrawal.ActualAmount),
+	}
+	if withdrawal.Fee > 0 {
+		revenueID, err := s.ledgerService.SystemAccountID(tx, constants.SystemAccountTypePlatformRevenue)
+		if err != nil {
+			return fmt.Errorf("获取平台收益账户失败: %w", err)
+		}
+		postings = append(postings, LedgerPosting{
+			Kind:        models.LedgerAccountSystem,
+			AccountID:   revenueID,
+			Amount:      withdrawal.Fee,
+			Type:        models.TransactionWithdrawFee,
+			Description: fmt.Sprintf("提现手续费：%s", withdrawal.ID),
+		})
+	}
+	if withdrawal.TaxAmount > 0 {
+		taxPayableID, err := s.ledgerService.SystemAccountID(tx, constants.SystemAccountTypeTaxPayable)
+		if err != nil {
+			return fmt.Errorf("获取代扣个税账户失败: %w", err)
+		}
+		postings = append(postings, LedgerPosting{
+			Kind:        models.LedgerAccountSystem,
+			AccountID:   taxPayableID,
+			Amount:      withdrawal.TaxAmount,
+			Type:        models.TransactionTaxWithholding,
+			Description: fmt.Sprintf("提现预扣个税 %s 元：%s", formatYuan(withdrawal.TaxWithheld), withdrawal.ID),
+		})
+	}
+	if _, err := s.ledgerService.Post(tx, &LedgerTransfer{
+		Type:        models.TransactionWithdraw,
+		Description: fmt.Sprintf("提现成功 %d 积分", withdrawal.Amount),
+		Postings:    postings,
+	}); err != nil {
+		return err
+	}
+
+	if err := tx.Model(withdrawal).Updates(map[string]interface{}{
+		"status":          models.WithdrawalStatusCompleted,
+		"payout_order_no": result.OrderNo,
+		"completed_at":    now,
+		"updated_at":      now,
+	}).Error; err != nil {
+		return fmt.Errorf("更新提现状态失败: %w", err)
+	}
+	withdrawal.Status = models.WithdrawalStatusCompleted
+	withdrawal.PayoutOrderNo = result.OrderNo
+	withdrawal.CompletedAt = &now
+
+	return s.auditService.WithTx(tx).LogFinancialOperation(
+		"system",
+		constants.AuditActionWithdrawalPaid,
+		constants.AuditResourceWithdrawal,
+		withdrawal.ID.String(),
+		map[string]interface{}{
+			"provider":        withdrawal.PayoutProvider,
+			"payout_order_no": result.OrderNo,
+			"amount":          withdrawal.Amount,
+			"fee":             withdrawal.Fee,
+			"tax_withheld":    withdrawal.TaxWithheld,
+		},
+		"",
+		"",
+	)
+}
+
+// failPayout 打款失败：冻结积分退回可用余额，出款现金退回
+func (s *PayoutService) failPayout(tx *gorm.DB, withdrawal *models.Withdrawal, result *PayoutResult, now time.Time) error {
+	postings := UnfreezePostings(withdrawal.AccountID, withdrawal.Amount)
+	if withdrawal.PayoutCashAccountID != nil {
+		clearingID, err := s.ledgerService.ClearingCashAccountID(tx)
+		if err != nil {
+			return err
+		}
+		postings = append(postings,
+			CashPosting(clearingID, -withdrawal.PayoutCents),
+			CashPosting(*withdrawal.PayoutCashAccountID, withdrawal.PayoutCents),
+		)
+	}
+	if _, err := s.ledgerService.Post(tx, &LedgerTransfer{
+		Type:        models.TransactionWithdrawRefund,
+		Description: fmt.Sprintf("提现打款失败退款 %d 积分", withdrawal.Amount),
+		Postings:    postings,
+	}); err != nil {
+		return err
+	}
+
+	failReason := result.FailReason
+	if len(failReason) > 500 {
+		failReason = failReason[:500]
+	}
+	if err := tx.Model(withdrawal).Updates(map[string]interface{}{
+		"status":             models.WithdrawalStatusFailed,
+		"payout_order_no":    result.OrderNo,
+		"payout_fail_reason": failReason,
+		"updated_at":         now,
+	}).Error; err != nil {
+		return fmt.Errorf("更新提现状态失败: %w", err)
+	}
+	withdrawal.Status = models.WithdrawalStatusFailed
+	withdrawal.PayoutOrderNo = result.OrderNo
+	withdrawal.PayoutFailReason = failReason
+
+	return s.auditService.WithTx(tx).LogFinancialOperation(
+		"system",
+		constants.AuditActionWithdrawalPayFail,
+		constants.AuditResourceWithdrawal,
+		withdrawal.ID.String(),
+		map[string]interface{}{
+			"provider":    withdrawal.PayoutProvider,
+			"fail_reason": failReason,
+			"refunded":    withdrawal.Amount,
+		},
+		"",
+		"",
+	)
+}
+
+// HandleCallback 校验渠道回调并应用打款结果
+func (s *PayoutService) HandleCallback(providerName string, r *http.Request, body []byte) (*models.Withdrawal, error) {
+	provider, err := s.ProviderByName(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := provider.VerifyCallback(r, body)
+	if err != nil {
+		return nil, err
+	}
+	return s.ApplyResult(result)
+}
+
+// Sync 主动查询一笔打款中提现的渠道结果；渠道侧无此单时用同一单号重新提交
+func (s *PayoutService) Sync(ctx context.Context, withdrawal *models.Withdrawal) (*models.Withdrawal, error) {
+	if !withdrawal.IsProcessing() || withdrawal.PayoutOutBizNo == nil {
+		return withdrawal, nil
+	}
+
+	provider, err := s.Provider(withdrawal.Method)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := provider.QueryTransfer(ctx, *withdrawal.PayoutOutBizNo)
+	if errors.Is(err, ErrPayoutOrderNotFound) {
+		payee, err := ParsePayoutPayee(withdrawal.Method, withdrawal.AccountInfo)
+		if err != nil {
+			return nil, err
+		}
+		return s.submit(ctx, provider, withdrawal, payee)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.ApplyResult(result)
+}
+
+// ConfirmManual 人工确认线下打款结果（仅人工打款渠道）
+func (s *PayoutService) ConfirmManual(withdrawalID string, succeeded bool, orderNo string, failReason string, operatorID string) (*models.Withdrawal, error) {
+	var withdrawal models.Withdrawal
+	if err := s.db.Where("id = ?", withdrawalID).First(&withdrawal).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWithdrawalNotFound
+		}
+		return nil, err
+	}
+	if withdrawal.PayoutProvider != PayoutProviderManual {
+		return nil, ErrPayoutNotManual
+	}
+	if !withdrawal.IsProcessing() {
+		return nil, ErrInvalidWithdrawalStatus
+	}
+
+	result := &PayoutResult{
+		OutBizNo: *withdrawal.PayoutOutBizNo,
+		OrderNo:  orderNo,
+		Status:   PayoutStatusSucceeded,
+	}
+	if !succeeded {
+		result.Status = PayoutStatusFailed
+		result.FailReason = failReason
+	}
+	log.Printf("[PayoutService] %s 人工确认提现 %s 打款结果: %s", operatorID, withdrawal.ID, result.Status)
+	return s.ApplyResult(result)
+}
+
+// PollProcessing 轮询打款中的提现（回调丢失或提交结果未知时兜底）
+func (s *PayoutService) PollProcessing(ctx context.Context, submittedBefore time.Time) (int, error) {
+	var withdrawals []models.Withdrawal
+	if err := s.db.Where("status = ? AND payout_provider <> ? AND payout_submitted_at < ?",
+		models.WithdrawalStatusProcessing, PayoutProviderManual, submittedBefore).
+		Order("payout_submitted_at ASC").
+		Limit(payoutPollBatchSize).
+		Find(&withdrawals).Error; err != nil {
+		return 0, err
+	}
+
+	resolved := 0
+	for i := range withdrawals {
+		if ctx.Err() != nil {
+			return resolved, ctx.Err()
+		}
+		updated, err := s.Sync(ctx, &withdrawals[i])
+		if err != nil {
+			log.Printf("[PayoutService] 查询提现 %s 打款结果失败: %v", withdrawals[i].ID, err)
+			continue
+		}
+		if !updated.IsProcessing() {
+			resolved++
+		}
+	}
+	return resolved, nil
+}
+
+// RegisterJobs 注册打款结果轮询任务
+func (s *PayoutService) RegisterJobs(scheduler *SchedulerService, interval time.Duration) {
+	scheduler.Register(ScheduledJob{
+		Name:     "poll-withdrawal-payouts",
+		Interval: interval,
+		Run: func(ctx context.Context, now time.Time) error {
+			resolved, err := s.PollProcessing(ctx, now.Add(-interval))
+			if resolved > 0 {
+				log.Printf("已确认 %d 笔提现打款结果", resolved)
+			}
+			return err
+		},
+	})
+}
+
+// payoutCashAccountID 提现方式对应的出款现金账户
+func (s *PayoutService) payoutCashAccountID(tx *gorm.DB, method models.WithdrawalMethod) (uuid.UUID, error) {
+	accountType := constants.CashAccountTypeBankTransfer
+	switch method {
+	case models.WithdrawalMethodAlipay:
+		accountType = constants.CashAccountTypeAlipay
+	case models.WithdrawalMethodWechat:
+		accountType = constants.CashAccountTypeWeChat
+	}
+
+	var account models.CashAccount
+	if err := tx.Where("account_type = ? AND is_active = ?", accountType, true).First(&account).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return uuid.Nil, fmt.Errorf("%w: %s", ErrCashAccountNotFound, accountType)
+		}
+		return uuid.Nil, err
+	}
+	return account.ID, nil
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+)
+
+// PDF 版面（A4，单位：pt）
+const (
+	pdfPageWidth  = 595
+	pdfPageHeight = 842
+	pdfMargin     = 50
+	pdfFontSize   = 10
+	pdfLeading    = 16
+)
+
+// WritePDF 写出简单的文字报表 PDF，不依赖第三方库
+// 使用阅读器内置的 STSong-Light 中文字体（UniGB-UCS2-H 编码），不嵌入字体文件；
+// 每行按 columns 给出的横坐标逐列绘制，只有一列的行超出版心时自动折行，超出页面时自动分页
+func WritePDF(w io.Writer, columns []float64, lines [][]string) error {
+	// 1. 排版：折行、分页
+	maxWidth := float64(pdfPageWidth - 2*pdfMargin)
+	linesPerPage := (pdfPageHeight - 2*pdfMargin) / pdfLeading
+	var pages [][][]string
+	var page [][]string
+	appendLine := func(line []string) {
+		if len(page) == linesPerPage {
+			pages = append(pages, page)
+			page = nil
+		}
+		page = append(page, line)
+	}
+	for _, line := range lines {
+		if len(line) == 1 {
+			for _, wrapped := range pdfWrap(line[0], maxWidth) {
+				appendLine([]string{wrapped})
+			}
+			continue
+		}
+		appendLine(line)
+	}
+	pages = append(pages, page)
+
+	// 2. 对象：1 目录、2 页面树、3-5 字体，之后每页依次为页面和内容流
+	var objects []string
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 6+i*2)
+	}
+	objects = append(objects,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)),
+		"<< /Type /Font /Subtype /Type0 /BaseFont /STSong-Light /Encoding /UniGB-UCS2-H /DescendantFonts [4 0 R] >>",
+		"<< /Type /Font /Subtype /CIDFontType0 /BaseFont /STSong-Light "+
+			"/CIDSystemInfo << /Registry (Adobe) /Ordering (GB1) /Supplement 2 >> "+
+			"/FontDescriptor 5 0 R /DW 1000 /W [1 95 500 814 939 500] >>",
+		"<< /Type /FontDescriptor /FontName /STSong-Light /Flags 6 /FontBBox [-25 -254 1000 880] "+
+			"/ItalicAngle 0 /Ascent 880 /Descent -120 /CapHeight 880 /StemV 93 >>",
+	)
+	for i, pageLines := range pages {
+		var content strings.Builder
+		for n, line := range pageLines {
+			y := float64(pdfPageHeight - pdfMargin - pdfFontSize - n*pdfLeading)
+			for c, cell := range line {
+				if cell == "" {
+					continue
+				}
+				x := float64(pdfMargin)
+				if c < len(columns) {
+					x = columns[c]
+				}
+				fmt.Fprintf(&content, "BT /F1 %d Tf %.1f %.1f Td <%s> Tj ET\n", pdfFontSize, x, y, pdfHex(cell))
+			}
+		}
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+				pdfPageWidth, pdfPageHeight, 7+i*2),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+		)
+	}
+
+	// 3. 写出正文、交叉引用表和文件尾
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// pdfHex 文本转为 UCS-2 大端十六进制字符串
+func pdfHex(s string) string {
+	var b strings.Builder
+	for _, unit := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", unit)
+	}
+	return b.String()
+}
+
+// pdfWrap 按字宽折行（ASCII 半角，其余全角）
+func pdfWrap(s string, maxWidth float64) []string {
+	var lines []string
+	var line []rune
+	width := 0.0
+	for _, r := range s {
+		w := float64(pdfFontSize)
+		if r < 0x80 {
+			w /= 2
+		}
+		if width+w > maxWidth && len(line) > 0 {
+			lines = append(lines, string(line))
+			line, width = nil, 0
+		}
+		line = append(line, r)
+		width += w
+	}
+	return append(lines, string(line))
+}
@@ -0,0 +1,234 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"pr-business/models"
+)
+
+// PlatformFeeService 平台手续费规则服务
+type PlatformFeeService struct {
+	db *gorm.DB
+}
+
+// NewPlatformFeeService 创建平台手续费规则服务
+func NewPlatformFeeService(db *gorm.DB) *PlatformFeeService {
+	return &PlatformFeeService{db: db}
+}
+
+// CreatePlatformFeeRuleRequest 创建手续费规则的输入参数
+type CreatePlatformFeeRuleRequest struct {
+	Scope         models.PlatformFeeScope
+	ScopeID       *uuid.UUID
+	FeeType       models.PlatformFeeType
+	RateBasis     int
+	FixedAmount   int
+	EffectiveFrom time.Time
+	EffectiveTo   *time.Time
+	Description   string
+}
+
+// ResolveRule 取活动在 at 时刻生效的手续费规则，优先级：活动 > 商家 > 服务商 > 全局
+// 没有任何规则时返回 nil（不收手续费）
+func (s *PlatformFeeService) ResolveRule(tx *gorm.DB, campaign *models.Campaign, at time.Time) (*models.PlatformFeeRule, error) {
+	candidates := []struct {
+		scope   models.PlatformFeeScope
+		scopeID *uuid.UUID
+	}{
+		{models.PlatformFeeScopeCampaign, &campaign.ID},
+		{models.PlatformFeeScopeMerchant, &campaign.MerchantID},
+		{models.PlatformFeeScopeProvider, campaign.ProviderID},
+		{models.PlatformFeeScopeGlobal, nil},
+	}
+
+	for _, candidate := range candidates {
+		query := tx.Where("scope = ? AND is_active = ? AND effective_from <= ? AND (effective_to IS NULL OR effective_to > ?)",
+			candidate.scope, true, at, at)
+		if candidate.scope != models.PlatformFeeScopeGlobal {
+			if candidate.scopeID == nil {
+				continue
+			}
+			query = query.Where("scope_id = ?", *candidate.scopeID)
+		}
+
+		var rule models.PlatformFeeRule
+		err := query.Order("effective_from DESC").First(&rule).Error
+		if err == nil {
+			return &rule, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("查询手续费规则失败: %w", err)
+		}
+	}
+
+	return nil, nil
+}
+
+// CreateRule 创建手续费规则
+func (s *PlatformFeeService) CreateRule(req *CreatePlatformFeeRuleRequest, operatorID string) (*models.PlatformFeeRule, error) {
+	if req.Scope == models.PlatformFeeScopeGlobal {
+		req.ScopeID = nil
+	} else if req.ScopeID == nil {
+		return nil, errors.New("非全局规则必须指定适用对象")
+	}
+
+	switch req.FeeType {
+	case models.PlatformFeeTypePercentage:
+		if req.RateBasis <= 0 || req.RateBasis > 10000 {
+			return nil, errors.New("手续费比例必须在 0-10000（万分比）之间")
+		}
+		req.FixedAmount = 0
+	case models.PlatformFeeTypeFixed:
+		if req.FixedAmount <= 0 {
+			return nil, errors.New("固定手续费必须大于0")
+		}
+		req.RateBasis = 0
+	default:
+		return nil, fmt.Errorf("无效的手续费类型: %s", req.FeeType)
+	}
+
+	if req.EffectiveTo != nil && !req.EffectiveTo.After(req.EffectiveFrom) {
+		return nil, errors.New("失效时间必须晚于生效时间")
+	}
+
+	rule := models.PlatformFeeRule{
+		Scope:         req.Scope,
+		ScopeID:       req.ScopeID,
+		FeeType:       req.FeeType,
+		RateBasis:     req.RateBasis,
+		FixedAmount:   req.FixedAmount,
+		EffectiveFrom: req.EffectiveFrom,
+		EffectiveTo:   req.EffectiveTo,
+		IsActive:      true,
+		Description:   req.Description,
+		CreatedBy:     operatorID,
+	}
+	if err := s.db.Create(&rule).Error; err != nil {
+		return nil, fmt.Errorf("创建手续费规则失败: %w", err)
+	}
+
+	return &rule, nil
+}
+
+// ListRules 查询手续费规则
+func (s *PlatformFeeService) ListRules(scope string, includeInactive bool, limit int, offset int) ([]models.PlatformFeeRule, int64, error) {
+	query := s.db.Model(&models.PlatformFeeRule{})
+	if scope != "" {
+		query = query.Where("scope = ?", scope)
+	}
+	if !includeInactive {
+		query = query.Where("is_active = ?", true)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计手续费规则失败: %w", err)
+	}
+
+	var rules []models.PlatformFeeRule
+	if err := query.Order("scope ASC, effective_from DESC").Limit(limit).Offset(offset).Find(&rules).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询手续费规则失败: %w", err)
+	}
+
+	return rules, total, nil
+}
+
+// EndRule 结束规则（设置失效时间，已结算的记录不受影响）
+func (s *PlatformFeeService) EndRule(id string, effectiveTo time.Time) (*models.PlatformFeeRule, error) {
+	ruleID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrPlatformFeeRuleNotFound
+	}
+
+	var rule models.PlatformFeeRule
+	if err := s.db.Where("id = ?", ruleID).First(&rule).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPlatformFeeRuleNotFound
+		}
+		return nil, err
+	}
+
+	if effectiveTo.Before(rule.EffectiveFrom) {
+		effectiveTo = rule.EffectiveFrom
+	}
+	rule.EffectiveTo = &effectiveTo
+	if !effectiveTo.After(rule.EffectiveFrom) {
+		rule.IsActive = false
+	}
+	if err := s.db.Save(&rule).Error; err != nil {
+		return nil, fmt.Errorf("更新手续费规则失败: %w", err)
+	}
+
+	return &rule, nil
+}
+
+// ListSettlementRecords 查询任务结算明细
+func (s *PlatformFeeService) ListSettlementRecords(campaignID string, from *time.Time, to *time.Time, limit int, offset int) ([]models.SettlementRecord, int64, error) {
+	query := s.db.Model(&models.SettlementRecord{})
+	if campaignID != "" {
+		query = query.Where("campaign_id = ?", campaignID)
+	}
+	if from != nil {
+		query = query.Where("settled_at >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("settled_at < ?", *to)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计结算明细失败: %w", err)
+	}
+
+	var records []models.SettlementRecord
+	if err := query.Order("settled_at DESC").Limit(limit).Offset(offset).Find(&records).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询结算明细失败: %w", err)
+	}
+
+	return records, total, nil
+}
+
+// RevenuePeriod 平台收益按周期汇总
+type RevenuePeriod struct {
+	Period       string `json:"period"`
+	TaskCount    int64  `json:"task_count"`
+	TaskAmount   int64  `json:"task_amount"`
+	PlatformFee  int64  `json:"platform_fee"`
+	CreatorPaid  int64  `json:"creator_paid"`
+	StaffPaid    int64  `json:"staff_paid"`
+	ProviderPaid int64  `json:"provider_paid"`
+}
+
+// RevenueReport 按日/月汇总结算明细中的平台手续费
+func (s *PlatformFeeService) RevenueReport(from time.Time, to time.Time, granularity string) ([]RevenuePeriod, error) {
+	format := "YYYY-MM-DD"
+	trunc := "day"
+	if granularity == "month" {
+		format = "YYYY-MM"
+		trunc = "month"
+	}
+
+	var periods []RevenuePeriod
+	err := s.db.Raw(`
+		SELECT TO_CHAR(DATE_TRUNC(?, settled_at), ?) AS period,
+		       COUNT(*) AS task_count,
+		       COALESCE(SUM(task_amount), 0) AS task_amount,
+		       COALESCE(SUM(platform_fee), 0) AS platform_fee,
+		       COALESCE(SUM(creator_amount), 0) AS creator_paid,
+		       COALESCE(SUM(staff_referral_amount), 0) AS staff_paid,
+		       COALESCE(SUM(provider_amount), 0) AS provider_paid
+		FROM settlement_records
+		WHERE settled_at >= ? AND settled_at < ?
+		GROUP BY 1
+		ORDER BY 1`, trunc, format, from, to).Scan(&periods).Error
+	if err != nil {
+		return nil, fmt.Errorf("汇总平台收益失败: %w", err)
+	}
+
+	return periods, nil
+}
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"pr-business/constants"
+	"pr-business/models"
+)
+
+// RoleContextService 解析和切换用户的激活角色
+// 用户可能同时拥有多个角色（如达人 + 商家员工），请求以激活角色和该角色下的一个组织作为身份；
+// 未选择或选择已失效（角色被收回、离开组织）时按角色优先级取默认身份
+type RoleContextService struct {
+	db        *gorm.DB
+	userCache *UserCache
+}
+
+// NewRoleContextService 创建角色身份服务
+func NewRoleContextService(db *gorm.DB, userCache *UserCache) *RoleContextService {
+	return &RoleContextService{db: db, userCache: userCache}
+}
+
+// Options 用户可切换的全部身份，按角色优先级排列；组织角色每个组织一项
+func (s *RoleContextService) Options(user *models.User) ([]models.RoleContext, error) {
+	var options []models.RoleContext
+	for _, role := range heldRoles(user) {
+		contexts, err := s.contextsFor(user, role)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, contexts...)
+	}
+	return options, nil
+}
+
+// Resolve 解析用户当前的身份
+// 已选择的激活角色仍然有效时使用它，否则取优先级最高的角色（组织角色取第一个组织）
+func (s *RoleContextService) Resolve(user *models.User) (*models.RoleContext, error) {
+	roles := heldRoles(user)
+
+	if user.ActiveRole != "" {
+		for _, role := range roles {
+			if !strings.EqualFold(role, user.ActiveRole) {
+				continue
+			}
+			contexts, err := s.contextsFor(user, role)
+			if err != nil {
+				return nil, err
+			}
+			for i := range contexts {
+				if user.ActiveOrgID == nil || contexts[i].Matches(role, user.ActiveOrgID) {
+					return &contexts[i], nil
+				}
+			}
+		}
+	}
+
+	for _, role := range roles {
+		contexts, err := s.contextsFor(user, role)
+		if err != nil {
+			return nil, err
+		}
+		if len(contexts) > 0 {
+			return &contexts[0], nil
+		}
+	}
+	return &models.RoleContext{Role: constants.RoleBasicUser}, nil
+}
+
+// Switch 切换激活角色；orgID 为空时取该角色下的第一个组织
+func (s *RoleContextService) Switch(user *models.User, role string, orgID *uuid.UUID) (*models.RoleContext, error) {
+	held := ""
+	for _, r := range heldRoles(user) {
+		if strings.EqualFold(r, role) {
+			held = r
+			break
+		}
+	}
+	if held == "" {
+		return nil, ErrRoleNotHeld
+	}
+
+	contexts, err := s.contextsFor(user, held)
+	if err != nil {
+		return nil, err
+	}
+	var selected *models.RoleContext
+	for i := range contexts {
+		if orgID == nil || contexts[i].Matches(held, orgID) {
+			selected = &contexts[i]
+			break
+		}
+	}
+	if selected == nil {
+		return nil, ErrRoleOrganizationNotFound
+	}
+
+	if err := s.db.Model(&models.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+		"active_role":   selected.Role,
+		"active_org_id": selected.OrgID,
+	}).Error; err != nil {
+		return nil, err
+	}
+	s.userCache.Invalidate(user.AuthCenterUserID)
+	user.ActiveRole = selected.Role
+	user.ActiveOrgID = selected.OrgID
+	return selected, nil
+}
+
+// contextsFor 角色下的身份：非组织角色一项；组织角色每个所属组织一项，找不到组织时返回不带组织的一项
+// 组织管理员和员工记录中历史上既有存本地用户ID的，也有存账号中心用户ID的，两者都匹配
+func (s *RoleContextService) contextsFor(user *models.User, role string) ([]models.RoleContext, error) {
+	userIDs := []string{user.ID, user.AuthCenterUserID}
+	var contexts []models.RoleContext
+
+	switch role {
+	case constants.RoleMerchantAdmin:
+		var merchants []models.Merchant
+		if err := s.db.Where("admin_id IN ? AND deleted_at IS NULL", userIDs).Order("created_at").Find(&merchants).Error; err != nil {
+			return nil, err
+		}
+		for i := range merchants {
+			contexts = append(contexts, models.RoleContext{Role: role, OrgType: models.OrgTypeMerchant, OrgID: &merchants[i].ID, OrgName: merchants[i].Name})
+		}
+	case constants.RoleMerchantStaff:
+		var staff []models.MerchantStaff
+		if err := s.db.Preload("Merchant").Where("user_id IN ? AND status = ?", userIDs, "active").Order("created_at").Find(&staff).Error; err != nil {
+			return nil, err
+		}
+		for i := range staff {
+			rc := models.RoleContext{Role: role, OrgType: models.OrgTypeMerchant, OrgID: &staff[i].MerchantID, StaffID: &staff[i].ID}
+			if staff[i].Merchant != nil {
+				rc.OrgName = staff[i].Merchant.Name
+			}
+			contexts = append(contexts, rc)
+		}
+	case constants.RoleServiceProviderAdmin:
+		var providers []models.ServiceProvider
+		if err := s.db.Where("admin_id IN ? AND deleted_at IS NULL", userIDs).Order("created_at").Find(&providers).Error; err != nil {
+			return nil, err
+		}
+		for i := range providers {
+			contexts = append(contexts, models.RoleContext{Role: role, OrgType: models.OrgTypeServiceProvider, OrgID: &providers[i].ID, OrgName: providers[i].Name})
+		}
+	case constants.RoleServiceProviderStaff:
+		var staff []models.ServiceProviderStaff
+		if err := s.db.Preload("Provider").Where("user_id IN ? AND status = ?", userIDs, "active").Order("created_at").Find(&staff).Error; err != nil {
+			return nil, err
+		}
+		for i := range staff {
+			rc := models.RoleContext{Role: role, OrgType: models.OrgTypeServiceProvider, OrgID: &staff[i].ProviderID, StaffID: &staff[i].ID}
+			if staff[i].Provider != nil {
+				rc.OrgName = staff[i].Provider.Name
+			}
+			contexts = append(contexts, rc)
+		}
+	default:
+		return []models.RoleContext{{Role: role}}, nil
+	}
+
+	if len(contexts) == 0 {
+		contexts = append(contexts, models.RoleContext{Role: role, OrgType: orgTypeOfRole(role)})
+	}
+	return contexts, nil
+}
+
+// heldRoles 用户拥有的角色，按优先级排列并统一为大写常量（DB 中可能存在小写角色）
+func heldRoles(user *models.User) []string {
+	var roles []string
+	for _, role := range constants.GetAllRoles() {
+		for _, r := range user.Roles {
+			if strings.EqualFold(r, role) {
+				roles = append(roles, role)
+				break
+			}
+		}
+	}
+	return roles
+}
+
+// orgTypeOfRole 组织角色对应的组织类型
+func orgTypeOfRole(role string) string {
+	switch role {
+	case constants.RoleMerchantAdmin, constants.RoleMerchantStaff:
+		return models.OrgTypeMerchant
+	case constants.RoleServiceProviderAdmin, constants.RoleServiceProviderStaff:
+		return models.OrgTypeServiceProvider
+	}
+	return ""
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ScheduledJob 定时任务定义
+type ScheduledJob struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context, now time.Time) error
+}
+
+// SchedulerService 进程内定时任务调度器
+// 每个任务执行前尝试获取 Postgres 会话级 advisory lock，多副本部署时同一时刻只有一个实例执行同一任务
+type SchedulerService struct {
+	db   *gorm.DB
+	jobs []ScheduledJob
+}
+
+// NewSchedulerService 创建定时任务调度器
+func NewSchedulerService(db *gorm.DB) *SchedulerService {
+	return &SchedulerService{db: db}
+}
+
+// Register 注册定时任务，需在 Start 之前调用
+func (s *SchedulerService) Register(job ScheduledJob) {
+	if job.Interval <= 0 {
+		job.Interval = time.Minute
+	}
+	s.jobs = append(s.jobs, job)
+}
+
+// Start 为每个任务启动一个后台协程，ctx 取消时退出
+func (s *SchedulerService) Start(ctx context.Context) {
+	for _, job := range s.jobs {
+		go s.loop(ctx, job)
+	}
+	log.Printf("定时任务调度器已启动，任务数: %d", len(s.jobs))
+}
+
+// loop 按间隔执行单个任务
+func (s *SchedulerService) loop(ctx context.Context, job ScheduledJob) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.RunOnce(ctx, job); err != nil {
+				log.Printf("定时任务 %s 执行失败: %v", job.Name, err)
+			}
+		}
+	}
+}
+
+// RunOnce 获取 advisory lock 后执行一次任务；锁被其他实例持有时直接跳过
+func (s *SchedulerService) RunOnce(ctx context.Context, job ScheduledJob) error {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return fmt.Errorf("获取数据库连接失败: %w", err)
+	}
+
+	// advisory lock 绑定在会话上，加锁、解锁必须使用同一连接
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("获取数据库连接失败: %w", err)
+	}
+	defer conn.Close()
+
+	key := schedulerLockKey(job.Name)
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&locked); err != nil {
+		return fmt.Errorf("获取任务锁失败: %w", err)
+	}
+	if !locked {
+		return nil
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", key); err != nil {
+			log.Printf("释放定时任务 %s 的锁失败: %v", job.Name, err)
+		}
+	}()
+
+	return job.Run(ctx, time.Now())
+}
+
+// schedulerLockKey 由任务名生成 advisory lock 的键
+func schedulerLockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("pr-business:scheduler:" + name))
+	return int64(h.Sum64())
+}
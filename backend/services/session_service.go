@@ -0,0 +1,365 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"pr-business/models"
+)
+
+// SessionService 登录会话：用账号中心 token 换取会话、刷新、退出登录和吊销
+// access token 由 TokenVerifier 以 JWT_SECRET 签发；refresh token 为随机串，只保存摘要，每次刷新轮换
+type SessionService struct {
+	db         *gorm.DB
+	verifier   *TokenVerifier
+	userCache  *UserCache
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+// NewSessionService 创建会话服务
+func NewSessionService(db *gorm.DB, verifier *TokenVerifier, userCache *UserCache, accessTTL, refreshTTL time.Duration) *SessionService {
+	if accessTTL <= 0 {
+		accessTTL = 24 * time.Hour
+	}
+	if refreshTTL <= 0 {
+		refreshTTL = 7 * 24 * time.Hour
+	}
+	return &SessionService{
+		db:         db,
+		verifier:   verifier,
+		userCache:  userCache,
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+	}
+}
+
+// SessionClient 发起会话请求的客户端信息
+type SessionClient struct {
+	IPAddress string
+	UserAgent string
+}
+
+// SessionTokens 会话凭证
+type SessionTokens struct {
+	SessionID        uuid.UUID `json:"sessionId"`
+	AccessToken      string    `json:"accessToken"`
+	RefreshToken     string    `json:"refreshToken"`
+	ExpiresAt        time.Time `json:"expiresAt"`
+	RefreshExpiresAt time.Time `json:"refreshExpiresAt"`
+}
+
+// Create 为已认证用户创建会话
+func (s *SessionService) Create(user *models.User, client SessionClient) (*SessionTokens, error) {
+	if user.Status == models.UserStatusBanned {
+		return nil, ErrUserBanned
+	}
+
+	refreshToken, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := models.AuthSession{
+		UserID:           user.ID,
+		RefreshTokenHash: hashRefreshToken(refreshToken),
+		IPAddress:        client.IPAddress,
+		UserAgent:        truncateString(client.UserAgent, 500),
+		ExpiresAt:        now.Add(s.refreshTTL),
+	}
+	if err := s.db.Create(&session).Error; err != nil {
+		return nil, err
+	}
+
+	return s.issue(user, &session, refreshToken, now)
+}
+
+// Refresh 用 refresh token 换取新的 access token 并轮换 refresh token
+// 已轮换掉的 refresh token 再次出现说明可能泄露，吊销整个会话
+func (s *SessionService) Refresh(refreshToken string, client SessionClient) (*SessionTokens, error) {
+	hash := hashRefreshToken(refreshToken)
+	now := time.Now()
+
+	var tokens *SessionTokens
+	reused := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var session models.AuthSession
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("refresh_token_hash = ?", hash).
+			First(&session).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("previous_token_hash = ?", hash).
+				First(&session).Error
+			if err == nil && session.RevokedAt == nil {
+				reused = true
+				return s.revokeSession(tx, &session, "system", "refresh token 重复使用", now)
+			}
+			if err == nil || errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidRefreshToken
+			}
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		if !session.IsActive(now) {
+			return ErrInvalidRefreshToken
+		}
+
+		var user models.User
+		if err := tx.Where("id = ?", session.UserID).First(&user).Error; err != nil {
+			return err
+		}
+		if user.Status == models.UserStatusBanned {
+			return ErrUserBanned
+		}
+
+		newToken, err := newRefreshToken()
+		if err != nil {
+			return err
+		}
+		session.PreviousTokenHash = session.RefreshTokenHash
+		session.RefreshTokenHash = hashRefreshToken(newToken)
+		session.LastRefreshedAt = &now
+		session.ExpiresAt = now.Add(s.refreshTTL)
+		if client.IPAddress != "" {
+			session.IPAddress = client.IPAddress
+		}
+		if client.UserAgent != "" {
+			session.UserAgent = truncateString(client.UserAgent, 500)
+		}
+		if err := tx.Save(&session).Error; err != nil {
+			return err
+		}
+
+		tokens, err = s.issue(&user, &session, newToken, now)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if reused {
+		log.Printf("[Session] refresh token reused, session revoked")
+		return nil, ErrInvalidRefreshToken
+	}
+	return tokens, nil
+}
+
+// Logout 退出登录：吊销当前 token；会话 token 同时吊销所属会话
+func (s *SessionService) Logout(user *models.User, claims *TokenClaims) error {
+	now := time.Now()
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if claims.SessionID != "" {
+			var session models.AuthSession
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("id = ? AND user_id = ?", claims.SessionID, user.ID).
+				First(&session).Error
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			if err == nil && session.RevokedAt == nil {
+				if err := s.revokeSession(tx, &session, user.ID, "退出登录", now); err != nil {
+					return err
+				}
+			}
+		}
+
+		if claims.TokenID == "" {
+			// 认证时已为没有 jti 的 token 补上摘要 ID，这里只可能是会话 token
+			if claims.SessionID == "" {
+				return ErrTokenNotRevocable
+			}
+			return nil
+		}
+		expiresAt := claims.ExpiresAt
+		if expiresAt.IsZero() {
+			// 远程校验的账号中心 token 不知道过期时间，按 refresh token 有效期保留
+			expiresAt = now.Add(s.refreshTTL)
+		}
+		return revokeToken(tx, claims.TokenID, user.ID, expiresAt, user.ID, "退出登录")
+	})
+}
+
+// ListSessions 查询用户的会话（含已吊销、已过期），按创建时间倒序
+func (s *SessionService) ListSessions(userID string) ([]models.AuthSession, error) {
+	var sessions []models.AuthSession
+	err := s.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&sessions).Error
+	return sessions, err
+}
+
+// RevokeUserSessions 吊销用户全部会话，并使此前签发的所有 token（含账号中心 token）失效
+// 返回吊销的会话数
+func (s *SessionService) RevokeUserSessions(userID, revokedBy, reason string) (int, error) {
+	now := time.Now()
+	revoked := 0
+	var user models.User
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", userID).First(&user).Error; err != nil {
+			return err
+		}
+
+		var sessions []models.AuthSession
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, now).
+			Find(&sessions).Error; err != nil {
+			return err
+		}
+		for i := range sessions {
+			if err := s.revokeSession(tx, &sessions[i], revokedBy, reason, now); err != nil {
+				return err
+			}
+		}
+		revoked = len(sessions)
+
+		return tx.Model(&models.User{}).Where("id = ?", userID).Update("tokens_revoked_before", now).Error
+	})
+	if err == nil {
+		s.userCache.Invalidate(user.AuthCenterUserID)
+	}
+	return revoked, err
+}
+
+// CheckToken 校验 token 是否已被吊销
+// 早于用户 tokens_revoked_before 签发的 token 失效；没有签发时间的 token（账号中心 token 可能不带 iat）
+// 按有效期不超过 refresh token 有效期估算最早签发时间，可能早于吊销时间即拒绝
+func (s *SessionService) CheckToken(user *models.User, claims *TokenClaims) error {
+	if user.TokensRevokedBefore != nil && s.earliestIssuedAt(claims).Before(user.TokensRevokedBefore.Truncate(time.Second)) {
+		return ErrTokenRevoked
+	}
+
+	ids := make([]string, 0, 2)
+	if claims.TokenID != "" {
+		ids = append(ids, claims.TokenID)
+	}
+	if claims.SessionID != "" {
+		ids = append(ids, claims.SessionID)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var count int64
+	if err := s.db.Model(&models.RevokedToken{}).Where("token_id IN ?", ids).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrTokenRevoked
+	}
+	return nil
+}
+
+// earliestIssuedAt token 最早可能的签发时间：有 iat 时取 iat，否则取过期时间（远程校验的 token 没有过期时间时取当前时间）减去 refresh token 有效期
+func (s *SessionService) earliestIssuedAt(claims *TokenClaims) time.Time {
+	if !claims.IssuedAt.IsZero() {
+		return claims.IssuedAt
+	}
+	expiresAt := claims.ExpiresAt
+	if expiresAt.IsZero() {
+		expiresAt = time.Now()
+	}
+	return expiresAt.Add(-s.refreshTTL)
+}
+
+// PurgeExpired 清理已过期的吊销记录和会话（对应的 token 本身已失效）
+func (s *SessionService) PurgeExpired(now time.Time) (int64, error) {
+	result := s.db.Where("expires_at < ?", now).Delete(&models.RevokedToken{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	purged := result.RowsAffected
+
+	result = s.db.Where("expires_at < ?", now.Add(-s.accessTTL)).Delete(&models.AuthSession{})
+	return purged + result.RowsAffected, result.Error
+}
+
+// RegisterJobs 注册定时清理过期吊销记录和会话
+func (s *SessionService) RegisterJobs(scheduler *SchedulerService, interval time.Duration) {
+	scheduler.Register(ScheduledJob{
+		Name:     "purge-auth-sessions",
+		Interval: interval,
+		Run: func(ctx context.Context, now time.Time) error {
+			purged, err := s.PurgeExpired(now)
+			if purged > 0 {
+				log.Printf("已清理 %d 条过期会话/吊销记录", purged)
+			}
+			return err
+		},
+	})
+}
+
+// issue 签发 access token
+func (s *SessionService) issue(user *models.User, session *models.AuthSession, refreshToken string, now time.Time) (*SessionTokens, error) {
+	expiresAt := now.Add(s.accessTTL)
+	accessToken, err := s.verifier.SignSession(user.AuthCenterUserID, session.ID.String(), uuid.New().String(), now, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SessionTokens{
+		SessionID:        session.ID,
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		ExpiresAt:        expiresAt,
+		RefreshExpiresAt: session.ExpiresAt,
+	}, nil
+}
+
+// revokeSession 吊销会话，并将会话ID加入吊销列表使其已签发的 access token 立即失效
+func (s *SessionService) revokeSession(tx *gorm.DB, session *models.AuthSession, revokedBy, reason string, now time.Time) error {
+	if err := tx.Model(session).Updates(map[string]interface{}{
+		"revoked_at":    now,
+		"revoked_by":    revokedBy,
+		"revoke_reason": reason,
+		"updated_at":    now,
+	}).Error; err != nil {
+		return err
+	}
+	return revokeToken(tx, session.ID.String(), session.UserID, now.Add(s.accessTTL), revokedBy, reason)
+}
+
+// revokeToken 写入吊销列表（重复吊销忽略）
+func revokeToken(tx *gorm.DB, tokenID, userID string, expiresAt time.Time, revokedBy, reason string) error {
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.RevokedToken{
+		TokenID:   tokenID,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+		Reason:    truncateString(reason, 500),
+		RevokedBy: revokedBy,
+	}).Error
+}
+
+// newRefreshToken 生成随机 refresh token
+func newRefreshToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashRefreshToken refresh token 摘要
+func hashRefreshToken(token string) string {
+	digest := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(digest[:])
+}
+
+// truncateString 按字符截断，避免超出字段长度
+func truncateString(value string, max int) string {
+	runes := []rune(value)
+	if len(runes) <= max {
+		return value
+	}
+	return string(runes[:max])
+}
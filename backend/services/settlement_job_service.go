@@ -0,0 +1,315 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"pr-business/models"
+)
+
+const (
+	// settlementRetryBaseDelay 首次重试等待时间，之后每次翻倍
+	settlementRetryBaseDelay = 30 * time.Second
+	// settlementRetryMaxDelay 单次重试最长等待时间
+	settlementRetryMaxDelay = time.Hour
+	// settlementJobLockTimeout 领取后超过该时间未完成视为 worker 崩溃，可被重新领取
+	settlementJobLockTimeout = 10 * time.Minute
+)
+
+// SettlementJobService 结算任务队列服务
+// 任务审核通过后写入 settlement_jobs，由 worker 池执行结算；失败按指数退避重试，重试耗尽进入死信
+type SettlementJobService struct {
+	db                *gorm.DB
+	settlementService *SettlementService
+	workerCount       int
+	maxAttempts       int
+	pollInterval      time.Duration
+	workerID          string
+}
+
+// NewSettlementJobService 创建结算任务队列服务
+func NewSettlementJobService(
+	db *gorm.DB,
+	settlementService *SettlementService,
+	workerCount int,
+	maxAttempts int,
+	pollInterval time.Duration,
+) *SettlementJobService {
+	if workerCount <= 0 {
+		workerCount = 1
+	}
+	if maxAttempts <= 0 {
+		maxAttempts = 8
+	}
+	if pollInterval <= 0 {
+		pollInterval = 5 * time.Second
+	}
+
+	hostname, _ := os.Hostname()
+
+	return &SettlementJobService{
+		db:                db,
+		settlementService: settlementService,
+		workerCount:       workerCount,
+		maxAttempts:       maxAttempts,
+		pollInterval:      pollInterval,
+		workerID:          fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+	}
+}
+
+// Enqueue 写入结算任务
+// 必须传入与任务状态更新相同的事务，保证"审核通过"与"待结算"同时落库
+func (s *SettlementJobService) Enqueue(tx *gorm.DB, task *models.Task, auditorUserID string) (*models.SettlementJob, error) {
+	job := models.SettlementJob{
+		TaskID:        task.ID,
+		CampaignID:    task.CampaignID,
+		AuditorUserID: auditorUserID,
+		Status:        models.SettlementJobStatusPending,
+		MaxAttempts:   s.maxAttempts,
+		NextRunAt:     time.Now(),
+	}
+
+	if err := tx.Create(&job).Error; err != nil {
+		return nil, fmt.Errorf("创建结算任务失败: %w", err)
+	}
+
+	return &job, nil
+}
+
+// Start 启动 worker 池，ctx 取消后退出
+func (s *SettlementJobService) Start(ctx context.Context) {
+	for i := 0; i < s.workerCount; i++ {
+		go s.runWorker(ctx, i)
+	}
+	log.Printf("[SettlementJob] started %d workers (%s)", s.workerCount, s.workerID)
+}
+
+// runWorker 单个 worker 循环：领取任务 → 执行 → 无任务时等待
+func (s *SettlementJobService) runWorker(ctx context.Context, index int) {
+	lockedBy := fmt.Sprintf("%s#%d", s.workerID, index)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		job, err := s.claimNextJob(lockedBy)
+		if err != nil {
+			log.Printf("[SettlementJob] claim failed: %v", err)
+		}
+
+		if job == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(s.pollInterval):
+			}
+			continue
+		}
+
+		s.processJob(job)
+	}
+}
+
+// claimNextJob 领取一个到期任务（SKIP LOCKED 保证多 worker / 多实例不会重复领取）
+func (s *SettlementJobService) claimNextJob(lockedBy string) (*models.SettlementJob, error) {
+	var claimed *models.SettlementJob
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		var job models.SettlementJob
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("(status = ? AND next_run_at <= ?) OR (status = ? AND locked_at < ?)",
+				models.SettlementJobStatusPending, now,
+				models.SettlementJobStatusRunning, now.Add(-settlementJobLockTimeout)).
+			Order("next_run_at ASC").
+			First(&job).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		job.Status = models.SettlementJobStatusRunning
+		job.LockedAt = &now
+		job.LockedBy = lockedBy
+		job.Attempts += 1
+		if err := tx.Save(&job).Error; err != nil {
+			return err
+		}
+
+		claimed = &job
+		return nil
+	})
+
+	return claimed, err
+}
+
+// processJob 执行结算并记录结果
+func (s *SettlementJobService) processJob(job *models.SettlementJob) {
+	settleErr := s.settle(job)
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"locked_at":  nil,
+		"locked_by":  "",
+		"updated_at": now,
+	}
+
+	if settleErr == nil {
+		updates["status"] = models.SettlementJobStatusSucceeded
+		updates["completed_at"] = &now
+		updates["last_error"] = ""
+	} else if job.Attempts >= job.MaxAttempts {
+		log.Printf("[SettlementJob] job %s dead after %d attempts: %v", job.ID, job.Attempts, settleErr)
+		updates["status"] = models.SettlementJobStatusDead
+		updates["last_error"] = settleErr.Error()
+	} else {
+		delay := settlementRetryDelay(job.Attempts)
+		log.Printf("[SettlementJob] job %s attempt %d failed, retry in %v: %v", job.ID, job.Attempts, delay, settleErr)
+		updates["status"] = models.SettlementJobStatusPending
+		updates["next_run_at"] = now.Add(delay)
+		updates["last_error"] = settleErr.Error()
+	}
+
+	// 只更新仍由本 worker 持有的任务，避免覆盖管理员在执行期间的取消操作
+	if err := s.db.Model(&models.SettlementJob{}).
+		Where("id = ? AND status = ? AND locked_by = ?", job.ID, models.SettlementJobStatusRunning, job.LockedBy).
+		Updates(updates).Error; err != nil {
+		log.Printf("[SettlementJob] update job %s failed: %v", job.ID, err)
+	}
+}
+
+// settle 加载任务并调用结算服务
+func (s *SettlementJobService) settle(job *models.SettlementJob) error {
+	var task models.Task
+	if err := s.db.Where("id = ?", job.TaskID).First(&task).Error; err != nil {
+		return fmt.Errorf("获取任务失败: %w", err)
+	}
+
+	if task.Status != models.TaskStatusApproved {
+		return fmt.Errorf("任务状态为 %s，不能结算", task.Status)
+	}
+
+	return s.settlementService.SettleTaskAfterApproval(&task, job.AuditorUserID)
+}
+
+// settlementRetryDelay 计算第 attempts 次失败后的等待时间（指数退避，有上限）
+func settlementRetryDelay(attempts int) time.Duration {
+	delay := settlementRetryBaseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= settlementRetryMaxDelay {
+			return settlementRetryMaxDelay
+		}
+	}
+	return delay
+}
+
+// ListJobs 查询结算任务列表
+func (s *SettlementJobService) ListJobs(status string, limit int, offset int) ([]models.SettlementJob, int64, error) {
+	query := s.db.Model(&models.SettlementJob{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计结算任务失败: %w", err)
+	}
+
+	var jobs []models.SettlementJob
+	if err := query.Preload("Task").Order("created_at DESC").Limit(limit).Offset(offset).Find(&jobs).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询结算任务失败: %w", err)
+	}
+
+	return jobs, total, nil
+}
+
+// RetryJob 人工重试（死信/已取消/等待中的任务立即重新执行，重置重试次数）
+func (s *SettlementJobService) RetryJob(id string) (*models.SettlementJob, error) {
+	var result *models.SettlementJob
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		job, err := s.lockJob(tx, id)
+		if err != nil {
+			return err
+		}
+
+		if !job.CanRetry() {
+			return ErrInvalidSettlementJobStatus
+		}
+
+		job.Status = models.SettlementJobStatusPending
+		job.Attempts = 0
+		job.NextRunAt = time.Now()
+		job.CancelledBy = ""
+		job.CancelReason = ""
+		if err := tx.Save(job).Error; err != nil {
+			return err
+		}
+
+		result = job
+		return nil
+	})
+
+	return result, err
+}
+
+// CancelJob 取消结算任务（任务保持 APPROVED，但不再自动结算）
+func (s *SettlementJobService) CancelJob(id string, reason string, operatorID string) (*models.SettlementJob, error) {
+	var result *models.SettlementJob
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		job, err := s.lockJob(tx, id)
+		if err != nil {
+			return err
+		}
+
+		if !job.CanCancel() {
+			return ErrInvalidSettlementJobStatus
+		}
+
+		job.Status = models.SettlementJobStatusCancelled
+		job.CancelledBy = operatorID
+		job.CancelReason = reason
+		if err := tx.Save(job).Error; err != nil {
+			return err
+		}
+
+		result = job
+		return nil
+	})
+
+	return result, err
+}
+
+// lockJob 行锁读取结算任务
+func (s *SettlementJobService) lockJob(tx *gorm.DB, id string) (*models.SettlementJob, error) {
+	jobID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrSettlementJobNotFound
+	}
+
+	var job models.SettlementJob
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", jobID).First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSettlementJobNotFound
+		}
+		return nil, err
+	}
+
+	return &job, nil
+}
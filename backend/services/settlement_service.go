@@ -3,10 +3,12 @@ package services
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 
+	"pr-business/constants"
 	"pr-business/models"
 )
 
@@ -17,6 +19,8 @@ type SettlementService struct {
 	permissionService  *AccountPermissionService
 	validatorService   *ValidatorService
 	cashAccountService *CashAccountService
+	ledgerService      *LedgerService
+	platformFeeService *PlatformFeeService
 }
 
 // NewSettlementService 创建结算服务
@@ -25,21 +29,29 @@ func NewSettlementService(
 	permissionService *AccountPermissionService,
 	validatorService *ValidatorService,
 	cashAccountService *CashAccountService,
+	ledgerService *LedgerService,
+	platformFeeService *PlatformFeeService,
 ) *SettlementService {
 	return &SettlementService{
 		db:                db,
 		permissionService:  permissionService,
 		validatorService:   validatorService,
 		cashAccountService: cashAccountService,
+		ledgerService:      ledgerService,
+		platformFeeService: platformFeeService,
 	}
 }
 
 // SettleTaskAfterApproval 任务审核通过后结算
-// 流程：
-// 1. 从商家账户扣除 campaign_amount（活动总金额）
-// 2. 给达人账户增加 creator_amount（达人收入）
-// 3. 给员工增加 staff_referral_amount（员工返佣）
-// 4. 给服务商增加 provider_amount（服务商分成）
+// 流程（同一组分录）：
+// 1. 从活动托管分账支出 task_amount（单个任务金额）；托管上线前发布的活动从商家冻结余额扣除
+// 2. 按生效的手续费规则计算平台手续费，计入 PLATFORM_REVENUE；
+//    手续费依次从服务商分成、员工返佣、达人收入中扣除
+// 3. 给达人账户增加 creator_amount（达人收入）
+// 4. 给员工增加 staff_referral_amount（员工返佣）
+// 5. 给服务商增加 provider_amount（服务商分成）
+// 6. 未分配部分（如找不到返佣员工）退回商家可用余额
+// 7. 记录结算明细 settlement_records
 func (s *SettlementService) SettleTaskAfterApproval(task *models.Task, auditorUserID string) error {
 	// 获取营销活动信息
 	var campaign models.Campaign
@@ -52,44 +64,60 @@ func (s *SettlementService) SettleTaskAfterApproval(task *models.Task, auditorUs
 		return errors.New("达人收入金额未配置或为0")
 	}
 
+	// 手续费按审核通过时间取规则，重试时结果不变
+	settledAt := time.Now()
+	if task.AuditedAt != nil {
+		settledAt = *task.AuditedAt
+	}
+
 	// 开始事务
 	return s.db.Transaction(func(tx *gorm.DB) error {
-		transactionGroupID := uuid.New()
+		// 幂等保护：结算任务可能被重试，已结算过的任务直接返回
+		var settledCount int64
+		if err := tx.Model(&models.SettlementRecord{}).
+			Where("task_id = ?", task.ID).
+			Count(&settledCount).Error; err != nil {
+			return fmt.Errorf("检查结算记录失败: %w", err)
+		}
+		if settledCount == 0 {
+			// 手续费上线前结算的任务没有结算明细
+			if err := tx.Model(&models.CreditTransaction{}).
+				Where("related_task_id = ? AND type = ?", task.ID, models.TransactionTaskIncome).
+				Count(&settledCount).Error; err != nil {
+				return fmt.Errorf("检查结算记录失败: %w", err)
+			}
+		}
+		if settledCount > 0 {
+			return nil
+		}
 
-		// 1. 从商家冻结账户扣除活动总金额
 		merchantAccount, err := s.findOrCreateAccount(tx, campaign.MerchantID, models.OwnerTypeOrgMerchant)
 		if err != nil {
 			return fmt.Errorf("获取商家账户失败: %w", err)
 		}
 
-		if merchantAccount.FrozenBalance < campaign.CampaignAmount {
-			return errors.New("商家冻结积分不足")
-		}
-
-		// 从冻结余额扣除
-		merchantAccount.FrozenBalance -= campaign.CampaignAmount
-		if err := tx.Save(&merchantAccount).Error; err != nil {
-			return fmt.Errorf("更新商家冻结余额失败: %w", err)
+		// 1. 支出来源：活动托管分账，或旧活动的商家冻结余额
+		source, err := s.fundingPosting(tx, &campaign, merchantAccount.ID, -campaign.TaskAmount)
+		if err != nil {
+			return err
 		}
-
-		// 记录商家扣款流水
-		taskPublishTransaction := models.CreditTransaction{
-			AccountID:        merchantAccount.ID,
-			Type:             models.TransactionTaskPublish,
-			Amount:           -campaign.CampaignAmount,
-			BalanceBefore:     merchantAccount.FrozenBalance + campaign.CampaignAmount,
-			BalanceAfter:      merchantAccount.FrozenBalance,
-			RelatedCampaignID: &campaign.ID,
-			RelatedTaskID:     &task.ID,
-			Description:       fmt.Sprintf("任务结算：%s", campaign.Title),
-			TransactionGroupID: &transactionGroupID,
-			GroupSequence:     intPtr(1),
+		source.Type = models.TransactionEscrowRelease
+		if source.Kind == models.LedgerAccountCreditFrozen {
+			source.Type = models.TransactionTaskPublish
 		}
-		if err := tx.Create(&taskPublishTransaction).Error; err != nil {
-			return fmt.Errorf("记录商家流水失败: %w", err)
+		source.Description = fmt.Sprintf("任务结算：%s", campaign.Title)
+
+		record := models.SettlementRecord{
+			TaskID:        task.ID,
+			CampaignID:    campaign.ID,
+			MerchantID:    campaign.MerchantID,
+			ProviderID:    campaign.ProviderID,
+			TaskAmount:    campaign.TaskAmount,
+			CreatorAmount: *campaign.CreatorAmount,
+			SettledAt:     settledAt,
 		}
 
-		// 2. 给达人账户增加收入
+		// 达人账户
 		creatorUserID, err := s.getCreatorUserID(tx, task.CreatorID)
 		if err != nil {
 			return fmt.Errorf("获取达人用户ID失败: %w", err)
@@ -100,97 +128,138 @@ func (s *SettlementService) SettleTaskAfterApproval(task *models.Task, auditorUs
 			return fmt.Errorf("获取达人账户失败: %w", err)
 		}
 
-		creatorBalanceBefore := creatorAccount.Balance
-		creatorAccount.Balance += *campaign.CreatorAmount
-		if err := tx.Save(&creatorAccount).Error; err != nil {
-			return fmt.Errorf("更新达人余额失败: %w", err)
-		}
-
-		// 记录达人收入流水
-		taskIncomeTransaction := models.CreditTransaction{
-			AccountID:        creatorAccount.ID,
-			Type:             models.TransactionTaskIncome,
-			Amount:           *campaign.CreatorAmount,
-			BalanceBefore:     creatorBalanceBefore,
-			BalanceAfter:      creatorAccount.Balance,
-			RelatedCampaignID: &campaign.ID,
-			RelatedTaskID:     &task.ID,
-			Description:       fmt.Sprintf("任务收入：%s", campaign.Title),
-			TransactionGroupID: &transactionGroupID,
-			GroupSequence:     intPtr(2),
-		}
-		if err := tx.Create(&taskIncomeTransaction).Error; err != nil {
-			return fmt.Errorf("记录达人流水失败: %w", err)
-		}
-
-		// 3. 员工返佣（如果配置了且任务有邀请人）
+		// 员工返佣账户（如果配置了且任务有邀请人）；找不到时返佣留在商家（见第6步）
+		var inviterAccount *models.CreditAccount
 		if campaign.StaffReferralAmount != nil && *campaign.StaffReferralAmount > 0 &&
 			task.InviterID != nil && *task.InviterID != "" {
-			// 查找员工账户
-			inviterAccount, err := s.findInviterAccount(tx, *task.InviterID, task.InviterType)
-			if err == nil {
-				staffBalanceBefore := inviterAccount.Balance
-				inviterAccount.Balance += *campaign.StaffReferralAmount
-				if err := tx.Save(&inviterAccount).Error; err != nil {
-					return fmt.Errorf("更新员工余额失败: %w", err)
-				}
-
-				// 记录员工返佣流水
-				staffReferralTransaction := models.CreditTransaction{
-					AccountID:        inviterAccount.ID,
-					Type:             models.TransactionStaffReferral,
-					Amount:           *campaign.StaffReferralAmount,
-					BalanceBefore:     staffBalanceBefore,
-					BalanceAfter:      inviterAccount.Balance,
-					RelatedCampaignID: &campaign.ID,
-					RelatedTaskID:     &task.ID,
-					Description:       fmt.Sprintf("员工返佣：%s", campaign.Title),
-					TransactionGroupID: &transactionGroupID,
-					GroupSequence:     intPtr(3),
-				}
-				if err := tx.Create(&staffReferralTransaction).Error; err != nil {
-					return fmt.Errorf("记录员工流水失败: %w", err)
-				}
+			if account, err := s.findInviterAccount(tx, *task.InviterID, task.InviterType); err == nil {
+				inviterAccount = account
+				record.StaffReferralAmount = *campaign.StaffReferralAmount
 			}
-			// 如果找不到员工账户，忽略返佣
 		}
 
-		// 4. 服务商分成（如果配置了且有服务商）
+		// 服务商账户（如果配置了且有服务商）
+		var providerAccount *models.CreditAccount
 		if campaign.ProviderAmount != nil && *campaign.ProviderAmount > 0 &&
 			campaign.ProviderID != nil {
-			providerAccount, err := s.findOrCreateAccount(tx, *campaign.ProviderID, models.OwnerTypeOrgProvider)
+			providerAccount, err = s.findOrCreateAccount(tx, *campaign.ProviderID, models.OwnerTypeOrgProvider)
 			if err != nil {
 				return fmt.Errorf("获取服务商账户失败: %w", err)
 			}
+			record.ProviderAmount = *campaign.ProviderAmount
+		}
 
-			providerBalanceBefore := providerAccount.Balance
-			providerAccount.Balance += *campaign.ProviderAmount
-			if err := tx.Save(&providerAccount).Error; err != nil {
-				return fmt.Errorf("更新服务商余额失败: %w", err)
-			}
+		distributed := record.CreatorAmount + record.StaffReferralAmount + record.ProviderAmount
+		if distributed > campaign.TaskAmount {
+			return fmt.Errorf("分配金额 %d 超过任务金额 %d", distributed, campaign.TaskAmount)
+		}
 
-			// 记录服务商收入流水
-			providerIncomeTransaction := models.CreditTransaction{
-				AccountID:        providerAccount.ID,
-				Type:             models.TransactionProviderIncome,
-				Amount:           *campaign.ProviderAmount,
-				BalanceBefore:     providerBalanceBefore,
-				BalanceAfter:      providerAccount.Balance,
-				RelatedCampaignID: &campaign.ID,
-				RelatedTaskID:     &task.ID,
-				Description:       fmt.Sprintf("服务商分成：%s", campaign.Title),
-				TransactionGroupID: &transactionGroupID,
-				GroupSequence:     intPtr(4),
-			}
-			if err := tx.Create(&providerIncomeTransaction).Error; err != nil {
-				return fmt.Errorf("记录服务商流水失败: %w", err)
+		// 2. 平台手续费
+		rule, err := s.platformFeeService.ResolveRule(tx, &campaign, settledAt)
+		if err != nil {
+			return err
+		}
+		if rule != nil {
+			record.FeeRuleID = &rule.ID
+			record.PlatformFee = rule.CalculateFee(campaign.TaskAmount)
+			deductPlatformFee(&record)
+		}
+
+		postings := []LedgerPosting{source}
+		if record.PlatformFee > 0 {
+			revenueAccountID, err := s.ledgerService.SystemAccountID(tx, constants.SystemAccountTypePlatformRevenue)
+			if err != nil {
+				return fmt.Errorf("获取平台收益账户失败: %w", err)
 			}
+			postings = append(postings, LedgerPosting{
+				Kind:        models.LedgerAccountSystem,
+				AccountID:   revenueAccountID,
+				Amount:      record.PlatformFee,
+				Type:        models.TransactionPlatformFee,
+				Description: fmt.Sprintf("平台手续费：%s", campaign.Title),
+			})
+		}
+
+		// 3. 达人收入
+		if record.CreatorAmount > 0 {
+			postings = append(postings, LedgerPosting{
+				Kind:        models.LedgerAccountCredit,
+				AccountID:   creatorAccount.ID,
+				Amount:      record.CreatorAmount,
+				Type:        models.TransactionTaskIncome,
+				Description: fmt.Sprintf("任务收入：%s", campaign.Title),
+			})
+		}
+
+		// 4. 员工返佣
+		if inviterAccount != nil && record.StaffReferralAmount > 0 {
+			postings = append(postings, LedgerPosting{
+				Kind:        models.LedgerAccountCredit,
+				AccountID:   inviterAccount.ID,
+				Amount:      record.StaffReferralAmount,
+				Type:        models.TransactionStaffReferral,
+				Description: fmt.Sprintf("员工返佣：%s", campaign.Title),
+			})
+		}
+
+		// 5. 服务商分成
+		if providerAccount != nil && record.ProviderAmount > 0 {
+			postings = append(postings, LedgerPosting{
+				Kind:        models.LedgerAccountCredit,
+				AccountID:   providerAccount.ID,
+				Amount:      record.ProviderAmount,
+				Type:        models.TransactionProviderIncome,
+				Description: fmt.Sprintf("服务商分成：%s", campaign.Title),
+			})
+		}
+
+		// 6. 未分配部分退回商家可用余额
+		record.RefundAmount = campaign.TaskAmount - record.PlatformFee - record.CreatorAmount -
+			record.StaffReferralAmount - record.ProviderAmount
+		if record.RefundAmount > 0 {
+			postings = append(postings, LedgerPosting{
+				Kind:        models.LedgerAccountCredit,
+				AccountID:   merchantAccount.ID,
+				Amount:      record.RefundAmount,
+				Type:        models.TransactionTaskRefund,
+				Description: fmt.Sprintf("任务结算未分配退回：%s", campaign.Title),
+			})
+		}
+
+		groupID, err := s.ledgerService.Post(tx, &LedgerTransfer{
+			RelatedCampaignID: &campaign.ID,
+			RelatedTaskID:     &task.ID,
+			Postings:          postings,
+		})
+		if err != nil {
+			return fmt.Errorf("记录结算分录失败: %w", err)
+		}
+
+		// 7. 结算明细
+		record.TransactionGroupID = groupID
+		if err := tx.Create(&record).Error; err != nil {
+			return fmt.Errorf("记录结算明细失败: %w", err)
 		}
 
 		return nil
 	})
 }
 
+// deductPlatformFee 从各方分配中扣除手续费：依次扣服务商分成、员工返佣、达人收入
+// 手续费最多收取到各方分配扣完为止，不占用退回商家的未分配部分
+func deductPlatformFee(record *models.SettlementRecord) {
+	remaining := record.PlatformFee
+	for _, share := range []*int{&record.ProviderAmount, &record.StaffReferralAmount, &record.CreatorAmount} {
+		cut := remaining
+		if cut > *share {
+			cut = *share
+		}
+		*share -= cut
+		remaining -= cut
+	}
+	record.PlatformFee -= remaining
+}
+
 // findOrCreateAccount 查找或创建组织账户
 func (s *SettlementService) findOrCreateAccount(tx *gorm.DB, ownerID uuid.UUID, ownerType models.OwnerType) (*models.CreditAccount, error) {
 	var account models.CreditAccount
@@ -266,74 +335,130 @@ func (s *SettlementService) findInviterAccount(tx *gorm.DB, inviterID string, in
 	}
 }
 
+// fundingPosting 活动资金来源分录
+// 已托管的活动从 TASK_ESCROW 系统账户支出；托管上线前发布的活动仍从商家冻结余额扣除
+func (s *SettlementService) fundingPosting(tx *gorm.DB, campaign *models.Campaign, merchantAccountID uuid.UUID, amount int) (LedgerPosting, error) {
+	escrow, err := s.ledgerService.FindCampaignEscrow(tx, campaign.ID)
+	if err != nil {
+		return LedgerPosting{}, err
+	}
+	if escrow == nil {
+		return FrozenPosting(merchantAccountID, amount), nil
+	}
+
+	escrowAccountID, err := s.ledgerService.SystemAccountID(tx, constants.SystemAccountTypeTaskEscrow)
+	if err != nil {
+		return LedgerPosting{}, err
+	}
+	return SystemPosting(escrowAccountID, amount), nil
+}
+
 // intPtr 返回int指针
 func intPtr(i int) *int {
 	return &i
 }
 
 // SettleCampaignAfterClose 活动关闭后结算，解冻未完成任务的积分
-// 流程：
-// 1. 检查活动状态是否为 CLOSED
-// 2. 统计已完成和未完成的任务数量
-// 3. 计算应退还的积分 = 未完成任务数量 * 任务金额
-// 4. 将商家冻结余额转回可用余额
 func (s *SettlementService) SettleCampaignAfterClose(campaign *models.Campaign) error {
-	// 开始事务
 	return s.db.Transaction(func(tx *gorm.DB) error {
-		// 统计任务完成情况
-		var completedTasksCount int64
-		if err := tx.Model(&models.Task{}).
-			Where("campaign_id = ? AND status = ?", campaign.ID, models.TaskStatusApproved).
-			Count(&completedTasksCount).Error; err != nil {
-			return fmt.Errorf("统计已完成任务失败: %w", err)
-		}
+		return s.SettleCampaignAfterCloseTx(tx, campaign)
+	})
+}
 
-		// 计算未完成任务数量
-		totalTasks := int64(campaign.Quota)
-		uncompletedTasks := totalTasks - completedTasksCount
+// SettleCampaignAfterCloseTx 在调用方事务内执行活动关闭退款
+// 流程：
+// 1. 统计已通过和待审核的任务数量（待审核任务保留托管，审核后再结算或退还）
+// 2. 计算应退还的积分 = (名额 - 已通过 - 待审核) * 任务金额
+// 3. 将活动托管分账中这部分积分退回商家可用余额
+func (s *SettlementService) SettleCampaignAfterCloseTx(tx *gorm.DB, campaign *models.Campaign) error {
+	// 统计任务完成情况
+	var retainedTasksCount int64
+	if err := tx.Model(&models.Task{}).
+		Where("campaign_id = ? AND status IN ?", campaign.ID,
+			[]models.TaskStatus{models.TaskStatusApproved, models.TaskStatusSubmitted}).
+		Count(&retainedTasksCount).Error; err != nil {
+		return fmt.Errorf("统计已完成任务失败: %w", err)
+	}
 
-		// 如果没有未完成的任务，无需处理
-		if uncompletedTasks <= 0 {
-			return nil
-		}
+	// 计算未完成任务数量
+	totalTasks := int64(campaign.Quota)
+	uncompletedTasks := totalTasks - retainedTasksCount
 
-		// 计算应退还的积分
-		refundAmount := uncompletedTasks * int64(campaign.TaskAmount)
+	// 如果没有未完成的任务，无需处理
+	if uncompletedTasks <= 0 {
+		return nil
+	}
 
-		// 获取商家积分账户
-		merchantAccount, err := s.findOrCreateAccount(tx, campaign.MerchantID, models.OwnerTypeOrgMerchant)
-		if err != nil {
-			return fmt.Errorf("获取商家账户失败: %w", err)
-		}
+	return s.refundCampaignSlots(tx, campaign, int(uncompletedTasks),
+		fmt.Sprintf("活动关闭退还积分：%s（未完成任务 %d/%d）", campaign.Title, uncompletedTasks, totalTasks))
+}
 
-		// 验证冻结余额足够
-		if merchantAccount.FrozenBalance < int(refundAmount) {
-			return errors.New("商家冻结积分不足")
-		}
+// RefundClosedCampaignSlot 已关闭活动中待审核的任务被拒绝时，退还该名额的积分
+func (s *SettlementService) RefundClosedCampaignSlot(tx *gorm.DB, campaign *models.Campaign, task *models.Task) error {
+	return s.refundCampaignSlots(tx, campaign, 1,
+		fmt.Sprintf("活动已关闭，任务审核未通过退还积分：%s（名额 #%d）", campaign.Title, task.TaskSlotNumber))
+}
 
-		// 解冻积分：从冻结余额转回可用余额
-		merchantAccount.FrozenBalance -= int(refundAmount)
-		merchantAccount.Balance += int(refundAmount)
-
-		// 记录解冻流水
-		refundTransaction := models.CreditTransaction{
-			AccountID:      merchantAccount.ID,
-			Type:           "CAMPAIGN_REFUND",
-			Amount:         int(refundAmount),
-			BalanceBefore:  merchantAccount.Balance - int(refundAmount),
-			BalanceAfter:   merchantAccount.Balance,
-			RelatedCampaignID: &campaign.ID,
-			Description:    fmt.Sprintf("活动关闭退还积分：%s（未完成任务 %d/%d）", campaign.Title, uncompletedTasks, totalTasks),
-		}
-		if err := tx.Create(&refundTransaction).Error; err != nil {
-			return fmt.Errorf("记录解冻流水失败: %w", err)
-		}
+// FundCampaignSlots 追加名额时从商家可用余额托管积分（旧活动转入商家冻结余额）
+func (s *SettlementService) FundCampaignSlots(tx *gorm.DB, campaign *models.Campaign, slots int, description string) error {
+	amount := slots * campaign.TaskAmount
 
-		// 保存积分账户更新
-		if err := tx.Save(&merchantAccount).Error; err != nil {
-			return fmt.Errorf("更新积分账户失败: %w", err)
-		}
+	merchantAccount, err := s.findOrCreateAccount(tx, campaign.MerchantID, models.OwnerTypeOrgMerchant)
+	if err != nil {
+		return fmt.Errorf("获取商家账户失败: %w", err)
+	}
 
-		return nil
-	})
+	target, err := s.fundingPosting(tx, campaign, merchantAccount.ID, amount)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.ledgerService.Post(tx, &LedgerTransfer{
+		Type:              models.TransactionCampaignFreeze,
+		Description:       description,
+		RelatedCampaignID: &campaign.ID,
+		Postings: []LedgerPosting{
+			CreditPosting(merchantAccount.ID, -amount),
+			target,
+		},
+	}); err != nil {
+		return fmt.Errorf("托管积分失败: %w", err)
+	}
+
+	return nil
+}
+
+// RefundCampaignSlots 减少名额时将对应积分退回商家可用余额
+func (s *SettlementService) RefundCampaignSlots(tx *gorm.DB, campaign *models.Campaign, slots int, description string) error {
+	return s.refundCampaignSlots(tx, campaign, slots, description)
+}
+
+// refundCampaignSlots 将若干名额的积分从活动托管分账（旧活动为商家冻结余额）退回商家可用余额
+func (s *SettlementService) refundCampaignSlots(tx *gorm.DB, campaign *models.Campaign, slots int, description string) error {
+	refundAmount := slots * campaign.TaskAmount
+
+	// 获取商家积分账户
+	merchantAccount, err := s.findOrCreateAccount(tx, campaign.MerchantID, models.OwnerTypeOrgMerchant)
+	if err != nil {
+		return fmt.Errorf("获取商家账户失败: %w", err)
+	}
+
+	source, err := s.fundingPosting(tx, campaign, merchantAccount.ID, -refundAmount)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.ledgerService.Post(tx, &LedgerTransfer{
+		Type:              models.TransactionCampaignRefund,
+		Description:       description,
+		RelatedCampaignID: &campaign.ID,
+		Postings: []LedgerPosting{
+			source,
+			CreditPosting(merchantAccount.ID, refundAmount),
+		},
+	}); err != nil {
+		return fmt.Errorf("记录退还分录失败: %w", err)
+	}
+
+	return nil
 }
@@ -0,0 +1,357 @@
+package services
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"pr-business/models"
+)
+
+// StatementSection 对账单分类：按交易类型归类，未列出的交易类型计入"其他"
+type StatementSection struct {
+	Key   string
+	Name  string
+	Types []string
+}
+
+// statementSectionOther 未归类交易
+var statementSectionOther = StatementSection{Key: "other", Name: "其他"}
+
+// statementSections 各类所有者的对账单分类
+var statementSections = map[models.OwnerType][]StatementSection{
+	models.OwnerTypeOrgMerchant: {
+		{Key: "recharge", Name: "充值", Types: []string{models.TransactionRecharge}},
+		{Key: "freeze", Name: "冻结", Types: []string{models.TransactionCampaignFreeze, models.TransactionWithdrawFreeze}},
+		{Key: "settlement", Name: "结算", Types: []string{models.TransactionEscrowRelease}},
+		{Key: "withdrawal", Name: "提现", Types: []string{models.TransactionWithdraw}},
+		{Key: "refund", Name: "退款", Types: []string{models.TransactionCampaignRefund, models.TransactionTaskRefund, models.TransactionRechargeRefund, models.TransactionWithdrawRefund}},
+	},
+	models.OwnerTypeOrgProvider: {
+		{Key: "commission", Name: "分成收入", Types: []string{models.TransactionProviderIncome}},
+		{Key: "referral", Name: "员工返佣", Types: []string{models.TransactionStaffReferral}},
+		{Key: "withdrawal", Name: "提现", Types: []string{models.TransactionWithdrawFreeze, models.TransactionWithdraw, models.TransactionWithdrawRefund}},
+	},
+	models.OwnerTypeUserPersonal: {
+		{Key: "task_income", Name: "任务收入", Types: []string{models.TransactionTaskIncome}},
+		{Key: "referral", Name: "员工返佣", Types: []string{models.TransactionStaffReferral}},
+		{Key: "withdrawal", Name: "提现", Types: []string{models.TransactionWithdrawFreeze, models.TransactionWithdraw, models.TransactionWithdrawRefund}},
+	},
+}
+
+// StatementPeriod 对账单期间 [Start, End)
+type StatementPeriod struct {
+	Start time.Time
+	End   time.Time
+}
+
+// ParseStatementMonth 解析对账月份（YYYY-MM），为空时取上个自然月
+func ParseStatementMonth(month string, now time.Time) (StatementPeriod, error) {
+	var start time.Time
+	if month == "" {
+		start = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).AddDate(0, -1, 0)
+	} else {
+		parsed, err := time.ParseInLocation("2006-01", month, now.Location())
+		if err != nil {
+			return StatementPeriod{}, fmt.Errorf("%w: 月份格式应为 YYYY-MM", ErrInvalidStatementQuery)
+		}
+		start = parsed
+	}
+	if start.After(now) {
+		return StatementPeriod{}, fmt.Errorf("%w: 月份不能晚于当前月份", ErrInvalidStatementQuery)
+	}
+	return StatementPeriod{Start: start, End: start.AddDate(0, 1, 0)}, nil
+}
+
+// Label 期间标识（YYYY-MM）
+func (p StatementPeriod) Label() string {
+	return p.Start.Format("2006-01")
+}
+
+// StatementSectionTotal 对账单分类合计（积分），可用余额和冻结余额的变动分开统计
+type StatementSectionTotal struct {
+	Key           string `json:"key"`
+	Name          string `json:"name"`
+	Inflow        int64  `json:"inflow"`        // 可用余额入账合计
+	Outflow       int64  `json:"outflow"`       // 可用余额出账合计（正数）
+	FrozenInflow  int64  `json:"frozenInflow"`  // 冻结余额入账合计
+	FrozenOutflow int64  `json:"frozenOutflow"` // 冻结余额出账合计（正数）
+	Count         int64  `json:"count"`
+}
+
+// AccountStatement 积分账户期间对账单
+// 期初/期末余额由积分流水累计得出（账本迁移时已写入期初余额流水），可用余额和冻结余额分别统计
+type AccountStatement struct {
+	AccountID      uuid.UUID                  `json:"accountId"`
+	OwnerID        uuid.UUID                  `json:"ownerId"`
+	OwnerType      models.OwnerType           `json:"ownerType"`
+	OwnerName      string                     `json:"ownerName"`
+	PeriodStart    time.Time                  `json:"periodStart"`
+	PeriodEnd      time.Time                  `json:"periodEnd"`
+	OpeningBalance int64                      `json:"openingBalance"`
+	ClosingBalance int64                      `json:"closingBalance"`
+	OpeningFrozen  int64                      `json:"openingFrozen"`
+	ClosingFrozen  int64                      `json:"closingFrozen"`
+	Sections       []StatementSectionTotal    `json:"sections"`
+	Entries        []models.CreditTransaction `json:"entries,omitempty"`
+}
+
+// StatementSummary 对账单汇总行（财务批量查看用，不含明细）
+type StatementSummary struct {
+	AccountID      uuid.UUID        `json:"accountId"`
+	OwnerID        uuid.UUID        `json:"ownerId"`
+	OwnerType      models.OwnerType `json:"ownerType"`
+	OwnerName      string           `json:"ownerName"`
+	OpeningBalance int64            `json:"openingBalance"`
+	Inflow         int64            `json:"inflow"`
+	Outflow        int64            `json:"outflow"`
+	ClosingBalance int64            `json:"closingBalance"`
+	OpeningFrozen  int64            `json:"openingFrozen"`
+	ClosingFrozen  int64            `json:"closingFrozen"`
+}
+
+// StatementService 月末对账单：按积分流水生成商家、服务商、达人的期间对账单，并导出 CSV / XLSX
+type StatementService struct {
+	db *gorm.DB
+}
+
+// NewStatementService 创建对账单服务
+func NewStatementService(db *gorm.DB) *StatementService {
+	return &StatementService{db: db}
+}
+
+// ownerNameSQL 账户所有者名称（商家/服务商名称，个人账户取用户昵称）
+const ownerNameSQL = `CASE ca.owner_type
+	WHEN 'ORG_MERCHANT' THEN (SELECT m.name FROM merchants m WHERE m.id = ca.owner_id)
+	WHEN 'ORG_PROVIDER' THEN (SELECT sp.name FROM service_providers sp WHERE sp.id = ca.owner_id)
+	ELSE (SELECT u.nickname FROM users u WHERE u.auth_center_user_id::text = ca.owner_id::text LIMIT 1)
+END`
+
+// GetAccountStatement 生成单个积分账户的期间对账单（含明细）
+func (s *StatementService) GetAccountStatement(accountID uuid.UUID, period StatementPeriod) (*AccountStatement, error) {
+	var account struct {
+		ID        uuid.UUID
+		OwnerID   uuid.UUID
+		OwnerType models.OwnerType
+		OwnerName string
+	}
+	err := s.db.Raw(`SELECT ca.id, ca.owner_id, ca.owner_type, COALESCE(`+ownerNameSQL+`, '') AS owner_name
+		FROM credit_accounts ca WHERE ca.id = ?`, accountID).Scan(&account).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询积分账户失败: %w", err)
+	}
+	if account.ID == uuid.Nil {
+		return nil, ErrCreditAccountNotFound
+	}
+
+	statement := &AccountStatement{
+		AccountID:   account.ID,
+		OwnerID:     account.OwnerID,
+		OwnerType:   account.OwnerType,
+		OwnerName:   account.OwnerName,
+		PeriodStart: period.Start,
+		PeriodEnd:   period.End,
+	}
+
+	// 期初余额：期间开始前的流水累计
+	var opening []struct {
+		BalanceType string
+		Amount      int64
+	}
+	if err := s.db.Model(&models.CreditTransaction{}).
+		Select("balance_type, COALESCE(SUM(amount), 0) AS amount").
+		Where("account_id = ? AND created_at < ?", accountID, period.Start).
+		Group("balance_type").
+		Scan(&opening).Error; err != nil {
+		return nil, fmt.Errorf("统计期初余额失败: %w", err)
+	}
+	for _, row := range opening {
+		if row.BalanceType == models.BalanceTypeFrozen {
+			statement.OpeningFrozen = row.Amount
+		} else {
+			statement.OpeningBalance = row.Amount
+		}
+	}
+
+	if err := s.db.Where("account_id = ? AND created_at >= ? AND created_at < ?", accountID, period.Start, period.End).
+		Order("created_at ASC, group_sequence ASC").
+		Find(&statement.Entries).Error; err != nil {
+		return nil, fmt.Errorf("查询积分流水失败: %w", err)
+	}
+
+	sections := statementSections[account.OwnerType]
+	totals := make([]StatementSectionTotal, len(sections)+1)
+	sectionIndex := make(map[string]int)
+	for i, section := range sections {
+		totals[i] = StatementSectionTotal{Key: section.Key, Name: section.Name}
+		for _, txType := range section.Types {
+			sectionIndex[txType] = i
+		}
+	}
+	otherIndex := len(sections)
+	totals[otherIndex] = StatementSectionTotal{Key: statementSectionOther.Key, Name: statementSectionOther.Name}
+
+	statement.ClosingBalance = statement.OpeningBalance
+	statement.ClosingFrozen = statement.OpeningFrozen
+	for _, entry := range statement.Entries {
+		i, ok := sectionIndex[entry.Type]
+		if !ok {
+			i = otherIndex
+		}
+		amount := int64(entry.Amount)
+		if entry.BalanceType == models.BalanceTypeFrozen {
+			statement.ClosingFrozen += amount
+			if amount >= 0 {
+				totals[i].FrozenInflow += amount
+			} else {
+				totals[i].FrozenOutflow -= amount
+			}
+		} else {
+			statement.ClosingBalance += amount
+			if amount >= 0 {
+				totals[i].Inflow += amount
+			} else {
+				totals[i].Outflow -= amount
+			}
+		}
+		totals[i].Count++
+	}
+	statement.Sections = totals
+
+	return statement, nil
+}
+
+// ListStatementSummaries 按所有者类型分页汇总期间对账单（不含明细）
+func (s *StatementService) ListStatementSummaries(ownerType models.OwnerType, period StatementPeriod, limit int, offset int) ([]StatementSummary, int64, error) {
+	if _, ok := statementSections[ownerType]; !ok {
+		return nil, 0, fmt.Errorf("%w: 无效的账户类型 %s", ErrInvalidStatementQuery, ownerType)
+	}
+
+	var total int64
+	if err := s.db.Model(&models.CreditAccount{}).Where("owner_type = ?", ownerType).Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计积分账户失败: %w", err)
+	}
+
+	var summaries []StatementSummary
+	err := s.db.Raw(`
+		SELECT ca.id AS account_id, ca.owner_id, ca.owner_type,
+		       COALESCE(`+ownerNameSQL+`, '') AS owner_name,
+		       COALESCE(SUM(ct.amount) FILTER (WHERE ct.balance_type <> ? AND ct.created_at < ?), 0) AS opening_balance,
+		       COALESCE(SUM(ct.amount) FILTER (WHERE ct.balance_type <> ? AND ct.created_at >= ? AND ct.amount > 0), 0) AS inflow,
+		       COALESCE(-SUM(ct.amount) FILTER (WHERE ct.balance_type <> ? AND ct.created_at >= ? AND ct.amount < 0), 0) AS outflow,
+		       COALESCE(SUM(ct.amount) FILTER (WHERE ct.balance_type <> ?), 0) AS closing_balance,
+		       COALESCE(SUM(ct.amount) FILTER (WHERE ct.balance_type = ? AND ct.created_at < ?), 0) AS opening_frozen,
+		       COALESCE(SUM(ct.amount) FILTER (WHERE ct.balance_type = ?), 0) AS closing_frozen
+		FROM credit_accounts ca
+		LEFT JOIN credit_transactions ct ON ct.account_id = ca.id AND ct.created_at < ?
+		WHERE ca.owner_type = ?
+		GROUP BY ca.id
+		ORDER BY ca.created_at ASC
+		LIMIT ? OFFSET ?`,
+		models.BalanceTypeFrozen, period.Start,
+		models.BalanceTypeFrozen, period.Start,
+		models.BalanceTypeFrozen, period.Start,
+		models.BalanceTypeFrozen,
+		models.BalanceTypeFrozen, period.Start,
+		models.BalanceTypeFrozen,
+		period.End,
+		ownerType,
+		limit, offset,
+	).Scan(&summaries).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("汇总对账单失败: %w", err)
+	}
+
+	return summaries, total, nil
+}
+
+// statementHeaderRows 对账单抬头和分类合计
+func statementHeaderRows(statement *AccountStatement) [][]interface{} {
+	rows := [][]interface{}{
+		{"账户ID", statement.AccountID.String()},
+		{"所有者类型", string(statement.OwnerType)},
+		{"所有者ID", statement.OwnerID.String()},
+		{"所有者名称", statement.OwnerName},
+		{"期间", statement.PeriodStart.Format("2006-01-02") + " 至 " + statement.PeriodEnd.AddDate(0, 0, -1).Format("2006-01-02")},
+		{"期初可用余额", statement.OpeningBalance},
+		{"期末可用余额", statement.ClosingBalance},
+		{"期初冻结余额", statement.OpeningFrozen},
+		{"期末冻结余额", statement.ClosingFrozen},
+		{},
+		{"分类", "可用入账", "可用出账", "冻结入账", "冻结出账", "笔数"},
+	}
+	for _, section := range statement.Sections {
+		rows = append(rows, []interface{}{section.Name, section.Inflow, section.Outflow, section.FrozenInflow, section.FrozenOutflow, section.Count})
+	}
+	return rows
+}
+
+// statementEntryRows 对账单明细
+func statementEntryRows(statement *AccountStatement) [][]interface{} {
+	rows := [][]interface{}{
+		{"时间", "交易类型", "余额类型", "金额", "变动前余额", "变动后余额", "交易组ID", "说明"},
+	}
+	for _, entry := range statement.Entries {
+		groupID := ""
+		if entry.TransactionGroupID != nil {
+			groupID = entry.TransactionGroupID.String()
+		}
+		rows = append(rows, []interface{}{
+			entry.CreatedAt.Format("2006-01-02 15:04:05"),
+			entry.Type,
+			entry.BalanceType,
+			entry.Amount,
+			entry.BalanceBefore,
+			entry.BalanceAfter,
+			groupID,
+			entry.Description,
+		})
+	}
+	return rows
+}
+
+// WriteCSV 将对账单写为 CSV（带 UTF-8 BOM，便于 Excel 直接打开）：抬头、分类合计，空行后为明细
+func (s *StatementService) WriteCSV(statement *AccountStatement, w io.Writer) error {
+	if _, err := w.Write([]byte("\xEF\xBB\xBF")); err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+	rows := statementHeaderRows(statement)
+	rows = append(rows, []interface{}{})
+	rows = append(rows, statementEntryRows(statement)...)
+	for _, row := range rows {
+		record := make([]string, len(row))
+		for i, value := range row {
+			switch v := value.(type) {
+			case string:
+				record[i] = v
+			case int:
+				record[i] = strconv.Itoa(v)
+			case int64:
+				record[i] = strconv.FormatInt(v, 10)
+			default:
+				record[i] = fmt.Sprint(v)
+			}
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteXLSX 将对账单写为 XLSX：汇总和明细分两个工作表
+func (s *StatementService) WriteXLSX(statement *AccountStatement, w io.Writer) error {
+	return WriteXLSX(w, []XLSXSheet{
+		{Name: "汇总", Rows: statementHeaderRows(statement)},
+		{Name: "明细", Rows: statementEntryRows(statement)},
+	})
+}
@@ -2,7 +2,7 @@ package services
 
 import (
 	"fmt"
-	"time"
+	"pr-business/constants"
 	"pr-business/models"
 
 	"github.com/google/uuid"
@@ -13,12 +13,14 @@ import (
 type SystemAccountService struct {
 	db               *gorm.DB
 	validatorService *ValidatorService
+	ledgerService    *LedgerService
 }
 
-func NewSystemAccountService(db *gorm.DB, validatorService *ValidatorService) *SystemAccountService {
+func NewSystemAccountService(db *gorm.DB, validatorService *ValidatorService, ledgerService *LedgerService) *SystemAccountService {
 	return &SystemAccountService{
 		db:               db,
 		validatorService: validatorService,
+		ledgerService:    ledgerService,
 	}
 }
 
@@ -39,24 +41,71 @@ func (s *SystemAccountService) GetEscrowBalance(accountType string) (int, error)
 	return totalBalance, nil
 }
 
-// UpdateSystemBalance 更新系统账户余额
+// CampaignEscrowTotals 活动托管分账汇总
+type CampaignEscrowTotals struct {
+	CampaignCount int   `json:"campaign_count"` // 仍有托管余额的活动数
+	Balance       int64 `json:"balance"`        // 分账余额合计
+	Funded        int64 `json:"funded"`         // 累计注资
+	Released      int64 `json:"released"`       // 累计支出（结算+退还）
+}
+
+// GetCampaignEscrowTotals 汇总活动托管分账
+func (s *SystemAccountService) GetCampaignEscrowTotals() (*CampaignEscrowTotals, error) {
+	var totals CampaignEscrowTotals
+	err := s.db.Model(&models.CampaignEscrow{}).
+		Select("COUNT(*) FILTER (WHERE balance > 0) AS campaign_count, " +
+			"COALESCE(SUM(balance), 0) AS balance, " +
+			"COALESCE(SUM(funded_amount), 0) AS funded, " +
+			"COALESCE(SUM(released_amount), 0) AS released").
+		Scan(&totals).Error
+	if err != nil {
+		return nil, fmt.Errorf("汇总活动托管分账失败: %w", err)
+	}
+	return &totals, nil
+}
+
+// ListCampaignEscrows 查询活动托管分账（onlyActive 为 true 时只返回仍有余额的活动）
+func (s *SystemAccountService) ListCampaignEscrows(onlyActive bool, limit int, offset int) ([]models.CampaignEscrow, int64, error) {
+	query := s.db.Model(&models.CampaignEscrow{})
+	if onlyActive {
+		query = query.Where("balance > 0")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计活动托管分账失败: %w", err)
+	}
+
+	var escrows []models.CampaignEscrow
+	if err := query.Preload("Campaign").Order("updated_at DESC").Limit(limit).Offset(offset).Find(&escrows).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询活动托管分账失败: %w", err)
+	}
+
+	return escrows, total, nil
+}
+
+// UpdateSystemBalance 更新系统账户余额（对手方为积分发行账户）
 func (s *SystemAccountService) UpdateSystemBalance(accountID string, amount int, description string, tx *gorm.DB) error {
 	id, err := uuid.Parse(accountID)
 	if err != nil {
 		return fmt.Errorf("无效的账户ID: %w", err)
 	}
 
-	account := models.SystemAccount{ID: id}
-	if err := tx.First(&account).Error; err != nil {
+	issuanceID, err := s.ledgerService.SystemAccountID(tx, constants.SystemAccountTypeCreditIssuance)
+	if err != nil {
 		return err
 	}
-
-	account.Balance += amount
-	account.UpdatedAt = time.Now()
-
-	if err := tx.Save(&account).Error; err != nil {
-		return err
+	if issuanceID == id {
+		return fmt.Errorf("不能直接调整积分发行账户")
 	}
 
-	return nil
+	_, err = s.ledgerService.Post(tx, &LedgerTransfer{
+		Type:        models.TransactionSystemAdjust,
+		Description: description,
+		Postings: []LedgerPosting{
+			SystemPosting(id, amount),
+			SystemPosting(issuanceID, -amount),
+		},
+	})
+	return err
 }
@@ -0,0 +1,301 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"pr-business/constants"
+	"pr-business/models"
+)
+
+// 审核超时后的处理方式
+const (
+	ReviewSLAActionEscalate = "ESCALATE" // 提高优先级并打上 ESCALATED 标签，等待人工处理
+	ReviewSLAActionApprove  = "APPROVE"  // 自动审核通过并进入结算队列
+)
+
+// taskDeadlineBatchSize 每轮最多处理的任务数，剩余的留到下一轮
+const taskDeadlineBatchSize = 200
+
+// TaskDeadlineService 任务截止时间相关的自动处理
+// 1. 提交截止时间已过仍未提交的 ASSIGNED 任务释放回 OPEN
+// 2. 超过审核时限的 SUBMITTED 任务升级或自动通过
+// 3. 关闭已过提交截止时间的活动
+type TaskDeadlineService struct {
+	db                   *gorm.DB
+	settlementJobService *SettlementJobService
+	lifecycleService     *CampaignLifecycleService
+	taskReviewService    *TaskReviewService
+	auditService         *AuditService
+	reviewSLA            time.Duration
+	reviewSLAAction      string
+}
+
+// NewTaskDeadlineService 创建任务截止时间处理服务；reviewSLA <= 0 表示不处理审核超时
+func NewTaskDeadlineService(
+	db *gorm.DB,
+	settlementJobService *SettlementJobService,
+	lifecycleService *CampaignLifecycleService,
+	taskReviewService *TaskReviewService,
+	auditService *AuditService,
+	reviewSLA time.Duration,
+	reviewSLAAction string,
+) *TaskDeadlineService {
+	if reviewSLAAction != ReviewSLAActionApprove {
+		reviewSLAAction = ReviewSLAActionEscalate
+	}
+
+	return &TaskDeadlineService{
+		db:                   db,
+		settlementJobService: settlementJobService,
+		lifecycleService:     lifecycleService,
+		taskReviewService:    taskReviewService,
+		auditService:         auditService,
+		reviewSLA:            reviewSLA,
+		reviewSLAAction:      reviewSLAAction,
+	}
+}
+
+// RegisterJobs 将截止时间相关的定时任务注册到调度器
+func (s *TaskDeadlineService) RegisterJobs(scheduler *SchedulerService, interval time.Duration) {
+	scheduler.Register(ScheduledJob{
+		Name:     "release-expired-assignments",
+		Interval: interval,
+		Run: func(ctx context.Context, now time.Time) error {
+			released, err := s.ReleaseExpiredAssignments(now)
+			if released > 0 {
+				log.Printf("已释放 %d 个超过提交截止时间的任务", released)
+			}
+			return err
+		},
+	})
+
+	if s.reviewSLA > 0 {
+		scheduler.Register(ScheduledJob{
+			Name:     "enforce-review-sla",
+			Interval: interval,
+			Run: func(ctx context.Context, now time.Time) error {
+				handled, err := s.EnforceReviewSLA(now)
+				if handled > 0 {
+					log.Printf("已处理 %d 个超过审核时限的任务（%s）", handled, s.reviewSLAAction)
+				}
+				return err
+			},
+		})
+	}
+
+	scheduler.Register(ScheduledJob{
+		Name:     "close-expired-campaigns",
+		Interval: interval,
+		Run: func(ctx context.Context, now time.Time) error {
+			closed, err := s.lifecycleService.CloseExpiredCampaigns(now)
+			if closed > 0 {
+				log.Printf("已自动关闭 %d 个过期活动", closed)
+			}
+			return err
+		},
+	})
+}
+
+// releasedTask 释放任务时返回的原接单信息（写入审计日志）
+type releasedTask struct {
+	ID         uuid.UUID
+	CampaignID uuid.UUID
+	CreatorID  *uuid.UUID
+	AssignedAt *time.Time
+}
+
+// ReleaseExpiredAssignments 将提交截止时间（或要求修改后的重新提交截止时间）已过、仍未提交的任务释放回 OPEN
+func (s *TaskDeadlineService) ReleaseExpiredAssignments(now time.Time) (int, error) {
+	var released []releasedTask
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// 先在子查询中加锁，再按读取时的版本更新，避免与达人提交并发
+		if err := tx.Raw(`
+			WITH expired AS (
+				SELECT t.id, t.version, t.creator_id, t.assigned_at
+				FROM tasks t
+				JOIN campaigns c ON c.id = t.campaign_id
+				WHERE t.status = ? AND COALESCE(t.revision_deadline, c.submission_deadline) < ?
+				ORDER BY c.submission_deadline
+				LIMIT ?
+				FOR UPDATE OF t SKIP LOCKED
+			)
+			UPDATE tasks t
+			SET status = ?, creator_id = NULL, assigned_at = NULL, platform = '',
+			    inviter_id = NULL, inviter_type = NULL, revision_deadline = NULL,
+			    version = t.version + 1, updated_at = ?
+			FROM expired e
+			WHERE t.id = e.id AND t.version = e.version
+			RETURNING t.id, t.campaign_id, e.creator_id, e.assigned_at`,
+			models.TaskStatusAssigned, now, taskDeadlineBatchSize,
+			models.TaskStatusOpen, now).Scan(&released).Error; err != nil {
+			return fmt.Errorf("释放超时任务失败: %w", err)
+		}
+
+		audit := s.auditService.WithTx(tx)
+		for _, task := range released {
+			if err := audit.LogFinancialOperation(
+				"system",
+				constants.AuditActionTaskRelease,
+				constants.AuditResourceTask,
+				task.ID.String(),
+				map[string]interface{}{
+					"campaign_id": task.CampaignID,
+					"creator_id":  task.CreatorID,
+					"assigned_at": task.AssignedAt,
+					"reason":      "超过提交截止时间未提交",
+				},
+				"",
+				"",
+			); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(released), nil
+}
+
+// EnforceReviewSLA 处理提交后超过审核时限仍未审核的任务
+func (s *TaskDeadlineService) EnforceReviewSLA(now time.Time) (int, error) {
+	if s.reviewSLA <= 0 {
+		return 0, nil
+	}
+	deadline := now.Add(-s.reviewSLA)
+
+	if s.reviewSLAAction == ReviewSLAActionApprove {
+		return s.autoApproveOverdue(deadline, now)
+	}
+	return s.escalateOverdue(deadline, now)
+}
+
+// overdueTask 超过审核时限的任务
+type overdueTask struct {
+	ID          uuid.UUID
+	CampaignID  uuid.UUID
+	SubmittedAt *time.Time
+}
+
+// escalateOverdue 提高超时任务的优先级并打上 ESCALATED 标签（每个任务只升级一次）
+// 升级不产生积分变动，不写账本交易，只记审计日志 TASK_ESCALATE
+func (s *TaskDeadlineService) escalateOverdue(deadline time.Time, now time.Time) (int, error) {
+	var escalated []overdueTask
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Raw(`
+			UPDATE tasks
+			SET priority = ?, tags = array_append(COALESCE(tags, '{}'), ?),
+			    version = version + 1, updated_at = ?
+			WHERE id IN (
+				SELECT id FROM tasks
+				WHERE status = ? AND submitted_at < ?
+				  AND NOT (? = ANY(COALESCE(tags, '{}')))
+				ORDER BY submitted_at
+				LIMIT ?
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING id, campaign_id, submitted_at`,
+			models.TaskPriorityHigh, models.TaskTagEscalated, now,
+			models.TaskStatusSubmitted, deadline,
+			models.TaskTagEscalated,
+			taskDeadlineBatchSize).Scan(&escalated).Error; err != nil {
+			return fmt.Errorf("升级超时任务失败: %w", err)
+		}
+
+		audit := s.auditService.WithTx(tx)
+		for _, task := range escalated {
+			if err := audit.LogFinancialOperation(
+				"system",
+				constants.AuditActionTaskEscalate,
+				constants.AuditResourceTask,
+				task.ID.String(),
+				map[string]interface{}{
+					"campaign_id":  task.CampaignID,
+					"submitted_at": task.SubmittedAt,
+					"review_sla":   s.reviewSLA.String(),
+				},
+				"",
+				"",
+			); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(escalated), nil
+}
+
+// autoApproveOverdue 自动通过超时任务，并在同一事务内写入结算任务
+func (s *TaskDeadlineService) autoApproveOverdue(deadline time.Time, now time.Time) (int, error) {
+	var approved []overdueTask
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Raw(`
+			UPDATE tasks
+			SET status = ?, audited_by = NULL, audited_at = ?, audit_note = ?,
+			    version = version + 1, updated_at = ?
+			WHERE id IN (
+				SELECT id FROM tasks
+				WHERE status = ? AND submitted_at < ?
+				ORDER BY submitted_at
+				LIMIT ?
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING id, campaign_id, submitted_at`,
+			models.TaskStatusApproved, now, "超过审核时限自动通过", now,
+			models.TaskStatusSubmitted, deadline,
+			taskDeadlineBatchSize).Scan(&approved).Error; err != nil {
+			return fmt.Errorf("自动通过超时任务失败: %w", err)
+		}
+
+		audit := s.auditService.WithTx(tx)
+		for _, task := range approved {
+			if _, err := s.settlementJobService.Enqueue(tx, &models.Task{ID: task.ID, CampaignID: task.CampaignID}, "system"); err != nil {
+				return err
+			}
+
+			if err := s.taskReviewService.RecordReview(tx, task.ID, models.TaskReviewActionApprove, "system", "超过审核时限自动通过", nil); err != nil {
+				return err
+			}
+
+			if err := audit.LogFinancialOperation(
+				"system",
+				constants.AuditActionTaskAutoApprove,
+				constants.AuditResourceTask,
+				task.ID.String(),
+				map[string]interface{}{
+					"campaign_id":  task.CampaignID,
+					"submitted_at": task.SubmittedAt,
+					"review_sla":   s.reviewSLA.String(),
+				},
+				"",
+				"",
+			); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(approved), nil
+}
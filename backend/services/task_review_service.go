@@ -0,0 +1,178 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"pr-business/constants"
+	"pr-business/models"
+)
+
+// TaskReviewService 任务提交/审核历史
+// 每次提交写入 task_submissions，审核结果写回该条记录；拒绝释放名额时历史仍然保留
+type TaskReviewService struct {
+	db *gorm.DB
+}
+
+// NewTaskReviewService 创建任务审核历史服务
+func NewTaskReviewService(db *gorm.DB) *TaskReviewService {
+	return &TaskReviewService{db: db}
+}
+
+// RecordSubmission 记录一次提交，必须与任务状态更新在同一事务内
+func (s *TaskReviewService) RecordSubmission(tx *gorm.DB, task *models.Task) (*models.TaskSubmission, error) {
+	if task.CreatorID == nil || task.SubmittedAt == nil {
+		return nil, errors.New("任务未分配达人或未提交")
+	}
+
+	var rounds int64
+	if err := tx.Model(&models.TaskSubmission{}).
+		Where("task_id = ? AND creator_id = ?", task.ID, *task.CreatorID).
+		Count(&rounds).Error; err != nil {
+		return nil, fmt.Errorf("统计提交次数失败: %w", err)
+	}
+
+	submission := models.TaskSubmission{
+		TaskID:      task.ID,
+		CampaignID:  task.CampaignID,
+		CreatorID:   *task.CreatorID,
+		Round:       int(rounds) + 1,
+		Platform:    task.Platform,
+		PlatformURL: task.PlatformURL,
+		Screenshots: task.Screenshots,
+		Notes:       task.Notes,
+		SubmittedAt: *task.SubmittedAt,
+	}
+	if submission.Screenshots == "" {
+		submission.Screenshots = "[]"
+	}
+	if err := tx.Create(&submission).Error; err != nil {
+		return nil, fmt.Errorf("记录提交历史失败: %w", err)
+	}
+
+	return &submission, nil
+}
+
+// RecordReview 将审核结果写回该任务最近一条待审核的提交记录
+// 迁移前提交、没有历史记录的任务直接跳过
+func (s *TaskReviewService) RecordReview(
+	tx *gorm.DB,
+	taskID uuid.UUID,
+	action models.TaskReviewAction,
+	reviewerID string,
+	note string,
+	revisionDeadline *time.Time,
+) error {
+	var submission models.TaskSubmission
+	err := tx.Where("task_id = ? AND review_action IS NULL", taskID).
+		Order("submitted_at DESC").
+		First(&submission).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("查询提交记录失败: %w", err)
+	}
+
+	now := time.Now()
+	if err := tx.Model(&submission).Updates(map[string]interface{}{
+		"review_action":     action,
+		"reviewed_by":       reviewerID,
+		"reviewed_at":       now,
+		"review_note":       note,
+		"revision_deadline": revisionDeadline,
+		"updated_at":        now,
+	}).Error; err != nil {
+		return fmt.Errorf("记录审核历史失败: %w", err)
+	}
+
+	return nil
+}
+
+// TaskTimelineEvent 任务时间线事件
+type TaskTimelineEvent struct {
+	At         time.Time              `json:"at"`
+	Event      string                 `json:"event"` // ASSIGNED, SUBMITTED, APPROVE, REJECT, REQUEST_REVISION, 以及系统动作（TASK_RELEASE 等）
+	ActorID    string                 `json:"actorId,omitempty"`
+	CreatorID  *uuid.UUID             `json:"creatorId,omitempty"`
+	Round      int                    `json:"round,omitempty"`
+	Submission *models.TaskSubmission `json:"submission,omitempty"`
+	Note       string                 `json:"note,omitempty"`
+	Detail     map[string]interface{} `json:"detail,omitempty"`
+}
+
+// Timeline 返回任务的完整时间线：各轮提交与审核、系统自动处理，以及当前的接单信息
+func (s *TaskReviewService) Timeline(task *models.Task) ([]TaskTimelineEvent, error) {
+	var submissions []models.TaskSubmission
+	if err := s.db.Where("task_id = ?", task.ID).
+		Order("submitted_at ASC").
+		Find(&submissions).Error; err != nil {
+		return nil, fmt.Errorf("查询提交历史失败: %w", err)
+	}
+
+	var logs []models.FinancialAuditLog
+	if err := s.db.Where("resource_type = ? AND resource_id = ?", constants.AuditResourceTask, task.ID.String()).
+		Order("created_at ASC").
+		Find(&logs).Error; err != nil {
+		return nil, fmt.Errorf("查询任务日志失败: %w", err)
+	}
+
+	events := make([]TaskTimelineEvent, 0, len(submissions)*2+len(logs)+1)
+
+	if task.AssignedAt != nil && task.CreatorID != nil {
+		events = append(events, TaskTimelineEvent{
+			At:        *task.AssignedAt,
+			Event:     string(models.TaskStatusAssigned),
+			CreatorID: task.CreatorID,
+		})
+	}
+
+	for i := range submissions {
+		submission := submissions[i]
+		creatorID := submission.CreatorID
+		events = append(events, TaskTimelineEvent{
+			At:         submission.SubmittedAt,
+			Event:      string(models.TaskStatusSubmitted),
+			CreatorID:  &creatorID,
+			Round:      submission.Round,
+			Submission: &submissions[i],
+		})
+
+		if submission.ReviewAction != nil && submission.ReviewedAt != nil {
+			event := TaskTimelineEvent{
+				At:        *submission.ReviewedAt,
+				Event:     string(*submission.ReviewAction),
+				CreatorID: &creatorID,
+				Round:     submission.Round,
+				Note:      submission.ReviewNote,
+			}
+			if submission.ReviewedBy != nil {
+				event.ActorID = *submission.ReviewedBy
+			}
+			if submission.RevisionDeadline != nil {
+				event.Detail = map[string]interface{}{"revision_deadline": submission.RevisionDeadline}
+			}
+			events = append(events, event)
+		}
+	}
+
+	for _, entry := range logs {
+		events = append(events, TaskTimelineEvent{
+			At:      entry.CreatedAt,
+			Event:   entry.Action,
+			ActorID: entry.UserID,
+			Detail:  entry.Changes,
+		})
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].At.Before(events[j].At)
+	})
+
+	return events, nil
+}
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"pr-business/models"
+)
+
+// UpdateTaskIfVersion 以 version 做比较交换（CAS）保存任务
+// 仅当数据库中的版本仍为 expectedVersion 时写入，并将版本加一；否则返回 ErrTaskVersionConflict
+// 所有修改任务状态的操作（接单、提交、审核、释放）都必须通过版本校验，避免并发审核重复结算
+func UpdateTaskIfVersion(tx *gorm.DB, task *models.Task, expectedVersion int) error {
+	task.Version = expectedVersion + 1
+	task.UpdatedAt = time.Now()
+
+	result := tx.Model(&models.Task{}).
+		Where("id = ? AND version = ?", task.ID, expectedVersion).
+		Select("*").
+		Omit("id", "created_at", clause.Associations).
+		Updates(task)
+	if result.Error != nil {
+		task.Version = expectedVersion
+		return fmt.Errorf("更新任务失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		task.Version = expectedVersion
+		return ErrTaskVersionConflict
+	}
+
+	return nil
+}
@@ -0,0 +1,346 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"pr-business/models"
+)
+
+// TaxWithholdingService 达人（个人账户）劳务报酬个税预扣
+// 提现时按当月累计计税收入计算应预扣税额，扣减当月已预扣部分后从到账积分中扣除；
+// 打款成功时预扣积分以 TAX_WITHHOLDING 计入代扣个税系统账户。被拒绝或打款失败的提现不计入累计
+type TaxWithholdingService struct {
+	db *gorm.DB
+}
+
+// NewTaxWithholdingService 创建个税预扣服务
+func NewTaxWithholdingService(db *gorm.DB) *TaxWithholdingService {
+	return &TaxWithholdingService{db: db}
+}
+
+// CreateTaxRuleRequest 创建预扣规则的输入参数（金额单位：分）
+type CreateTaxRuleRequest struct {
+	Name               string
+	ThresholdAmount    int
+	FixedDeduction     int
+	DeductionRateBasis int
+	Brackets           models.TaxBrackets
+	Description        string
+}
+
+// TaxAssessment 单笔提现的预扣结果（金额单位：分）
+type TaxAssessment struct {
+	Rule   *models.TaxWithholdingRule // 为空表示不预扣
+	Income int                        // 本次计税收入
+	Tax    int                        // 本次预扣税额
+}
+
+// RuleID 生效规则ID，不预扣时为空
+func (a *TaxAssessment) RuleID() *uuid.UUID {
+	if a.Rule == nil {
+		return nil
+	}
+	return &a.Rule.ID
+}
+
+// Resolve 取当前生效的预扣规则，没有时返回 nil
+func (s *TaxWithholdingService) Resolve(tx *gorm.DB) (*models.TaxWithholdingRule, error) {
+	var rule models.TaxWithholdingRule
+	err := tx.Where("is_active = ?", true).Order("created_at DESC").First(&rule).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("查询个税预扣规则失败: %w", err)
+	}
+	return &rule, nil
+}
+
+// Assess 计算提现应预扣的个税；只对个人账户预扣
+// 调用方应先锁定积分账户，保证同一账户并发申请时当月累计有效
+func (s *TaxWithholdingService) Assess(tx *gorm.DB, account *models.CreditAccount, income int, now time.Time) (*TaxAssessment, error) {
+	assessment := &TaxAssessment{Income: income}
+	if account.OwnerType != models.OwnerTypeUserPersonal || income <= 0 {
+		return assessment, nil
+	}
+
+	rule, err := s.Resolve(tx)
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil {
+		return assessment, nil
+	}
+	assessment.Rule = rule
+
+	// 同一来源的连续性收入以一个月为一次：按当月累计收入计算应预扣总额，减去当月已预扣
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	var month struct {
+		Income   int64
+		Withheld int64
+	}
+	if err := tx.Model(&models.Withdrawal{}).
+		Select("COALESCE(SUM(tax_income), 0) AS income, COALESCE(SUM(tax_withheld), 0) AS withheld").
+		Where("account_id = ? AND created_at >= ? AND status NOT IN ?", account.ID, monthStart,
+			[]models.WithdrawalStatus{models.WithdrawalStatusRejected, models.WithdrawalStatusFailed}).
+		Scan(&month).Error; err != nil {
+		return nil, fmt.Errorf("统计当月计税收入失败: %w", err)
+	}
+
+	_, due := rule.Calculate(int(month.Income) + income)
+	if tax := due - int(month.Withheld); tax > 0 {
+		assessment.Tax = tax
+	}
+	return assessment, nil
+}
+
+// CreateRule 创建预扣规则并停用旧规则（旧规则保留以便追溯历史提现）
+func (s *TaxWithholdingService) CreateRule(req *CreateTaxRuleRequest, operatorID string) (*models.TaxWithholdingRule, error) {
+	if req.ThresholdAmount < 0 || req.FixedDeduction < 0 || req.DeductionRateBasis < 0 || req.DeductionRateBasis > 10000 {
+		return nil, fmt.Errorf("%w: 减除费用参数无效", ErrInvalidTaxRule)
+	}
+	if len(req.Brackets) == 0 {
+		return nil, fmt.Errorf("%w: 预扣率表不能为空", ErrInvalidTaxRule)
+	}
+	for i, bracket := range req.Brackets {
+		if bracket.RateBasis < 0 || bracket.RateBasis > 10000 || bracket.QuickDeduction < 0 || bracket.UpTo < 0 {
+			return nil, fmt.Errorf("%w: 第 %d 档参数无效", ErrInvalidTaxRule, i+1)
+		}
+		last := i == len(req.Brackets)-1
+		if last != (bracket.UpTo == 0) {
+			return nil, fmt.Errorf("%w: 只有最后一档不设上限", ErrInvalidTaxRule)
+		}
+		if i > 0 && !last && bracket.UpTo <= req.Brackets[i-1].UpTo {
+			return nil, fmt.Errorf("%w: 各档上限必须递增", ErrInvalidTaxRule)
+		}
+	}
+
+	rule := models.TaxWithholdingRule{
+		Name:               req.Name,
+		ThresholdAmount:    req.ThresholdAmount,
+		FixedDeduction:     req.FixedDeduction,
+		DeductionRateBasis: req.DeductionRateBasis,
+		Brackets:           req.Brackets,
+		IsActive:           true,
+		Description:        req.Description,
+		CreatedBy:          operatorID,
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.TaxWithholdingRule{}).
+			Where("is_active = ?", true).
+			Updates(map[string]interface{}{"is_active": false, "updated_at": time.Now()}).Error; err != nil {
+			return fmt.Errorf("停用旧预扣规则失败: %w", err)
+		}
+		if err := tx.Create(&rule).Error; err != nil {
+			return fmt.Errorf("创建预扣规则失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &rule, nil
+}
+
+// ListRules 查询预扣规则
+func (s *TaxWithholdingService) ListRules(includeInactive bool, limit int, offset int) ([]models.TaxWithholdingRule, int64, error) {
+	query := s.db.Model(&models.TaxWithholdingRule{})
+	if !includeInactive {
+		query = query.Where("is_active = ?", true)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计预扣规则失败: %w", err)
+	}
+
+	var rules []models.TaxWithholdingRule
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&rules).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询预扣规则失败: %w", err)
+	}
+
+	return rules, total, nil
+}
+
+// TaxCertificateMonth 收入及预扣凭证的月度明细
+type TaxCertificateMonth struct {
+	Month       string `json:"month"`       // YYYY-MM
+	Earnings    int64  `json:"earnings"`    // 入账收入（积分：任务收入、员工返佣）
+	Withdrawals int64  `json:"withdrawals"` // 已完成提现笔数
+	Income      int64  `json:"income"`      // 计税收入（分）
+	TaxWithheld int64  `json:"taxWithheld"` // 预扣税额（分）
+}
+
+// TaxCertificate 达人年度收入及个税预扣凭证
+type TaxCertificate struct {
+	AccountID        uuid.UUID             `json:"accountId"`
+	OwnerID          uuid.UUID             `json:"ownerId"`
+	OwnerName        string                `json:"ownerName"`
+	Year             int                   `json:"year"`
+	Months           []TaxCertificateMonth `json:"months"`
+	TotalEarnings    int64                 `json:"totalEarnings"`
+	TotalIncome      int64                 `json:"totalIncome"`
+	TotalTaxWithheld int64                 `json:"totalTaxWithheld"`
+	GeneratedAt      time.Time             `json:"generatedAt"`
+}
+
+// GetCertificate 生成个人账户指定纳税年度的收入及预扣凭证
+// 收入取积分流水中的任务收入和员工返佣；计税收入和预扣税额取已完成的提现（按申请月份归集，与预扣计算一致）
+func (s *TaxWithholdingService) GetCertificate(accountID uuid.UUID, year int) (*TaxCertificate, error) {
+	var account models.CreditAccount
+	if err := s.db.Where("id = ?", accountID).First(&account).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCreditAccountNotFound
+		}
+		return nil, err
+	}
+	if account.OwnerType != models.OwnerTypeUserPersonal {
+		return nil, ErrTaxCertificateUnavailable
+	}
+
+	certificate := &TaxCertificate{
+		AccountID:   account.ID,
+		OwnerID:     account.OwnerID,
+		Year:        year,
+		Months:      make([]TaxCertificateMonth, 12),
+		GeneratedAt: time.Now(),
+	}
+	s.db.Model(&models.User{}).Select("nickname").
+		Where("auth_center_user_id::text = ?", account.OwnerID.String()).
+		Limit(1).Scan(&certificate.OwnerName)
+
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.Local)
+	end := start.AddDate(1, 0, 0)
+	for i := range certificate.Months {
+		certificate.Months[i].Month = start.AddDate(0, i, 0).Format("2006-01")
+	}
+
+	var earnings []struct {
+		Month  int
+		Amount int64
+	}
+	if err := s.db.Model(&models.CreditTransaction{}).
+		Select("EXTRACT(MONTH FROM created_at)::int AS month, COALESCE(SUM(amount), 0) AS amount").
+		Where("account_id = ? AND type IN ? AND amount > 0 AND created_at >= ? AND created_at < ?",
+			account.ID, []string{models.TransactionTaskIncome, models.TransactionStaffReferral}, start, end).
+		Group("month").
+		Scan(&earnings).Error; err != nil {
+		return nil, fmt.Errorf("统计收入失败: %w", err)
+	}
+	for _, row := range earnings {
+		certificate.Months[row.Month-1].Earnings = row.Amount
+		certificate.TotalEarnings += row.Amount
+	}
+
+	var withheld []struct {
+		Month    int
+		Count    int64
+		Income   int64
+		Withheld int64
+	}
+	if err := s.db.Model(&models.Withdrawal{}).
+		Select("EXTRACT(MONTH FROM created_at)::int AS month, COUNT(*) AS count, "+
+			"COALESCE(SUM(tax_income), 0) AS income, COALESCE(SUM(tax_withheld), 0) AS withheld").
+		Where("account_id = ? AND status = ? AND created_at >= ? AND created_at < ?",
+			account.ID, models.WithdrawalStatusCompleted, start, end).
+		Group("month").
+		Scan(&withheld).Error; err != nil {
+		return nil, fmt.Errorf("统计预扣税额失败: %w", err)
+	}
+	for _, row := range withheld {
+		month := &certificate.Months[row.Month-1]
+		month.Withdrawals = row.Count
+		month.Income = row.Income
+		month.TaxWithheld = row.Withheld
+		certificate.TotalIncome += row.Income
+		certificate.TotalTaxWithheld += row.Withheld
+	}
+
+	return certificate, nil
+}
+
+// certificateTitle 凭证标题
+func certificateTitle(certificate *TaxCertificate) string {
+	return fmt.Sprintf("个人劳务报酬收入及个税预扣凭证（%d 年度）", certificate.Year)
+}
+
+// certificateNote 凭证说明
+const certificateNote = "本凭证由平台根据提现记录生成，计税收入和预扣税额按提现申请月份归集，实际税额以税务机关申报记录为准。"
+
+// WriteCertificateCSV 将凭证写为 CSV（带 UTF-8 BOM，金额单位为元）
+func (s *TaxWithholdingService) WriteCertificateCSV(certificate *TaxCertificate, w io.Writer) error {
+	if _, err := w.Write([]byte("\xEF\xBB\xBF")); err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+	records := [][]string{
+		{certificateTitle(certificate)},
+		{"账户ID", certificate.AccountID.String()},
+		{"用户ID", certificate.OwnerID.String()},
+		{"昵称", certificate.OwnerName},
+		{"生成时间", certificate.GeneratedAt.Format("2006-01-02 15:04:05")},
+		{},
+		{"月份", "入账收入（积分）", "提现笔数", "计税收入（元）", "预扣税额（元）"},
+	}
+	for _, month := range certificate.Months {
+		records = append(records, []string{
+			month.Month,
+			strconv.FormatInt(month.Earnings, 10),
+			strconv.FormatInt(month.Withdrawals, 10),
+			formatYuan(int(month.Income)),
+			formatYuan(int(month.TaxWithheld)),
+		})
+	}
+	records = append(records,
+		[]string{"合计", strconv.FormatInt(certificate.TotalEarnings, 10), "", formatYuan(int(certificate.TotalIncome)), formatYuan(int(certificate.TotalTaxWithheld))},
+		[]string{},
+		[]string{certificateNote},
+	)
+
+	if err := writer.WriteAll(records); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+// WriteCertificatePDF 将凭证写为 PDF（金额单位为元）
+func (s *TaxWithholdingService) WriteCertificatePDF(certificate *TaxCertificate, w io.Writer) error {
+	columns := []float64{50, 130, 250, 330, 440}
+	lines := [][]string{
+		{certificateTitle(certificate)},
+		{},
+		{"账户ID：" + certificate.AccountID.String()},
+		{"用户ID：" + certificate.OwnerID.String()},
+		{"昵称：" + certificate.OwnerName},
+		{"生成时间：" + certificate.GeneratedAt.Format("2006-01-02 15:04:05")},
+		{},
+		{"月份", "入账收入（积分）", "提现笔数", "计税收入（元）", "预扣税额（元）"},
+	}
+	for _, month := range certificate.Months {
+		lines = append(lines, []string{
+			month.Month,
+			strconv.FormatInt(month.Earnings, 10),
+			strconv.FormatInt(month.Withdrawals, 10),
+			formatYuan(int(month.Income)),
+			formatYuan(int(month.TaxWithheld)),
+		})
+	}
+	lines = append(lines,
+		[]string{"合计", strconv.FormatInt(certificate.TotalEarnings, 10), "", formatYuan(int(certificate.TotalIncome)), formatYuan(int(certificate.TotalTaxWithheld))},
+		[]string{},
+		[]string{certificateNote},
+	)
+
+	return WritePDF(w, columns, lines)
+}
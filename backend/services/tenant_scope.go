@@ -0,0 +1,157 @@
+package services
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"pr-business/constants"
+	"pr-business/models"
+)
+
+// TenantScope 按当前身份（激活角色和组织）限定列表查询可见的数据
+// 各方法是 GORM scope，用法：db.Scopes(scope.Campaigns).Find(&campaigns)
+// 超级管理员不限；组织身份只能看到本商家或本服务商的数据；达人只能看到自己的数据；其他身份看不到任何数据
+type TenantScope struct {
+	rc      *models.RoleContext
+	userIDs []string   // 本地用户ID和账号中心用户ID（历史数据两种都有）
+	ownerID *uuid.UUID // 个人积分账户的 owner_id（账号中心用户ID）
+}
+
+// NewTenantScope 创建数据范围；user 或 rc 为空时不可见任何数据
+func NewTenantScope(user *models.User, rc *models.RoleContext) *TenantScope {
+	scope := &TenantScope{rc: rc}
+	if user == nil {
+		scope.rc = nil
+		return scope
+	}
+	scope.userIDs = []string{user.ID, user.AuthCenterUserID}
+	if ownerID, err := uuid.Parse(user.AuthCenterUserID); err == nil {
+		scope.ownerID = &ownerID
+	}
+	return scope
+}
+
+// unrestricted 是否不限数据范围（超级管理员）
+func (s *TenantScope) unrestricted() bool {
+	return s.rc != nil && s.rc.Is(constants.RoleSuperAdmin)
+}
+
+// org 当前身份代表的组织类型和ID，非组织身份或未加入组织时返回 false
+func (s *TenantScope) org() (string, uuid.UUID, bool) {
+	if s.rc == nil || !s.rc.HasOrg() {
+		return "", uuid.Nil, false
+	}
+	return s.rc.OrgType, *s.rc.OrgID, true
+}
+
+// isCreator 当前身份是否为达人
+func (s *TenantScope) isCreator() bool {
+	return s.rc != nil && s.rc.Is(constants.RoleCreator)
+}
+
+// nothing 不返回任何数据
+func nothing(db *gorm.DB) *gorm.DB {
+	return db.Where("1 = 0")
+}
+
+// Campaigns 营销活动：组织身份看本组织的活动，达人只看自己接过任务的活动
+// 活动记录含预算、佣金拆分和商家信息，达人浏览开放中的任务走任务大厅，不通过活动接口
+func (s *TenantScope) Campaigns(db *gorm.DB) *gorm.DB {
+	if s.unrestricted() {
+		return db
+	}
+	if orgType, orgID, ok := s.org(); ok {
+		switch orgType {
+		case models.OrgTypeMerchant:
+			return db.Where("campaigns.merchant_id = ?", orgID)
+		case models.OrgTypeServiceProvider:
+			return db.Where("campaigns.provider_id = ?", orgID)
+		}
+	}
+	if s.isCreator() {
+		return db.Where("campaigns.id IN (SELECT campaign_id FROM tasks WHERE creator_id IN (SELECT id FROM creators WHERE user_id IN ?))", s.userIDs)
+	}
+	return nothing(db)
+}
+
+// Tasks 任务：组织身份看本组织活动的任务，达人看自己接的任务
+func (s *TenantScope) Tasks(db *gorm.DB) *gorm.DB {
+	if s.unrestricted() {
+		return db
+	}
+	if orgType, orgID, ok := s.org(); ok {
+		switch orgType {
+		case models.OrgTypeMerchant:
+			return db.Where("tasks.campaign_id IN (SELECT id FROM campaigns WHERE merchant_id = ?)", orgID)
+		case models.OrgTypeServiceProvider:
+			return db.Where("tasks.campaign_id IN (SELECT id FROM campaigns WHERE provider_id = ?)", orgID)
+		}
+	}
+	if s.isCreator() {
+		return db.Where("tasks.creator_id IN (SELECT id FROM creators WHERE user_id IN ?)", s.userIDs)
+	}
+	return nothing(db)
+}
+
+// Creators 达人：服务商管理员看本组织员工邀请的达人；服务商员工有达人管理权限时同管理员，否则只看自己邀请的达人；达人看自己
+func (s *TenantScope) Creators(db *gorm.DB) *gorm.DB {
+	if s.unrestricted() {
+		return db
+	}
+	if orgType, orgID, ok := s.org(); ok && orgType == models.OrgTypeServiceProvider {
+		orgInvited := "creators.inviter_id IN (SELECT user_id::text FROM service_provider_staff WHERE provider_id = ?)"
+		switch {
+		case s.rc.Is(constants.RoleServiceProviderAdmin):
+			return db.Where(orgInvited, orgID)
+		case s.rc.Is(constants.RoleServiceProviderStaff) && s.rc.StaffID != nil:
+			return db.Where(
+				"creators.inviter_id IN ? OR (EXISTS (SELECT 1 FROM provider_staff_permissions WHERE staff_id = ? AND permission_code = ?) AND "+orgInvited+")",
+				s.userIDs, *s.rc.StaffID, constants.PermissionManageCreators, orgID,
+			)
+		}
+	}
+	if s.isCreator() {
+		return db.Where("creators.user_id IN ?", s.userIDs)
+	}
+	return nothing(db)
+}
+
+// CreditTransactions 积分流水：只看可见积分账户的流水
+func (s *TenantScope) CreditTransactions(db *gorm.DB) *gorm.DB {
+	return s.byAccount(db, "credit_transactions.account_id")
+}
+
+// Withdrawals 提现记录：只看可见积分账户的提现
+func (s *TenantScope) Withdrawals(db *gorm.DB) *gorm.DB {
+	return s.byAccount(db, "withdrawals.account_id")
+}
+
+// byAccount 按积分账户限定：本人的个人账户，以及当前身份所代表组织的账户
+// owner_id 必须和 owner_type 一起匹配，组织账户的 owner_id 是组织ID，不能只按 owner_id 判断
+func (s *TenantScope) byAccount(db *gorm.DB, column string) *gorm.DB {
+	if s.unrestricted() {
+		return db
+	}
+	if s.rc == nil {
+		return nothing(db)
+	}
+
+	accounts := db.Session(&gorm.Session{NewDB: true}).Model(&models.CreditAccount{}).Select("id")
+	matched := false
+	if s.ownerID != nil {
+		accounts = accounts.Where("owner_type = ? AND owner_id = ?", models.OwnerTypeUserPersonal, *s.ownerID)
+		matched = true
+	}
+	if orgType, orgID, ok := s.org(); ok {
+		ownerType := models.OwnerTypeOrgProvider
+		if orgType == models.OrgTypeMerchant {
+			ownerType = models.OwnerTypeOrgMerchant
+		}
+		accounts = accounts.Or("owner_type = ? AND owner_id = ?", ownerType, orgID)
+		matched = true
+	}
+	if !matched {
+		return nothing(db)
+	}
+	return db.Where(column+" IN (?)", accounts)
+}
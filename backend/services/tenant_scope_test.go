@@ -0,0 +1,61 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"pr-business/constants"
+	"pr-business/models"
+)
+
+// dryRunDB 只生成 SQL 不连接数据库
+func dryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestTenantScopeSQL(t *testing.T) {
+	db := dryRunDB(t)
+	merchantID := uuid.New()
+	user := &models.User{ID: "user-1", AuthCenterUserID: uuid.NewString()}
+	creator := NewTenantScope(user, &models.RoleContext{Role: constants.RoleCreator})
+	merchantAdmin := NewTenantScope(user, &models.RoleContext{Role: constants.RoleMerchantAdmin, OrgType: models.OrgTypeMerchant, OrgID: &merchantID})
+
+	campaignSQL := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.Scopes(creator.Campaigns).Find(&[]models.Campaign{})
+	})
+	// 达人不能通过活动接口看到未参与的开放中活动（含预算和商家信息）
+	if strings.Contains(campaignSQL, "status") || !strings.Contains(campaignSQL, "FROM tasks") {
+		t.Fatalf("creator campaign scope = %s", campaignSQL)
+	}
+
+	for name, scope := range map[string]*TenantScope{"creator": creator, "merchant admin": merchantAdmin} {
+		sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+			return tx.Scopes(scope.CreditTransactions).Find(&[]models.CreditTransaction{})
+		})
+		// 本人的账户只匹配个人账户类型，组织账户的 owner_id 不会与用户ID混淆
+		if !strings.Contains(sql, "owner_type = '"+string(models.OwnerTypeUserPersonal)+"' AND owner_id") {
+			t.Fatalf("%s account scope = %s", name, sql)
+		}
+	}
+	orgSQL := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.Scopes(merchantAdmin.Withdrawals).Find(&[]models.Withdrawal{})
+	})
+	if !strings.Contains(orgSQL, "owner_type = '"+string(models.OwnerTypeOrgMerchant)+"' AND owner_id = '"+merchantID.String()+"'") {
+		t.Fatalf("merchant withdrawal scope = %s", orgSQL)
+	}
+
+	if sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.Scopes(NewTenantScope(nil, nil).Tasks).Find(&[]models.Task{})
+	}); !strings.Contains(sql, "1 = 0") {
+		t.Fatalf("anonymous task scope = %s", sql)
+	}
+}
@@ -0,0 +1,334 @@
+package services
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+)
+
+// token 签名算法
+const (
+	TokenAlgHS256 = "HS256" // 与账号中心共享 JWT_SECRET
+	TokenAlgRS256 = "RS256" // 账号中心私钥签名，JWKS 公钥校验
+)
+
+// SessionTokenIssuer 本系统签发的会话 access token 的 iss
+const SessionTokenIssuer = "pr-business"
+
+const (
+	// tokenClockLeeway 校验 exp/nbf 时容忍的时钟偏差
+	tokenClockLeeway = 30 * time.Second
+	// jwksMinRefreshInterval 遇到未知 kid 时两次拉取 JWKS 的最小间隔，避免伪造 kid 打满账号中心
+	jwksMinRefreshInterval = time.Minute
+)
+
+// TokenClaims 校验通过的 token 声明
+type TokenClaims struct {
+	UserID    string    // 账号中心用户ID（userId，缺省取 sub）
+	UnionID   string    // 微信 unionId
+	TokenID   string    // jti
+	SessionID string    // sid，本系统签发的会话 access token 才有
+	IssuedAt  time.Time // iat，未签发时间时为零值
+	ExpiresAt time.Time // exp
+}
+
+// JSONWebKeySet JWKS 公钥集
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// JSONWebKey JWKS 中的单个公钥（仅支持 RSA）
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// TokenVerifier 本地校验账号中心签发的 JWT 和本系统签发的会话 token
+// HS256 使用 JWT_SECRET 校验；RS256 使用从账号中心拉取并缓存的 JWKS 公钥校验。
+// 公钥定期在后台刷新，刷新失败时继续使用已缓存的公钥，账号中心不可用不影响未过期 token
+type TokenVerifier struct {
+	secret          []byte
+	issuer          string
+	client          AuthCenterClient
+	refreshInterval time.Duration
+
+	mu            sync.RWMutex
+	keys          map[string]*rsa.PublicKey
+	keysFetchedAt time.Time
+	lastFetchAt   time.Time
+	refreshing    bool
+}
+
+// NewTokenVerifier 创建 token 校验器
+// secret 为空时不接受 HS256；issuer 非空时校验 iss；client 为 nil 时不接受 RS256
+func NewTokenVerifier(secret, issuer string, client AuthCenterClient, jwksRefreshInterval time.Duration) *TokenVerifier {
+	if jwksRefreshInterval <= 0 {
+		jwksRefreshInterval = time.Hour
+	}
+	return &TokenVerifier{
+		secret:          []byte(secret),
+		issuer:          issuer,
+		client:          client,
+		refreshInterval: jwksRefreshInterval,
+		keys:            map[string]*rsa.PublicKey{},
+	}
+}
+
+// Verify 校验 token 签名和有效期，返回声明
+func (v *TokenVerifier) Verify(ctx context.Context, token string) (*TokenClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeTokenSegment(parts[0], &header); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	signingInput := parts[0] + "." + parts[1]
+
+	switch header.Alg {
+	case TokenAlgHS256:
+		if len(v.secret) == 0 {
+			return nil, ErrInvalidToken
+		}
+		mac := hmac.New(sha256.New, v.secret)
+		mac.Write([]byte(signingInput))
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return nil, ErrInvalidToken
+		}
+	case TokenAlgRS256:
+		key, err := v.publicKey(ctx, header.Kid)
+		if err != nil {
+			return nil, err
+		}
+		digest := sha256.Sum256([]byte(signingInput))
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+			return nil, ErrInvalidToken
+		}
+	default:
+		return nil, ErrInvalidToken
+	}
+
+	var payload struct {
+		Sub     string   `json:"sub"`
+		UserID  string   `json:"userId"`
+		UnionID string   `json:"unionId"`
+		Jti     string   `json:"jti"`
+		Sid     string   `json:"sid"`
+		Iss     string   `json:"iss"`
+		Exp     *float64 `json:"exp"`
+		Nbf     *float64 `json:"nbf"`
+		Iat     *float64 `json:"iat"`
+	}
+	if err := decodeTokenSegment(parts[1], &payload); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	claims := &TokenClaims{
+		UserID:    payload.UserID,
+		UnionID:   payload.UnionID,
+		TokenID:   payload.Jti,
+		SessionID: payload.Sid,
+	}
+	if claims.UserID == "" {
+		claims.UserID = payload.Sub
+	}
+	if claims.UserID == "" || payload.Exp == nil {
+		return nil, ErrInvalidToken
+	}
+	if payload.Iss == SessionTokenIssuer {
+		// 会话 token 只能由本系统用 JWT_SECRET 签发
+		if header.Alg != TokenAlgHS256 || claims.SessionID == "" || claims.TokenID == "" {
+			return nil, ErrInvalidToken
+		}
+	} else if v.issuer != "" && payload.Iss != v.issuer {
+		return nil, ErrInvalidToken
+	}
+
+	now := time.Now()
+	claims.ExpiresAt = time.Unix(int64(*payload.Exp), 0)
+	if now.After(claims.ExpiresAt.Add(tokenClockLeeway)) {
+		return nil, ErrTokenExpired
+	}
+	if payload.Nbf != nil && now.Add(tokenClockLeeway).Before(time.Unix(int64(*payload.Nbf), 0)) {
+		return nil, ErrInvalidToken
+	}
+	if payload.Iat != nil {
+		claims.IssuedAt = time.Unix(int64(*payload.Iat), 0)
+	}
+
+	return claims, nil
+}
+
+// SignSession 用 JWT_SECRET（HS256）签发会话 access token
+func (v *TokenVerifier) SignSession(authCenterUserID, sessionID, tokenID string, issuedAt, expiresAt time.Time) (string, error) {
+	if len(v.secret) == 0 {
+		return "", ErrSessionSigningDisabled
+	}
+
+	header, _ := json.Marshal(map[string]string{"alg": TokenAlgHS256, "typ": "JWT"})
+	payload, _ := json.Marshal(map[string]interface{}{
+		"iss":    SessionTokenIssuer,
+		"userId": authCenterUserID,
+		"sid":    sessionID,
+		"jti":    tokenID,
+		"iat":    issuedAt.Unix(),
+		"exp":    expiresAt.Unix(),
+	})
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	mac := hmac.New(sha256.New, v.secret)
+	mac.Write([]byte(signingInput))
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// publicKey 按 kid 取 JWKS 公钥
+// 已缓存时直接使用（过期则后台刷新）；未缓存时同步拉取一次，受最小拉取间隔限制
+func (v *TokenVerifier) publicKey(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	if v.client == nil {
+		return nil, ErrInvalidToken
+	}
+
+	v.mu.RLock()
+	key := v.lookupKey(kid)
+	stale := time.Since(v.keysFetchedAt) > v.refreshInterval
+	v.mu.RUnlock()
+
+	if key != nil {
+		if stale {
+			v.refreshKeysAsync()
+		}
+		return key, nil
+	}
+
+	v.mu.Lock()
+	if time.Since(v.lastFetchAt) < jwksMinRefreshInterval {
+		v.mu.Unlock()
+		return nil, ErrInvalidToken
+	}
+	v.lastFetchAt = time.Now()
+	v.mu.Unlock()
+
+	if err := v.refreshKeys(ctx); err != nil {
+		log.Printf("[TokenVerifier] fetch JWKS failed: %v", err)
+	}
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	if key := v.lookupKey(kid); key != nil {
+		return key, nil
+	}
+	return nil, ErrInvalidToken
+}
+
+// lookupKey 查找公钥；token 未带 kid 且只有一把公钥时使用该公钥（调用方持有读锁）
+func (v *TokenVerifier) lookupKey(kid string) *rsa.PublicKey {
+	if kid == "" && len(v.keys) == 1 {
+		for _, key := range v.keys {
+			return key
+		}
+	}
+	return v.keys[kid]
+}
+
+// refreshKeysAsync 后台刷新公钥，同一时间只有一个刷新
+func (v *TokenVerifier) refreshKeysAsync() {
+	v.mu.Lock()
+	if v.refreshing || time.Since(v.lastFetchAt) < jwksMinRefreshInterval {
+		v.mu.Unlock()
+		return
+	}
+	v.refreshing = true
+	v.lastFetchAt = time.Now()
+	v.mu.Unlock()
+
+	go func() {
+		defer func() {
+			v.mu.Lock()
+			v.refreshing = false
+			v.mu.Unlock()
+		}()
+		if err := v.refreshKeys(context.Background()); err != nil {
+			log.Printf("[TokenVerifier] refresh JWKS failed, keep cached keys: %v", err)
+		}
+	}()
+}
+
+// refreshKeys 拉取 JWKS 并替换缓存；拉取失败或没有可用公钥时保留原缓存
+func (v *TokenVerifier) refreshKeys(ctx context.Context) error {
+	keySet, err := v.client.GetJWKS(ctx)
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(keySet.Keys))
+	for _, jwk := range keySet.Keys {
+		if jwk.Kty != "RSA" || (jwk.Use != "" && jwk.Use != "sig") || (jwk.Alg != "" && jwk.Alg != TokenAlgRS256) {
+			continue
+		}
+		key, err := parseRSAJWK(jwk)
+		if err != nil {
+			log.Printf("[TokenVerifier] skip invalid JWK %q: %v", jwk.Kid, err)
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	if len(keys) == 0 {
+		return errors.New("JWKS 中没有可用的 RSA 公钥")
+	}
+
+	v.mu.Lock()
+	v.keys = keys
+	v.keysFetchedAt = time.Now()
+	v.mu.Unlock()
+	return nil
+}
+
+// parseRSAJWK 将 JWK 的 n/e 解析为 RSA 公钥
+func parseRSAJWK(jwk JSONWebKey) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+	if err != nil {
+		return nil, err
+	}
+	exponent := new(big.Int).SetBytes(e)
+	if len(n) == 0 || !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+		return nil, errors.New("RSA 公钥参数不正确")
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+}
+
+// decodeTokenSegment 解码 JWT 的 base64url 段
+func decodeTokenSegment(segment string, out interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
@@ -0,0 +1,330 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"pr-business/models"
+)
+
+// transactionTypeCodePattern 交易类型代码格式：大写字母开头，大写字母、数字、下划线
+var transactionTypeCodePattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]{1,49}$`)
+
+// builtinTransactionTypes 代码中记账使用的交易类型，启动时补齐数据库中缺失的定义（已有定义不覆盖）
+var builtinTransactionTypes = []models.TransactionType{
+	{Code: models.TransactionRecharge, Name: "商家充值", Description: "商家充值积分", AccountTypes: "{ORG_MERCHANT}", AmountDirection: models.AmountDirectionPositive},
+	{Code: models.TransactionRechargeRefund, Name: "充值退款", Description: "在线充值原路退款，渠道受理后扣除frozen_balance", AccountTypes: "{ORG_MERCHANT}", AmountDirection: models.AmountDirectionNegative},
+	{Code: models.TransactionRechargeRefundFreeze, Name: "充值退款冻结", Description: "发起在线充值退款，从balance转入frozen_balance", AccountTypes: "{ORG_MERCHANT}", AmountDirection: models.AmountDirectionNegative},
+	{Code: models.TransactionRechargeRefundRelease, Name: "充值退款失败", Description: "渠道拒绝退款，frozen_balance转回balance", AccountTypes: "{ORG_MERCHANT}", AmountDirection: models.AmountDirectionPositive},
+	{Code: models.TransactionCampaignFreeze, Name: "活动冻结", Description: "活动发布时冻结商家积分", AccountTypes: "{ORG_MERCHANT}", AmountDirection: models.AmountDirectionNegative},
+	{Code: models.TransactionCampaignRefund, Name: "活动退还", Description: "活动关闭时未完成名额积分退回", AccountTypes: "{ORG_MERCHANT}", AmountDirection: models.AmountDirectionPositive},
+	{Code: models.TransactionTaskPublish, Name: "发布任务", Description: "未托管活动结算时从商家frozen_balance扣除", AccountTypes: "{ORG_MERCHANT}", AmountDirection: models.AmountDirectionNegative},
+	{Code: models.TransactionEscrowRelease, Name: "托管支出", Description: "任务结算时从 TASK_ESCROW 支出", AccountTypes: "{SYSTEM}", AmountDirection: models.AmountDirectionNegative},
+	{Code: models.TransactionTaskIncome, Name: "任务收入", Description: "达人任务结算收入", AccountTypes: "{USER_PERSONAL}", AmountDirection: models.AmountDirectionPositive},
+	{Code: models.TransactionStaffReferral, Name: "员工返佣", Description: "员工邀请的达人完成任务时的返佣", AccountTypes: "{USER_PERSONAL,ORG_PROVIDER,ORG_MERCHANT}", AmountDirection: models.AmountDirectionPositive},
+	{Code: models.TransactionProviderIncome, Name: "服务商收入", Description: "服务商完成任务审核通过获得的收入", AccountTypes: "{ORG_PROVIDER}", AmountDirection: models.AmountDirectionPositive},
+	{Code: models.TransactionTaskRefund, Name: "任务退款", Description: "任务结算未分配部分退回商家balance", AccountTypes: "{ORG_MERCHANT}", AmountDirection: models.AmountDirectionPositive},
+	{Code: models.TransactionPlatformFee, Name: "平台手续费", Description: "任务结算时扣除的平台手续费，计入 PLATFORM_REVENUE", AccountTypes: "{SYSTEM}", AmountDirection: models.AmountDirectionPositive},
+	{Code: models.TransactionWithdrawFreeze, Name: "提现冻结", Description: "提现申请，从balance转入frozen_balance", AccountTypes: "{USER_PERSONAL,ORG_MERCHANT,ORG_PROVIDER}", AmountDirection: models.AmountDirectionNegative},
+	{Code: models.TransactionWithdrawRefund, Name: "提现拒绝", Description: "提现被拒绝或打款失败，frozen_balance转回balance", AccountTypes: "{USER_PERSONAL,ORG_MERCHANT,ORG_PROVIDER}", AmountDirection: models.AmountDirectionPositive},
+	{Code: models.TransactionWithdraw, Name: "提现", Description: "提现打款成功，扣除frozen_balance", AccountTypes: "{USER_PERSONAL,ORG_MERCHANT,ORG_PROVIDER}", AmountDirection: models.AmountDirectionNegative},
+	{Code: models.TransactionWithdrawFee, Name: "提现手续费", Description: "提现打款成功时从提现积分中扣除的手续费，计入 PLATFORM_REVENUE", AccountTypes: "{SYSTEM}", AmountDirection: models.AmountDirectionPositive},
+	{Code: models.TransactionTaxWithholding, Name: "个税预扣", Description: "达人提现打款成功时预扣的个税，计入 TAX_PAYABLE", AccountTypes: "{SYSTEM}", AmountDirection: models.AmountDirectionPositive},
+	{Code: models.TransactionCashAdjust, Name: "现金账户调整", Description: "超管调整现金账户余额，对手方为清算账户", AccountTypes: "{CASH}", AmountDirection: models.AmountDirectionPositive},
+	{Code: models.TransactionSystemAdjust, Name: "系统账户调整", Description: "超管调整系统账户余额，对手方为积分发行账户", AccountTypes: "{SYSTEM}", AmountDirection: models.AmountDirectionPositive},
+}
+
+// TransactionTypeService 交易类型注册表
+// 记账时校验交易类型：代码必须已登记、启用且未废弃；金额方向和账户类型必须与定义一致。
+// 校验对象是"承担"该类型的分录：积分账户按账户汇总（有可用余额分录时取可用余额，否则取冻结余额），
+// 系统/现金账户只校验显式指定了 Type 的分录；沿用 LedgerTransfer.Type 的系统/现金分录是对冲方，只校验代码
+type TransactionTypeService struct {
+	db *gorm.DB
+}
+
+// NewTransactionTypeService 创建交易类型注册表
+func NewTransactionTypeService(db *gorm.DB) *TransactionTypeService {
+	return &TransactionTypeService{db: db}
+}
+
+// DefineTransactionTypeRequest 新增或修改交易类型的输入参数
+type DefineTransactionTypeRequest struct {
+	Code            string
+	Name            string
+	Description     string
+	AccountTypes    []string
+	AmountDirection string
+	ChangeNote      string
+}
+
+// Seed 补齐内置交易类型（已存在的定义不覆盖，以数据库为准）
+func (s *TransactionTypeService) Seed() error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		for _, builtin := range builtinTransactionTypes {
+			definition := builtin
+			definition.IsActive = true
+			definition.Version = 1
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&definition)
+			if result.Error != nil {
+				return fmt.Errorf("登记交易类型 %s 失败: %w", definition.Code, result.Error)
+			}
+			if result.RowsAffected == 0 {
+				continue
+			}
+			if err := s.snapshot(tx, &definition, "内置交易类型", "system"); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// List 查询交易类型
+func (s *TransactionTypeService) List(includeDeprecated bool) ([]models.TransactionType, error) {
+	query := s.db.Model(&models.TransactionType{})
+	if !includeDeprecated {
+		query = query.Where("is_active = ? AND deprecated_at IS NULL", true)
+	}
+
+	var types []models.TransactionType
+	if err := query.Order("code").Find(&types).Error; err != nil {
+		return nil, fmt.Errorf("查询交易类型失败: %w", err)
+	}
+	return types, nil
+}
+
+// Versions 查询交易类型的历史版本
+func (s *TransactionTypeService) Versions(code string) ([]models.TransactionTypeVersion, error) {
+	var versions []models.TransactionTypeVersion
+	if err := s.db.Where("code = ?", code).Order("version DESC").Find(&versions).Error; err != nil {
+		return nil, fmt.Errorf("查询交易类型版本失败: %w", err)
+	}
+	if len(versions) == 0 {
+		return nil, ErrTransactionTypeNotFound
+	}
+	return versions, nil
+}
+
+// Define 新增交易类型，或修改启用中的交易类型（版本号加一）；已废弃的代码不能重新启用
+func (s *TransactionTypeService) Define(req *DefineTransactionTypeRequest, operatorID string) (*models.TransactionType, error) {
+	if !transactionTypeCodePattern.MatchString(req.Code) {
+		return nil, fmt.Errorf("%w: 代码只能包含大写字母、数字和下划线", ErrInvalidTransactionType)
+	}
+	if req.AmountDirection != models.AmountDirectionPositive && req.AmountDirection != models.AmountDirectionNegative {
+		return nil, fmt.Errorf("%w: 金额方向只能是 positive 或 negative", ErrInvalidTransactionType)
+	}
+	if len(req.AccountTypes) == 0 {
+		return nil, fmt.Errorf("%w: 至少需要一个适用账户类型", ErrInvalidTransactionType)
+	}
+	for _, ownerType := range req.AccountTypes {
+		switch ownerType {
+		case string(models.OwnerTypeOrgMerchant), string(models.OwnerTypeOrgProvider), string(models.OwnerTypeUserPersonal),
+			models.TransactionAccountSystem, models.TransactionAccountCash:
+		default:
+			return nil, fmt.Errorf("%w: 未知的账户类型 %s", ErrInvalidTransactionType, ownerType)
+		}
+	}
+
+	var definition models.TransactionType
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("code = ?", req.Code).First(&definition).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("查询交易类型失败: %w", err)
+		}
+
+		now := time.Now()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			definition = models.TransactionType{Code: req.Code, IsActive: true, Version: 1, CreatedAt: now}
+		} else {
+			if !definition.IsUsable() {
+				return fmt.Errorf("%w: %s 已废弃，请使用新的代码", ErrTransactionTypeInactive, req.Code)
+			}
+			definition.Version++
+		}
+		definition.Name = req.Name
+		definition.Description = req.Description
+		definition.AccountTypes = models.FormatAccountTypes(req.AccountTypes)
+		definition.AmountDirection = req.AmountDirection
+		definition.UpdatedAt = now
+
+		if err := tx.Save(&definition).Error; err != nil {
+			return fmt.Errorf("保存交易类型失败: %w", err)
+		}
+		return s.snapshot(tx, &definition, req.ChangeNote, operatorID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &definition, nil
+}
+
+// Deprecate 废弃交易类型，之后不能再用于记账；代码中仍在使用的内置类型不能废弃
+func (s *TransactionTypeService) Deprecate(code string, reason string, operatorID string) (*models.TransactionType, error) {
+	for _, builtin := range builtinTransactionTypes {
+		if builtin.Code == code {
+			return nil, fmt.Errorf("%w: %s 仍在记账代码中使用，不能废弃", ErrInvalidTransactionType, code)
+		}
+	}
+
+	var definition models.TransactionType
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("code = ?", code).First(&definition).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrTransactionTypeNotFound
+			}
+			return fmt.Errorf("查询交易类型失败: %w", err)
+		}
+		if !definition.IsUsable() {
+			return fmt.Errorf("%w: %s 已废弃", ErrTransactionTypeInactive, code)
+		}
+
+		now := time.Now()
+		definition.IsActive = false
+		definition.DeprecatedAt = &now
+		definition.Version++
+		definition.UpdatedAt = now
+		if err := tx.Model(&definition).Updates(map[string]interface{}{
+			"is_active":     false,
+			"deprecated_at": now,
+			"version":       definition.Version,
+			"updated_at":    now,
+		}).Error; err != nil {
+			return fmt.Errorf("废弃交易类型失败: %w", err)
+		}
+		return s.snapshot(tx, &definition, reason, operatorID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &definition, nil
+}
+
+// ValidateTransfer 在事务内按注册表校验一组分录的交易类型（creditAccounts 为已锁定的积分账户）
+func (s *TransactionTypeService) ValidateTransfer(tx *gorm.DB, transfer *LedgerTransfer, creditAccounts map[uuid.UUID]*models.CreditAccount) error {
+	codes := map[string]bool{}
+	if transfer.Type != "" {
+		codes[transfer.Type] = true
+	}
+	for _, posting := range transfer.Postings {
+		if posting.Type != "" {
+			codes[posting.Type] = true
+		}
+	}
+	codeList := make([]string, 0, len(codes))
+	for code := range codes {
+		codeList = append(codeList, code)
+	}
+
+	var types []models.TransactionType
+	if err := tx.Where("code IN ?", codeList).Find(&types).Error; err != nil {
+		return fmt.Errorf("查询交易类型失败: %w", err)
+	}
+	definitions := make(map[string]*models.TransactionType, len(types))
+	for i := range types {
+		definitions[types[i].Code] = &types[i]
+	}
+	for _, code := range codeList {
+		definition, ok := definitions[code]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrTransactionTypeNotFound, code)
+		}
+		if !definition.IsUsable() {
+			return fmt.Errorf("%w: %s", ErrTransactionTypeInactive, code)
+		}
+	}
+
+	// 积分账户：同一账户同一类型汇总，有可用余额分录时以可用余额变动为准，否则以冻结余额变动为准
+	type creditKey struct {
+		accountID uuid.UUID
+		code      string
+	}
+	available := map[creditKey]int{}
+	frozen := map[creditKey]int{}
+	seen := map[creditKey]bool{}
+	var keys []creditKey
+	for _, posting := range transfer.Postings {
+		code := posting.Type
+		if code == "" {
+			code = transfer.Type
+		}
+		key := creditKey{accountID: posting.AccountID, code: code}
+
+		switch posting.Kind {
+		case models.LedgerAccountCredit, models.LedgerAccountCreditFrozen:
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+			if posting.Kind == models.LedgerAccountCredit {
+				available[key] += posting.Amount
+			} else {
+				frozen[key] += posting.Amount
+			}
+		case models.LedgerAccountSystem, models.LedgerAccountCash:
+			if posting.Type == "" {
+				continue
+			}
+			owner := models.TransactionAccountSystem
+			if posting.Kind == models.LedgerAccountCash {
+				owner = models.TransactionAccountCash
+			}
+			if err := checkTransactionType(definitions[code], owner, posting.Amount); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, key := range keys {
+		amount, ok := available[key]
+		if !ok {
+			amount = frozen[key]
+		}
+		account, ok := creditAccounts[key.accountID]
+		if !ok {
+			return ErrCreditAccountNotFound
+		}
+		if err := checkTransactionType(definitions[key.code], string(account.OwnerType), amount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkTransactionType 校验账户类型和金额方向
+func checkTransactionType(definition *models.TransactionType, ownerType string, amount int) error {
+	if !definition.AllowsOwner(ownerType) {
+		return fmt.Errorf("%w: %s 不适用于 %s 账户", ErrTransactionTypeMismatch, definition.Code, ownerType)
+	}
+	if amount == 0 {
+		return nil
+	}
+	if (amount > 0) != (definition.AmountDirection == models.AmountDirectionPositive) {
+		return fmt.Errorf("%w: %s 的金额方向应为 %s，实际为 %d", ErrTransactionTypeMismatch, definition.Code, definition.AmountDirection, amount)
+	}
+	return nil
+}
+
+// snapshot 记录交易类型的版本快照
+func (s *TransactionTypeService) snapshot(tx *gorm.DB, definition *models.TransactionType, note string, operatorID string) error {
+	version := models.TransactionTypeVersion{
+		Code:            definition.Code,
+		Version:         definition.Version,
+		Name:            definition.Name,
+		Description:     definition.Description,
+		AccountTypes:    definition.AccountTypes,
+		AmountDirection: definition.AmountDirection,
+		IsActive:        definition.IsUsable(),
+		ChangeNote:      note,
+		ChangedBy:       operatorID,
+	}
+	if err := tx.Create(&version).Error; err != nil {
+		return fmt.Errorf("记录交易类型版本失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"time"
+
+	"pr-business/models"
+)
+
+// UserCache 认证路径的本地用户短时缓存：账号中心用户ID → 用户
+// 本服务修改用户（角色变化、吊销 token、切换激活角色、资料同步）后必须调用 Invalidate；
+// 封禁状态和 token 吊销时间由 AuthIdentityService 每次请求单独读取，不受缓存影响；
+// 其他实例对角色、资料的修改最迟在 TTL 后生效，TTL 应保持在秒级
+// 所有方法允许 nil 接收者（不缓存）
+type UserCache struct {
+	users *boundedCache
+	ttl   time.Duration
+}
+
+// NewUserCache 创建用户缓存；ttl <= 0 时返回 nil，即不缓存
+func NewUserCache(size int, ttl time.Duration) *UserCache {
+	if ttl <= 0 {
+		return nil
+	}
+	if size <= 0 {
+		size = 10000
+	}
+	return &UserCache{users: newBoundedCache(size), ttl: ttl}
+}
+
+// Get 读取缓存的用户副本（调用方修改不影响缓存）
+func (c *UserCache) Get(authCenterUserID string) (*models.User, bool) {
+	if c == nil {
+		return nil, false
+	}
+	cached, ok := c.users.Get(authCenterUserID)
+	if !ok {
+		return nil, false
+	}
+	return copyUser(cached.(*models.User)), true
+}
+
+// Set 缓存用户副本
+func (c *UserCache) Set(user *models.User) {
+	if c == nil || user.AuthCenterUserID == "" {
+		return
+	}
+	c.users.Set(user.AuthCenterUserID, copyUser(user), time.Now().Add(c.ttl))
+}
+
+// Invalidate 删除缓存，下一次请求重新读取数据库
+func (c *UserCache) Invalidate(authCenterUserID string) {
+	if c == nil || authCenterUserID == "" {
+		return
+	}
+	c.users.Delete(authCenterUserID)
+}
+
+// copyUser 复制用户，角色切片单独复制
+func copyUser(user *models.User) *models.User {
+	copied := *user
+	copied.Roles = append(models.Roles(nil), user.Roles...)
+	return &copied
+}
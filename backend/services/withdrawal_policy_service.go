@@ -0,0 +1,322 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"pr-business/models"
+)
+
+// earningTransactionTypes 计入"首笔收入"的交易类型（冷静期从首笔收入到账开始计算）
+var earningTransactionTypes = []string{
+	models.TransactionTaskIncome,
+	models.TransactionStaffReferral,
+	models.TransactionProviderIncome,
+}
+
+// WithdrawalPolicyService 提现策略服务：单笔限额、每日/每月累计上限、首笔收入冷静期、
+// 按提现方式的手续费阶梯，以及把可疑提现标记为人工复核的风控规则
+type WithdrawalPolicyService struct {
+	db *gorm.DB
+}
+
+// NewWithdrawalPolicyService 创建提现策略服务
+func NewWithdrawalPolicyService(db *gorm.DB) *WithdrawalPolicyService {
+	return &WithdrawalPolicyService{db: db}
+}
+
+// CreateWithdrawalPolicyRequest 创建提现策略的输入参数
+type CreateWithdrawalPolicyRequest struct {
+	Scope                string
+	MinAmount            int
+	MaxAmount            int
+	DailyLimit           int
+	MonthlyLimit         int
+	CoolingOffHours      int
+	ReviewAmount         int
+	ReferralWindowHours  int
+	FlagNewPayoutAccount bool
+	FeeTiers             []models.WithdrawalFeeTier
+	Description          string
+}
+
+// WithdrawalAssessment 提现申请按策略评估的结果
+type WithdrawalAssessment struct {
+	Policy    *models.WithdrawalPolicy // 为空表示没有生效策略
+	Fee       int
+	RiskFlags []string
+}
+
+// PolicyID 生效策略ID，没有策略时为空
+func (a *WithdrawalAssessment) PolicyID() *uuid.UUID {
+	if a.Policy == nil {
+		return nil
+	}
+	return &a.Policy.ID
+}
+
+// Resolve 取账户所有者类型的生效策略，没有时取全局策略；都没有时返回 nil
+func (s *WithdrawalPolicyService) Resolve(tx *gorm.DB, ownerType models.OwnerType) (*models.WithdrawalPolicy, error) {
+	for _, scope := range []string{string(ownerType), models.WithdrawalPolicyScopeGlobal} {
+		var policy models.WithdrawalPolicy
+		err := tx.Preload("FeeTiers").
+			Where("scope = ? AND is_active = ?", scope, true).
+			Order("created_at DESC").
+			First(&policy).Error
+		if err == nil {
+			return &policy, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("查询提现策略失败: %w", err)
+		}
+	}
+	return nil, nil
+}
+
+// Quote 试算手续费（不校验累计上限和冷静期）
+func (s *WithdrawalPolicyService) Quote(account *models.CreditAccount, method models.WithdrawalMethod, amount int) (*WithdrawalAssessment, error) {
+	policy, err := s.Resolve(s.db, account.OwnerType)
+	if err != nil {
+		return nil, err
+	}
+	assessment := &WithdrawalAssessment{Policy: policy, RiskFlags: []string{}}
+	if policy != nil {
+		assessment.Fee = policy.CalculateFee(method, amount)
+	}
+	return assessment, nil
+}
+
+// Assess 在提现事务内按策略校验并计算手续费、风控标记
+// 调用方应先锁定积分账户，保证同一账户并发申请时累计金额校验有效
+func (s *WithdrawalPolicyService) Assess(tx *gorm.DB, account *models.CreditAccount, method models.WithdrawalMethod, amount int, accountInfoHash string, now time.Time) (*WithdrawalAssessment, error) {
+	policy, err := s.Resolve(tx, account.OwnerType)
+	if err != nil {
+		return nil, err
+	}
+	assessment := &WithdrawalAssessment{Policy: policy, RiskFlags: []string{}}
+	if policy == nil {
+		return assessment, nil
+	}
+
+	// 1. 单笔限额
+	if policy.MinAmount > 0 && amount < policy.MinAmount {
+		return nil, fmt.Errorf("%w: 单笔最低提现 %d 积分", ErrWithdrawalPolicyViolation, policy.MinAmount)
+	}
+	if policy.MaxAmount > 0 && amount > policy.MaxAmount {
+		return nil, fmt.Errorf("%w: 单笔最高提现 %d 积分", ErrWithdrawalPolicyViolation, policy.MaxAmount)
+	}
+
+	// 2. 每日/每月累计上限（按账户所有者统计，被拒绝和打款失败的不计入）
+	if policy.DailyLimit > 0 || policy.MonthlyLimit > 0 {
+		dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+
+		var totals struct {
+			Daily   int64
+			Monthly int64
+		}
+		if err := tx.Raw(`
+			SELECT COALESCE(SUM(amount) FILTER (WHERE created_at >= ?), 0) AS daily,
+			       COALESCE(SUM(amount), 0) AS monthly
+			FROM withdrawals
+			WHERE account_id IN (SELECT id FROM credit_accounts WHERE owner_id = ?)
+			  AND status NOT IN ?
+			  AND created_at >= ?`,
+			dayStart, account.OwnerID,
+			[]models.WithdrawalStatus{models.WithdrawalStatusRejected, models.WithdrawalStatusFailed},
+			monthStart).Scan(&totals).Error; err != nil {
+			return nil, fmt.Errorf("统计累计提现金额失败: %w", err)
+		}
+
+		if policy.DailyLimit > 0 && totals.Daily+int64(amount) > int64(policy.DailyLimit) {
+			return nil, fmt.Errorf("%w: 每日累计提现上限 %d 积分，今日已申请 %d 积分", ErrWithdrawalPolicyViolation, policy.DailyLimit, totals.Daily)
+		}
+		if policy.MonthlyLimit > 0 && totals.Monthly+int64(amount) > int64(policy.MonthlyLimit) {
+			return nil, fmt.Errorf("%w: 每月累计提现上限 %d 积分，本月已申请 %d 积分", ErrWithdrawalPolicyViolation, policy.MonthlyLimit, totals.Monthly)
+		}
+	}
+
+	// 3. 首笔收入冷静期
+	if policy.CoolingOffHours > 0 {
+		var firstEarning sql.NullTime
+		if err := tx.Model(&models.LedgerEntry{}).
+			Select("MIN(created_at)").
+			Where("account_kind = ? AND account_id = ? AND direction = ? AND type IN ?",
+				models.LedgerAccountCredit, account.ID, models.LedgerDirectionCredit, earningTransactionTypes).
+			Row().Scan(&firstEarning); err != nil {
+			return nil, fmt.Errorf("查询首笔收入失败: %w", err)
+		}
+		if firstEarning.Valid {
+			availableAt := firstEarning.Time.Add(time.Duration(policy.CoolingOffHours) * time.Hour)
+			if now.Before(availableAt) {
+				return nil, fmt.Errorf("%w: 首笔收入到账 %d 小时后才能提现，请于 %s 后申请",
+					ErrWithdrawalPolicyViolation, policy.CoolingOffHours, availableAt.Format("2006-01-02 15:04"))
+			}
+		}
+	}
+
+	// 4. 手续费
+	assessment.Fee = policy.CalculateFee(method, amount)
+
+	// 5. 风控标记：命中任一规则的提现进入人工复核队列
+	if policy.ReviewAmount > 0 && amount >= policy.ReviewAmount {
+		assessment.RiskFlags = append(assessment.RiskFlags, models.WithdrawalRiskLargeAmount)
+	}
+
+	if policy.ReferralWindowHours > 0 {
+		var referrals int64
+		if err := tx.Model(&models.LedgerEntry{}).
+			Where("account_kind = ? AND account_id = ? AND direction = ? AND type = ? AND created_at >= ?",
+				models.LedgerAccountCredit, account.ID, models.LedgerDirectionCredit, models.TransactionStaffReferral,
+				now.Add(-time.Duration(policy.ReferralWindowHours)*time.Hour)).
+			Count(&referrals).Error; err != nil {
+			return nil, fmt.Errorf("查询返佣记录失败: %w", err)
+		}
+		if referrals > 0 {
+			assessment.RiskFlags = append(assessment.RiskFlags, models.WithdrawalRiskAfterReferral)
+		}
+	}
+
+	if policy.FlagNewPayoutAccount {
+		var used int64
+		if err := tx.Model(&models.Withdrawal{}).
+			Where("account_id IN (SELECT id FROM credit_accounts WHERE owner_id = ?) AND account_info_hash = ? AND status = ?",
+				account.OwnerID, accountInfoHash, models.WithdrawalStatusCompleted).
+			Count(&used).Error; err != nil {
+			return nil, fmt.Errorf("查询历史收款账户失败: %w", err)
+		}
+		if used == 0 {
+			assessment.RiskFlags = append(assessment.RiskFlags, models.WithdrawalRiskNewPayoutAccount)
+		}
+	}
+
+	return assessment, nil
+}
+
+// CreatePolicy 创建提现策略并停用同一范围的旧策略
+func (s *WithdrawalPolicyService) CreatePolicy(req *CreateWithdrawalPolicyRequest, operatorID string) (*models.WithdrawalPolicy, error) {
+	switch req.Scope {
+	case models.WithdrawalPolicyScopeGlobal, string(models.OwnerTypeUserPersonal), string(models.OwnerTypeOrgMerchant), string(models.OwnerTypeOrgProvider):
+	default:
+		return nil, fmt.Errorf("无效的策略范围: %s", req.Scope)
+	}
+	if req.MinAmount < 0 || req.MaxAmount < 0 || req.DailyLimit < 0 || req.MonthlyLimit < 0 ||
+		req.CoolingOffHours < 0 || req.ReviewAmount < 0 || req.ReferralWindowHours < 0 {
+		return nil, errors.New("限额和时长不能为负数")
+	}
+	if req.MaxAmount > 0 && req.MinAmount > req.MaxAmount {
+		return nil, errors.New("单笔最低金额不能高于单笔最高金额")
+	}
+	if req.DailyLimit > 0 && req.MonthlyLimit > 0 && req.DailyLimit > req.MonthlyLimit {
+		return nil, errors.New("每日上限不能高于每月上限")
+	}
+	for _, tier := range req.FeeTiers {
+		switch tier.Method {
+		case models.WithdrawalMethodAlipay, models.WithdrawalMethodWechat, models.WithdrawalMethodBank:
+		default:
+			return nil, fmt.Errorf("无效的提现方式: %s", tier.Method)
+		}
+		if tier.MinAmount < 0 || tier.RateBasis < 0 || tier.RateBasis > 10000 ||
+			tier.FixedAmount < 0 || tier.MinFee < 0 || tier.MaxFee < 0 {
+			return nil, errors.New("手续费阶梯参数无效")
+		}
+		if tier.MaxFee > 0 && tier.MinFee > tier.MaxFee {
+			return nil, errors.New("最低手续费不能高于最高手续费")
+		}
+	}
+
+	policy := models.WithdrawalPolicy{
+		Scope:                req.Scope,
+		MinAmount:            req.MinAmount,
+		MaxAmount:            req.MaxAmount,
+		DailyLimit:           req.DailyLimit,
+		MonthlyLimit:         req.MonthlyLimit,
+		CoolingOffHours:      req.CoolingOffHours,
+		ReviewAmount:         req.ReviewAmount,
+		ReferralWindowHours:  req.ReferralWindowHours,
+		FlagNewPayoutAccount: req.FlagNewPayoutAccount,
+		IsActive:             true,
+		Description:          req.Description,
+		CreatedBy:            operatorID,
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.WithdrawalPolicy{}).
+			Where("scope = ? AND is_active = ?", req.Scope, true).
+			Updates(map[string]interface{}{"is_active": false, "updated_at": time.Now()}).Error; err != nil {
+			return fmt.Errorf("停用旧提现策略失败: %w", err)
+		}
+		if err := tx.Omit("FeeTiers").Create(&policy).Error; err != nil {
+			return fmt.Errorf("创建提现策略失败: %w", err)
+		}
+
+		policy.FeeTiers = make([]models.WithdrawalFeeTier, 0, len(req.FeeTiers))
+		for _, tier := range req.FeeTiers {
+			tier.ID = uuid.Nil
+			tier.PolicyID = policy.ID
+			policy.FeeTiers = append(policy.FeeTiers, tier)
+		}
+		if len(policy.FeeTiers) > 0 {
+			if err := tx.Create(&policy.FeeTiers).Error; err != nil {
+				return fmt.Errorf("创建手续费阶梯失败: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &policy, nil
+}
+
+// ListPolicies 查询提现策略
+func (s *WithdrawalPolicyService) ListPolicies(scope string, includeInactive bool, limit int, offset int) ([]models.WithdrawalPolicy, int64, error) {
+	query := s.db.Model(&models.WithdrawalPolicy{})
+	if scope != "" {
+		query = query.Where("scope = ?", scope)
+	}
+	if !includeInactive {
+		query = query.Where("is_active = ?", true)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计提现策略失败: %w", err)
+	}
+
+	var policies []models.WithdrawalPolicy
+	if err := query.Preload("FeeTiers").Order("scope ASC, created_at DESC").Limit(limit).Offset(offset).Find(&policies).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询提现策略失败: %w", err)
+	}
+
+	return policies, total, nil
+}
+
+// DeactivatePolicy 停用提现策略（停用后按全局策略或不限制处理）
+func (s *WithdrawalPolicyService) DeactivatePolicy(id string) (*models.WithdrawalPolicy, error) {
+	policyID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrWithdrawalPolicyNotFound
+	}
+
+	var policy models.WithdrawalPolicy
+	if err := s.db.Preload("FeeTiers").Where("id = ?", policyID).First(&policy).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWithdrawalPolicyNotFound
+		}
+		return nil, err
+	}
+
+	if err := s.db.Model(&policy).Updates(map[string]interface{}{"is_active": false, "updated_at": time.Now()}).Error; err != nil {
+		return nil, fmt.Errorf("停用提现策略失败: %w", err)
+	}
+	policy.IsActive = false
+
+	return &policy, nil
+}
@@ -0,0 +1,370 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"pr-business/constants"
+	"pr-business/models"
+)
+
+// WithdrawalInput 提现申请参数
+type WithdrawalInput struct {
+	AccountID   uuid.UUID
+	Amount      int // 单位：积分
+	Method      models.WithdrawalMethod
+	AccountInfo map[string]interface{}
+	Description string
+}
+
+// WithdrawalActor 提现操作者（写入审计日志）
+type WithdrawalActor struct {
+	UserID    string // 认证中心用户ID
+	IPAddress string
+	UserAgent string
+}
+
+// WithdrawalService 提现领域服务
+// 申请（按提现策略校验、计算手续费并冻结积分）→ 审核（通过/拒绝退回）→ 提交打款（PayoutService）→ 完成/失败退回，
+// 每一步写审计日志
+type WithdrawalService struct {
+	db            *gorm.DB
+	ledgerService *LedgerService
+	auditService  *AuditService
+	policyService *WithdrawalPolicyService
+	rateService   *ExchangeRateService
+	taxService    *TaxWithholdingService
+	payoutService *PayoutService
+}
+
+// NewWithdrawalService 创建提现服务
+func NewWithdrawalService(db *gorm.DB, ledgerService *LedgerService, auditService *AuditService, policyService *WithdrawalPolicyService, rateService *ExchangeRateService, taxService *TaxWithholdingService, payoutService *PayoutService) *WithdrawalService {
+	return &WithdrawalService{
+		db:            db,
+		ledgerService: ledgerService,
+		auditService:  auditService,
+		policyService: policyService,
+		rateService:   rateService,
+		taxService:    taxService,
+		payoutService: payoutService,
+	}
+}
+
+// Create 创建提现申请并冻结积分（可用余额 → 冻结余额）
+func (s *WithdrawalService) Create(input *WithdrawalInput, actor WithdrawalActor) (*models.Withdrawal, error) {
+	if input.Amount <= 0 {
+		return nil, fmt.Errorf("%w: 提现积分必须大于0", ErrInvalidAmount)
+	}
+
+	accountInfoBytes, err := json.Marshal(input.AccountInfo)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPayoutAccountInfoInvalid, err)
+	}
+	hash := sha256.Sum256(accountInfoBytes)
+
+	withdrawal := models.Withdrawal{
+		ID:              uuid.New(),
+		AccountID:       input.AccountID,
+		Amount:          input.Amount,
+		Method:          input.Method,
+		AccountInfo:     string(accountInfoBytes),
+		AccountInfoHash: hex.EncodeToString(hash[:]),
+		Description:     input.Description,
+		Status:          models.WithdrawalStatusPending,
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// 锁定积分账户，同一账户的并发申请串行校验累计限额
+		var account models.CreditAccount
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", input.AccountID).First(&account).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrCreditAccountNotFound
+			}
+			return err
+		}
+
+		now := time.Now()
+		assessment, err := s.policyService.Assess(tx, &account, input.Method, input.Amount, withdrawal.AccountInfoHash, now)
+		if err != nil {
+			return err
+		}
+		rate, err := s.rateService.Resolve(tx, now)
+		if err != nil {
+			return err
+		}
+		// 手续费和预扣个税从提现积分中扣除，剩余积分按申请时生效的汇率换算为打款金额
+		withdrawal.Fee = assessment.Fee
+		withdrawal.ExchangeRateID = &rate.ID
+		net := input.Amount - assessment.Fee
+		tax, err := s.taxService.Assess(tx, &account, rate.CreditsToCents(net, WithdrawalPayoutRounding), now)
+		if err != nil {
+			return err
+		}
+		withdrawal.TaxRuleID = tax.RuleID()
+		withdrawal.TaxIncome = tax.Income
+		withdrawal.TaxWithheld = tax.Tax
+		withdrawal.TaxAmount = rate.CentsToCredits(tax.Tax, TaxWithholdingRounding)
+		withdrawal.ActualAmount = net - withdrawal.TaxAmount
+		withdrawal.PayoutCents = rate.CreditsToCents(withdrawal.ActualAmount, WithdrawalPayoutRounding)
+		if withdrawal.PayoutCents <= 0 {
+			return fmt.Errorf("%w: 扣除手续费 %d 积分和预扣个税 %d 积分后到账金额不足1分",
+				ErrWithdrawalPolicyViolation, assessment.Fee, withdrawal.TaxAmount)
+		}
+		withdrawal.PolicyID = assessment.PolicyID()
+		withdrawal.RiskFlags = assessment.RiskFlags
+		withdrawal.RiskReview = len(assessment.RiskFlags) > 0
+
+		if err := tx.Create(&withdrawal).Error; err != nil {
+			return fmt.Errorf("创建提现申请失败: %w", err)
+		}
+
+		// 冻结积分（记账服务内行锁校验余额）
+		if _, err := s.ledgerService.Post(tx, &LedgerTransfer{
+			Type:        models.TransactionWithdrawFreeze,
+			Description: fmt.Sprintf("提现申请 %d 积分", withdrawal.Amount),
+			Postings:    FreezePostings(withdrawal.AccountID, withdrawal.Amount),
+		}); err != nil {
+			return err
+		}
+
+		return s.audit(tx, actor, constants.AuditActionWithdrawalRequest, &withdrawal, map[string]interface{}{
+			"amount":        withdrawal.Amount,
+			"fee":           withdrawal.Fee,
+			"actual_amount": withdrawal.ActualAmount,
+			"payout_cents":  withdrawal.PayoutCents,
+			"exchange_rate": rate.Version,
+			"tax_income":    withdrawal.TaxIncome,
+			"tax_withheld":  withdrawal.TaxWithheld,
+			"method":        withdrawal.Method,
+			"policy_id":     withdrawal.PolicyID,
+			"risk_flags":    withdrawal.RiskFlags,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &withdrawal, nil
+}
+
+// Approve 审核通过（pending → approved），等待提交打款
+func (s *WithdrawalService) Approve(id string, auditNote string, auditorID string, actor WithdrawalActor) (*models.Withdrawal, error) {
+	var withdrawal models.Withdrawal
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockWithdrawal(tx, id, &withdrawal); err != nil {
+			return err
+		}
+		if !withdrawal.CanAudit() {
+			return ErrInvalidWithdrawalStatus
+		}
+		// 命中风控规则的提现需要填写复核意见
+		if withdrawal.RiskReview && strings.TrimSpace(auditNote) == "" {
+			return ErrWithdrawalReviewNoteRequired
+		}
+
+		now := time.Now()
+		if err := tx.Model(&withdrawal).Updates(map[string]interface{}{
+			"status":     models.WithdrawalStatusApproved,
+			"audit_note": auditNote,
+			"audited_by": auditorID,
+			"audited_at": now,
+			"updated_at": now,
+		}).Error; err != nil {
+			return fmt.Errorf("更新提现状态失败: %w", err)
+		}
+		withdrawal.Status = models.WithdrawalStatusApproved
+		withdrawal.AuditNote = auditNote
+		withdrawal.AuditedBy = &auditorID
+		withdrawal.AuditedAt = &now
+
+		return s.audit(tx, actor, constants.AuditActionWithdrawalApprove, &withdrawal, map[string]interface{}{
+			"audit_note": auditNote,
+			"risk_flags": withdrawal.RiskFlags,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &withdrawal, nil
+}
+
+// Reject 拒绝提现并退回冻结积分；待审核或已通过但尚未提交打款的提现可以拒绝
+func (s *WithdrawalService) Reject(id string, reason string, auditorID string, actor WithdrawalActor) (*models.Withdrawal, error) {
+	var withdrawal models.Withdrawal
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockWithdrawal(tx, id, &withdrawal); err != nil {
+			return err
+		}
+		if !withdrawal.CanAudit() && !withdrawal.CanProcess() {
+			return ErrInvalidWithdrawalStatus
+		}
+
+		if _, err := s.ledgerService.Post(tx, &LedgerTransfer{
+			Type:        models.TransactionWithdrawRefund,
+			Description: fmt.Sprintf("提现拒绝退款 %d 积分", withdrawal.Amount),
+			Postings:    UnfreezePostings(withdrawal.AccountID, withdrawal.Amount),
+		}); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(&withdrawal).Updates(map[string]interface{}{
+			"status":     models.WithdrawalStatusRejected,
+			"audit_note": reason,
+			"audited_by": auditorID,
+			"audited_at": now,
+			"updated_at": now,
+		}).Error; err != nil {
+			return fmt.Errorf("更新提现状态失败: %w", err)
+		}
+		withdrawal.Status = models.WithdrawalStatusRejected
+		withdrawal.AuditNote = reason
+		withdrawal.AuditedBy = &auditorID
+		withdrawal.AuditedAt = &now
+
+		return s.audit(tx, actor, constants.AuditActionWithdrawalReject, &withdrawal, map[string]interface{}{
+			"reject_reason": reason,
+			"refunded":      withdrawal.Amount,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &withdrawal, nil
+}
+
+// Process 提交打款：从提现方式对应的现金账户出款，渠道结果异步确认
+func (s *WithdrawalService) Process(ctx context.Context, id string, actor WithdrawalActor) (*models.Withdrawal, error) {
+	return s.payoutService.Dispatch(ctx, id, actor.UserID)
+}
+
+// Get 查询提现记录
+func (s *WithdrawalService) Get(id string) (*models.Withdrawal, error) {
+	var withdrawal models.Withdrawal
+	if err := s.db.Where("id = ?", id).First(&withdrawal).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWithdrawalNotFound
+		}
+		return nil, err
+	}
+	return &withdrawal, nil
+}
+
+// WithdrawalQuote 提现试算结果
+type WithdrawalQuote struct {
+	*WithdrawalAssessment
+	TaxWithheld  int                  // 预计预扣个税（分）
+	TaxAmount    int                  // 预扣个税对应的积分
+	ActualAmount int                  // 扣除手续费和预扣个税后的到账积分
+	ExchangeRate *models.ExchangeRate // 当前生效的积分汇率
+	PayoutCents  int                  // 预计打款金额（分）
+}
+
+// Quote 按账户当前生效的提现策略、积分汇率和个税预扣规则试算手续费和到账金额
+func (s *WithdrawalService) Quote(account *models.CreditAccount, method models.WithdrawalMethod, amount int) (*WithdrawalQuote, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("%w: 提现积分必须大于0", ErrInvalidAmount)
+	}
+	assessment, err := s.policyService.Quote(account, method, amount)
+	if err != nil {
+		return nil, err
+	}
+	rate, err := s.rateService.Current()
+	if err != nil {
+		return nil, err
+	}
+
+	net := amount - assessment.Fee
+	tax, err := s.taxService.Assess(s.db, account, rate.CreditsToCents(net, WithdrawalPayoutRounding), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	taxAmount := rate.CentsToCredits(tax.Tax, TaxWithholdingRounding)
+	actualAmount := net - taxAmount
+	return &WithdrawalQuote{
+		WithdrawalAssessment: assessment,
+		TaxWithheld:          tax.Tax,
+		TaxAmount:            taxAmount,
+		ActualAmount:         actualAmount,
+		ExchangeRate:         rate,
+		PayoutCents:          rate.CreditsToCents(actualAmount, WithdrawalPayoutRounding),
+	}, nil
+}
+
+// WithdrawalFilter 提现列表查询条件
+type WithdrawalFilter struct {
+	AccountIDs []uuid.UUID             // 为空表示不限账户（超管）
+	Scope      func(*gorm.DB) *gorm.DB // 当前身份的数据范围，见 TenantScope.Withdrawals
+	Status     string
+	RiskReview *bool // 只看需要（或不需要）人工复核的提现
+	Page       int
+	PageSize   int
+}
+
+// List 分页查询提现记录
+func (s *WithdrawalService) List(filter WithdrawalFilter) ([]models.Withdrawal, int64, error) {
+	query := s.db.Model(&models.Withdrawal{})
+	if filter.Scope != nil {
+		query = query.Scopes(filter.Scope)
+	}
+	if filter.AccountIDs != nil {
+		query = query.Where("account_id IN ?", filter.AccountIDs)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.RiskReview != nil {
+		query = query.Where("risk_review = ?", *filter.RiskReview)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var withdrawals []models.Withdrawal
+	offset := (filter.Page - 1) * filter.PageSize
+	if err := query.Order("created_at DESC").Offset(offset).Limit(filter.PageSize).Find(&withdrawals).Error; err != nil {
+		return nil, 0, err
+	}
+	return withdrawals, total, nil
+}
+
+// audit 在事务内记录提现审计日志
+func (s *WithdrawalService) audit(tx *gorm.DB, actor WithdrawalActor, action string, withdrawal *models.Withdrawal, changes map[string]interface{}) error {
+	changes["status"] = withdrawal.Status
+	return s.auditService.WithTx(tx).LogFinancialOperation(
+		actor.UserID,
+		action,
+		constants.AuditResourceWithdrawal,
+		withdrawal.ID.String(),
+		changes,
+		actor.IPAddress,
+		actor.UserAgent,
+	)
+}
+
+// lockWithdrawal 在事务内锁定提现记录
+func lockWithdrawal(tx *gorm.DB, id string, withdrawal *models.Withdrawal) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(withdrawal).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrWithdrawalNotFound
+		}
+		return err
+	}
+	return nil
+}
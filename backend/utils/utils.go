@@ -3,13 +3,14 @@ package utils
 import (
 	"strings"
 
-	"pr-business/constants"
 	"pr-business/models"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
-// HasRole 检查用户是否拥有指定角色（不区分大小写，兼容 DB/JWT 中 creator 与 CREATOR）
+// HasRole 检查用户的角色列表中是否有指定角色（不区分大小写，兼容 DB/JWT 中 creator 与 CREATOR）
+// 仅用于维护用户的角色列表；判断当前请求能否操作请用 ActingAs，只看激活的角色
 func HasRole(user *models.User, role string) bool {
 	for _, r := range user.Roles {
 		if strings.EqualFold(r, role) {
@@ -19,26 +20,6 @@ func HasRole(user *models.User, role string) bool {
 	return false
 }
 
-// HasAnyRole 检查用户是否拥有任一指定角色
-func HasAnyRole(user *models.User, roles ...string) bool {
-	for _, role := range roles {
-		if HasRole(user, role) {
-			return true
-		}
-	}
-	return false
-}
-
-// HasAllRoles 检查用户是否拥有所有指定角色
-func HasAllRoles(user *models.User, roles ...string) bool {
-	for _, role := range roles {
-		if !HasRole(user, role) {
-			return false
-		}
-	}
-	return true
-}
-
 // GetCurrentUser 从上下文中获取当前用户
 func GetCurrentUser(c *gin.Context) (*models.User, bool) {
 	user, exists := c.Get("user")
@@ -48,32 +29,26 @@ func GetCurrentUser(c *gin.Context) (*models.User, bool) {
 	return user.(*models.User), true
 }
 
-// IsSuperAdmin 检查是否是超级管理员
-func IsSuperAdmin(user *models.User) bool {
-	return HasRole(user, constants.RoleSuperAdmin)
-}
-
-// IsMerchantAdmin 检查是否是商家管理员
-func IsMerchantAdmin(user *models.User) bool {
-	return HasRole(user, constants.RoleMerchantAdmin)
-}
-
-// IsServiceProviderAdmin 检查是否是服务商管理员
-func IsServiceProviderAdmin(user *models.User) bool {
-	return HasRole(user, constants.RoleServiceProviderAdmin)
-}
-
-// IsCreator 检查是否是达人
-func IsCreator(user *models.User) bool {
-	return HasRole(user, constants.RoleCreator)
+// GetRoleContext 从上下文中获取当前请求的身份（激活角色和组织）
+func GetRoleContext(c *gin.Context) (*models.RoleContext, bool) {
+	rc, exists := c.Get("roleContext")
+	if !exists {
+		return nil, false
+	}
+	return rc.(*models.RoleContext), true
 }
 
-// IsMerchantStaff 检查是否是商家员工
-func IsMerchantStaff(user *models.User) bool {
-	return HasRole(user, constants.RoleMerchantStaff)
+// ActingAs 当前请求是否以其中一个角色操作
+func ActingAs(c *gin.Context, roles ...string) bool {
+	rc, ok := GetRoleContext(c)
+	return ok && rc.Is(roles...)
 }
 
-// IsServiceProviderStaff 检查是否是服务商员工
-func IsServiceProviderStaff(user *models.User) bool {
-	return HasRole(user, constants.RoleServiceProviderStaff)
+// ActiveOrgID 当前身份代表的组织（商家或服务商）ID，非组织身份或未加入组织时为 uuid.Nil
+func ActiveOrgID(c *gin.Context) uuid.UUID {
+	rc, ok := GetRoleContext(c)
+	if !ok || rc.OrgID == nil {
+		return uuid.Nil
+	}
+	return *rc.OrgID
 }
@@ -1,6 +1,7 @@
 import { createContext, useContext, useState, useEffect } from 'react'
 import type { ReactNode } from 'react'
 import type { User } from '../types'
+import { authApi } from '../services/api'
 
 interface AuthContextType {
   user: User | null
@@ -44,6 +45,11 @@ export const AuthProvider = ({ children }: { children: ReactNode }) => {
   }
 
   const logout = () => {
+    // 吊销服务端会话，失败不影响本地退出
+    const currentToken = localStorage.getItem('accessToken')
+    if (currentToken) {
+      authApi.logout(currentToken).catch(() => {})
+    }
     localStorage.removeItem('accessToken')
     localStorage.removeItem('refreshToken')
     localStorage.removeItem('user')
@@ -33,16 +33,28 @@ export default function Login() {
       },
     })
       .then(res => res.json())
-      .then(data => {
+      .then(async data => {
         if (!data.success) {
           setError(data.error || '登录失败')
           return
         }
 
-        // 保存 auth-center token
+        // 用 auth-center token 换取本系统会话（access token + refresh token）
+        const sessionRes = await fetch(`${API_BASE_URL}/api/v1/auth/session`, {
+          method: 'POST',
+          headers: {
+            'Authorization': `Bearer ${token}`,
+          },
+        })
+        const session = await sessionRes.json()
+        if (!session.success) {
+          setError(session.error || '登录失败')
+          return
+        }
+
         login(
-          token,
-          '', // 不需要 refresh token
+          session.data.accessToken,
+          session.data.refreshToken,
           {
             id: data.data.id,
             authCenterUserId: data.data.authCenterUserId,
@@ -47,7 +47,7 @@ export default function MyTasks() {
       await taskApi.submitTask(selectedTask.id, {
         platformUrl,
         notes,
-      })
+      }, selectedTask.version)
       toast.showSuccess('提交成功！')
       setSubmitModalOpen(false)
       setSelectedTask(null)
@@ -56,6 +56,12 @@ export default function MyTasks() {
       loadTasks()
     } catch (err: any) {
       toast.showError(err.response?.data?.error || '提交失败')
+      // 任务已被修改（如审核人已处理），刷新列表
+      if (err.response?.status === 409) {
+        setSubmitModalOpen(false)
+        setSelectedTask(null)
+        loadTasks()
+      }
     }
   }
 
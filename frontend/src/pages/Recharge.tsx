@@ -18,7 +18,6 @@ export default function Recharge() {
   const paymentMethods = [
     { id: 'alipay', name: '支付宝', icon: '💙' },
     { id: 'wechat', name: '微信支付', icon: '💚' },
-    { id: 'bank', name: '银行转账', icon: '🏦' },
   ]
 
   const [selectedMethod, setSelectedMethod] = useState(paymentMethods[0].id)
@@ -56,9 +55,10 @@ export default function Recharge() {
     }
 
     try {
-      const response = await creditApi.recharge({ amount: rechargeAmount })
-      alert(`充值成功！当前余额：${response.account.balance} 积分`)
+      const response = await creditApi.recharge({ amount: rechargeAmount, provider: selectedMethod })
       setAmount('')
+      window.open(response.order.paymentUrl, '_blank')
+      alert(`${response.message}，应付 ${(response.order.cashAmount / 100).toFixed(2)} 元，支付成功后积分自动到账`)
       await loadAccount()
     } catch (err: any) {
       setError(err.response?.data?.error || '充值失败')
@@ -168,7 +168,7 @@ export default function Recharge() {
                 <label className="block text-sm font-medium text-gray-700 mb-3">
                   充值方式 <span className="text-red-500">*</span>
                 </label>
-                <div className="grid grid-cols-3 gap-4">
+                <div className="grid grid-cols-2 gap-4">
                   {paymentMethods.map((method) => (
                     <button
                       key={method.id}
@@ -192,9 +192,8 @@ export default function Recharge() {
                 <h4 className="text-sm font-medium text-gray-900 mb-2">充值说明</h4>
                 <ul className="text-xs text-gray-600 space-y-1">
                   <li>• 单笔充值金额最低 100 积分</li>
-                  <li>• 充值到账时间为实时</li>
+                  <li>• 支付成功后自动到账</li>
                   <li>• 如有疑问请联系客服</li>
-                  <li>• 本服务为模拟充值，实际环境需对接第三方支付</li>
                 </ul>
               </div>
 
@@ -51,12 +51,39 @@ export default function WithdrawalReview() {
   }
 
   const handleProcess = async (id: string) => {
-    if (!confirm('确认已完成打款？')) return
+    if (!confirm('确认提交打款？')) return
 
     setProcessingId(id)
     try {
-      await withdrawalApi.processWithdrawal(id)
-      alert('打款成功')
+      const result = await withdrawalApi.processWithdrawal(id)
+      alert(result.message)
+      loadWithdrawals()
+    } catch (err: any) {
+      alert(err.response?.data?.error || '操作失败')
+    } finally {
+      setProcessingId(null)
+    }
+  }
+
+  const handleSync = async (id: string) => {
+    setProcessingId(id)
+    try {
+      await withdrawalApi.syncWithdrawal(id)
+      loadWithdrawals()
+    } catch (err: any) {
+      alert(err.response?.data?.error || '操作失败')
+    } finally {
+      setProcessingId(null)
+    }
+  }
+
+  const handleConfirmPayout = async (id: string, succeeded: boolean) => {
+    const input = succeeded ? prompt('请输入银行流水号（可选）') : prompt('请输入打款失败原因')
+    if (input === null || (!succeeded && !input)) return
+
+    setProcessingId(id)
+    try {
+      await withdrawalApi.confirmPayout(id, succeeded ? { succeeded, orderNo: input } : { succeeded, failReason: input })
       loadWithdrawals()
     } catch (err: any) {
       alert(err.response?.data?.error || '操作失败')
@@ -69,8 +96,10 @@ export default function WithdrawalReview() {
     const statusMap: Record<string, string> = {
       pending: '待审核',
       approved: '已通过',
+      processing: '打款中',
       rejected: '已拒绝',
       completed: '已完成',
+      failed: '打款失败',
     }
     return statusMap[status] || status
   }
@@ -79,8 +108,10 @@ export default function WithdrawalReview() {
     const colorMap: Record<string, string> = {
       pending: 'bg-yellow-100 text-yellow-800',
       approved: 'bg-blue-100 text-blue-800',
+      processing: 'bg-indigo-100 text-indigo-800',
       rejected: 'bg-red-100 text-red-800',
       completed: 'bg-green-100 text-green-800',
+      failed: 'bg-red-100 text-red-800',
     }
     return colorMap[status] || 'bg-gray-100 text-gray-800'
   }
@@ -195,7 +226,16 @@ export default function WithdrawalReview() {
             <>
               <Button
                 size="sm"
-                onClick={() => handleAudit(row.id, true)}
+                onClick={() => {
+                  if (!row.riskReview) {
+                    handleAudit(row.id, true)
+                    return
+                  }
+                  const note = prompt('该提现命中风控规则，请填写复核意见')
+                  if (note) {
+                    handleAudit(row.id, true, note)
+                  }
+                }}
                 disabled={processingId === row.id}
                 className="bg-green-600 hover:bg-green-700"
               >
@@ -223,9 +263,49 @@ export default function WithdrawalReview() {
               disabled={processingId === row.id}
               className="bg-blue-600 hover:bg-blue-700"
             >
-              {processingId === row.id ? '处理中...' : '确认打款'}
+              {processingId === row.id ? '处理中...' : '提交打款'}
             </Button>
           )}
+          {row.status === 'processing' && row.payoutProvider !== 'manual' && (
+            <Button
+              size="sm"
+              variant="outline"
+              onClick={() => handleSync(row.id)}
+              disabled={processingId === row.id}
+            >
+              {processingId === row.id ? '查询中...' : '查询结果'}
+            </Button>
+          )}
+          {row.status === 'processing' && row.payoutProvider === 'manual' && (
+            <>
+              <Button
+                size="sm"
+                onClick={() => handleConfirmPayout(row.id, true)}
+                disabled={processingId === row.id}
+                className="bg-green-600 hover:bg-green-700"
+              >
+                已转账
+              </Button>
+              <Button
+                size="sm"
+                variant="destructive"
+                onClick={() => handleConfirmPayout(row.id, false)}
+                disabled={processingId === row.id}
+              >
+                转账失败
+              </Button>
+            </>
+          )}
+          {row.riskReview && (
+            <div className="text-xs text-orange-600 mt-1">
+              风控复核: {row.riskFlags?.join(', ')}
+            </div>
+          )}
+          {row.payoutFailReason && (
+            <div className="text-xs text-red-500 mt-1">
+              失败原因: {row.payoutFailReason}
+            </div>
+          )}
           {row.auditNote && (
             <div className="text-xs text-gray-500 mt-1">
               备注: {row.auditNote}
@@ -1,6 +1,7 @@
 import axios, { AxiosError } from 'axios'
 import type {
   User,
+  RoleContext,
   ApiError,
   InvitationCode,
   UseInvitationCodeRequest,
@@ -33,6 +34,7 @@ import type {
   CreditAccountWithPermission,
   TransactionsResponse,
   RechargeRequest,
+  RechargeOrder,
   Withdrawal,
   CreateWithdrawalRequest,
   AuditWithdrawalRequest,
@@ -61,13 +63,47 @@ api.interceptors.request.use((config) => {
   return config
 })
 
-// 响应拦截器 - 简化版，401直接跳转登录
+// 刷新会话：并发的 401 共用同一次刷新
+let refreshing: Promise<string | null> | null = null
+
+const refreshAccessToken = (): Promise<string | null> => {
+  const refreshToken = localStorage.getItem('refreshToken')
+  if (!refreshToken) {
+    return Promise.resolve(null)
+  }
+  if (!refreshing) {
+    refreshing = axios
+      .post(`${API_BASE_URL}/api/v1/auth/refresh`, { refreshToken })
+      .then((res) => {
+        localStorage.setItem('accessToken', res.data.data.accessToken)
+        localStorage.setItem('refreshToken', res.data.data.refreshToken)
+        return res.data.data.accessToken as string
+      })
+      .catch(() => null)
+      .finally(() => {
+        refreshing = null
+      })
+  }
+  return refreshing
+}
+
+// 响应拦截器 - 401 时先尝试刷新会话，失败再跳转登录
 api.interceptors.response.use(
   (response) => response,
   async (error: AxiosError<ApiError>) => {
-    // 401错误：token无效或过期，直接跳转登录
+    const config = error.config as (typeof error.config & { _retried?: boolean }) | undefined
+    if (error.response?.status === 401 && config && !config._retried) {
+      config._retried = true
+      const token = await refreshAccessToken()
+      if (token) {
+        config.headers.Authorization = `Bearer ${token}`
+        return api(config)
+      }
+    }
+    // 401错误：token无效或过期且无法刷新，跳转登录
     if (error.response?.status === 401) {
       localStorage.removeItem('accessToken')
+      localStorage.removeItem('refreshToken')
       localStorage.removeItem('user')
       window.location.href = '/login'
     }
@@ -82,6 +118,31 @@ export const authApi = {
     const response = await api.get<{ success: boolean; data: User }>('/api/v1/user/me')
     return response.data.data
   },
+
+  // 获取当前激活角色和可切换的身份
+  getActiveRole: async () => {
+    const response = await api.get<{ success: boolean; data: { active: RoleContext; options: RoleContext[] } }>('/api/v1/user/active-role')
+    return response.data.data
+  },
+
+  // 切换激活角色（orgId 为空时取该角色下的第一个组织）
+  switchActiveRole: async (role: string, orgId?: string) => {
+    const response = await api.put<{ success: boolean; data: RoleContext }>('/api/v1/user/active-role', { role, orgId })
+    return response.data.data
+  },
+
+  // 获取当前身份可执行的操作（用于显示或隐藏按钮）
+  getCapabilities: async () => {
+    const response = await api.get<{ success: boolean; data: { roleContext: RoleContext; actions: string[]; permissions: string[] } }>('/api/v1/user/me/capabilities')
+    return response.data.data
+  },
+
+  // 退出登录（吊销当前会话）
+  logout: async (token: string) => {
+    await axios.post(`${API_BASE_URL}/api/v1/auth/logout`, null, {
+      headers: { Authorization: `Bearer ${token}` },
+    })
+  },
 }
 
 // 邀请码API
@@ -420,6 +481,10 @@ export const campaignApi = {
   },
 }
 
+// 携带任务版本（If-Match），任务已被他人修改时后端返回 409 和当前状态
+const taskVersionHeaders = (version?: number) =>
+  version === undefined ? undefined : { headers: { 'If-Match': `"${version}"` } }
+
 // 任务API
 export const taskApi = {
   // 获取任务列表
@@ -459,20 +524,20 @@ export const taskApi = {
   },
 
   // 接任务
-  acceptTask: async (id: string, data: AcceptTaskRequest) => {
-    const response = await api.post<Task>(`/api/v1/tasks/${id}/accept`, data)
+  acceptTask: async (id: string, data: AcceptTaskRequest, version?: number) => {
+    const response = await api.post<Task>(`/api/v1/tasks/${id}/accept`, data, taskVersionHeaders(version))
     return response.data
   },
 
   // 提交任务
-  submitTask: async (id: string, data: SubmitTaskRequest) => {
-    const response = await api.post<Task>(`/api/v1/tasks/${id}/submit`, data)
+  submitTask: async (id: string, data: SubmitTaskRequest, version?: number) => {
+    const response = await api.post<Task>(`/api/v1/tasks/${id}/submit`, data, taskVersionHeaders(version))
     return response.data
   },
 
   // 审核任务
-  auditTask: async (id: string, data: AuditTaskRequest) => {
-    const response = await api.post<Task>(`/api/v1/tasks/${id}/audit`, data)
+  auditTask: async (id: string, data: AuditTaskRequest, version?: number) => {
+    const response = await api.post<Task>(`/api/v1/tasks/${id}/audit`, data, taskVersionHeaders(version))
     return response.data
   },
 }
@@ -497,10 +562,10 @@ export const creditApi = {
     return response.data
   },
 
-  // 充值（仅商家管理员）
+  // 在线充值（仅商家管理员）：创建支付订单，支付成功后自动到账
   recharge: async (data: RechargeRequest) => {
-    const response = await api.post<{ message: string; account: CreditAccount }>(
-      '/api/v1/credit/recharge',
+    const response = await api.post<{ message: string; order: RechargeOrder }>(
+      '/api/v1/recharge-orders/online',
       data
     )
     return response.data
@@ -546,6 +611,18 @@ export const withdrawalApi = {
     )
     return response.data
   },
+
+  // 查询打款结果（仅超管）
+  syncWithdrawal: async (id: string) => {
+    const response = await api.post<Withdrawal>(`/api/v1/withdrawals/${id}/sync`)
+    return response.data
+  },
+
+  // 人工确认银行转账结果（仅超管）
+  confirmPayout: async (id: string, data: { succeeded: boolean; orderNo?: string; failReason?: string }) => {
+    const response = await api.post<Withdrawal>(`/api/v1/withdrawals/${id}/payout-result`, data)
+    return response.data
+  },
 }
 
 // 任务邀请API
@@ -30,6 +30,7 @@ export interface User {
   avatarUrl: string
   profile: Record<string, any>
   roles: string[]
+  activeRole?: RoleContext | null // 当前激活角色和组织
   status: string
   lastLoginAt: string | null
   lastLoginIp: string
@@ -38,6 +39,14 @@ export interface User {
   updatedAt: string
 }
 
+// 激活角色：以哪个角色、代表哪个组织操作
+export interface RoleContext {
+  role: string
+  orgType?: 'merchant' | 'service_provider'
+  orgId?: string // UUID
+  orgName?: string
+}
+
 // 登录请求
 export interface LoginRequest {
   authCode: string
@@ -516,6 +525,18 @@ export interface TransactionsResponse {
 
 export interface RechargeRequest {
   amount: number
+  provider: string // 支付渠道：alipay/wechat
+}
+
+// 在线充值订单（支付成功后由渠道回调入账）
+export interface RechargeOrder {
+  id: string
+  amount: number
+  cashAmount: number // 应付金额（分）
+  paymentMethod: string
+  paymentUrl: string
+  status: 'pending' | 'approved' | 'rejected' | 'completed' | 'refunded'
+  createdAt: string
 }
 
 // 提现类型
@@ -528,11 +549,22 @@ export interface Withdrawal {
   method: 'ALIPAY' | 'WECHAT' | 'BANK'
   accountInfo: string // JSONB string
   accountInfoHash: string
-  status: 'pending' | 'approved' | 'rejected' | 'completed'
+  status: 'pending' | 'approved' | 'processing' | 'rejected' | 'completed' | 'failed'
   auditNote: string
   auditedBy: string | null
   auditedAt: string | null
   completedAt: string | null
+  payoutProvider?: string
+  payoutOrderNo?: string
+  payoutFailReason?: string
+  payoutSubmittedAt?: string | null
+  policyId?: string | null
+  riskFlags?: string[]
+  riskReview?: boolean
+  exchangeRateId?: string | null
+  payoutCents?: number // 实际打款金额（分）
+  taxWithheld?: number // 预扣个税（分）
+  taxAmount?: number // 预扣个税对应的积分
   createdAt: string
   updatedAt: string
   account?: CreditAccount
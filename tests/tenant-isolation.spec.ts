@@ -0,0 +1,171 @@
+import { test, expect, APIRequestContext } from '@playwright/test';
+import { createHmac, randomUUID } from 'crypto';
+
+// 多租户数据隔离：两个商家管理员互相读不到对方的数据
+// 只针对本地或测试环境运行，需要两个不同商家，每个商家各有营销活动、任务（已有达人接单）、积分流水和提现记录。
+// 身份二选一：
+//   直接提供两个商家管理员的 token：
+//     MERCHANT_A_TOKEN=... MERCHANT_B_TOKEN=... npx playwright test tenant-isolation
+//   后端以 AUTH_TOKEN_VERIFY_MODE=local 启动时，提供 JWT_SECRET 和两个商家管理员的账号中心用户ID，由测试签发 token：
+//     JWT_SECRET=... MERCHANT_A_USER_ID=... MERCHANT_B_USER_ID=... npx playwright test tenant-isolation
+// API_BASE_URL 默认 http://localhost:8080；后端配置了 AUTH_TOKEN_ISSUER 时同样设置该变量
+const baseURL = process.env.API_BASE_URL || 'http://localhost:8080';
+
+// signToken 用 JWT_SECRET 签发 HS256 token（与账号中心本地校验一致）
+function signToken(userId: string): string {
+  const encode = (value: object) => Buffer.from(JSON.stringify(value)).toString('base64url');
+  const now = Math.floor(Date.now() / 1000);
+  const payload: Record<string, unknown> = { sub: userId, userId, jti: randomUUID(), iat: now, exp: now + 3600 };
+  if (process.env.AUTH_TOKEN_ISSUER) {
+    payload.iss = process.env.AUTH_TOKEN_ISSUER;
+  }
+  const input = `${encode({ alg: 'HS256', typ: 'JWT' })}.${encode(payload)}`;
+  const signature = createHmac('sha256', process.env.JWT_SECRET || '').update(input).digest('base64url');
+  return `${input}.${signature}`;
+}
+
+function merchantToken(name: 'A' | 'B'): string {
+  const token = process.env[`MERCHANT_${name}_TOKEN`];
+  if (token) {
+    return token;
+  }
+  const userId = process.env[`MERCHANT_${name}_USER_ID`];
+  return userId && process.env.JWT_SECRET ? signToken(userId) : '';
+}
+
+const tokens = { A: merchantToken('A'), B: merchantToken('B') };
+
+interface MerchantSession {
+  name: string;
+  token: string;
+  merchantId: string;
+  accountId: string;
+}
+
+// MerchantData 商家身份能读到的数据
+interface MerchantData {
+  campaigns: any[];
+  tasks: any[];
+  transactions: any[];
+  withdrawals: any[];
+}
+
+async function get(request: APIRequestContext, token: string, path: string) {
+  const response = await request.get(`${baseURL}${path}`, {
+    headers: { Authorization: `Bearer ${token}` },
+  });
+  return { status: response.status(), body: response.ok() ? await response.json() : null };
+}
+
+async function loadSession(request: APIRequestContext, name: string, token: string): Promise<MerchantSession> {
+  const switched = await request.put(`${baseURL}/api/v1/user/active-role`, {
+    headers: { Authorization: `Bearer ${token}` },
+    data: { role: 'MERCHANT_ADMIN' },
+  });
+  expect(switched.status(), `商家${name}应拥有商家管理员角色`).toBe(200);
+
+  const merchant = await get(request, token, '/api/v1/merchant/me');
+  expect(merchant.status, `商家${name}的当前身份应为商家管理员`).toBe(200);
+  const account = await get(request, token, '/api/v1/credit/balance');
+  expect(account.status, `商家${name}应有积分账户`).toBe(200);
+  return { name, token, merchantId: merchant.body.id, accountId: account.body.id };
+}
+
+async function loadData(request: APIRequestContext, session: MerchantSession): Promise<MerchantData> {
+  const campaigns = await get(request, session.token, '/api/v1/campaigns');
+  const tasks = await get(request, session.token, '/api/v1/tasks');
+  const transactions = await get(request, session.token, '/api/v1/credit/transactions?page_size=100');
+  const withdrawals = await get(request, session.token, '/api/v1/withdrawals?page_size=100');
+  for (const response of [campaigns, tasks, transactions, withdrawals]) {
+    expect(response.status).toBe(200);
+  }
+  return {
+    campaigns: campaigns.body,
+    tasks: tasks.body,
+    transactions: transactions.body.data,
+    withdrawals: withdrawals.body.withdrawals,
+  };
+}
+
+const ids = (items: any[]) => items.map((item) => item.id);
+
+test.describe('多租户数据隔离', () => {
+  test.skip(!tokens.A || !tokens.B, '需要设置 MERCHANT_A_TOKEN/MERCHANT_B_TOKEN，或 JWT_SECRET 和 MERCHANT_A_USER_ID/MERCHANT_B_USER_ID');
+
+  let sessions: MerchantSession[] = [];
+  let data: MerchantData[] = [];
+
+  test.beforeAll(async ({ playwright }) => {
+    const request = await playwright.request.newContext();
+    sessions = [await loadSession(request, 'A', tokens.A), await loadSession(request, 'B', tokens.B)];
+    expect(sessions[0].merchantId, '两个账号必须属于不同商家').not.toBe(sessions[1].merchantId);
+
+    data = [await loadData(request, sessions[0]), await loadData(request, sessions[1])];
+    await request.dispose();
+
+    // 对方没有数据时"读不到"没有意义，测试环境必须为两个商家都准备好数据
+    for (const [i, session] of sessions.entries()) {
+      expect(data[i].campaigns.length, `商家${session.name}需要至少一个营销活动`).toBeGreaterThan(0);
+      expect(data[i].tasks.filter((task) => task.creatorId).length, `商家${session.name}需要至少一个已接单的任务`).toBeGreaterThan(0);
+      expect(data[i].transactions.length, `商家${session.name}需要至少一条积分流水`).toBeGreaterThan(0);
+      expect(data[i].withdrawals.length, `商家${session.name}需要至少一条提现记录`).toBeGreaterThan(0);
+    }
+  });
+
+  for (const [self, other] of [[0, 1], [1, 0]]) {
+    test(`商家${self === 0 ? 'A' : 'B'}读不到商家${other === 0 ? 'A' : 'B'}的数据`, async ({ request }) => {
+      const me = sessions[self];
+      const them = sessions[other];
+      const mine = data[self];
+      const theirs = data[other];
+
+      // 营销活动：只有本商家的活动，对方的活动ID不在列表中，按对方商家ID过滤和按ID查询都查不到
+      for (const campaign of mine.campaigns) {
+        expect(campaign.merchantId).toBe(me.merchantId);
+      }
+      for (const id of ids(theirs.campaigns)) {
+        expect(ids(mine.campaigns), `不应看到商家${them.name}的活动 ${id}`).not.toContain(id);
+        expect((await get(request, me.token, `/api/v1/campaigns/${id}`)).status).toBe(404);
+      }
+      const filtered = await get(request, me.token, `/api/v1/campaigns?merchant_id=${them.merchantId}`);
+      expect(filtered.body).toEqual([]);
+
+      // 任务：只有本商家活动的任务，对方的任务按ID查询、查时间线都返回 404
+      for (const task of mine.tasks) {
+        expect(task.campaign.merchantId).toBe(me.merchantId);
+      }
+      for (const id of ids(theirs.tasks)) {
+        expect(ids(mine.tasks), `不应看到商家${them.name}的任务 ${id}`).not.toContain(id);
+        expect((await get(request, me.token, `/api/v1/tasks/${id}`)).status).toBe(404);
+        expect((await get(request, me.token, `/api/v1/tasks/${id}/timeline`)).status).toBe(404);
+      }
+
+      // 达人：商家身份无权查看达人列表，对方任务的达人按ID也查不到
+      expect((await get(request, me.token, '/api/v1/creators')).status).toBe(403);
+      for (const creatorId of new Set(theirs.tasks.map((task) => task.creatorId).filter(Boolean))) {
+        const creator = await get(request, me.token, `/api/v1/creators/${creatorId}`);
+        expect(creator.status).toBe(404);
+        expect(creator.body).toBeNull();
+      }
+
+      // 积分流水：只有本商家账户的流水，对方的流水ID不在列表中
+      for (const transaction of mine.transactions) {
+        expect(transaction.accountId).toBe(me.accountId);
+      }
+      for (const id of ids(theirs.transactions)) {
+        expect(ids(mine.transactions), `不应看到商家${them.name}的积分流水 ${id}`).not.toContain(id);
+      }
+
+      // 提现记录：只有本人提现账户的提现（一个账户），对方的提现ID和提现账户都不在列表中，按ID查询也拿不到内容
+      const myWithdrawalAccounts = new Set(mine.withdrawals.map((withdrawal) => withdrawal.accountId));
+      expect(myWithdrawalAccounts.size).toBe(1);
+      for (const withdrawal of theirs.withdrawals) {
+        expect(myWithdrawalAccounts.has(withdrawal.accountId), `不应看到商家${them.name}提现账户的记录`).toBe(false);
+        expect(ids(mine.withdrawals), `不应看到商家${them.name}的提现 ${withdrawal.id}`).not.toContain(withdrawal.id);
+        const detail = await get(request, me.token, `/api/v1/withdrawals/${withdrawal.id}`);
+        expect([403, 404]).toContain(detail.status);
+        expect(detail.body).toBeNull();
+      }
+    });
+  }
+});